@@ -13,14 +13,17 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 	configaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/config_access"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cmd"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/configschema"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
@@ -53,6 +56,10 @@ func init() {
 // It parses command-line flags, loads configuration, and starts the appropriate
 // service based on the provided flags (login, codex-login, or server mode).
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+
 	fmt.Printf("CLIProxyAPI Version: %s, Commit: %s, BuiltAt: %s\n", buildinfo.Version, buildinfo.Commit, buildinfo.BuildDate)
 
 	// Command-line flags to control the application's behavior.
@@ -72,6 +79,9 @@ func main() {
 	var password string
 	var tuiMode bool
 	var standalone bool
+	var reencryptAuth bool
+	var validateConfigPath string
+	var configSchemaPath string
 
 	// Define command-line flags for different operation modes.
 	flag.BoolVar(&login, "login", false, "Login Google Account")
@@ -90,6 +100,9 @@ func main() {
 	flag.StringVar(&password, "password", "", "")
 	flag.BoolVar(&tuiMode, "tui", false, "Start with terminal management UI")
 	flag.BoolVar(&standalone, "standalone", false, "In TUI mode, start an embedded local server")
+	flag.BoolVar(&reencryptAuth, "reencrypt-auth", false, "Re-encrypt all auth files with the current AUTH_ENCRYPTION_KEY and exit")
+	flag.StringVar(&configSchemaPath, "config-schema", "", "Write the JSON Schema of config.yaml to this file and exit")
+	flag.StringVar(&validateConfigPath, "validate-config", "", "Validate a config file and show its changes against -config without applying it")
 
 	flag.CommandLine.Usage = func() {
 		out := flag.CommandLine.Output()
@@ -121,6 +134,29 @@ func main() {
 	// Parse the command-line flags.
 	flag.Parse()
 
+	if configSchemaPath != "" {
+		schema, errSchema := configschema.ConfigSchemaJSON()
+		if errSchema == nil {
+			errSchema = os.WriteFile(configSchemaPath, append(schema, '\n'), 0o644)
+		}
+		if errSchema != nil {
+			fmt.Fprintf(os.Stderr, "config-schema: %v\n", errSchema)
+			os.Exit(1)
+		}
+		fmt.Printf("config schema written to %s\n", configSchemaPath)
+		os.Exit(0)
+	}
+
+	if validateConfigPath != "" {
+		runningPath := configPath
+		if runningPath == "" {
+			if wd, errWd := os.Getwd(); errWd == nil {
+				runningPath = filepath.Join(wd, "config.yaml")
+			}
+		}
+		os.Exit(runValidateConfig(validateConfigPath, runningPath))
+	}
+
 	// Core application variables.
 	var err error
 	var cfg *config.Config
@@ -130,6 +166,7 @@ func main() {
 		pgStoreDSN           string
 		pgStoreSchema        string
 		pgStoreLocalPath     string
+		pgStoreSyncInterval  time.Duration
 		pgStoreInst          *store.PostgresStore
 		useGitStore          bool
 		gitStoreRemoteURL    string
@@ -160,6 +197,17 @@ func main() {
 		}
 	}
 
+	// Auth file encryption must be configured before any store reads or writes auth files.
+	keyring, errKeyring := authcrypt.LoadFromEnv()
+	if errKeyring != nil {
+		log.Errorf("failed to load auth encryption key: %v", errKeyring)
+		return
+	}
+	authcrypt.Configure(keyring)
+	if keyring.CanSeal() {
+		log.Infof("auth file encryption enabled (key %s)", keyring.KeyID())
+	}
+
 	lookupEnv := func(keys ...string) (string, bool) {
 		for _, key := range keys {
 			if value, ok := os.LookupEnv(key); ok {
@@ -182,6 +230,13 @@ func main() {
 		if value, ok := lookupEnv("PGSTORE_LOCAL_PATH", "pgstore_local_path"); ok {
 			pgStoreLocalPath = value
 		}
+		if value, ok := lookupEnv("PGSTORE_SYNC_INTERVAL", "pgstore_sync_interval"); ok {
+			if seconds, errParse := strconv.Atoi(value); errParse == nil {
+				pgStoreSyncInterval = time.Duration(seconds) * time.Second
+			} else {
+				log.Warnf("ignoring invalid PGSTORE_SYNC_INTERVAL %q", value)
+			}
+		}
 		if pgStoreLocalPath == "" {
 			if writableBase != "" {
 				pgStoreLocalPath = writableBase
@@ -455,7 +510,9 @@ func main() {
 
 	// Handle different command modes based on the provided flags.
 
-	if vertexImport != "" {
+	if reencryptAuth {
+		cmd.DoReencryptAuthFiles(cfg)
+	} else if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport)
 	} else if login {
@@ -485,6 +542,11 @@ func main() {
 			cmd.WaitForCloudDeploy()
 			return
 		}
+		// Follow config and auth changes made by other instances sharing the database.
+		if usePostgresStore && pgStoreSyncInterval >= 0 {
+			pgStoreInst.StartSync(context.Background(), pgStoreSyncInterval)
+			defer pgStoreInst.StopSync()
+		}
 		if tuiMode {
 			if standalone {
 				// Standalone mode: start an embedded local server and connect TUI client to it.
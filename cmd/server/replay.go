@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/replay"
+)
+
+// runReplay implements the "replay" subcommand. It re-issues a captured request log
+// against a target and prints the structured diff as JSON. The exit code is 0 when
+// the responses match, 1 when they differ and 2 on usage or replay errors.
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	var target, model, apiKey, ignore, output string
+	var timeout time.Duration
+	fs.StringVar(&target, "target", replay.DefaultTarget, "Base URL to replay against (this proxy or a stub upstream)")
+	fs.StringVar(&model, "model", "", "Override the request model")
+	fs.StringVar(&apiKey, "api-key", "", "Client API key to send; captured credentials are never replayed")
+	fs.StringVar(&ignore, "ignore", "", "Comma-separated JSON paths to exclude from the diff")
+	fs.StringVar(&output, "output", "", "Write the result JSON to this file instead of stdout")
+	fs.DurationVar(&timeout, "timeout", 5*time.Minute, "Replay timeout")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s replay [flags] <request-log-file>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	capture, err := replay.ParseLogFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 2
+	}
+	var ignorePaths []string
+	for _, path := range strings.Split(ignore, ",") {
+		if trimmed := strings.TrimSpace(path); trimmed != "" {
+			ignorePaths = append(ignorePaths, trimmed)
+		}
+	}
+	result, err := replay.Replay(context.Background(), capture, replay.Options{
+		Target:      target,
+		Model:       model,
+		APIKey:      apiKey,
+		IgnorePaths: ignorePaths,
+		Timeout:     timeout,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: encode result: %v\n", err)
+		return 2
+	}
+	if output != "" {
+		if err = os.WriteFile(output, append(data, '\n'), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "replay: write output: %v\n", err)
+			return 2
+		}
+	} else {
+		fmt.Println(string(data))
+	}
+	if !result.Diff.Equal {
+		return 1
+	}
+	return 0
+}
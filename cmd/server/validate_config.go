@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
+)
+
+// runValidateConfig implements the --validate-config flag. It validates the candidate file
+// without starting the server and, when the running config file exists, prints the changes
+// the candidate would introduce. The exit code is 0 when the candidate is valid, 1 when it
+// has errors and 2 when it cannot be read.
+func runValidateConfig(candidatePath, runningPath string) int {
+	data, err := os.ReadFile(candidatePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "validate-config: %v\n", err)
+		return 2
+	}
+	report := config.ValidateConfigData(data, candidatePath)
+	for _, issue := range report.Errors {
+		fmt.Println(formatValidationIssue(candidatePath, issue))
+	}
+	for _, issue := range report.Warnings {
+		fmt.Println(formatValidationIssue(candidatePath, issue))
+	}
+
+	if report.Config != nil && runningPath != "" {
+		if _, errStat := os.Stat(runningPath); errStat == nil {
+			running, errLoad := config.LoadConfigOptional(runningPath, false)
+			if errLoad != nil {
+				fmt.Fprintf(os.Stderr, "validate-config: load running config %s: %v\n", runningPath, errLoad)
+			} else if changes := diff.BuildConfigChangeDetails(running, report.Config); len(changes) > 0 {
+				fmt.Printf("changes against %s:\n", runningPath)
+				for _, change := range changes {
+					fmt.Printf("  %s\n", change)
+				}
+			} else {
+				fmt.Printf("no changes against %s\n", runningPath)
+			}
+		}
+	}
+
+	if !report.Valid {
+		fmt.Printf("%s: %d error(s), %d warning(s)\n", candidatePath, len(report.Errors), len(report.Warnings))
+		return 1
+	}
+	fmt.Printf("%s: valid (%d warning(s))\n", candidatePath, len(report.Warnings))
+	return 0
+}
+
+func formatValidationIssue(file string, issue config.ValidationIssue) string {
+	location := file
+	if issue.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", file, issue.Line, issue.Column)
+	}
+	if issue.Path != "" {
+		return fmt.Sprintf("%s: %s: %s: %s", location, issue.Severity, issue.Path, issue.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, issue.Severity, issue.Message)
+}
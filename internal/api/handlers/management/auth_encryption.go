@@ -0,0 +1,64 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+)
+
+// GetAuthEncryptionStatus reports whether auth files are encrypted at rest and
+// how many files are still plaintext or sealed with a previous master key.
+func (h *Handler) GetAuthEncryptionStatus(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "configuration unavailable"})
+		return
+	}
+	status, err := authcrypt.Inspect(h.cfg.AuthDir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// ReencryptAuthFiles rewrites every stale auth file with the current master key and
+// pushes the changed files to the token store. Rotate the master key by moving the
+// old key to AUTH_ENCRYPTION_PREVIOUS_KEYS, restarting, and calling this endpoint.
+func (h *Handler) ReencryptAuthFiles(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "configuration unavailable"})
+		return
+	}
+	if authcrypt.Current() == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("auth encryption is not configured; set %s or %s", authcrypt.EnvKey, authcrypt.EnvKeyFile)})
+		return
+	}
+	if strings.TrimSpace(h.cfg.AuthDir) == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "auth directory not configured"})
+		return
+	}
+	report, err := authcrypt.ResealDir(h.cfg.AuthDir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(report.Resealed) > 0 {
+		if persister, ok := h.tokenStoreWithBaseDir().(interface {
+			PersistAuthFiles(ctx context.Context, message string, paths ...string) error
+		}); ok {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+			defer cancel()
+			message := fmt.Sprintf("Re-encrypt %d auth files", len(report.Resealed))
+			if errPersist := persister.PersistAuthFiles(ctx, message, report.Resealed...); errPersist != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("files re-encrypted locally but not persisted: %v", errPersist), "report": report})
+				return
+			}
+		}
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -119,7 +120,12 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_yaml", "message": err.Error()})
 		return
 	}
-	// Validate config using LoadConfigOptional with optional=false to enforce parsing
+	current, _ := os.ReadFile(h.configFilePath)
+	if errManaged := config.CheckManagedChange(current, body); errManaged != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden_change", "message": errManaged.Error()})
+		return
+	}
+	// Validate config the way it would load, without resolving secret references.
 	tmpDir := filepath.Dir(h.configFilePath)
 	tmpFile, err := os.CreateTemp(tmpDir, "config-validate-*.yaml")
 	if err != nil {
@@ -141,13 +147,14 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 	defer func() {
 		_ = os.Remove(tempFile)
 	}()
-	_, err = config.LoadConfigOptional(tempFile, false)
+	_, err = config.LoadConfigCandidate(tempFile)
 	if err != nil {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_config", "message": err.Error()})
 		return
 	}
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	previous, _ := os.ReadFile(h.configFilePath)
 	if WriteConfig(h.configFilePath, body) != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "write_failed", "message": "failed to write config"})
 		return
@@ -159,12 +166,28 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 		return
 	}
 	h.cfg = newCfg
+	h.recordConfigVersionLocked(c, previous, "put-yaml")
 	c.JSON(http.StatusOK, gin.H{"ok": true, "changed": []string{"config"}})
 }
 
 // GetConfigYAML returns the raw config.yaml file bytes without re-encoding.
 // It preserves comments and original formatting/styles.
+//
+// ?view=merged returns the document after includes, the overlay and ${VAR} interpolation,
+// ?view=files lists every source file with its content, and ?file=<path> returns one
+// source file, addressed by its path relative to the config directory.
 func (h *Handler) GetConfigYAML(c *gin.Context) {
+	switch {
+	case c.Query("view") == "merged":
+		h.getMergedConfigYAML(c)
+		return
+	case c.Query("view") == "files":
+		h.getConfigSourceFiles(c)
+		return
+	case c.Query("file") != "":
+		h.getConfigSourceFile(c, c.Query("file"))
+		return
+	}
 	data, err := os.ReadFile(h.configFilePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -181,6 +204,98 @@ func (h *Handler) GetConfigYAML(c *gin.Context) {
 	_, _ = c.Writer.Write(data)
 }
 
+func writeConfigYAML(c *gin.Context, data []byte) {
+	c.Header("Content-Type", "application/yaml; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	_, _ = c.Writer.Write(data)
+}
+
+func (h *Handler) getMergedConfigYAML(c *gin.Context) {
+	composition, err := config.ComposeConfigFile(h.configFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found", "message": "config file not found"})
+			return
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_config", "message": err.Error()})
+		return
+	}
+	c.Header("X-Config-Composed", strconv.FormatBool(composition.Composed))
+	writeConfigYAML(c, composition.Data)
+}
+
+type configSourceFile struct {
+	Path    string `json:"path"`
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+func (h *Handler) configSources(c *gin.Context) ([]config.ConfigSource, bool) {
+	composition, err := config.ComposeConfigFile(h.configFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found", "message": "config file not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_config", "message": err.Error()})
+		return nil, false
+	}
+	return composition.Sources, true
+}
+
+// relativeConfigPath shows source paths relative to the config directory when possible.
+func (h *Handler) relativeConfigPath(path string) string {
+	baseDir, err := filepath.Abs(filepath.Dir(h.configFilePath))
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (h *Handler) getConfigSourceFiles(c *gin.Context) {
+	sources, ok := h.configSources(c)
+	if !ok {
+		return
+	}
+	files := make([]configSourceFile, 0, len(sources))
+	for _, source := range sources {
+		data, err := os.ReadFile(source.Path)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "read_failed", "message": err.Error()})
+			return
+		}
+		files = append(files, configSourceFile{Path: h.relativeConfigPath(source.Path), Role: source.Role, Content: string(data)})
+	}
+	c.JSON(http.StatusOK, gin.H{"files": files})
+}
+
+// getConfigSourceFile serves one source file. Only files that are part of the current
+// composition can be read.
+func (h *Handler) getConfigSourceFile(c *gin.Context, name string) {
+	sources, ok := h.configSources(c)
+	if !ok {
+		return
+	}
+	for _, source := range sources {
+		if h.relativeConfigPath(source.Path) != name && source.Path != name {
+			continue
+		}
+		data, err := os.ReadFile(source.Path)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "read_failed", "message": err.Error()})
+			return
+		}
+		writeConfigYAML(c, data)
+		return
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "not_found", "message": "file is not part of the config"})
+}
+
 // Debug
 func (h *Handler) GetDebug(c *gin.Context) { c.JSON(200, gin.H{"debug": h.cfg.Debug}) }
 func (h *Handler) PutDebug(c *gin.Context) { h.updateBoolField(c, func(v bool) { h.cfg.Debug = v }) }
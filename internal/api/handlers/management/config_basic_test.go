@@ -0,0 +1,153 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestGetConfigYAML_ComposedViews(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	mainContent := "include: providers/*.yaml\nport: 2456\n"
+	if err := os.MkdirAll(filepath.Join(dir, "providers"), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte(mainContent), 0o644); err != nil {
+		t.Fatalf("WriteFile(config): %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "providers", "keys.yaml"), []byte("api-keys:\n  - included-key\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile(include): %v", err)
+	}
+	cfg, err := internalconfig.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	handler := NewHandler(cfg, configPath, nil)
+	router := gin.New()
+	router.GET("/config.yaml", handler.GetConfigYAML)
+	router.PUT("/debug", handler.PutDebug)
+	get := func(target string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, target, nil))
+		return resp
+	}
+
+	if resp := get("/config.yaml"); resp.Body.String() != mainContent {
+		t.Fatalf("raw view = %q, want main file", resp.Body.String())
+	}
+	resp := get("/config.yaml?view=merged")
+	if body := resp.Body.String(); !strings.Contains(body, "included-key") || strings.Contains(body, "include:") {
+		t.Fatalf("merged view = %q", body)
+	}
+
+	resp = get("/config.yaml?view=files")
+	var files struct {
+		Files []configSourceFile `json:"files"`
+	}
+	if err = json.Unmarshal(resp.Body.Bytes(), &files); err != nil || len(files.Files) != 2 {
+		t.Fatalf("files view = %s, err=%v", resp.Body.String(), err)
+	}
+	if files.Files[1].Path != "providers/keys.yaml" || files.Files[1].Role != internalconfig.ConfigSourceInclude {
+		t.Fatalf("unexpected include entry: %+v", files.Files[1])
+	}
+
+	if resp = get("/config.yaml?file=providers/keys.yaml"); !strings.Contains(resp.Body.String(), "included-key") {
+		t.Fatalf("file view = %q", resp.Body.String())
+	}
+	if resp = get("/config.yaml?file=../secrets.yaml"); resp.Code != http.StatusNotFound {
+		t.Fatalf("foreign file status = %d, want 404", resp.Code)
+	}
+
+	put := httptest.NewRecorder()
+	router.ServeHTTP(put, httptest.NewRequest(http.MethodPut, "/debug", strings.NewReader(`{"value":true}`)))
+	if put.Code != http.StatusConflict {
+		t.Fatalf("field update on composed config status = %d, want 409", put.Code)
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != mainContent {
+		t.Fatalf("main file modified: %q", data)
+	}
+}
+
+func TestPutConfigYAML_RejectsNewSecretReferences(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	mainContent := "port: 2456\n"
+	if err := os.WriteFile(configPath, []byte(mainContent), 0o644); err != nil {
+		t.Fatalf("WriteFile(config): %v", err)
+	}
+	cfg, err := internalconfig.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	handler := NewHandler(cfg, configPath, nil)
+	router := gin.New()
+	router.PUT("/config.yaml", handler.PutConfigYAML)
+
+	marker := filepath.Join(dir, "ran")
+	body := "port: 2456\nsecret-refs:\n  allow-file-and-exec: true\ncodex-api-key:\n  - api-key: \"exec:touch " + marker + "\"\n    base-url: https://api.openai.com/v1\n"
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPut, "/config.yaml", strings.NewReader(body)))
+	if resp.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403: %s", resp.Code, resp.Body.String())
+	}
+	if _, err = os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("exec: reference was run (stat err = %v)", err)
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != mainContent {
+		t.Fatalf("config file modified: %q", data)
+	}
+}
+
+func TestPersist_RestoresConfigOnNewSecretReferenceAndKeepsKnownOnes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TEST_MGMT_CODEX_KEY", "sk-codex-from-env")
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	mainContent := "port: 2456\ncodex-api-key:\n  - api-key: \"env:TEST_MGMT_CODEX_KEY\"\n    base-url: https://api.openai.com/v1\n"
+	if err := os.WriteFile(configPath, []byte(mainContent), 0o644); err != nil {
+		t.Fatalf("WriteFile(config): %v", err)
+	}
+	cfg, err := internalconfig.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	handler := NewHandler(cfg, configPath, nil)
+	router := gin.New()
+	router.PUT("/codex-api-key", handler.PutCodexKeys)
+	put := func(body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodPut, "/codex-api-key", strings.NewReader(body)))
+		return resp
+	}
+
+	resp := put(`[{"api-key":"env:HOME","base-url":"https://api.openai.com/v1"}]`)
+	if resp.Code != http.StatusForbidden {
+		t.Fatalf("new reference: status = %d, want 403: %s", resp.Code, resp.Body.String())
+	}
+	if keys := handler.cfg.CodexKey; len(keys) != 1 || keys[0].APIKey != "sk-codex-from-env" {
+		t.Fatalf("rejected change left in memory: %+v", keys)
+	}
+
+	// The reference already in the file may be sent back, here behind a new entry.
+	resp = put(`[{"api-key":"sk-plain","base-url":"https://example.com/v1"},{"api-key":"env:TEST_MGMT_CODEX_KEY","base-url":"https://api.openai.com/v1"}]`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("known reference: status = %d, want 200: %s", resp.Code, resp.Body.String())
+	}
+	if keys := handler.cfg.CodexKey; len(keys) != 2 || keys[1].APIKey != "sk-codex-from-env" {
+		t.Fatalf("known reference not resolved in memory: %+v", keys)
+	}
+	data, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(data), "env:TEST_MGMT_CODEX_KEY") || strings.Contains(string(data), "sk-codex-from-env") {
+		t.Fatalf("saved config = %s, want the reference and not the secret", data)
+	}
+}
@@ -0,0 +1,221 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/confighistory"
+	log "github.com/sirupsen/logrus"
+)
+
+// configAuthorHeader lets management clients name the person or tool making a change.
+const configAuthorHeader = "X-Config-Author"
+
+// configHistoryLocked returns the history store for the current config, rebuilding it when
+// the configured directory or limit changes. Callers hold h.mu.
+func (h *Handler) configHistoryLocked() *confighistory.Store {
+	if h.cfg == nil {
+		return nil
+	}
+	store := confighistory.ForConfig(h.configFilePath, h.cfg.ConfigHistory)
+	if store == nil {
+		h.history = nil
+		return nil
+	}
+	if h.history == nil || h.history.Dir() != store.Dir() || h.history.MaxVersions() != store.MaxVersions() {
+		h.history = store
+	}
+	return h.history
+}
+
+func (h *Handler) configHistory() *confighistory.Store {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.configHistoryLocked()
+}
+
+// recordConfigVersionLocked snapshots the config file after a successful write.
+// Failures are logged; the write itself has already been accepted. Callers hold h.mu.
+func (h *Handler) recordConfigVersionLocked(c *gin.Context, previous []byte, source string) {
+	store := h.configHistoryLocked()
+	if store == nil {
+		return
+	}
+	current, err := os.ReadFile(h.configFilePath)
+	if err != nil {
+		log.WithError(err).Warn("config history: failed to read config after write")
+		return
+	}
+	if _, err = store.Record(previous, current, configAuthor(c), source); err != nil {
+		log.WithError(err).Warn("config history: failed to record version")
+	}
+}
+
+func configAuthor(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	if author := strings.TrimSpace(c.GetHeader(configAuthorHeader)); author != "" {
+		return author
+	}
+	return "management (" + c.ClientIP() + ")"
+}
+
+// ListConfigVersions returns the stored config snapshots, newest first.
+func (h *Handler) ListConfigVersions(c *gin.Context) {
+	store := h.configHistory()
+	if store == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false, "versions": []confighistory.Version{}})
+		return
+	}
+	versions, err := store.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "read_failed", "message": err.Error()})
+		return
+	}
+	if versions == nil {
+		versions = []confighistory.Version{}
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "versions": versions})
+}
+
+// GetConfigVersion returns the metadata and raw YAML of one snapshot.
+func (h *Handler) GetConfigVersion(c *gin.Context) {
+	version, content, ok := h.loadConfigVersion(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": version, "content": string(content)})
+}
+
+// DiffConfigVersions compares two snapshots. "to" defaults to the current config file,
+// which is also addressable explicitly as "current".
+func (h *Handler) DiffConfigVersions(c *gin.Context) {
+	from := strings.TrimSpace(c.Query("from"))
+	if from == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": "from is required"})
+		return
+	}
+	to := strings.TrimSpace(c.Query("to"))
+	if to == "" {
+		to = "current"
+	}
+	fromContent, ok := h.configVersionContent(c, from)
+	if !ok {
+		return
+	}
+	toContent, ok := h.configVersionContent(c, to)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":    from,
+		"to":      to,
+		"summary": confighistory.Summarize(fromContent, toContent),
+		"diff":    confighistory.UnifiedDiff(from, to, fromContent, toContent),
+	})
+}
+
+// RollbackConfigVersion validates a snapshot and writes it back to config.yaml. The file
+// watcher picks up the write and hot reloads the server like any other config change.
+func (h *Handler) RollbackConfigVersion(c *gin.Context) {
+	id := c.Param("id")
+	target, content, ok := h.loadConfigVersion(c, id)
+	if !ok {
+		return
+	}
+	current, _ := os.ReadFile(h.configFilePath)
+	if errManaged := config.CheckManagedChange(current, content); errManaged != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden_change", "message": errManaged.Error()})
+		return
+	}
+	if errValidate := validateConfigBytes(filepath.Dir(h.configFilePath), content); errValidate != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_config", "message": errValidate.Error()})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	previous, err := os.ReadFile(h.configFilePath)
+	if err != nil && !os.IsNotExist(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "read_failed", "message": err.Error()})
+		return
+	}
+	if err = WriteConfig(h.configFilePath, content); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "write_failed", "message": err.Error()})
+		return
+	}
+	newCfg, err := config.LoadConfig(h.configFilePath)
+	if err != nil {
+		// Put the previous file back so a failed rollback leaves the server as it was.
+		if len(previous) > 0 {
+			if errRestore := WriteConfig(h.configFilePath, previous); errRestore != nil {
+				log.WithError(errRestore).Error("config history: failed to restore config after failed rollback")
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "reload_failed", "message": err.Error()})
+		return
+	}
+	h.cfg = newCfg
+	h.recordConfigVersionLocked(c, previous, confighistory.SourceRollback+":"+target.ID)
+	c.JSON(http.StatusOK, gin.H{"ok": true, "rolled-back-to": target.ID, "changed": []string{"config"}})
+}
+
+func (h *Handler) loadConfigVersion(c *gin.Context, id string) (confighistory.Version, []byte, bool) {
+	store := h.configHistory()
+	if store == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found", "message": "config history is disabled"})
+		return confighistory.Version{}, nil, false
+	}
+	version, content, err := store.Get(id)
+	if errors.Is(err, confighistory.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found", "message": "config version not found"})
+		return confighistory.Version{}, nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "read_failed", "message": err.Error()})
+		return confighistory.Version{}, nil, false
+	}
+	return version, content, true
+}
+
+func (h *Handler) configVersionContent(c *gin.Context, id string) ([]byte, bool) {
+	if id != "current" {
+		_, content, ok := h.loadConfigVersion(c, id)
+		return content, ok
+	}
+	content, err := os.ReadFile(h.configFilePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "read_failed", "message": err.Error()})
+		return nil, false
+	}
+	return content, true
+}
+
+// validateConfigBytes parses data the same way the server loads config.yaml, without
+// resolving secret references, using a temporary file next to the real one so relative
+// paths resolve identically.
+func validateConfigBytes(dir string, data []byte) error {
+	tmpFile, err := os.CreateTemp(dir, "config-validate-*.yaml")
+	if err != nil {
+		return err
+	}
+	tempFile := tmpFile.Name()
+	defer func() {
+		_ = os.Remove(tempFile)
+	}()
+	if _, err = tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	_, err = config.LoadConfigCandidate(tempFile)
+	return err
+}
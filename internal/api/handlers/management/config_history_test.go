@@ -0,0 +1,83 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/confighistory"
+)
+
+func TestConfigHistory_RecordsWritesAndRollsBack(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	original := "port: 2456\ndebug: false\n"
+	if err := os.WriteFile(configPath, []byte(original), 0o644); err != nil {
+		t.Fatalf("WriteFile(config): %v", err)
+	}
+	cfg, err := internalconfig.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	handler := NewHandler(cfg, configPath, nil)
+	router := gin.New()
+	mgmt := router.Group("/v0/management")
+	{
+		mgmt.PUT("/config.yaml", handler.PutConfigYAML)
+		mgmt.GET("/config/versions", handler.ListConfigVersions)
+		mgmt.GET("/config/versions/:id", handler.GetConfigVersion)
+		mgmt.POST("/config/versions/:id/rollback", handler.RollbackConfigVersion)
+		mgmt.GET("/config/diff", handler.DiffConfigVersions)
+	}
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(configAuthorHeader, "alice")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	if resp := do(http.MethodPut, "/v0/management/config.yaml", "port: 2456\ndebug: true\n"); resp.Code != http.StatusOK {
+		t.Fatalf("PUT config.yaml status = %d, body=%s", resp.Code, resp.Body.String())
+	}
+
+	resp := do(http.MethodGet, "/v0/management/config/versions", "")
+	var listed struct {
+		Versions []confighistory.Version `json:"versions"`
+	}
+	if err = json.Unmarshal(resp.Body.Bytes(), &listed); err != nil || len(listed.Versions) != 2 {
+		t.Fatalf("versions = %s, err=%v; want baseline and edit", resp.Body.String(), err)
+	}
+	edit, baseline := listed.Versions[0], listed.Versions[1]
+	if baseline.Source != confighistory.SourceBaseline || edit.Author != "alice" || edit.Source != "put-yaml" {
+		t.Fatalf("unexpected versions: %+v", listed.Versions)
+	}
+	if len(edit.Summary) == 0 || !strings.Contains(strings.Join(edit.Summary, "\n"), "debug") {
+		t.Fatalf("summary = %v, want debug change", edit.Summary)
+	}
+
+	resp = do(http.MethodGet, "/v0/management/config/diff?from="+baseline.ID, "")
+	if resp.Code != http.StatusOK || !strings.Contains(resp.Body.String(), `-debug: false\n+debug: true`) {
+		t.Fatalf("diff status = %d, body=%s", resp.Code, resp.Body.String())
+	}
+
+	if resp = do(http.MethodPost, "/v0/management/config/versions/"+baseline.ID+"/rollback", ""); resp.Code != http.StatusOK {
+		t.Fatalf("rollback status = %d, body=%s", resp.Code, resp.Body.String())
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != original {
+		t.Fatalf("config after rollback = %q, want %q", data, original)
+	}
+	if handler.cfg.Debug {
+		t.Fatal("handler config not reloaded after rollback")
+	}
+
+	if resp = do(http.MethodGet, "/v0/management/config/versions/..%2Fconfig", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("malformed id status = %d, want 404", resp.Code)
+	}
+}
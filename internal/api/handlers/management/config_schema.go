@@ -0,0 +1,14 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/configschema"
+)
+
+// GetConfigSchema returns the JSON Schema of config.yaml for editors and form generation.
+func (h *Handler) GetConfigSchema(c *gin.Context) {
+	c.Header("Content-Type", "application/schema+json")
+	c.JSON(http.StatusOK, configschema.ConfigSchema())
+}
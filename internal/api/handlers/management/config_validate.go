@@ -0,0 +1,62 @@
+package management
+
+import (
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/confighistory"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
+)
+
+// PostConfigVerb serves custom-verb routes such as POST /config:validate. Gin has no
+// literal-colon routes, so the route is registered as a wildcard that captures ":<verb>".
+func (h *Handler) PostConfigVerb(c *gin.Context) {
+	switch c.Param("verb") {
+	case ":validate":
+		h.ValidateConfigYAML(c)
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+	}
+}
+
+// ValidateConfigYAML runs full semantic validation of a candidate config.yaml and previews
+// how it differs from the running config. Nothing is written or reloaded.
+func (h *Handler) ValidateConfigYAML(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_yaml", "message": "cannot read request body"})
+		return
+	}
+
+	h.mu.Lock()
+	running := h.cfg
+	configFilePath := h.configFilePath
+	h.mu.Unlock()
+
+	// Documents the management API may not apply are not loaded at all, so their includes,
+	// variables and secret references are never read.
+	current, _ := os.ReadFile(configFilePath)
+	var report *config.ValidationReport
+	if errManaged := config.CheckManagedChange(current, body); errManaged != nil {
+		report = &config.ValidationReport{
+			Errors:   []config.ValidationIssue{{Severity: config.ValidationSeverityError, Message: errManaged.Error()}},
+			Warnings: []config.ValidationIssue{},
+		}
+	} else {
+		report = config.ValidateConfigData(body, configFilePath)
+	}
+	changes := []string{}
+	if report.Config != nil && running != nil {
+		changes = diff.BuildConfigChangeDetails(running, report.Config)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"valid":    report.Valid,
+		"errors":   report.Errors,
+		"warnings": report.Warnings,
+		"changes":  changes,
+		"diff":     confighistory.UnifiedDiff("current", "candidate", current, body),
+	})
+}
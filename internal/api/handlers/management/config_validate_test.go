@@ -0,0 +1,75 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestValidateConfigYAML_ReportsWithoutApplying(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	original := "port: 2456\ndebug: false\n"
+	if err := os.WriteFile(configPath, []byte(original), 0o644); err != nil {
+		t.Fatalf("WriteFile(config): %v", err)
+	}
+	cfg, err := internalconfig.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	handler := NewHandler(cfg, configPath, nil)
+	router := gin.New()
+	router.POST("/v0/management/config:verb", handler.PostConfigVerb)
+	do := func(target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	var result struct {
+		Valid   bool                             `json:"valid"`
+		Errors  []internalconfig.ValidationIssue `json:"errors"`
+		Changes []string                         `json:"changes"`
+		Diff    string                           `json:"diff"`
+	}
+	resp := do("/v0/management/config:validate", "port: 2456\ndebug: true\n")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("validate status = %d, body=%s", resp.Code, resp.Body.String())
+	}
+	if err = json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !result.Valid || strings.Join(result.Changes, "\n") != "debug: false -> true" {
+		t.Fatalf("result = %+v, want valid with debug change", result)
+	}
+	if !strings.Contains(result.Diff, "+debug: true") {
+		t.Fatalf("diff = %q, want debug line", result.Diff)
+	}
+
+	resp = do("/v0/management/config:validate", "port: 2456\nproxy-url: ftp://proxy.local\n")
+	result.Errors = nil
+	if err = json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Path != "proxy-url" {
+		t.Fatalf("result = %+v, want proxy-url error", result)
+	}
+
+	if data, _ := os.ReadFile(configPath); string(data) != original {
+		t.Fatalf("config changed by validation: %q", data)
+	}
+	if handler.cfg.Debug {
+		t.Fatal("handler config changed by validation")
+	}
+	if resp = do("/v0/management/config:apply", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("unknown verb status = %d, want 404", resp.Code)
+	}
+}
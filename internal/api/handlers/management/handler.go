@@ -4,6 +4,7 @@ package management
 
 import (
 	"crypto/subtle"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -15,9 +16,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/confighistory"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -47,6 +50,8 @@ type Handler struct {
 	allowRemoteOverride bool
 	envSecret           string
 	logDir              string
+	history             *confighistory.Store
+	relay               WebsocketRelay
 }
 
 // NewHandler creates a new management handler instance.
@@ -112,6 +117,9 @@ func (h *Handler) SetAuthManager(manager *coreauth.Manager) { h.authManager = ma
 // SetUsageStatistics allows replacing the usage statistics reference.
 func (h *Handler) SetUsageStatistics(stats *usage.RequestStatistics) { h.usageStats = stats }
 
+// SetWebsocketRelay supplies the websocket relay hooks used by the ws-relay endpoints.
+func (h *Handler) SetWebsocketRelay(relay WebsocketRelay) { h.relay = relay }
+
 // SetLocalPassword configures the runtime-local password accepted for localhost requests.
 func (h *Handler) SetLocalPassword(password string) { h.localPassword = password }
 
@@ -270,11 +278,28 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 func (h *Handler) persist(c *gin.Context) bool {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	if paths := h.cfg.AdoptSecretRefs(); len(paths) > 0 {
+		// The caller already applied the change; go back to the config on disk.
+		if restored, errLoad := config.LoadConfig(h.configFilePath); errLoad == nil {
+			h.cfg = restored
+		} else {
+			log.WithError(errLoad).Error("failed to reload config after rejected change")
+		}
+		err := &config.ManagedChangeError{Violations: []string{"secret references at " + strings.Join(paths, ", ")}}
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden_change", "message": err.Error()})
+		return false
+	}
+	previous, _ := os.ReadFile(h.configFilePath)
 	// Preserve comments when writing
 	if err := config.SaveConfigPreserveComments(h.configFilePath, h.cfg); err != nil {
+		if errors.Is(err, config.ErrComposedConfig) {
+			c.JSON(http.StatusConflict, gin.H{"error": "composed_config", "message": err.Error()})
+			return false
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", err)})
 		return false
 	}
+	h.recordConfigVersionLocked(c, previous, "management")
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	return true
 }
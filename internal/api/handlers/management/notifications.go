@@ -0,0 +1,72 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
+)
+
+// GetNotificationStatus reports configured webhook targets and delivery counters.
+func (h *Handler) GetNotificationStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, notify.Default().Status())
+}
+
+// GetNotificationDeadLetters returns recent notifications that could not be delivered.
+func (h *Handler) GetNotificationDeadLetters(c *gin.Context) {
+	items := notify.Default().DeadLetters()
+	if items == nil {
+		items = []notify.DeadLetter{}
+	}
+	c.JSON(http.StatusOK, gin.H{"dead_letters": items})
+}
+
+type testNotificationRequest struct {
+	Target  string `json:"target"`
+	Message string `json:"message"`
+}
+
+// SendTestNotification delivers a test event synchronously and reports the per-target outcome.
+// The optional body selects a single "target" by name and overrides the "message".
+func (h *Handler) SendTestNotification(c *gin.Context) {
+	notifier := notify.Default()
+	if notifier == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notifications not enabled"})
+		return
+	}
+	var body testNotificationRequest
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+	}
+	message := strings.TrimSpace(body.Message)
+	if message == "" {
+		message = "test notification from CLIProxyAPI"
+	}
+	results, err := notifier.Send(c.Request.Context(), body.Target, notify.Event{
+		Type:     notify.EventTest,
+		Severity: notify.SeverityInfo,
+		Message:  message,
+	})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	status := http.StatusOK
+	for _, result := range results {
+		if result.Error != "" {
+			status = http.StatusBadGateway
+			break
+		}
+	}
+	c.JSON(status, gin.H{"results": results})
+}
@@ -1,19 +1,25 @@
 package management
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/promptqueue"
 )
 
+// GetPromptQueueMetrics returns prompt queue counters and the current depth of each session.
 func (h *Handler) GetPromptQueueMetrics(c *gin.Context) {
 	manager := promptqueue.GetDefaultManager()
 	c.JSON(http.StatusOK, manager.MetricsSnapshot())
 }
 
+// GetPromptQueueSubmissions lists queued, running and finished submissions, newest first,
+// optionally filtered by status and session_key.
 func (h *Handler) GetPromptQueueSubmissions(c *gin.Context) {
 	manager := promptqueue.GetDefaultManager()
 	status := strings.TrimSpace(c.Query("status"))
@@ -34,6 +40,7 @@ func (h *Handler) GetPromptQueueSubmissions(c *gin.Context) {
 	})
 }
 
+// GetPromptQueueEvents returns queue events after since_seq, optionally for one session_key.
 func (h *Handler) GetPromptQueueEvents(c *gin.Context) {
 	manager := promptqueue.GetDefaultManager()
 	sessionKey := strings.TrimSpace(c.Query("session_key"))
@@ -47,6 +54,69 @@ func (h *Handler) GetPromptQueueEvents(c *gin.Context) {
 	})
 }
 
+// StreamPromptQueueEvents streams queue events as Server-Sent Events. Events after since_seq
+// or the Last-Event-ID header are replayed first; session_key limits the stream to one session.
+//
+// Event types: submission_queued, queue_overloaded, submission_started, submission_succeeded,
+// submission_failed.
+func (h *Handler) StreamPromptQueueEvents(c *gin.Context) {
+	manager := promptqueue.GetDefaultManager()
+	sessionKey := strings.TrimSpace(c.Query("session_key"))
+	lastSeq := parseInt64(c.Query("since_seq"), 0)
+	if lastSeq == 0 {
+		lastSeq = parseInt64(c.GetHeader("Last-Event-ID"), 0)
+	}
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+
+	subID, events := manager.Subscribe()
+	defer manager.Unsubscribe(subID)
+
+	// The subscription only signals new events; EventsSince delivers them in order and fills
+	// anything a slow subscriber dropped.
+	flushSince := func() {
+		for {
+			batch := manager.EventsSince(sessionKey, lastSeq, 500)
+			for _, event := range batch {
+				_, _ = c.Writer.Write(promptQueueEventToSSE(event))
+				lastSeq = event.Seq
+			}
+			if len(batch) < 500 {
+				break
+			}
+		}
+		c.Writer.Flush()
+	}
+	_, _ = c.Writer.Write([]byte(": connected\n\n"))
+	flushSince()
+
+	clientGone := c.Request.Context().Done()
+	heartbeat := time.NewTicker(20 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-clientGone:
+			return
+		case <-heartbeat.C:
+			_, _ = c.Writer.Write([]byte(": heartbeat\n\n"))
+			c.Writer.Flush()
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+			flushSince()
+		}
+	}
+}
+
+func promptQueueEventToSSE(event promptqueue.Event) []byte {
+	data, _ := json.Marshal(event)
+	return []byte(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data))
+}
+
 func parseNonNegativeInt(raw string, fallback int) int {
 	value, err := strconv.Atoi(strings.TrimSpace(raw))
 	if err != nil || value < 0 {
@@ -0,0 +1,170 @@
+package management
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/replay"
+)
+
+type replayRequest struct {
+	File        string            `json:"file"`
+	RequestID   string            `json:"request_id"`
+	Target      string            `json:"target"`
+	Model       string            `json:"model"`
+	APIKey      string            `json:"api_key"`
+	Headers     map[string]string `json:"headers"`
+	IgnorePaths []string          `json:"ignore_paths"`
+	TimeoutSecs int               `json:"timeout_seconds"`
+}
+
+// ReplayRequestLog re-issues a captured request log and returns a structured diff
+// between the recorded and the new response.
+// Body:
+//   - file or request_id: Log file name in the log directory, or its request ID
+//   - target: Base URL to replay against (default: this proxy)
+//   - model: Optional model override
+//   - api_key: Client API key to send; captured credentials are never replayed. Required
+//     when target is not this proxy
+//   - headers: Extra headers for the replayed request
+//   - ignore_paths: JSON paths excluded from the diff
+//   - timeout_seconds: Replay timeout (default 300)
+func (h *Handler) ReplayRequestLog(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return
+	}
+	if h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "configuration unavailable"})
+		return
+	}
+	dir := h.logDirectory()
+	if strings.TrimSpace(dir) == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "log directory not configured"})
+		return
+	}
+
+	var body replayRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if target := strings.TrimSpace(body.Target); target != "" && !isLocalReplayTarget(target, h.cfg.Port) && strings.TrimSpace(body.APIKey) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "api_key is required to replay against a target other than this proxy"})
+		return
+	}
+	path, status, err := resolveRequestLogFile(dir, strings.TrimSpace(body.File), strings.TrimSpace(body.RequestID))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	capture, err := replay.ParseLogFile(path)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := replay.Options{
+		Target:      strings.TrimSpace(body.Target),
+		Model:       body.Model,
+		APIKey:      body.APIKey,
+		Headers:     body.Headers,
+		IgnorePaths: body.IgnorePaths,
+		Timeout:     time.Duration(body.TimeoutSecs) * time.Second,
+	}
+	if opts.Target == "" {
+		scheme := "http"
+		if h.cfg.TLS.Enable {
+			scheme = "https"
+			// The loopback listener usually presents a certificate for the public name.
+			opts.Client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+		}
+		opts.Target = fmt.Sprintf("%s://127.0.0.1:%d", scheme, h.cfg.Port)
+	}
+
+	result, err := replay.Replay(c.Request.Context(), capture, opts)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"file":   filepath.Base(path),
+		"result": result,
+	})
+}
+
+// isLocalReplayTarget reports whether target is this proxy's loopback listener.
+func isLocalReplayTarget(target string, port int) bool {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	if parsed.Port() != strconv.Itoa(port) {
+		return false
+	}
+	host := parsed.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// resolveRequestLogFile locates a request log by file name or request ID, keeping
+// the result inside dir. It returns an HTTP status alongside any error.
+func resolveRequestLogFile(dir, name, requestID string) (string, int, error) {
+	if name == "" && requestID == "" {
+		return "", http.StatusBadRequest, fmt.Errorf("file or request_id is required")
+	}
+	if strings.ContainsAny(name+requestID, "/\\") {
+		return "", http.StatusBadRequest, fmt.Errorf("invalid file name")
+	}
+	if name == "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return "", http.StatusNotFound, fmt.Errorf("log directory not found")
+			}
+			return "", http.StatusInternalServerError, fmt.Errorf("failed to list log directory: %v", err)
+		}
+		suffix := "-" + requestID + ".log"
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), suffix) {
+				name = entry.Name()
+				break
+			}
+		}
+		if name == "" {
+			return "", http.StatusNotFound, fmt.Errorf("log file not found for the given request ID")
+		}
+	}
+
+	dirAbs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to resolve log directory: %v", err)
+	}
+	fullPath := filepath.Clean(filepath.Join(dirAbs, name))
+	if !strings.HasPrefix(fullPath, dirAbs+string(os.PathSeparator)) {
+		return "", http.StatusBadRequest, fmt.Errorf("invalid log file path")
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", http.StatusNotFound, fmt.Errorf("log file not found")
+		}
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to read log file: %v", err)
+	}
+	if info.IsDir() {
+		return "", http.StatusBadRequest, fmt.Errorf("invalid log file")
+	}
+	return fullPath, http.StatusOK, nil
+}
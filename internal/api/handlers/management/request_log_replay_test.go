@@ -0,0 +1,43 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestReplayRequestLog_RequiresKeyForExternalTargets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := &Handler{cfg: &config.Config{Port: 8317}, logDir: t.TempDir()}
+	router := gin.New()
+	router.POST("/v0/management/request-logs/replay", handler.ReplayRequestLog)
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v0/management/request-logs/replay", strings.NewReader(body))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := do(`{"request_id":"missing","target":"https://collector.example.com"}`)
+	if resp.Code != http.StatusBadRequest || !strings.Contains(resp.Body.String(), "api_key is required") {
+		t.Fatalf("external target without key: status = %d, body=%s", resp.Code, resp.Body.String())
+	}
+	// Past the target check the request only fails because the log does not exist.
+	for _, body := range []string{
+		`{"request_id":"missing","target":"https://collector.example.com","api_key":"k"}`,
+		`{"request_id":"missing","target":"http://127.0.0.1:8317"}`,
+		`{"request_id":"missing","target":"http://localhost:8317"}`,
+		`{"request_id":"missing"}`,
+	} {
+		if resp = do(body); resp.Code != http.StatusNotFound {
+			t.Fatalf("%s: status = %d, body=%s", body, resp.Code, resp.Body.String())
+		}
+	}
+	if resp = do(`{"request_id":"missing","target":"http://127.0.0.1:9999"}`); resp.Code != http.StatusBadRequest {
+		t.Fatalf("other local port without key: status = %d", resp.Code)
+	}
+}
@@ -0,0 +1,69 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+// SearchRequestLogs searches the request log index.
+// Query params:
+//   - from, to: Time range (RFC3339, unix seconds or YYYY-MM-DD); to is exclusive
+//   - status: Exact status code ("429") or class ("4xx")
+//   - model, provider, auth_index, request_id, method: Equality filters
+//   - client_key: Client API key or its index hash
+//   - url: Substring match on the request URL
+//   - q: Case-insensitive full-text match over the log file contents
+//   - offset, limit: Pagination (default limit 100, max 1000)
+func (h *Handler) SearchRequestLogs(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return
+	}
+	dir := h.logDirectory()
+	if strings.TrimSpace(dir) == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "log directory not configured"})
+		return
+	}
+	from, to, err := parseUsageTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	query := logging.RequestLogQuery{
+		From:      from,
+		To:        to,
+		Status:    c.Query("status"),
+		Model:     c.Query("model"),
+		Provider:  c.Query("provider"),
+		AuthIndex: c.Query("auth_index"),
+		ClientKey: c.Query("client_key"),
+		RequestID: c.Query("request_id"),
+		Method:    c.Query("method"),
+		URL:       c.Query("url"),
+		Text:      c.Query("q"),
+		Offset:    offset,
+		Limit:     limit,
+	}
+	entries, total, err := logging.SearchRequestLogs(dir, query)
+	if err != nil {
+		if errors.Is(err, logging.ErrInvalidRequestLogQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+		"offset":  offset,
+		"limit":   limit,
+	})
+}
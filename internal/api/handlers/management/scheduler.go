@@ -0,0 +1,14 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/fairshare"
+)
+
+// GetSchedulerStats returns the fair-share scheduler's in-flight and waiting counts, per
+// provider and per hashed client key.
+func (h *Handler) GetSchedulerStats(c *gin.Context) {
+	c.JSON(http.StatusOK, fairshare.Default().Stats())
+}
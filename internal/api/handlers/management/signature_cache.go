@@ -0,0 +1,35 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+)
+
+// GetSignatureCache returns the thinking-signature cache counters and the entry count of each
+// model group.
+func (h *Handler) GetSignatureCache(c *gin.Context) {
+	c.JSON(http.StatusOK, cache.GetSignatureCacheStats())
+}
+
+// GetSignatureCacheEntries lists cached signatures, most recently used first, optionally for one
+// model group. Signatures are shortened to a prefix.
+func (h *Handler) GetSignatureCacheEntries(c *gin.Context) {
+	group := strings.TrimSpace(c.Query("group"))
+	limit := parseBoundedInt(c.Query("limit"), 200, 1, 5000)
+	c.JSON(http.StatusOK, gin.H{
+		"entries": cache.ListSignatureEntries(group, limit),
+		"group":   group,
+		"limit":   limit,
+	})
+}
+
+// DeleteSignatureCache clears the signatures of one model group, or all of them without group,
+// in memory and in the configured backend.
+func (h *Handler) DeleteSignatureCache(c *gin.Context) {
+	group := strings.TrimSpace(c.Query("group"))
+	cache.ClearSignatureCache(group)
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "group": group})
+}
@@ -0,0 +1,36 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// GetTranslatorRoute shows the formats a request passes through from the client format "from"
+// to the upstream format "to". Responses travel the same path in reverse.
+func (h *Handler) GetTranslatorRoute(c *gin.Context) {
+	from := strings.TrimSpace(c.Query("from"))
+	to := strings.TrimSpace(c.Query("to"))
+	if from == "" || to == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing from or to"})
+		return
+	}
+	route := sdktranslator.Route(sdktranslator.FromString(from), sdktranslator.FromString(to))
+	path := make([]string, 0, len(route))
+	for _, format := range route {
+		path = append(path, format.String())
+	}
+	hops := len(path) - 1
+	if hops < 0 {
+		hops = 0
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":  from,
+		"to":    to,
+		"path":  path,
+		"hops":  hops,
+		"found": route != nil,
+	})
+}
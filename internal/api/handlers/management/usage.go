@@ -235,7 +235,14 @@ func parseSinceSeq(c *gin.Context) int64 {
 //   - model: Filter by model name (optional)
 //   - provider: Filter by provider (optional)
 //   - success: Filter by success status (optional, "true" or "false")
+//
+// When durable usage storage is enabled, history is served from the usage store
+// and additionally supports from/to time range filters.
 func (h *Handler) GetRequestHistory(c *gin.Context) {
+	if store := usage.GetSQLStore(); store != nil {
+		h.getRequestHistoryFromStore(c, store)
+		return
+	}
 	if h == nil || h.usageStats == nil {
 		c.JSON(http.StatusOK, gin.H{"requests": []interface{}{}, "total": 0})
 		return
@@ -336,6 +343,73 @@ func (h *Handler) GetRequestHistory(c *gin.Context) {
 	})
 }
 
+func (h *Handler) getRequestHistoryFromStore(c *gin.Context, store *usage.SQLStore) {
+	from, to, err := parseUsageTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit := 100
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+		if limit > 1000 {
+			limit = 1000
+		}
+		if limit < 1 {
+			limit = 1
+		}
+	}
+	offset := 0
+	if o := c.Query("offset"); o != "" {
+		fmt.Sscanf(o, "%d", &offset)
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	query := usage.HistoryQuery{
+		From:      from,
+		To:        to,
+		Provider:  c.Query("provider"),
+		Model:     c.Query("model"),
+		APIKey:    c.Query("api_key"),
+		AuthIndex: c.Query("auth_index"),
+		Source:    c.Query("source"),
+		RequestID: c.Query("request_id"),
+		Limit:     limit,
+		Offset:    offset,
+	}
+	if raw := c.Query("success"); raw != "" {
+		wantSuccess := raw == "true"
+		query.Success = &wantSuccess
+	}
+
+	records, total, err := store.History(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	items := make([]RequestHistoryItem, 0, len(records))
+	for _, record := range records {
+		items = append(items, RequestHistoryItem{
+			Timestamp: record.RequestedAt,
+			RequestID: record.RequestID,
+			APIKey:    record.APIKey,
+			Provider:  record.Provider,
+			Model:     record.Model,
+			AuthIndex: record.AuthIndex,
+			Source:    record.Source,
+			Success:   !record.Failed,
+			Tokens:    record.Tokens.TotalTokens,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"requests": items,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
 func matchesProvider(apiKey, provider string) bool {
 	// Simple heuristic: check if the API key starts with the provider name
 	return len(apiKey) >= len(provider) && apiKey[:len(provider)] == provider
@@ -346,6 +420,7 @@ type RequestHistoryItem struct {
 	Timestamp time.Time `json:"timestamp"`
 	RequestID string    `json:"request_id,omitempty"`
 	APIKey    string    `json:"api_key"`
+	Provider  string    `json:"provider,omitempty"`
 	Model     string    `json:"model"`
 	AuthIndex string    `json:"auth_index"`
 	Source    string    `json:"source"`
@@ -0,0 +1,166 @@
+package management
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+// GetUsageStorageStatus reports whether durable usage storage is enabled and how it is configured.
+func (h *Handler) GetUsageStorageStatus(c *gin.Context) {
+	store := usage.GetSQLStore()
+	if store == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":        true,
+		"driver":         store.Driver(),
+		"location":       store.Location(),
+		"retention_days": int(store.Retention() / (24 * time.Hour)),
+	})
+}
+
+// QueryUsage aggregates durable usage records.
+// Query params:
+//   - from, to: Time range (RFC3339 or unix seconds); to is exclusive
+//   - group_by: Comma-separated dimensions (key, model, provider, auth, source)
+//   - granularity: "hour" or "day" rollups (optional)
+//   - provider, model, api_key, auth_index, source: Equality filters (optional)
+//   - limit: Max number of rows to return (default 1000, max 10000)
+func (h *Handler) QueryUsage(c *gin.Context) {
+	store := usage.GetSQLStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage storage not enabled"})
+		return
+	}
+	from, to, err := parseUsageTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var groupBy []string
+	for _, raw := range c.QueryArray("group_by") {
+		for _, part := range strings.Split(raw, ",") {
+			if trimmed := strings.TrimSpace(part); trimmed != "" {
+				groupBy = append(groupBy, trimmed)
+			}
+		}
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	query := usage.UsageQuery{
+		From:        from,
+		To:          to,
+		GroupBy:     groupBy,
+		Granularity: c.Query("granularity"),
+		Provider:    c.Query("provider"),
+		Model:       c.Query("model"),
+		APIKey:      c.Query("api_key"),
+		AuthIndex:   c.Query("auth_index"),
+		Source:      c.Query("source"),
+		Limit:       limit,
+	}
+	rows, err := store.Query(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, usage.ErrInvalidUsageQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"rows":        rows,
+		"group_by":    groupBy,
+		"granularity": query.Granularity,
+	})
+}
+
+type usagePruneRequest struct {
+	Before        string `json:"before"`
+	OlderThanDays int    `json:"older_than_days"`
+}
+
+// PruneUsage deletes durable usage records older than the requested cutoff.
+// The body accepts either "before" (RFC3339 or unix seconds) or "older_than_days".
+func (h *Handler) PruneUsage(c *gin.Context) {
+	store := usage.GetSQLStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage storage not enabled"})
+		return
+	}
+	var body usagePruneRequest
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+	}
+	var cutoff time.Time
+	switch {
+	case strings.TrimSpace(body.Before) != "":
+		cutoff, err = parseUsageTime(body.Before)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case body.OlderThanDays > 0:
+		cutoff = time.Now().Add(-time.Duration(body.OlderThanDays) * 24 * time.Hour)
+	case store.Retention() > 0:
+		cutoff = time.Now().Add(-store.Retention())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "before or older_than_days is required"})
+		return
+	}
+	removed, err := store.Prune(c.Request.Context(), cutoff)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"removed": removed, "before": cutoff.UTC()})
+}
+
+func parseUsageTimeRange(c *gin.Context) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+	if raw := strings.TrimSpace(c.Query("from")); raw != "" {
+		if from, err = parseUsageTime(raw); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if raw := strings.TrimSpace(c.Query("to")); raw != "" {
+		if to, err = parseUsageTime(raw); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to must be after from")
+	}
+	return from, to, nil
+}
+
+func parseUsageTime(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+		return ts, nil
+	}
+	if ts, err := time.Parse("2006-01-02", raw); err == nil {
+		return ts, nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", raw)
+}
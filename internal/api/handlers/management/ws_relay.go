@@ -0,0 +1,81 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
+)
+
+// WebsocketRelay exposes the running websocket relays to the management API.
+type WebsocketRelay struct {
+	Stats       func() []wsrelay.SessionStats
+	Audit       func() []wsrelay.AuditEvent
+	IssueTicket func(name string, ttl time.Duration) (string, time.Time, error)
+}
+
+type relayTicketRequest struct {
+	Name       string `json:"name"`
+	TTLSeconds int    `json:"ttl_seconds"`
+}
+
+// GetWebsocketRelaySessions lists the websocket relay sessions with their health and
+// first-response latency, including detached sessions still inside the resume window.
+func (h *Handler) GetWebsocketRelaySessions(c *gin.Context) {
+	sessions := []wsrelay.SessionStats{}
+	if h.relay.Stats != nil {
+		if stats := h.relay.Stats(); stats != nil {
+			sessions = stats
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// GetWebsocketRelayAudit returns recent relay session joins, resumes, leaves and rejected
+// connection attempts, oldest first.
+func (h *Handler) GetWebsocketRelayAudit(c *gin.Context) {
+	events := []wsrelay.AuditEvent{}
+	if h.relay.Audit != nil {
+		if log := h.relay.Audit(); log != nil {
+			events = log
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// CreateWebsocketRelayTicket issues a single-use join ticket for /v1/ws. The client passes it
+// in the ticket query parameter or the X-Relay-Ticket header. A name binds the session to the
+// provider aistudio-<name>; ttl_seconds defaults to ws-relay.ticket-ttl-seconds.
+func (h *Handler) CreateWebsocketRelayTicket(c *gin.Context) {
+	if h.relay.IssueTicket == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "websocket relay not running"})
+		return
+	}
+	var body relayTicketRequest
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+	}
+	ttl := time.Duration(body.TTLSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = 60 * time.Second
+		if h.cfg != nil && h.cfg.WebsocketRelay.TicketTTLSeconds > 0 {
+			ttl = time.Duration(h.cfg.WebsocketRelay.TicketTTLSeconds) * time.Second
+		}
+	}
+	ticket, expiresAt, err := h.relay.IssueTicket(body.Name, ttl)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ticket": ticket, "expires_at": expiresAt.UTC()})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/queuehealth"
+	"github.com/tidwall/gjson"
 )
 
 const requestBodyOverrideContextKey = "REQUEST_BODY_OVERRIDE"
@@ -297,6 +298,11 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 		}
 
 		w.streamWriter.SetFirstChunkTimestamp(w.firstChunkTimestamp)
+		if metadataSetter, ok := w.streamWriter.(interface {
+			SetMetadata(logging.RequestLogMetadata)
+		}); ok {
+			metadataSetter.SetMetadata(w.requestMetadata(c, w.extractRequestBody(c)))
+		}
 
 		// Write API Request and Response to the streaming log before closing
 		apiRequest := w.extractAPIRequest(c)
@@ -315,7 +321,32 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 		return nil
 	}
 
-	return w.logRequest(w.extractRequestBody(c), finalStatusCode, w.cloneHeaders(), w.body.Bytes(), w.extractAPIRequest(c), w.extractAPIResponse(c), w.extractAPIResponseTimestamp(c), slicesAPIResponseError, forceLog)
+	requestBody := w.extractRequestBody(c)
+	return w.logRequest(requestBody, finalStatusCode, w.cloneHeaders(), w.body.Bytes(), w.extractAPIRequest(c), w.extractAPIResponse(c), w.extractAPIResponseTimestamp(c), slicesAPIResponseError, forceLog, w.requestMetadata(c, requestBody))
+}
+
+// requestMetadata gathers the routing details recorded in the request log index.
+func (w *ResponseWriterWrapper) requestMetadata(c *gin.Context, requestBody []byte) logging.RequestLogMetadata {
+	route := logging.GetGinRequestRoute(c)
+	metadata := logging.RequestLogMetadata{
+		Model:     route.Model,
+		Provider:  route.Provider,
+		AuthIndex: route.AuthIndex,
+	}
+	if metadata.Model == "" && len(requestBody) > 0 {
+		metadata.Model = gjson.GetBytes(requestBody, "model").String()
+	}
+	if c != nil {
+		if apiKey, exists := c.Get("apiKey"); exists {
+			if key, ok := apiKey.(string); ok {
+				metadata.ClientKey = key
+			}
+		}
+	}
+	if w.requestInfo != nil && !w.requestInfo.Timestamp.IsZero() {
+		metadata.Latency = time.Since(w.requestInfo.Timestamp)
+	}
+	return metadata
 }
 
 func (w *ResponseWriterWrapper) cloneHeaders() map[string][]string {
@@ -387,11 +418,33 @@ func (w *ResponseWriterWrapper) extractRequestBody(c *gin.Context) []byte {
 	return nil
 }
 
-func (w *ResponseWriterWrapper) logRequest(requestBody []byte, statusCode int, headers map[string][]string, body []byte, apiRequestBody, apiResponseBody []byte, apiResponseTimestamp time.Time, apiResponseErrors []*interfaces.ErrorMessage, forceLog bool) error {
+func (w *ResponseWriterWrapper) logRequest(requestBody []byte, statusCode int, headers map[string][]string, body []byte, apiRequestBody, apiResponseBody []byte, apiResponseTimestamp time.Time, apiResponseErrors []*interfaces.ErrorMessage, forceLog bool, metadata logging.RequestLogMetadata) error {
 	if w.requestInfo == nil {
 		return nil
 	}
 
+	if loggerWithMetadata, ok := w.logger.(interface {
+		LogRequestWithMetadata(string, string, map[string][]string, []byte, int, map[string][]string, []byte, []byte, []byte, []*interfaces.ErrorMessage, bool, string, time.Time, time.Time, logging.RequestLogMetadata) error
+	}); ok {
+		return loggerWithMetadata.LogRequestWithMetadata(
+			w.requestInfo.URL,
+			w.requestInfo.Method,
+			w.requestInfo.Headers,
+			requestBody,
+			statusCode,
+			headers,
+			body,
+			apiRequestBody,
+			apiResponseBody,
+			apiResponseErrors,
+			forceLog,
+			w.requestInfo.RequestID,
+			w.requestInfo.Timestamp,
+			apiResponseTimestamp,
+			metadata,
+		)
+	}
+
 	if loggerWithOptions, ok := w.logger.(interface {
 		LogRequestWithOptions(string, string, map[string][]string, []byte, int, map[string][]string, []byte, []byte, []byte, []*interfaces.ErrorMessage, bool, string, time.Time, time.Time) error
 	}); ok {
@@ -24,9 +24,12 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/middleware"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
 	ampmodule "github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/amp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/configschema"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -216,6 +219,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 			requestLogger = optionState.requestLoggerFactory(cfg, configFilePath)
 		}
 		if requestLogger != nil {
+			applyRequestLogRedaction(requestLogger, cfg.RequestLogRedaction)
 			engine.Use(middleware.RequestLoggingMiddleware(requestLogger))
 			if setter, ok := requestLogger.(interface{ SetEnabled(bool) }); ok {
 				toggle = setter.SetEnabled
@@ -255,6 +259,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 	managementasset.SetCurrentConfig(cfg)
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
+	notify.Configure(cfg.Notifications, filepath.Dir(configFilePath))
 	// Initialize management handler
 	s.mgmt = managementHandlers.NewHandler(cfg, configFilePath, authManager)
 	if optionState.localPassword != "" {
@@ -480,9 +485,35 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
+		mgmt.GET("/usage/history", s.mgmt.GetRequestHistory)
+		mgmt.GET("/usage/storage", s.mgmt.GetUsageStorageStatus)
+		mgmt.GET("/usage/query", s.mgmt.QueryUsage)
+		mgmt.POST("/usage/prune", s.mgmt.PruneUsage)
+		mgmt.GET("/prompt-queue/metrics", s.mgmt.GetPromptQueueMetrics)
+		mgmt.GET("/prompt-queue/submissions", s.mgmt.GetPromptQueueSubmissions)
+		mgmt.GET("/prompt-queue/events", s.mgmt.GetPromptQueueEvents)
+		mgmt.GET("/prompt-queue/events/stream", s.mgmt.StreamPromptQueueEvents)
+		mgmt.GET("/scheduler", s.mgmt.GetSchedulerStats)
+		mgmt.GET("/signature-cache", s.mgmt.GetSignatureCache)
+		mgmt.GET("/signature-cache/entries", s.mgmt.GetSignatureCacheEntries)
+		mgmt.DELETE("/signature-cache", s.mgmt.DeleteSignatureCache)
+		mgmt.GET("/translator/route", s.mgmt.GetTranslatorRoute)
+		mgmt.GET("/ws-relay/sessions", s.mgmt.GetWebsocketRelaySessions)
+		mgmt.GET("/ws-relay/audit", s.mgmt.GetWebsocketRelayAudit)
+		mgmt.POST("/ws-relay/tickets", s.mgmt.CreateWebsocketRelayTicket)
+		mgmt.GET("/notifications", s.mgmt.GetNotificationStatus)
+		mgmt.GET("/notifications/dead-letters", s.mgmt.GetNotificationDeadLetters)
+		mgmt.POST("/notifications/test", s.mgmt.SendTestNotification)
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
 		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
+		mgmt.POST("/config:verb", s.mgmt.PostConfigVerb)
+		mgmt.GET("/config.schema.json", s.mgmt.GetConfigSchema)
+		mgmt.GET("/openapi.json", s.serveManagementOpenAPI)
+		mgmt.GET("/config/versions", s.mgmt.ListConfigVersions)
+		mgmt.GET("/config/versions/:id", s.mgmt.GetConfigVersion)
+		mgmt.POST("/config/versions/:id/rollback", s.mgmt.RollbackConfigVersion)
+		mgmt.GET("/config/diff", s.mgmt.DiffConfigVersions)
 		mgmt.GET("/latest-version", s.mgmt.GetLatestVersion)
 
 		mgmt.GET("/debug", s.mgmt.GetDebug)
@@ -535,6 +566,8 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/request-error-logs", s.mgmt.GetRequestErrorLogs)
 		mgmt.GET("/request-error-logs/:name", s.mgmt.DownloadRequestErrorLog)
 		mgmt.GET("/request-log-by-id/:id", s.mgmt.GetRequestLogByID)
+		mgmt.GET("/request-logs/search", s.mgmt.SearchRequestLogs)
+		mgmt.POST("/request-logs/replay", s.mgmt.ReplayRequestLog)
 		mgmt.GET("/request-log", s.mgmt.GetRequestLog)
 		mgmt.PUT("/request-log", s.mgmt.PutRequestLog)
 		mgmt.PATCH("/request-log", s.mgmt.PutRequestLog)
@@ -619,6 +652,8 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
 		mgmt.PATCH("/auth-files/status", s.mgmt.PatchAuthFileStatus)
 		mgmt.PATCH("/auth-files/fields", s.mgmt.PatchAuthFileFields)
+		mgmt.GET("/auth-files/encryption", s.mgmt.GetAuthEncryptionStatus)
+		mgmt.POST("/auth-files/reencrypt", s.mgmt.ReencryptAuthFiles)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
 
 		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
@@ -644,6 +679,17 @@ func (s *Server) managementAvailabilityMiddleware() gin.HandlerFunc {
 	}
 }
 
+// serveManagementOpenAPI describes the management routes as registered on the engine, so
+// the document cannot drift from the routing table.
+func (s *Server) serveManagementOpenAPI(c *gin.Context) {
+	routes := s.engine.Routes()
+	mgmtRoutes := make([]configschema.Route, 0, len(routes))
+	for _, route := range routes {
+		mgmtRoutes = append(mgmtRoutes, configschema.Route{Method: route.Method, Path: route.Path, Handler: route.Handler})
+	}
+	c.JSON(http.StatusOK, configschema.ManagementOpenAPI(mgmtRoutes, buildinfo.Version))
+}
+
 func (s *Server) serveManagementControlPanel(c *gin.Context) {
 	cfg := s.cfg
 	if cfg == nil || cfg.RemoteManagement.DisableControlPanel {
@@ -859,6 +905,19 @@ func (s *Server) applyAccessConfig(oldCfg, newCfg *config.Config) {
 	}
 }
 
+// applyRequestLogRedaction installs redaction rules on loggers that support them.
+func applyRequestLogRedaction(requestLogger logging.RequestLogger, cfg config.RequestLogRedactionConfig) {
+	setter, ok := requestLogger.(interface {
+		SetRedaction(config.RequestLogRedactionConfig) error
+	})
+	if !ok {
+		return
+	}
+	if err := setter.SetRedaction(cfg); err != nil {
+		log.Errorf("invalid request-log-redaction config, keeping previous rules: %v", err)
+	}
+}
+
 // UpdateClients updates the server's client list and configuration.
 // This method is called when the configuration or authentication tokens change.
 //
@@ -895,6 +954,10 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		usage.SetStatisticsEnabled(cfg.UsageStatisticsEnabled)
 	}
 
+	if s.requestLogger != nil && (oldCfg == nil || !reflect.DeepEqual(oldCfg.RequestLogRedaction, cfg.RequestLogRedaction)) {
+		applyRequestLogRedaction(s.requestLogger, cfg.RequestLogRedaction)
+	}
+
 	if s.requestLogger != nil && (oldCfg == nil || oldCfg.ErrorLogsMaxFiles != cfg.ErrorLogsMaxFiles) {
 		if setter, ok := s.requestLogger.(interface{ SetErrorLogsMaxFiles(int) }); ok {
 			setter.SetErrorLogsMaxFiles(cfg.ErrorLogsMaxFiles)
@@ -905,6 +968,10 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	}
 
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.Notifications, cfg.Notifications) {
+		notify.Configure(cfg.Notifications, filepath.Dir(s.configFilePath))
+	}
+
 	if s.handlers != nil && s.handlers.AuthManager != nil {
 		s.handlers.AuthManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second)
 	}
@@ -1011,6 +1078,15 @@ func (s *Server) SetWebsocketAuthChangeHandler(fn func(bool, bool)) {
 	s.wsAuthChanged = fn
 }
 
+// SetWebsocketRelay supplies the relay sessions, audit log and ticket issuer behind the
+// management ws-relay endpoints.
+func (s *Server) SetWebsocketRelay(relay managementHandlers.WebsocketRelay) {
+	if s == nil || s.mgmt == nil {
+		return
+	}
+	s.mgmt.SetWebsocketRelay(relay)
+}
+
 // (management handlers moved to internal/api/handlers/management)
 
 // AuthMiddleware returns a Gin middleware handler that authenticates requests
@@ -1,10 +1,12 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -116,3 +118,50 @@ func TestAmpProviderModelRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestManagementOpenAPI_CoversRegisteredRoutes(t *testing.T) {
+	server := newTestServer(t)
+	server.registerManagementRoutes()
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	server.serveManagementOpenAPI(c)
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			RequestBody struct {
+				Content map[string]struct {
+					Schema map[string]any `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+
+	paramPattern := regexp.MustCompile(`/[:*]([A-Za-z0-9_]+)`)
+	seenIDs := make(map[string]string)
+	for _, route := range server.engine.Routes() {
+		if !strings.HasPrefix(route.Path, "/v0/management") || route.Path == "/v0/management/config:verb" {
+			continue
+		}
+		path := paramPattern.ReplaceAllString(route.Path, "/{$1}")
+		op, ok := doc.Paths[path][strings.ToLower(route.Method)]
+		if !ok {
+			t.Errorf("route %s %s missing from openapi", route.Method, route.Path)
+			continue
+		}
+		if prev, dup := seenIDs[op.OperationID]; dup {
+			t.Errorf("operationId %q used by %s and %s %s", op.OperationID, prev, route.Method, path)
+		}
+		seenIDs[op.OperationID] = route.Method + " " + path
+	}
+	if _, ok := doc.Paths["/v0/management/config:validate"]["post"]; !ok {
+		t.Error("config:validate missing from openapi")
+	}
+	debugBody := doc.Paths["/v0/management/debug"]["put"].RequestBody.Content["application/json"].Schema
+	if props, _ := debugBody["properties"].(map[string]any); props["value"] == nil {
+		t.Errorf("PUT /debug body = %#v, want {value}", debugBody)
+	}
+}
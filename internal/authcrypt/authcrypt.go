@@ -0,0 +1,503 @@
+// Package authcrypt provides envelope encryption for auth files at rest.
+//
+// Each payload is encrypted with a fresh AES-256-GCM data key, and the data key is
+// wrapped with a master key supplied through the environment or a key file. The
+// result is stored as a small JSON document, so it remains valid wherever auth
+// files are expected to be JSON (for example the Postgres JSONB column). Files
+// without the envelope marker are treated as legacy plaintext and read as-is.
+package authcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	envelopeVersion = "v1"
+	envelopeAlg     = "AES-256-GCM"
+	envelopeMarker  = "cliproxy_encrypted"
+	payloadAAD      = "cliproxy-auth-payload:v1"
+	wrapAADPrefix   = "cliproxy-auth-dek:"
+)
+
+// Environment variables read by LoadFromEnv. Lowercase variants are accepted as well.
+const (
+	EnvKey          = "AUTH_ENCRYPTION_KEY"
+	EnvKeyFile      = "AUTH_ENCRYPTION_KEY_FILE"
+	EnvPreviousKeys = "AUTH_ENCRYPTION_PREVIOUS_KEYS"
+)
+
+var (
+	// ErrMasterKeyRequired is returned when an encrypted payload is read without a configured keyring.
+	ErrMasterKeyRequired = errors.New("authcrypt: auth file is encrypted but no master key is configured")
+	// ErrUnknownKey is returned when an envelope was sealed with a key that is not in the keyring.
+	ErrUnknownKey = errors.New("authcrypt: auth file was encrypted with an unknown master key")
+)
+
+type envelope struct {
+	Version    string `json:"cliproxy_encrypted"`
+	Alg        string `json:"alg"`
+	KeyID      string `json:"kid"`
+	WrappedKey string `json:"wrapped_key"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// Keyring holds the master key used for sealing and any previous keys still
+// accepted for opening. A keyring without a primary key only decrypts, which
+// allows converting encrypted files back to plaintext.
+type Keyring struct {
+	primary *masterKey
+	keys    map[string]*masterKey
+}
+
+var current atomic.Pointer[Keyring]
+
+// NewKeyring builds a keyring from a primary key and optional previous keys.
+// Each key must be 32 bytes. primary may be nil for a decrypt-only keyring.
+func NewKeyring(primary []byte, previous ...[]byte) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]*masterKey)}
+	if primary != nil {
+		mk, err := newMasterKey(primary)
+		if err != nil {
+			return nil, err
+		}
+		k.primary = mk
+		k.keys[mk.id] = mk
+	}
+	for _, raw := range previous {
+		mk, err := newMasterKey(raw)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := k.keys[mk.id]; !exists {
+			k.keys[mk.id] = mk
+		}
+	}
+	if len(k.keys) == 0 {
+		return nil, fmt.Errorf("authcrypt: keyring needs at least one key")
+	}
+	return k, nil
+}
+
+func newMasterKey(raw []byte) (*masterKey, error) {
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("authcrypt: master key must be 32 bytes, got %d", len(raw))
+	}
+	aead, err := newGCM(raw)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return &masterKey{id: hex.EncodeToString(sum[:8]), aead: aead}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: %w", err)
+	}
+	return aead, nil
+}
+
+// KeyID returns the identifier of the primary key, or "" for a decrypt-only keyring.
+func (k *Keyring) KeyID() string {
+	if k == nil || k.primary == nil {
+		return ""
+	}
+	return k.primary.id
+}
+
+// CanSeal reports whether the keyring has a primary key.
+func (k *Keyring) CanSeal() bool {
+	return k != nil && k.primary != nil
+}
+
+// ParseKey decodes a master key given as base64 (standard or URL alphabet) or hex.
+func ParseKey(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("authcrypt: empty master key")
+	}
+	if len(value) == 64 {
+		if decoded, err := hex.DecodeString(value); err == nil {
+			return decoded, nil
+		}
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := enc.DecodeString(value); err == nil && len(decoded) == 32 {
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("authcrypt: master key must be 32 bytes encoded as base64 or hex")
+}
+
+// LoadKeyFile reads a master key file containing the encoded key or 32 raw bytes.
+func LoadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: read key file: %w", err)
+	}
+	if len(data) == 32 {
+		return data, nil
+	}
+	return ParseKey(string(data))
+}
+
+// LoadFromEnv builds a keyring from AUTH_ENCRYPTION_KEY or AUTH_ENCRYPTION_KEY_FILE,
+// plus the comma-separated AUTH_ENCRYPTION_PREVIOUS_KEYS. It returns nil when no
+// key is configured.
+func LoadFromEnv() (*Keyring, error) {
+	var primary []byte
+	if value, ok := lookupEnv(EnvKey); ok {
+		key, err := ParseKey(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvKey, err)
+		}
+		primary = key
+	} else if path, okFile := lookupEnv(EnvKeyFile); okFile {
+		key, err := LoadKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvKeyFile, err)
+		}
+		primary = key
+	}
+	var previous [][]byte
+	if value, ok := lookupEnv(EnvPreviousKeys); ok {
+		for _, part := range strings.Split(value, ",") {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			key, err := ParseKey(part)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", EnvPreviousKeys, err)
+			}
+			previous = append(previous, key)
+		}
+	}
+	if primary == nil && len(previous) == 0 {
+		return nil, nil
+	}
+	return NewKeyring(primary, previous...)
+}
+
+func lookupEnv(key string) (string, bool) {
+	for _, name := range []string{key, strings.ToLower(key)} {
+		if value, ok := os.LookupEnv(name); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value), true
+		}
+	}
+	return "", false
+}
+
+// Configure installs the process-wide keyring. Passing nil disables encryption.
+func Configure(k *Keyring) {
+	current.Store(k)
+}
+
+// Current returns the process-wide keyring, or nil when encryption is disabled.
+func Current() *Keyring {
+	return current.Load()
+}
+
+// Enabled reports whether new auth payloads are encrypted.
+func Enabled() bool {
+	return Current().CanSeal()
+}
+
+// IsEncrypted reports whether data is an encryption envelope.
+func IsEncrypted(data []byte) bool {
+	if !bytes.Contains(data, []byte(envelopeMarker)) {
+		return false
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return false
+	}
+	return env.Version != ""
+}
+
+// Seal encrypts plaintext with the process-wide keyring. It returns plaintext
+// unchanged when encryption is disabled.
+func Seal(plaintext []byte) ([]byte, error) {
+	return Current().Seal(plaintext)
+}
+
+// Open decrypts data with the process-wide keyring. Legacy plaintext is returned unchanged.
+func Open(data []byte) ([]byte, error) {
+	return Current().Open(data)
+}
+
+// Seal encrypts plaintext with a fresh data key wrapped by the primary key.
+// Without a primary key plaintext is returned unchanged.
+func (k *Keyring) Seal(plaintext []byte) ([]byte, error) {
+	if !k.CanSeal() || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate data key: %w", err)
+	}
+	dataAEAD, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, dataAEAD.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate nonce: %w", err)
+	}
+	wrapNonce := make([]byte, k.primary.aead.NonceSize())
+	if _, err = rand.Read(wrapNonce); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate nonce: %w", err)
+	}
+	wrapped := k.primary.aead.Seal(wrapNonce, wrapNonce, dek, []byte(wrapAADPrefix+k.primary.id))
+	env := envelope{
+		Version:    envelopeVersion,
+		Alg:        envelopeAlg,
+		KeyID:      k.primary.id,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(dataAEAD.Seal(nil, nonce, plaintext, []byte(payloadAAD))),
+	}
+	out, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: encode envelope: %w", err)
+	}
+	return out, nil
+}
+
+// Open decrypts an envelope. Data that is not an envelope is returned unchanged.
+func (k *Keyring) Open(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("authcrypt: decode envelope: %w", err)
+	}
+	if env.Version != envelopeVersion || env.Alg != envelopeAlg {
+		return nil, fmt.Errorf("authcrypt: unsupported envelope %s/%s", env.Version, env.Alg)
+	}
+	if k == nil {
+		return nil, ErrMasterKeyRequired
+	}
+	mk, ok := k.keys[env.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w (kid %s)", ErrUnknownKey, env.KeyID)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(env.WrappedKey)
+	if err != nil || len(wrapped) < mk.aead.NonceSize() {
+		return nil, fmt.Errorf("authcrypt: invalid wrapped key")
+	}
+	nonceSize := mk.aead.NonceSize()
+	dek, err := mk.aead.Open(nil, wrapped[:nonceSize], wrapped[nonceSize:], []byte(wrapAADPrefix+mk.id))
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: unwrap data key: %w", err)
+	}
+	dataAEAD, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(env.Nonce)
+	if err != nil || len(nonce) != dataAEAD.NonceSize() {
+		return nil, fmt.Errorf("authcrypt: invalid nonce")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: invalid ciphertext")
+	}
+	plaintext, err := dataAEAD.Open(nil, nonce, ciphertext, []byte(payloadAAD))
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: decrypt payload: %w", err)
+	}
+	return plaintext, nil
+}
+
+// needsReseal reports whether data is not stored the way the keyring would store it.
+func (k *Keyring) needsReseal(data []byte) bool {
+	encrypted := IsEncrypted(data)
+	if !k.CanSeal() {
+		return encrypted
+	}
+	if !encrypted {
+		return true
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return true
+	}
+	return env.KeyID != k.primary.id
+}
+
+// NeedsReseal reports whether stored data differs from what the process-wide
+// keyring would write: plaintext while encryption is enabled, an envelope
+// sealed with a previous key, or an envelope while encryption is disabled.
+func NeedsReseal(data []byte) bool {
+	return Current().needsReseal(data)
+}
+
+// ReadFile reads an auth file and decrypts it when necessary.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return data, nil
+	}
+	plaintext, err := Open(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return plaintext, nil
+}
+
+// WriteFile seals plaintext with the process-wide keyring and writes it to path. When auth
+// encryption is disabled the file is written as plaintext, so token writers can always use it.
+func WriteFile(path string, plaintext []byte, perm fs.FileMode) error {
+	data, err := Seal(plaintext)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, perm)
+}
+
+// ResealReport summarises a ResealDir run.
+type ResealReport struct {
+	KeyID    string            `json:"key_id,omitempty"`
+	Scanned  int               `json:"scanned"`
+	Resealed []string          `json:"resealed"`
+	Failed   map[string]string `json:"failed,omitempty"`
+}
+
+// ResealDir rewrites every JSON auth file under dir that NeedsReseal, so that all
+// files end up sealed with the current primary key (or decrypted when the keyring
+// is decrypt-only). Files are replaced atomically.
+func ResealDir(dir string) (ResealReport, error) {
+	k := Current()
+	report := ResealReport{KeyID: k.KeyID(), Resealed: []string{}}
+	if k == nil {
+		return report, fmt.Errorf("authcrypt: no master key configured")
+	}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+			return nil
+		}
+		report.Scanned++
+		if errReseal := resealFile(k, path); errReseal != nil {
+			if errors.Is(errReseal, errUnchanged) {
+				return nil
+			}
+			if report.Failed == nil {
+				report.Failed = make(map[string]string)
+			}
+			report.Failed[path] = errReseal.Error()
+			return nil
+		}
+		report.Resealed = append(report.Resealed, path)
+		return nil
+	})
+	return report, err
+}
+
+var errUnchanged = errors.New("unchanged")
+
+func resealFile(k *Keyring, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 || !k.needsReseal(data) {
+		return errUnchanged
+	}
+	plaintext, err := k.Open(data)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(plaintext) {
+		return fmt.Errorf("authcrypt: %s is not JSON", filepath.Base(path))
+	}
+	sealed, err := k.Seal(plaintext)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".reseal"
+	if err = os.WriteFile(tmp, sealed, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// DirStatus describes how the auth files under a directory are stored.
+type DirStatus struct {
+	Enabled   bool   `json:"enabled"`
+	KeyID     string `json:"key_id,omitempty"`
+	Encrypted int    `json:"encrypted"`
+	Plaintext int    `json:"plaintext"`
+	// Stale counts files that NeedsReseal would rewrite.
+	Stale int `json:"stale"`
+}
+
+// Inspect counts encrypted, plaintext and stale JSON auth files under dir.
+func Inspect(dir string) (DirStatus, error) {
+	k := Current()
+	status := DirStatus{Enabled: k.CanSeal(), KeyID: k.KeyID()}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+			return nil
+		}
+		data, errRead := os.ReadFile(path)
+		if errRead != nil || len(data) == 0 {
+			return nil
+		}
+		if IsEncrypted(data) {
+			status.Encrypted++
+		} else {
+			status.Plaintext++
+		}
+		if k.needsReseal(data) {
+			status.Stale++
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	return status, err
+}
@@ -0,0 +1,110 @@
+package authcrypt
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestSealOpenRoundTripAndLegacyPlaintext(t *testing.T) {
+	keyring, err := NewKeyring(testKey(1))
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	plaintext := []byte(`{"type":"codex","refresh_token":"rt-secret"}`)
+
+	sealed, err := keyring.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if bytes.Contains(sealed, []byte("rt-secret")) || !IsEncrypted(sealed) {
+		t.Fatalf("sealed payload leaks plaintext or lacks envelope: %s", sealed)
+	}
+	opened, err := keyring.Open(sealed)
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("Open() = %s, %v", opened, err)
+	}
+
+	legacy, err := keyring.Open(plaintext)
+	if err != nil || !bytes.Equal(legacy, plaintext) {
+		t.Fatalf("legacy Open() = %s, %v", legacy, err)
+	}
+
+	other, _ := NewKeyring(testKey(2))
+	if _, err = other.Open(sealed); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Open() with other key error = %v, want ErrUnknownKey", err)
+	}
+	var none *Keyring
+	if _, err = none.Open(sealed); !errors.Is(err, ErrMasterKeyRequired) {
+		t.Fatalf("Open() without key error = %v, want ErrMasterKeyRequired", err)
+	}
+
+	tampered := bytes.Replace(sealed, []byte(`"ciphertext": "`), []byte(`"ciphertext": "AA`), 1)
+	if _, err = keyring.Open(tampered); err == nil {
+		t.Fatal("expected error for tampered ciphertext")
+	}
+}
+
+func TestResealDirEncryptsRotatesAndDecrypts(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+	dir := t.TempDir()
+	plainPath := filepath.Join(dir, "plain.json")
+	if err := os.WriteFile(plainPath, []byte(`{"type":"claude","access_token":"at-1"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	oldKeyring, _ := NewKeyring(testKey(1))
+	Configure(oldKeyring)
+	if err := WriteFile(filepath.Join(dir, "old.json"), []byte(`{"type":"gemini"}`), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	rotated, _ := NewKeyring(testKey(2), testKey(1))
+	Configure(rotated)
+	status, err := Inspect(dir)
+	if err != nil || status.Encrypted != 1 || status.Plaintext != 1 || status.Stale != 2 {
+		t.Fatalf("Inspect() = %+v, %v", status, err)
+	}
+	report, err := ResealDir(dir)
+	if err != nil || len(report.Resealed) != 2 || len(report.Failed) != 0 {
+		t.Fatalf("ResealDir() = %+v, %v", report, err)
+	}
+	if status, _ = Inspect(dir); status.Encrypted != 2 || status.Stale != 0 {
+		t.Fatalf("after rotation Inspect() = %+v", status)
+	}
+
+	newOnly, _ := NewKeyring(testKey(2))
+	Configure(newOnly)
+	data, err := ReadFile(plainPath)
+	if err != nil || !bytes.Contains(data, []byte("at-1")) {
+		t.Fatalf("ReadFile() after rotation = %s, %v", data, err)
+	}
+
+	decryptOnly, _ := NewKeyring(nil, testKey(2))
+	Configure(decryptOnly)
+	if report, err = ResealDir(dir); err != nil || len(report.Resealed) != 2 {
+		t.Fatalf("decrypt ResealDir() = %+v, %v", report, err)
+	}
+	raw, _ := os.ReadFile(plainPath)
+	if IsEncrypted(raw) || !bytes.Contains(raw, []byte("at-1")) {
+		t.Fatalf("file not decrypted: %s", raw)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	if _, err := ParseKey("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="); err != nil {
+		t.Fatalf("base64 key error = %v", err)
+	}
+	if _, err := ParseKey("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"); err != nil {
+		t.Fatalf("hex key error = %v", err)
+	}
+	if _, err := ParseKey("too-short"); err == nil {
+		t.Fatal("expected error for short key")
+	}
+}
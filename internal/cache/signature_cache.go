@@ -1,17 +1,39 @@
 package cache
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // SignatureEntry holds a cached thinking signature with timestamp
 type SignatureEntry struct {
-	Signature string
-	Timestamp time.Time
+	Signature string    `json:"signature"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// storedAt is the last use written to the SignatureStore.
+	storedAt time.Time
+}
+
+// SignatureStore persists signatures outside the process so that they survive restarts and
+// are shared between replicas. The in-memory cache writes through to it and consults it on a
+// miss.
+type SignatureStore interface {
+	// LoadSignature returns the entry stored for textHash in the model group.
+	LoadSignature(ctx context.Context, group, textHash string) (SignatureEntry, bool, error)
+	// SaveSignature stores or replaces the entry for textHash in the model group.
+	SaveSignature(ctx context.Context, group, textHash string, entry SignatureEntry) error
+	// ClearSignatures removes the entries of the model group, or all entries when group is "".
+	ClearSignatures(ctx context.Context, group string) error
+	// PurgeSignatures removes entries last used before the cutoff.
+	PurgeSignatures(ctx context.Context, before time.Time) error
 }
 
 const (
@@ -26,8 +48,82 @@ const (
 
 	// CacheCleanupInterval controls how often stale entries are purged
 	CacheCleanupInterval = 10 * time.Minute
+
+	// DefaultMaxSignaturesPerGroup bounds each model group unless SetMaxSignaturesPerGroup is called
+	DefaultMaxSignaturesPerGroup = 10000
+
+	// signatureStoreTimeout bounds each call into the SignatureStore
+	signatureStoreTimeout = 2 * time.Second
+
+	// signatureStoreLookupTimeout bounds the store lookup on a memory miss, which runs while the
+	// request is being translated
+	signatureStoreLookupTimeout = 250 * time.Millisecond
+
+	// signatureStoreMissTTL is how long a text the store did not know is not looked up again.
+	// Another replica may cache it in the meantime, so it is kept short.
+	signatureStoreMissTTL = time.Minute
+
+	// signatureStoreRetryDelay is how long lookups are skipped after the store failed
+	signatureStoreRetryDelay = 30 * time.Second
+
+	// signatureStoreTouchInterval throttles writing sliding-expiration refreshes to the store.
+	// It is well below SignatureCacheTTL so an entry in use is never purged from the store.
+	signatureStoreTouchInterval = CacheCleanupInterval
 )
 
+var (
+	// maxSignaturesPerGroup is the entry limit of each model group; the oldest entries are evicted
+	maxSignaturesPerGroup atomic.Int64
+
+	// signatureStore is the optional persistent tier behind the in-memory cache
+	signatureStore atomic.Pointer[signatureStoreHolder]
+
+	// signaturesDirty records changes since the last snapshot
+	signaturesDirty atomic.Bool
+
+	// signatureStoreRetryAt is the unix nanosecond time before which store lookups are skipped
+	signatureStoreRetryAt atomic.Int64
+
+	signatureHits      atomic.Int64
+	signatureMisses    atomic.Int64
+	signatureStoreHits atomic.Int64
+	signatureWrites    atomic.Int64
+	signatureEvictions atomic.Int64
+)
+
+type signatureStoreHolder struct {
+	store SignatureStore
+}
+
+func init() {
+	maxSignaturesPerGroup.Store(DefaultMaxSignaturesPerGroup)
+}
+
+// SetMaxSignaturesPerGroup changes the entry limit of each model group. Values <= 0 restore the default.
+func SetMaxSignaturesPerGroup(limit int) {
+	if limit <= 0 {
+		limit = DefaultMaxSignaturesPerGroup
+	}
+	maxSignaturesPerGroup.Store(int64(limit))
+}
+
+// SetSignatureStore installs the persistent tier. Passing nil keeps signatures in memory only.
+func SetSignatureStore(store SignatureStore) {
+	signatureStoreRetryAt.Store(0)
+	if store == nil {
+		signatureStore.Store(nil)
+		return
+	}
+	signatureStore.Store(&signatureStoreHolder{store: store})
+}
+
+func currentSignatureStore() SignatureStore {
+	if holder := signatureStore.Load(); holder != nil {
+		return holder.store
+	}
+	return nil
+}
+
 // signatureCache stores signatures by model group -> textHash -> SignatureEntry
 var signatureCache sync.Map
 
@@ -38,6 +134,8 @@ var cacheCleanupOnce sync.Once
 type groupCache struct {
 	mu      sync.RWMutex
 	entries map[string]SignatureEntry
+	// misses maps texts the store did not know to when it may be asked again
+	misses map[string]time.Time
 }
 
 // hashText creates a stable, Unicode-safe key from text content
@@ -74,6 +172,13 @@ func startCacheCleanup() {
 // purgeExpiredCaches removes caches with no valid (non-expired) entries.
 func purgeExpiredCaches() {
 	now := time.Now()
+	if store := currentSignatureStore(); store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), signatureStoreTimeout)
+		if err := store.PurgeSignatures(ctx, now.Add(-SignatureCacheTTL)); err != nil {
+			log.Debugf("signature cache: purge store: %v", err)
+		}
+		cancel()
+	}
 	signatureCache.Range(func(key, value any) bool {
 		sc := value.(*groupCache)
 		sc.mu.Lock()
@@ -83,7 +188,12 @@ func purgeExpiredCaches() {
 				delete(sc.entries, k)
 			}
 		}
-		isEmpty := len(sc.entries) == 0
+		for k, until := range sc.misses {
+			if now.After(until) {
+				delete(sc.misses, k)
+			}
+		}
+		isEmpty := len(sc.entries) == 0 && len(sc.misses) == 0
 		sc.mu.Unlock()
 		// Remove cache bucket if empty
 		if isEmpty {
@@ -105,14 +215,116 @@ func CacheSignature(modelName, text, signature string) {
 
 	groupKey := GetModelGroup(modelName)
 	textHash := hashText(text)
+	now := time.Now()
+	entry := SignatureEntry{
+		Signature: signature,
+		Timestamp: now,
+		storedAt:  now,
+	}
+	storeInMemory(groupKey, textHash, entry)
+	signatureWrites.Add(1)
+	saveToStore(groupKey, textHash, entry)
+}
+
+// saveToStore writes the entry to the persistent tier in the background, since signatures are
+// cached and looked up while responses stream.
+func saveToStore(groupKey, textHash string, entry SignatureEntry) {
+	store := currentSignatureStore()
+	if store == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), signatureStoreTimeout)
+		defer cancel()
+		if err := store.SaveSignature(ctx, groupKey, textHash, entry); err != nil {
+			log.Debugf("signature cache: save to store: %v", err)
+		}
+	}()
+}
+
+// touchEntry refreshes the sliding expiration of an entry that was just used. The store, which
+// every replica purges by last use, gets the new time at most once per touch interval. The
+// caller holds the group lock.
+func touchEntry(entry SignatureEntry, now time.Time) (SignatureEntry, bool) {
+	entry.Timestamp = now
+	if now.Sub(entry.storedAt) < signatureStoreTouchInterval {
+		return entry, false
+	}
+	entry.storedAt = now
+	return entry, true
+}
+
+// storeInMemory puts an entry into the group's map, evicting the oldest entries when the group
+// is over its limit.
+func storeInMemory(groupKey, textHash string, entry SignatureEntry) {
 	sc := getOrCreateGroupCache(groupKey)
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
 
-	sc.entries[textHash] = SignatureEntry{
-		Signature: signature,
-		Timestamp: time.Now(),
+	sc.entries[textHash] = entry
+	delete(sc.misses, textHash)
+	signaturesDirty.Store(true)
+	limit := int(maxSignaturesPerGroup.Load())
+	if len(sc.entries) <= limit {
+		return
+	}
+	// Evict a tenth of the group at once so a full group does not sort on every insert.
+	type aged struct {
+		hash string
+		at   time.Time
+	}
+	all := make([]aged, 0, len(sc.entries))
+	for hash, e := range sc.entries {
+		all = append(all, aged{hash: hash, at: e.Timestamp})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].at.Before(all[j].at) })
+	evict := len(sc.entries) - limit + limit/10
+	for i := 0; i < evict && i < len(all); i++ {
+		delete(sc.entries, all[i].hash)
+	}
+	signatureEvictions.Add(int64(evict))
+}
+
+// loadFromStore looks the entry up in the persistent tier and copies it into memory. It runs
+// on the request path, so texts the store did not know are not asked for again for a while,
+// and lookups pause after the store failed.
+func loadFromStore(groupKey, textHash string, now time.Time) (SignatureEntry, bool) {
+	store := currentSignatureStore()
+	if store == nil || now.UnixNano() < signatureStoreRetryAt.Load() {
+		return SignatureEntry{}, false
+	}
+	sc := getOrCreateGroupCache(groupKey)
+	sc.mu.RLock()
+	until, missed := sc.misses[textHash]
+	sc.mu.RUnlock()
+	if missed && now.Before(until) {
+		return SignatureEntry{}, false
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), signatureStoreLookupTimeout)
+	defer cancel()
+	entry, ok, err := store.LoadSignature(ctx, groupKey, textHash)
+	if err != nil {
+		log.Debugf("signature cache: load from store: %v", err)
+		signatureStoreRetryAt.Store(now.Add(signatureStoreRetryDelay).UnixNano())
+		return SignatureEntry{}, false
+	}
+	if !ok || now.Sub(entry.Timestamp) > SignatureCacheTTL {
+		sc.mu.Lock()
+		if sc.misses == nil {
+			sc.misses = make(map[string]time.Time)
+		}
+		sc.misses[textHash] = now.Add(signatureStoreMissTTL)
+		sc.mu.Unlock()
+		return SignatureEntry{}, false
+	}
+	entry.storedAt = entry.Timestamp
+	entry, touch := touchEntry(entry, now)
+	storeInMemory(groupKey, textHash, entry)
+	if touch {
+		saveToStore(groupKey, textHash, entry)
+	}
+	signatureStoreHits.Add(1)
+	return entry, true
 }
 
 // GetCachedSignature retrieves a cached signature for a given model group and text.
@@ -126,56 +338,62 @@ func GetCachedSignature(modelName, text string) string {
 		}
 		return ""
 	}
-	val, ok := signatureCache.Load(groupKey)
-	if !ok {
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
-		}
-		return ""
-	}
-	sc := val.(*groupCache)
-
 	textHash := hashText(text)
-
 	now := time.Now()
 
-	sc.mu.Lock()
-	entry, exists := sc.entries[textHash]
-	if !exists {
-		sc.mu.Unlock()
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
+	if val, ok := signatureCache.Load(groupKey); ok {
+		sc := val.(*groupCache)
+		sc.mu.Lock()
+		entry, exists := sc.entries[textHash]
+		if exists && now.Sub(entry.Timestamp) <= SignatureCacheTTL {
+			// Refresh TTL on access (sliding expiration).
+			entry, touch := touchEntry(entry, now)
+			sc.entries[textHash] = entry
+			sc.mu.Unlock()
+			if touch {
+				saveToStore(groupKey, textHash, entry)
+			}
+			signatureHits.Add(1)
+			return entry.Signature
 		}
-		return ""
-	}
-	if now.Sub(entry.Timestamp) > SignatureCacheTTL {
-		delete(sc.entries, textHash)
-		sc.mu.Unlock()
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
+		if exists {
+			delete(sc.entries, textHash)
 		}
-		return ""
+		sc.mu.Unlock()
 	}
 
-	// Refresh TTL on access (sliding expiration).
-	entry.Timestamp = now
-	sc.entries[textHash] = entry
-	sc.mu.Unlock()
-
-	return entry.Signature
+	if entry, ok := loadFromStore(groupKey, textHash, now); ok {
+		signatureHits.Add(1)
+		return entry.Signature
+	}
+	signatureMisses.Add(1)
+	if groupKey == "gemini" {
+		return "skip_thought_signature_validator"
+	}
+	return ""
 }
 
-// ClearSignatureCache clears signature cache for a specific model group or all groups.
+// ClearSignatureCache clears signature cache for a specific model group or all groups,
+// including the entries in the persistent store.
 func ClearSignatureCache(modelName string) {
+	groupKey := ""
 	if modelName == "" {
 		signatureCache.Range(func(key, _ any) bool {
 			signatureCache.Delete(key)
 			return true
 		})
-		return
+	} else {
+		groupKey = GetModelGroup(modelName)
+		signatureCache.Delete(groupKey)
+	}
+	signaturesDirty.Store(true)
+	if store := currentSignatureStore(); store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), signatureStoreTimeout)
+		defer cancel()
+		if err := store.ClearSignatures(ctx, groupKey); err != nil {
+			log.Warnf("signature cache: clear store: %v", err)
+		}
 	}
-	groupKey := GetModelGroup(modelName)
-	signatureCache.Delete(groupKey)
 }
 
 // HasValidSignature checks if a signature is valid (non-empty and long enough)
@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultSignatureSnapshotInterval is how often StartSignatureSnapshots writes changes.
+const DefaultSignatureSnapshotInterval = time.Minute
+
+// signatureSnapshot is the on-disk form of the cache: model group -> text hash -> entry.
+type signatureSnapshot struct {
+	Version int                                  `json:"version"`
+	Groups  map[string]map[string]SignatureEntry `json:"groups"`
+}
+
+// SaveSignatureSnapshot writes the live in-memory entries to path, replacing it atomically.
+func SaveSignatureSnapshot(path string) error {
+	snapshot := signatureSnapshot{Version: 1, Groups: make(map[string]map[string]SignatureEntry)}
+	now := time.Now()
+	signaturesDirty.Store(false)
+	signatureCache.Range(func(key, value any) bool {
+		sc := value.(*groupCache)
+		sc.mu.RLock()
+		entries := make(map[string]SignatureEntry, len(sc.entries))
+		for hash, entry := range sc.entries {
+			if now.Sub(entry.Timestamp) <= SignatureCacheTTL {
+				entries[hash] = entry
+			}
+		}
+		sc.mu.RUnlock()
+		if len(entries) > 0 {
+			snapshot.Groups[key.(string)] = entries
+		}
+		return true
+	})
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("signature cache: encode snapshot: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("signature cache: create snapshot directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("signature cache: write snapshot: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("signature cache: replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSignatureSnapshot merges the unexpired entries stored at path into the cache and returns
+// how many were loaded. A missing file loads nothing.
+func LoadSignatureSnapshot(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("signature cache: read snapshot: %w", err)
+	}
+	var snapshot signatureSnapshot
+	if err = json.Unmarshal(data, &snapshot); err != nil {
+		return 0, fmt.Errorf("signature cache: decode snapshot: %w", err)
+	}
+	now := time.Now()
+	loaded := 0
+	for group, entries := range snapshot.Groups {
+		for hash, entry := range entries {
+			if entry.Signature == "" || now.Sub(entry.Timestamp) > SignatureCacheTTL {
+				continue
+			}
+			storeInMemory(group, hash, entry)
+			loaded++
+		}
+	}
+	return loaded, nil
+}
+
+// StartSignatureSnapshots loads the snapshot at path and then writes the cache back to it
+// every interval while it changes. The returned stop function writes a final snapshot.
+func StartSignatureSnapshots(path string, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultSignatureSnapshotInterval
+	}
+	if loaded, err := LoadSignatureSnapshot(path); err != nil {
+		log.Warnf("%v", err)
+	} else if loaded > 0 {
+		log.Infof("signature cache: restored %d entries from %s", loaded, path)
+	}
+	signaturesDirty.Store(false)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if !signaturesDirty.Load() {
+					continue
+				}
+				if err := SaveSignatureSnapshot(path); err != nil {
+					log.Warnf("%v", err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+			if err := SaveSignatureSnapshot(path); err != nil {
+				log.Warnf("%v", err)
+			}
+		})
+	}
+}
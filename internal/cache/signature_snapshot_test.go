@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeSignatureStore struct {
+	mu      sync.Mutex
+	entries map[string]SignatureEntry
+	cleared []string
+	loads   int
+	loadErr error
+}
+
+func (s *fakeSignatureStore) LoadSignature(_ context.Context, group, textHash string) (SignatureEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads++
+	if s.loadErr != nil {
+		return SignatureEntry{}, false, s.loadErr
+	}
+	entry, ok := s.entries[group+"/"+textHash]
+	return entry, ok, nil
+}
+
+func (s *fakeSignatureStore) SaveSignature(_ context.Context, group, textHash string, entry SignatureEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[group+"/"+textHash] = entry
+	return nil
+}
+
+func (s *fakeSignatureStore) ClearSignatures(_ context.Context, group string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cleared = append(s.cleared, group)
+	return nil
+}
+
+func (s *fakeSignatureStore) PurgeSignatures(context.Context, time.Time) error { return nil }
+
+func TestSignatureSnapshot_RoundTrip(t *testing.T) {
+	ClearSignatureCache("")
+	t.Cleanup(func() { ClearSignatureCache("") })
+
+	text := "snapshot thinking text"
+	signature := "snapshotSignature_1234567890123456789012345678901234567890"
+	CacheSignature(testModelName, text, signature)
+
+	path := filepath.Join(t.TempDir(), "signatures.json")
+	if err := SaveSignatureSnapshot(path); err != nil {
+		t.Fatalf("SaveSignatureSnapshot: %v", err)
+	}
+	ClearSignatureCache("")
+	loaded, err := LoadSignatureSnapshot(path)
+	if err != nil || loaded != 1 {
+		t.Fatalf("LoadSignatureSnapshot = %d, %v", loaded, err)
+	}
+	if got := GetCachedSignature(testModelName, text); got != signature {
+		t.Fatalf("signature after reload = %q", got)
+	}
+}
+
+func TestSignatureCache_EvictsOldestPerGroup(t *testing.T) {
+	ClearSignatureCache("")
+	SetMaxSignaturesPerGroup(10)
+	t.Cleanup(func() {
+		SetMaxSignaturesPerGroup(0)
+		ClearSignatureCache("")
+	})
+
+	base := time.Now().Add(-time.Minute)
+	for i := 0; i < 11; i++ {
+		storeInMemory("claude", hashText(string(rune('a'+i))), SignatureEntry{
+			Signature: "evictionSignature_12345678901234567890123456789012345678901",
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+		})
+	}
+	stats := GetSignatureCacheStats()
+	// Going over the limit evicts the overflow plus a tenth of the limit.
+	if stats.Groups["claude"] != 9 {
+		t.Fatalf("entries after eviction = %d, want 9", stats.Groups["claude"])
+	}
+	if GetCachedSignature(testModelName, "a") != "" || GetCachedSignature(testModelName, "b") != "" {
+		t.Fatalf("oldest entries survived eviction")
+	}
+	if GetCachedSignature(testModelName, "k") == "" {
+		t.Fatalf("newest entry was evicted")
+	}
+}
+
+func TestSignatureCache_ReadsThroughStore(t *testing.T) {
+	ClearSignatureCache("")
+	store := &fakeSignatureStore{entries: make(map[string]SignatureEntry)}
+	SetSignatureStore(store)
+	t.Cleanup(func() {
+		SetSignatureStore(nil)
+		ClearSignatureCache("")
+	})
+
+	text := "shared thinking text"
+	signature := "sharedSignature_123456789012345678901234567890123456789012"
+	store.entries["claude/"+hashText(text)] = SignatureEntry{Signature: signature, Timestamp: time.Now()}
+
+	before := GetSignatureCacheStats().StoreHits
+	if got := GetCachedSignature(testModelName, text); got != signature {
+		t.Fatalf("read-through signature = %q", got)
+	}
+	if got := GetSignatureCacheStats().StoreHits - before; got != 1 {
+		t.Fatalf("store hits = %d, want 1", got)
+	}
+
+	ClearSignatureCache(testModelName)
+	if len(store.cleared) == 0 || store.cleared[len(store.cleared)-1] != "claude" {
+		t.Fatalf("store clears = %v", store.cleared)
+	}
+}
+
+func TestSignatureCache_LimitsStoreLookupsOnMiss(t *testing.T) {
+	ClearSignatureCache("")
+	store := &fakeSignatureStore{entries: make(map[string]SignatureEntry)}
+	SetSignatureStore(store)
+	t.Cleanup(func() {
+		SetSignatureStore(nil)
+		ClearSignatureCache("")
+	})
+
+	// A text the store does not know is looked up once, not on every request.
+	GetCachedSignature(testModelName, "unknown text")
+	GetCachedSignature(testModelName, "unknown text")
+	if store.loads != 1 {
+		t.Fatalf("store loads = %d, want 1", store.loads)
+	}
+	// Caching the text locally ends the negative entry.
+	signature := "localSignature_12345678901234567890123456789012345678901234"
+	CacheSignature(testModelName, "unknown text", signature)
+	if got := GetCachedSignature(testModelName, "unknown text"); got != signature {
+		t.Fatalf("signature = %q", got)
+	}
+
+	// After a store failure, misses are answered from memory until the retry delay passes.
+	store.mu.Lock()
+	store.loadErr = errors.New("store down")
+	store.loads = 0
+	store.mu.Unlock()
+	GetCachedSignature(testModelName, "first text")
+	GetCachedSignature(testModelName, "second text")
+	if store.loads != 1 {
+		t.Fatalf("store loads while failing = %d, want 1", store.loads)
+	}
+}
+
+func TestSignatureCache_WritesSlidingExpirationToStore(t *testing.T) {
+	ClearSignatureCache("")
+	store := &fakeSignatureStore{entries: make(map[string]SignatureEntry)}
+	SetSignatureStore(store)
+	t.Cleanup(func() {
+		SetSignatureStore(nil)
+		ClearSignatureCache("")
+	})
+	storedUse := func(key string) time.Time {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return store.entries[key].Timestamp
+	}
+	waitForUse := func(key string, after time.Time) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !storedUse(key).After(after) {
+			if time.Now().After(deadline) {
+				t.Fatalf("store use of %s = %v, want after %v", key, storedUse(key), after)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// An entry read from the store close to expiry is refreshed there too.
+	text := "long running conversation"
+	signature := "slidingSignature_1234567890123456789012345678901234567890"
+	key := "claude/" + hashText(text)
+	old := time.Now().Add(-SignatureCacheTTL + time.Minute)
+	store.entries[key] = SignatureEntry{Signature: signature, Timestamp: old}
+	if got := GetCachedSignature(testModelName, text); got != signature {
+		t.Fatalf("read-through signature = %q", got)
+	}
+	waitForUse(key, old)
+
+	// A memory hit writes through once the last stored use is older than the touch interval.
+	storeInMemory("claude", hashText(text), SignatureEntry{Signature: signature, Timestamp: time.Now(), storedAt: old})
+	marker := time.Now()
+	store.mu.Lock()
+	store.entries[key] = SignatureEntry{Signature: signature, Timestamp: old}
+	store.mu.Unlock()
+	if got := GetCachedSignature(testModelName, text); got != signature {
+		t.Fatalf("memory signature = %q", got)
+	}
+	waitForUse(key, marker)
+
+	// Further hits within the interval stay in memory.
+	store.mu.Lock()
+	store.entries[key] = SignatureEntry{Signature: signature, Timestamp: old}
+	store.mu.Unlock()
+	GetCachedSignature(testModelName, text)
+	time.Sleep(20 * time.Millisecond)
+	if got := storedUse(key); !got.Equal(old) {
+		t.Fatalf("store use = %v, want unchanged %v", got, old)
+	}
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"sort"
+	"time"
+)
+
+// SignatureCacheStats summarizes the signature cache for monitoring.
+type SignatureCacheStats struct {
+	// Groups maps each model group to its number of live in-memory entries.
+	Groups             map[string]int `json:"groups"`
+	Entries            int            `json:"entries"`
+	MaxEntriesPerGroup int            `json:"max_entries_per_group"`
+	Hits               int64          `json:"hits"`
+	Misses             int64          `json:"misses"`
+	// StoreHits counts hits served by the persistent store after an in-memory miss.
+	StoreHits int64 `json:"store_hits"`
+	Writes    int64 `json:"writes"`
+	Evictions int64 `json:"evictions"`
+	// Store reports whether a persistent store is installed.
+	Store bool `json:"store"`
+}
+
+// SignatureEntryInfo describes one cached signature without exposing it in full.
+type SignatureEntryInfo struct {
+	Group           string    `json:"group"`
+	TextHash        string    `json:"text_hash"`
+	SignaturePrefix string    `json:"signature_prefix"`
+	SignatureLength int       `json:"signature_length"`
+	LastUsed        time.Time `json:"last_used"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// GetSignatureCacheStats returns entry counts per model group and the cache counters since start.
+func GetSignatureCacheStats() SignatureCacheStats {
+	stats := SignatureCacheStats{
+		Groups:             make(map[string]int),
+		MaxEntriesPerGroup: int(maxSignaturesPerGroup.Load()),
+		Hits:               signatureHits.Load(),
+		Misses:             signatureMisses.Load(),
+		StoreHits:          signatureStoreHits.Load(),
+		Writes:             signatureWrites.Load(),
+		Evictions:          signatureEvictions.Load(),
+		Store:              currentSignatureStore() != nil,
+	}
+	now := time.Now()
+	signatureCache.Range(func(key, value any) bool {
+		sc := value.(*groupCache)
+		sc.mu.RLock()
+		live := 0
+		for _, entry := range sc.entries {
+			if now.Sub(entry.Timestamp) <= SignatureCacheTTL {
+				live++
+			}
+		}
+		sc.mu.RUnlock()
+		stats.Groups[key.(string)] = live
+		stats.Entries += live
+		return true
+	})
+	return stats
+}
+
+// ListSignatureEntries returns the live in-memory entries of a model group, or of all groups
+// when group is "", most recently used first. limit <= 0 returns every entry.
+func ListSignatureEntries(group string, limit int) []SignatureEntryInfo {
+	now := time.Now()
+	var out []SignatureEntryInfo
+	signatureCache.Range(func(key, value any) bool {
+		name := key.(string)
+		if group != "" && name != group {
+			return true
+		}
+		sc := value.(*groupCache)
+		sc.mu.RLock()
+		for hash, entry := range sc.entries {
+			if now.Sub(entry.Timestamp) > SignatureCacheTTL {
+				continue
+			}
+			prefix := entry.Signature
+			if len(prefix) > 12 {
+				prefix = prefix[:12]
+			}
+			out = append(out, SignatureEntryInfo{
+				Group:           name,
+				TextHash:        hash,
+				SignaturePrefix: prefix,
+				SignatureLength: len(entry.Signature),
+				LastUsed:        entry.Timestamp,
+				ExpiresAt:       entry.Timestamp.Add(SignatureCacheTTL),
+			})
+		}
+		sc.mu.RUnlock()
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].LastUsed.After(out[j].LastUsed) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
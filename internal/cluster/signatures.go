@@ -0,0 +1,63 @@
+package cluster
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+)
+
+var _ cache.SignatureStore = (*SQLState)(nil)
+
+// LoadSignature returns the thinking signature stored for textHash in the model group.
+func (s *SQLState) LoadSignature(ctx context.Context, group, textHash string) (cache.SignatureEntry, bool, error) {
+	var (
+		entry  cache.SignatureEntry
+		usedAt int64
+	)
+	query := s.rebind(`SELECT signature, used_at FROM ` + signatureTable + ` WHERE model_group = ? AND text_hash = ?`)
+	err := s.db.QueryRowContext(ctx, query, group, textHash).Scan(&entry.Signature, &usedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entry, false, nil
+	}
+	if err != nil {
+		return entry, false, fmt.Errorf("cluster: load signature: %w", err)
+	}
+	entry.Timestamp = time.UnixMilli(usedAt)
+	return entry, true, nil
+}
+
+// SaveSignature stores the thinking signature for textHash in the model group.
+func (s *SQLState) SaveSignature(ctx context.Context, group, textHash string, entry cache.SignatureEntry) error {
+	query := s.rebind(`INSERT INTO ` + signatureTable + ` (model_group, text_hash, signature, used_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (model_group, text_hash) DO UPDATE SET signature = excluded.signature, used_at = excluded.used_at`)
+	if _, err := s.db.ExecContext(ctx, query, group, textHash, entry.Signature, entry.Timestamp.UnixMilli()); err != nil {
+		return fmt.Errorf("cluster: save signature: %w", err)
+	}
+	return nil
+}
+
+// ClearSignatures removes the signatures of the model group, or every signature when group is "".
+func (s *SQLState) ClearSignatures(ctx context.Context, group string) error {
+	var err error
+	if group == "" {
+		_, err = s.db.ExecContext(ctx, `DELETE FROM `+signatureTable)
+	} else {
+		_, err = s.db.ExecContext(ctx, s.rebind(`DELETE FROM `+signatureTable+` WHERE model_group = ?`), group)
+	}
+	if err != nil {
+		return fmt.Errorf("cluster: clear signatures: %w", err)
+	}
+	return nil
+}
+
+// PurgeSignatures removes signatures last used before the cutoff.
+func (s *SQLState) PurgeSignatures(ctx context.Context, before time.Time) error {
+	if _, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM `+signatureTable+` WHERE used_at < ?`), before.UnixMilli()); err != nil {
+		return fmt.Errorf("cluster: purge signatures: %w", err)
+	}
+	return nil
+}
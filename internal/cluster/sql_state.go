@@ -0,0 +1,216 @@
+// Package cluster shares credential runtime state between proxy replicas through
+// PostgreSQL (or SQLite for replicas on a single host).
+package cluster
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/sqlutil"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// DriverPostgres shares state through PostgreSQL.
+	DriverPostgres = "postgres"
+	// DriverSQLite shares state through a SQLite file, for replicas on one host.
+	DriverSQLite = "sqlite"
+
+	defaultSQLiteFile = "cluster.db"
+	stateTable        = "cluster_runtime_state"
+	leaseTable        = "cluster_leases"
+	signatureTable    = "cluster_signatures"
+)
+
+// SQLState implements coreauth.SharedState on top of a SQL database.
+type SQLState struct {
+	db     *sql.DB
+	driver string
+}
+
+var _ coreauth.SharedState = (*SQLState)(nil)
+
+// Open connects to the backend described by cfg and ensures the schema exists.
+// baseDir anchors relative SQLite paths, usually the config file directory.
+func Open(ctx context.Context, cfg config.ClusterConfig, baseDir string) (*SQLState, error) {
+	var (
+		db  *sql.DB
+		err error
+	)
+	driver := cfg.Driver
+	if driver == "" {
+		driver = DriverPostgres
+	}
+	switch driver {
+	case DriverSQLite:
+		location := cfg.Path
+		if location == "" {
+			location = defaultSQLiteFile
+		}
+		if !filepath.IsAbs(location) && baseDir != "" {
+			location = filepath.Join(baseDir, location)
+		}
+		if err = os.MkdirAll(filepath.Dir(location), 0o755); err != nil {
+			return nil, fmt.Errorf("cluster: create directory: %w", err)
+		}
+		db, err = sql.Open("sqlite", "file:"+location+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+		if err != nil {
+			return nil, fmt.Errorf("cluster: open sqlite database: %w", err)
+		}
+		db.SetMaxOpenConns(1)
+	case DriverPostgres:
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = sqlutil.PostgresDSNFromEnv()
+		}
+		if dsn == "" {
+			return nil, fmt.Errorf("cluster: postgres DSN is required (set cluster.dsn or PGSTORE_DSN)")
+		}
+		db, err = sql.Open("pgx", dsn)
+		if err != nil {
+			return nil, fmt.Errorf("cluster: open postgres connection: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("cluster: unsupported driver %q", cfg.Driver)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err = db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("cluster: ping database: %w", err)
+	}
+	state := &SQLState{db: db, driver: driver}
+	if err = state.ensureSchema(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return state, nil
+}
+
+// Close releases the database connection.
+func (s *SQLState) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *SQLState) ensureSchema(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS ` + stateTable + ` (
+			auth_id TEXT PRIMARY KEY,
+			node_id TEXT NOT NULL DEFAULT '',
+			state TEXT NOT NULL,
+			updated_at BIGINT NOT NULL,
+			published_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + stateTable + `_published_idx ON ` + stateTable + ` (published_at)`,
+		`CREATE TABLE IF NOT EXISTS ` + leaseTable + ` (
+			name TEXT PRIMARY KEY,
+			holder TEXT NOT NULL,
+			expires_at BIGINT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS ` + signatureTable + ` (
+			model_group TEXT NOT NULL,
+			text_hash TEXT NOT NULL,
+			signature TEXT NOT NULL,
+			used_at BIGINT NOT NULL,
+			PRIMARY KEY (model_group, text_hash)
+		)`,
+	}
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("cluster: create schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// PublishRuntimeState stores the latest runtime state of one auth, replacing the previous one.
+func (s *SQLState) PublishRuntimeState(ctx context.Context, state coreauth.RuntimeState) error {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("cluster: encode runtime state: %w", err)
+	}
+	// Publishes run concurrently, so an older state must not overwrite a newer one.
+	query := s.rebind(`INSERT INTO ` + stateTable + ` (auth_id, node_id, state, updated_at, published_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (auth_id) DO UPDATE SET node_id = excluded.node_id, state = excluded.state,
+			updated_at = excluded.updated_at, published_at = excluded.published_at
+		WHERE excluded.updated_at >= ` + stateTable + `.updated_at`)
+	if _, err = s.db.ExecContext(ctx, query, state.AuthID, state.NodeID, string(payload), state.UpdatedAt.UnixMilli(), time.Now().UnixMilli()); err != nil {
+		return fmt.Errorf("cluster: publish runtime state: %w", err)
+	}
+	return nil
+}
+
+// FetchRuntimeStates returns states published after since. A zero since returns every state.
+func (s *SQLState) FetchRuntimeStates(ctx context.Context, since time.Time) ([]coreauth.RuntimeState, error) {
+	var cursor int64
+	if !since.IsZero() {
+		cursor = since.UnixMilli()
+	}
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT state FROM `+stateTable+` WHERE published_at > ?`), cursor)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: fetch runtime states: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var out []coreauth.RuntimeState
+	for rows.Next() {
+		var payload string
+		if err = rows.Scan(&payload); err != nil {
+			return nil, fmt.Errorf("cluster: scan runtime state: %w", err)
+		}
+		var state coreauth.RuntimeState
+		if err = json.Unmarshal([]byte(payload), &state); err != nil {
+			continue
+		}
+		out = append(out, state)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("cluster: iterate runtime states: %w", err)
+	}
+	return out, nil
+}
+
+// AcquireLease takes the named lease when it is free or expired, renews it when holder
+// already owns it, and reports whether holder owns it afterwards.
+func (s *SQLState) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	query := s.rebind(`INSERT INTO ` + leaseTable + ` (name, holder, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+		WHERE ` + leaseTable + `.holder = excluded.holder OR ` + leaseTable + `.expires_at < ?`)
+	if _, err := s.db.ExecContext(ctx, query, name, holder, now.Add(ttl).UnixMilli(), now.UnixMilli()); err != nil {
+		return false, fmt.Errorf("cluster: acquire lease: %w", err)
+	}
+	var current string
+	if err := s.db.QueryRowContext(ctx, s.rebind(`SELECT holder FROM `+leaseTable+` WHERE name = ?`), name).Scan(&current); err != nil {
+		return false, fmt.Errorf("cluster: read lease: %w", err)
+	}
+	return current == holder, nil
+}
+
+// ReleaseLease gives up the named lease when holder owns it so another replica can take over immediately.
+func (s *SQLState) ReleaseLease(ctx context.Context, name, holder string) error {
+	query := s.rebind(`DELETE FROM ` + leaseTable + ` WHERE name = ? AND holder = ?`)
+	if _, err := s.db.ExecContext(ctx, query, name, holder); err != nil {
+		return fmt.Errorf("cluster: release lease: %w", err)
+	}
+	return nil
+}
+
+// rebind converts '?' placeholders into the positional form expected by PostgreSQL.
+func (s *SQLState) rebind(query string) string {
+	if s.driver != DriverPostgres {
+		return query
+	}
+	return sqlutil.RebindPostgres(query)
+}
@@ -0,0 +1,95 @@
+package cluster
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func openTestState(t *testing.T, path string) *SQLState {
+	t.Helper()
+	state, err := Open(context.Background(), config.ClusterConfig{Driver: DriverSQLite, Path: path}, "")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { _ = state.Close() })
+	return state
+}
+
+func TestAcquireLeaseElectsSingleHolder(t *testing.T) {
+	state := openTestState(t, filepath.Join(t.TempDir(), "cluster.db"))
+	ctx := context.Background()
+
+	if ok, err := state.AcquireLease(ctx, coreauth.RefreshLeaseName, "node-a", time.Minute); err != nil || !ok {
+		t.Fatalf("node-a AcquireLease() = %v, %v", ok, err)
+	}
+	if ok, err := state.AcquireLease(ctx, coreauth.RefreshLeaseName, "node-b", time.Minute); err != nil || ok {
+		t.Fatalf("node-b AcquireLease() = %v, %v, want false", ok, err)
+	}
+	if ok, err := state.AcquireLease(ctx, coreauth.RefreshLeaseName, "node-a", time.Minute); err != nil || !ok {
+		t.Fatalf("node-a renew = %v, %v", ok, err)
+	}
+	if err := state.ReleaseLease(ctx, coreauth.RefreshLeaseName, "node-a"); err != nil {
+		t.Fatalf("ReleaseLease() error = %v", err)
+	}
+	if ok, err := state.AcquireLease(ctx, coreauth.RefreshLeaseName, "node-b", time.Millisecond); err != nil || !ok {
+		t.Fatalf("node-b takeover = %v, %v", ok, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if ok, err := state.AcquireLease(ctx, coreauth.RefreshLeaseName, "node-a", time.Minute); err != nil || !ok {
+		t.Fatalf("node-a after expiry = %v, %v", ok, err)
+	}
+}
+
+func TestManagersConvergeOnQuotaCooldown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.db")
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	newNode := func(nodeID string) *coreauth.Manager {
+		manager := coreauth.NewManager(nil, nil, nil)
+		if _, err := manager.Register(ctx, &coreauth.Auth{ID: "shared-auth", Provider: "claude", Status: coreauth.StatusActive}); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+		manager.SetSharedState(openTestState(t, path), coreauth.SharedStateOptions{
+			NodeID:       nodeID,
+			SyncInterval: 20 * time.Millisecond,
+			LeaseTTL:     time.Minute,
+		})
+		manager.StartSharedStateSync(ctx)
+		t.Cleanup(manager.StopSharedStateSync)
+		return manager
+	}
+	nodeA := newNode("node-a")
+	nodeB := newNode("node-b")
+
+	retryAfter := 10 * time.Minute
+	nodeA.MarkResult(ctx, coreauth.Result{
+		AuthID:     "shared-auth",
+		Provider:   "claude",
+		Model:      "claude-sonnet-4",
+		Success:    false,
+		RetryAfter: &retryAfter,
+		Error:      &coreauth.Error{HTTPStatus: 429, Message: "rate limited"},
+	})
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		auth, _ := nodeB.GetByID("shared-auth")
+		if state := auth.ModelStates["claude-sonnet-4"]; state != nil && state.Quota.Exceeded && state.NextRetryAfter.After(time.Now()) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("node-b did not receive the cooldown: %+v", auth.ModelStates)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if nodeA.IsRefreshLeader() == nodeB.IsRefreshLeader() {
+		t.Fatalf("expected exactly one refresh leader, got a=%v b=%v", nodeA.IsRefreshLeader(), nodeB.IsRefreshLeader())
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoReencryptAuthFiles rewrites every auth file with the current master key.
+// Plaintext files are encrypted, files sealed with a previous key are rotated,
+// and without a primary key encrypted files are converted back to plaintext.
+// Changed files are pushed to the registered token store when it supports it.
+func DoReencryptAuthFiles(cfg *config.Config) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if resolved, errResolve := util.ResolveAuthDir(cfg.AuthDir); errResolve == nil {
+		cfg.AuthDir = resolved
+	}
+	if authcrypt.Current() == nil {
+		log.Errorf("reencrypt-auth: set %s or %s first", authcrypt.EnvKey, authcrypt.EnvKeyFile)
+		return
+	}
+	report, err := authcrypt.ResealDir(cfg.AuthDir)
+	if err != nil {
+		log.Errorf("reencrypt-auth: %v", err)
+		return
+	}
+	for path, reason := range report.Failed {
+		log.Errorf("reencrypt-auth: %s: %s", path, reason)
+	}
+	if err = PersistResealedAuthFiles(context.Background(), report); err != nil {
+		log.Errorf("reencrypt-auth: %v", err)
+		return
+	}
+	fmt.Printf("Re-encrypted %d of %d auth files (key %s)\n", len(report.Resealed), report.Scanned, displayKeyID(report.KeyID))
+}
+
+// PersistResealedAuthFiles pushes resealed files to the registered token store
+// when it mirrors the auth directory to a remote backend.
+func PersistResealedAuthFiles(ctx context.Context, report authcrypt.ResealReport) error {
+	if len(report.Resealed) == 0 {
+		return nil
+	}
+	persister, ok := sdkAuth.GetTokenStore().(interface {
+		PersistAuthFiles(ctx context.Context, message string, paths ...string) error
+	})
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	message := fmt.Sprintf("Re-encrypt %d auth files", len(report.Resealed))
+	if err := persister.PersistAuthFiles(ctx, message, report.Resealed...); err != nil {
+		return fmt.Errorf("persist resealed auth files: %w", err)
+	}
+	return nil
+}
+
+func displayKeyID(id string) string {
+	if id == "" {
+		return "none, decrypted"
+	}
+	return id
+}
@@ -59,6 +59,9 @@ type Config struct {
 	// When exceeded, the oldest log files are deleted until within the limit. Set to 0 to disable.
 	LogsMaxTotalSizeMB int `yaml:"logs-max-total-size-mb" json:"logs-max-total-size-mb"`
 
+	// RequestLogRedaction masks secrets and personal data before request logs are written.
+	RequestLogRedaction RequestLogRedactionConfig `yaml:"request-log-redaction" json:"request-log-redaction"`
+
 	// ErrorLogsMaxFiles limits the number of error log files retained when request logging is disabled.
 	// When exceeded, the oldest error log files are deleted. Default is 10. Set to 0 to disable cleanup.
 	ErrorLogsMaxFiles int `yaml:"error-logs-max-files" json:"error-logs-max-files"`
@@ -66,6 +69,25 @@ type Config struct {
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// UsageStorage configures durable per-request usage storage in SQLite or PostgreSQL.
+	UsageStorage UsageStorageConfig `yaml:"usage-storage" json:"usage-storage"`
+
+	// Notifications configures outbound webhook notifications for operational events.
+	Notifications NotificationsConfig `yaml:"notifications" json:"notifications"`
+
+	// Cluster shares credential runtime state between proxy replicas. Changes require a restart.
+	Cluster ClusterConfig `yaml:"cluster" json:"cluster"`
+
+	// SignatureCache selects where thinking signatures are kept. Backend changes require a restart.
+	SignatureCache SignatureCacheConfig `yaml:"signature-cache,omitempty" json:"signature-cache,omitempty"`
+
+	// RemoteWorkers lets other proxy processes execute requests with their own credentials over
+	// an outbound websocket. Changes require a restart.
+	RemoteWorkers RemoteWorkersConfig `yaml:"remote-workers" json:"remote-workers"`
+
+	// ConfigHistory controls the snapshots kept of config writes made through the management API.
+	ConfigHistory ConfigHistoryConfig `yaml:"config-history" json:"config-history"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -83,6 +105,9 @@ type Config struct {
 	// WebsocketAuth enables or disables authentication for the WebSocket API.
 	WebsocketAuth bool `yaml:"ws-auth" json:"ws-auth"`
 
+	// WebsocketRelay tunes the relay sessions behind /v1/ws and the remote worker endpoint.
+	WebsocketRelay WebsocketRelayConfig `yaml:"ws-relay" json:"ws-relay"`
+
 	// GeminiKey defines Gemini API key configurations with optional routing overrides.
 	GeminiKey []GeminiKey `yaml:"gemini-api-key" json:"gemini-api-key"`
 
@@ -96,9 +121,15 @@ type Config struct {
 	// These are used as fallbacks when the client does not send its own headers.
 	ClaudeHeaderDefaults ClaudeHeaderDefaults `yaml:"claude-header-defaults" json:"claude-header-defaults"`
 
+	// ClaudePromptCaching controls the cache_control breakpoints added to Claude requests.
+	ClaudePromptCaching ClaudePromptCaching `yaml:"claude-prompt-caching,omitempty" json:"claude-prompt-caching,omitempty"`
+
 	// OpenAICompatibility defines OpenAI API compatibility configurations for external providers.
 	OpenAICompatibility []OpenAICompatibility `yaml:"openai-compatibility" json:"openai-compatibility"`
 
+	// HTTPProviders defines upstreams with bespoke JSON APIs, mapped to and from a built-in format.
+	HTTPProviders []HTTPProvider `yaml:"http-providers,omitempty" json:"http-providers,omitempty"`
+
 	// VertexCompatAPIKey defines Vertex AI-compatible API key configurations for third-party providers.
 	// Used for services that use Vertex AI-style paths but with simple API key authentication.
 	VertexCompatAPIKey []VertexCompatKey `yaml:"vertex-api-key" json:"vertex-api-key"`
@@ -106,6 +137,9 @@ type Config struct {
 	// AmpCode contains Amp CLI upstream configuration, management restrictions, and model mappings.
 	AmpCode AmpCode `yaml:"ampcode" json:"ampcode"`
 
+	// SecretRefs controls resolution of env:/file:/exec: references in upstream API key fields.
+	SecretRefs SecretRefsConfig `yaml:"secret-refs" json:"secret-refs"`
+
 	// OAuthExcludedModels defines per-provider global model exclusions applied to OAuth/file-backed auth entries.
 	OAuthExcludedModels map[string][]string `yaml:"oauth-excluded-models,omitempty" json:"oauth-excluded-models,omitempty"`
 
@@ -121,6 +155,13 @@ type Config struct {
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
 	legacyMigrationPending bool `yaml:"-" json:"-"`
+
+	// secretRefs maps the paths of resolved key fields to their references so that
+	// SaveConfigPreserveComments never writes resolved secrets to disk.
+	secretRefs map[string]secretRef `yaml:"-" json:"-"`
+
+	// composition records the files the config was loaded from; see ComposeConfigFile.
+	composition *ConfigComposition `yaml:"-" json:"-"`
 }
 
 // ClaudeHeaderDefaults configures default header values injected into Claude API requests
@@ -132,6 +173,17 @@ type ClaudeHeaderDefaults struct {
 	Timeout        string `yaml:"timeout" json:"timeout"`
 }
 
+// ClaudePromptCaching controls automatic prompt-caching breakpoints on requests sent to Claude,
+// whatever format the client used.
+type ClaudePromptCaching struct {
+	// Mode selects when breakpoints are added:
+	// - "missing" (default): only to requests that carry no cache_control at all
+	// - "auto": also to requests that carry some, on the tools, the system prompt and the last
+	//   stable conversation turn, within the limit of four breakpoints per request
+	// - "off": never
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+}
+
 // TLSConfig holds HTTPS server settings.
 type TLSConfig struct {
 	// Enable toggles HTTPS server mode.
@@ -177,6 +229,225 @@ type QuotaExceeded struct {
 	DisableFatalAccounts bool `yaml:"disable-fatal-accounts" json:"disable-fatal-accounts"`
 }
 
+// RequestLogRedactionConfig configures redaction applied to request logs before they hit disk.
+type RequestLogRedactionConfig struct {
+	// Enabled turns on redaction for request and error logs.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Headers extends the built-in header denylist (Authorization, Cookie, X-Api-Key, ...).
+	// Matching is case-insensitive.
+	Headers []string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// HeaderAction selects "mask" (default) or "hash" for denylisted headers.
+	HeaderAction string `yaml:"header-action,omitempty" json:"header-action,omitempty"`
+
+	// BodyPaths masks JSON fields by path. "#" matches every array element,
+	// e.g. "messages.#.content".
+	BodyPaths []RedactionPathRule `yaml:"body-paths,omitempty" json:"body-paths,omitempty"`
+
+	// Detectors lists pattern detectors applied to headers and bodies. Built-in names are
+	// "api_key", "jwt", "email" and "card_number"; any other name requires Pattern.
+	// When empty, all built-in detectors are enabled with the "mask" action.
+	Detectors []RedactionDetectorRule `yaml:"detectors,omitempty" json:"detectors,omitempty"`
+}
+
+// RedactionPathRule masks a JSON body field.
+type RedactionPathRule struct {
+	// Path is a dot-separated JSON path; "#" iterates arrays.
+	Path string `yaml:"path" json:"path"`
+
+	// Action selects "mask" (default) or "hash".
+	Action string `yaml:"action,omitempty" json:"action,omitempty"`
+}
+
+// RedactionDetectorRule enables a pattern detector.
+type RedactionDetectorRule struct {
+	// Name identifies a built-in detector or labels a custom one.
+	Name string `yaml:"name" json:"name"`
+
+	// Pattern is a regular expression for custom detectors.
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+
+	// Action selects "mask" (default) or "hash".
+	Action string `yaml:"action,omitempty" json:"action,omitempty"`
+}
+
+// NotificationsConfig configures outbound webhook notifications.
+type NotificationsConfig struct {
+	// Enabled toggles webhook delivery.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// RefreshFailureThreshold is the number of consecutive refresh failures for one auth
+	// before a refresh_failed notification is sent. Defaults to 3.
+	RefreshFailureThreshold int `yaml:"refresh-failure-threshold,omitempty" json:"refresh-failure-threshold,omitempty"`
+
+	// DeadLetterFile appends undeliverable notifications as JSON lines. Relative paths are
+	// resolved from the config file directory. Empty keeps dead letters in memory only.
+	DeadLetterFile string `yaml:"dead-letter-file,omitempty" json:"dead-letter-file,omitempty"`
+
+	// Webhooks lists the delivery targets.
+	Webhooks []NotificationWebhook `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+}
+
+// NotificationWebhook describes a single webhook target.
+type NotificationWebhook struct {
+	// Name identifies the target in management responses and test requests.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// URL is the endpoint receiving POST requests.
+	URL string `yaml:"url" json:"url"`
+
+	// Format selects the payload shape: "json" (default), "slack" or "discord".
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+
+	// Secret enables HMAC-SHA256 signing of the request body when set.
+	Secret string `yaml:"secret,omitempty" json:"-"`
+
+	// Events filters which event types are delivered. Empty or "*" delivers all events.
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+
+	// Headers adds extra HTTP headers to every delivery.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// TimeoutSeconds bounds a single delivery attempt. Defaults to 10.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+
+	// MaxRetries is the number of retries after the first failed attempt.
+	// Defaults to 3; a negative value disables retries.
+	MaxRetries int `yaml:"max-retries,omitempty" json:"max-retries,omitempty"`
+}
+
+// UsageStorageConfig configures the durable usage sink.
+type UsageStorageConfig struct {
+	// Driver selects the storage backend: "sqlite" or "postgres". Empty disables durable storage.
+	Driver string `yaml:"driver,omitempty" json:"driver,omitempty"`
+
+	// Path is the SQLite database file. Relative paths are resolved from the config file directory.
+	// Defaults to "usage.db".
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// DSN is the PostgreSQL connection string. When empty, PGSTORE_DSN is used.
+	DSN string `yaml:"dsn,omitempty" json:"-"`
+
+	// Table overrides the table name used for usage records. Defaults to "usage_records".
+	Table string `yaml:"table,omitempty" json:"table,omitempty"`
+
+	// RetentionDays deletes records older than this many days. <= 0 keeps records forever.
+	RetentionDays int `yaml:"retention-days,omitempty" json:"retention-days,omitempty"`
+}
+
+// ConfigHistoryConfig configures config version history for management API writes.
+type ConfigHistoryConfig struct {
+	// MaxVersions is the number of snapshots kept. 0 keeps the default of 50; a negative value disables history.
+	MaxVersions int `yaml:"max-versions,omitempty" json:"max-versions,omitempty"`
+
+	// Dir is the snapshot directory. Relative paths are resolved from the config file directory.
+	// Defaults to "config-history".
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+}
+
+// ClusterConfig configures shared credential runtime state for multi-replica deployments.
+type ClusterConfig struct {
+	// Enabled turns on cluster mode.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Driver selects the shared backend: "postgres" (default) or "sqlite" for replicas on one host.
+	Driver string `yaml:"driver,omitempty" json:"driver,omitempty"`
+
+	// DSN is the PostgreSQL connection string. When empty, PGSTORE_DSN is used.
+	DSN string `yaml:"dsn,omitempty" json:"-"`
+
+	// Path is the SQLite database file. Relative paths are resolved from the config file directory.
+	// Defaults to "cluster.db".
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// NodeID identifies this replica. Defaults to hostname-pid.
+	NodeID string `yaml:"node-id,omitempty" json:"node-id,omitempty"`
+
+	// SyncIntervalSeconds is how often remote state is pulled. Defaults to 2.
+	SyncIntervalSeconds int `yaml:"sync-interval-seconds,omitempty" json:"sync-interval-seconds,omitempty"`
+
+	// LeaseSeconds is how long the token refresh leader keeps leadership without renewing. Defaults to 30.
+	LeaseSeconds int `yaml:"lease-seconds,omitempty" json:"lease-seconds,omitempty"`
+}
+
+// SignatureCacheConfig configures the cache of Claude and Gemini thinking signatures that
+// multi-turn conversations replay.
+type SignatureCacheConfig struct {
+	// Backend is "memory" (default), "file" to snapshot the cache to Path, or "postgres" to share
+	// it through the cluster database. The postgres backend requires cluster mode.
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// Path is the snapshot file of the file backend. Relative paths are resolved from the config
+	// file directory. Defaults to "signature-cache.json".
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// SnapshotIntervalSeconds is how often the file backend writes changes. Defaults to 60.
+	SnapshotIntervalSeconds int `yaml:"snapshot-interval-seconds,omitempty" json:"snapshot-interval-seconds,omitempty"`
+
+	// MaxEntriesPerGroup bounds the in-memory entries of each model group. Defaults to 10000.
+	MaxEntriesPerGroup int `yaml:"max-entries-per-group,omitempty" json:"max-entries-per-group,omitempty"`
+}
+
+// RemoteWorkersConfig configures both sides of remote worker mode. A conductor sets Accept; a
+// worker sets ConductorURL.
+type RemoteWorkersConfig struct {
+	// Accept serves the worker endpoint on /v1/ws/worker and routes to the auths workers register.
+	Accept bool `yaml:"accept" json:"accept"`
+
+	// Token is the shared secret workers present in the X-Worker-Token header. Required on both sides.
+	Token string `yaml:"token,omitempty" json:"-"`
+
+	// ConductorURL is the ws:// or wss:// worker endpoint of the conductor this process serves.
+	ConductorURL string `yaml:"conductor-url,omitempty" json:"conductor-url,omitempty"`
+
+	// Name identifies this worker on the conductor. Defaults to the hostname.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// APIKey is sent as a bearer token when the conductor enables ws-auth.
+	APIKey string `yaml:"api-key,omitempty" json:"-"`
+
+	// Providers limits which local auths are offered to the conductor. Empty offers all.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// WebsocketRelayConfig configures websocket relay sessions.
+type WebsocketRelayConfig struct {
+	// ResumeGraceSeconds is how long a dropped session waits for its client to reconnect and
+	// resume in-flight requests. Defaults to 30; negative fails them as soon as the connection drops.
+	ResumeGraceSeconds int `yaml:"resume-grace-seconds,omitempty" json:"resume-grace-seconds,omitempty"`
+
+	// Token is a shared secret clients send in the X-Relay-Token header or the token query
+	// parameter. Setting Token or Keys makes a credential mandatory on /v1/ws.
+	Token string `yaml:"token,omitempty" json:"-"`
+
+	// Keys are per-client credentials. A session opened with a key joins as provider
+	// aistudio-<name>, so the client keeps its identity across reconnects.
+	Keys []WebsocketRelayKey `yaml:"keys,omitempty" json:"keys,omitempty"`
+
+	// TicketSecret signs the join tickets issued by the management API. Defaults to a random
+	// per-process secret; set it when several replicas must accept each other's tickets.
+	TicketSecret string `yaml:"ticket-secret,omitempty" json:"-"`
+
+	// TicketTTLSeconds is how long a join ticket stays valid. Defaults to 60.
+	TicketTTLSeconds int `yaml:"ticket-ttl-seconds,omitempty" json:"ticket-ttl-seconds,omitempty"`
+
+	// AllowedOrigins lists the browser origins accepted on /v1/ws. Empty accepts any origin.
+	AllowedOrigins []string `yaml:"allowed-origins,omitempty" json:"allowed-origins,omitempty"`
+
+	// MaxSessions caps concurrently open relay sessions. 0 means no cap.
+	MaxSessions int `yaml:"max-sessions,omitempty" json:"max-sessions,omitempty"`
+}
+
+// WebsocketRelayKey binds a relay credential to a provider name.
+type WebsocketRelayKey struct {
+	// Key is the credential the client presents like the relay token.
+	Key string `yaml:"key" json:"-"`
+
+	// Name identifies the client; its sessions join as aistudio-<name>.
+	Name string `yaml:"name" json:"name"`
+}
+
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
@@ -497,6 +768,102 @@ type OpenAICompatibilityModel struct {
 func (m OpenAICompatibilityModel) GetName() string  { return m.Name }
 func (m OpenAICompatibilityModel) GetAlias() string { return m.Alias }
 
+// HTTPProvider configures an upstream whose wire format is described by field mappings
+// instead of a dedicated executor. Requests are translated into Format, reshaped by the
+// request mappings and sent to URL; responses are mapped back into Format and translated
+// to the client's format.
+type HTTPProvider struct {
+	// Name is the provider key used for routing and usage.
+	Name string `yaml:"name" json:"name"`
+
+	// Priority controls selection preference when multiple providers or credentials match.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces model aliases for this provider.
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// URL is the endpoint template. {model} and {api_key} are replaced per request.
+	URL string `yaml:"url" json:"url"`
+
+	// StreamURL is the endpoint template for streaming requests. Defaults to URL.
+	StreamURL string `yaml:"stream-url,omitempty" json:"stream-url,omitempty"`
+
+	// Headers are header templates; {api_key} and {model} are replaced per request.
+	// Defaults to "Authorization: Bearer {api_key}" when an API key is set.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// APIKeyEntries defines API keys with optional per-key proxy configuration.
+	APIKeyEntries []OpenAICompatibilityAPIKey `yaml:"api-key-entries,omitempty" json:"api-key-entries,omitempty"`
+
+	// Format is the built-in format the mappings read from and write to: openai (chat
+	// completions, the default), claude or gemini.
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+
+	// Request builds the upstream body from the request translated into Format.
+	Request HTTPMapping `yaml:"request,omitempty" json:"request,omitempty"`
+
+	// Response builds a Format response from the upstream body.
+	Response HTTPMapping `yaml:"response,omitempty" json:"response,omitempty"`
+
+	// Stream describes streamed responses and builds a Format chunk from each upstream event.
+	Stream HTTPStreamMapping `yaml:"stream,omitempty" json:"stream,omitempty"`
+
+	// Usage lists where token counts are found in upstream responses and stream events.
+	Usage HTTPUsagePaths `yaml:"usage,omitempty" json:"usage,omitempty"`
+
+	// Models defines the model configurations including aliases for routing.
+	Models []OpenAICompatibilityModel `yaml:"models" json:"models"`
+}
+
+// HTTPMapping copies fields from a source JSON document into a template.
+type HTTPMapping struct {
+	// Template is the JSON document the fields are written into. Defaults to {} for requests
+	// and to a minimal Format response otherwise.
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+
+	// Fields are applied in order. Without fields the source document is used unchanged.
+	Fields []HTTPFieldMapping `yaml:"fields,omitempty" json:"fields,omitempty"`
+}
+
+// HTTPFieldMapping copies one value.
+type HTTPFieldMapping struct {
+	// From is a gjson path into the source document. In request mappings $model is the
+	// upstream model name and $stream whether the request streams.
+	From string `yaml:"from,omitempty" json:"from,omitempty"`
+
+	// To is the sjson path written in the template.
+	To string `yaml:"to" json:"to"`
+
+	// Value is written when From is empty or missing from the source.
+	Value any `yaml:"value,omitempty" json:"value,omitempty"`
+}
+
+// HTTPStreamMapping describes a streamed upstream response.
+type HTTPStreamMapping struct {
+	// Mode is how events are framed: sse (data: lines, the default) or ndjson.
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+
+	// Done is the event payload that ends the stream. Defaults to [DONE].
+	Done string `yaml:"done,omitempty" json:"done,omitempty"`
+
+	// Template is the JSON chunk the fields are written into. Defaults to a minimal Format chunk.
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+
+	// Fields are applied to every event. Events where no From path exists are skipped.
+	// Without fields events are used unchanged. Mapped streams support the openai and gemini formats.
+	Fields []HTTPFieldMapping `yaml:"fields,omitempty" json:"fields,omitempty"`
+}
+
+// HTTPUsagePaths are gjson paths to token counts in upstream responses and stream events.
+// When unset, usage is read from the mapped Format document.
+type HTTPUsagePaths struct {
+	InputTokens     string `yaml:"input-tokens,omitempty" json:"input-tokens,omitempty"`
+	OutputTokens    string `yaml:"output-tokens,omitempty" json:"output-tokens,omitempty"`
+	TotalTokens     string `yaml:"total-tokens,omitempty" json:"total-tokens,omitempty"`
+	ReasoningTokens string `yaml:"reasoning-tokens,omitempty" json:"reasoning-tokens,omitempty"`
+	CachedTokens    string `yaml:"cached-tokens,omitempty" json:"cached-tokens,omitempty"`
+}
+
 // LoadConfig reads a YAML configuration file from the given path,
 // unmarshals it into a Config struct, applies environment variable overrides,
 // and returns it.
@@ -541,6 +908,44 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	if optional && len(data) == 0 {
 		return &Config{}, nil
 	}
+	return loadConfigData(configFile, data, loadOptions{optional: optional, persist: true, resolveSecrets: true})
+}
+
+// LoadConfigCandidate parses a config file submitted through the management API. It loads
+// like LoadConfig but only checks that secret references are well-formed: nothing is read,
+// run or written back to disk.
+func LoadConfigCandidate(configFile string) (*Config, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return loadConfigData(configFile, data, loadOptions{})
+}
+
+// loadOptions controls loadConfigData.
+type loadOptions struct {
+	// optional returns an empty config instead of failing on invalid documents.
+	optional bool
+	// persist allows a plaintext remote management key to be written back to the config
+	// file in hashed form.
+	persist bool
+	// resolveSecrets resolves secret references; otherwise they are only checked.
+	resolveSecrets bool
+}
+
+// loadConfigData parses data as if it had been read from configFile.
+func loadConfigData(configFile string, data []byte, opts loadOptions) (*Config, error) {
+	optional, persist := opts.optional, opts.persist
+	// Resolve includes, the CONFIG_ENV overlay and ${VAR} interpolation.
+	composition, err := composeConfigData(configFile, data)
+	if err != nil {
+		if optional {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("failed to compose config file: %w", err)
+	}
+	data = composition.Data
+	composition.Data = nil
 
 	// Unmarshal the YAML data into the Config struct.
 	var cfg Config
@@ -568,6 +973,7 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		}
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	cfg.composition = composition
 
 	// NOTE: Startup legacy key migration is intentionally disabled.
 	// Reason: avoid mutating config.yaml during server startup.
@@ -595,8 +1001,11 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		cfg.RemoteManagement.SecretKey = hashed
 
 		// Persist the hashed value back to the config file to avoid re-hashing on next startup.
-		// Preserve YAML comments and ordering; update only the nested key.
-		_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
+		// Preserve YAML comments and ordering; update only the nested key. A composed config
+		// may take the key from another file or ${VAR}, so it is only hashed in memory.
+		if persist && !composition.Composed {
+			_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
+		}
 	}
 
 	cfg.RemoteManagement.PanelGitHubRepository = strings.TrimSpace(cfg.RemoteManagement.PanelGitHubRepository)
@@ -618,6 +1027,16 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	}
 	cfg.ProxyURL = strings.TrimSpace(cfg.ProxyURL)
 
+	// Resolve env:/file:/exec: secret references before keys are sanitized.
+	if opts.resolveSecrets {
+		err = cfg.ResolveSecretRefs()
+	} else {
+		err = cfg.CheckSecretRefs()
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	// Sanitize Gemini API key configuration and migrate legacy entries.
 	cfg.SanitizeGeminiKeys()
 
@@ -632,6 +1051,7 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 
 	// Sanitize OpenAI compatibility providers: drop entries without base-url
 	cfg.SanitizeOpenAICompatibility()
+	cfg.SanitizeHTTPProviders()
 
 	// Normalize OAuth provider model exclusion map.
 	cfg.OAuthExcludedModels = NormalizeOAuthExcludedModels(cfg.OAuthExcludedModels)
@@ -648,6 +1068,13 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Normalize egress determinism config.
 	cfg.SanitizeEgressDeterminism()
 
+	// Normalize durable usage storage settings.
+	cfg.SanitizeUsageStorage()
+	cfg.SanitizeNotifications()
+	cfg.SanitizeCluster()
+	cfg.SanitizeRemoteWorkers()
+	cfg.SanitizeWebsocketRelay()
+
 	// Enforce per-account proxy hard constraints when enabled.
 	if err := cfg.ValidateAccountProxyConstraint(); err != nil {
 		return nil, err
@@ -656,6 +1083,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Follow key entries that sanitization dropped or reordered.
+	cfg.reanchorSecretRefs()
+
 	// NOTE: Legacy migration persistence is intentionally disabled together with
 	// startup legacy migration to keep startup read-only for config.yaml.
 	// Re-enable the block below if automatic startup migration is needed again.
@@ -795,6 +1225,41 @@ func (cfg *Config) SanitizeOpenAICompatibility() {
 	cfg.OpenAICompatibility = out
 }
 
+// SanitizeHTTPProviders normalizes HTTP provider entries and drops those without a name or URL.
+func (cfg *Config) SanitizeHTTPProviders() {
+	if cfg == nil || len(cfg.HTTPProviders) == 0 {
+		return
+	}
+	out := make([]HTTPProvider, 0, len(cfg.HTTPProviders))
+	for i := range cfg.HTTPProviders {
+		e := cfg.HTTPProviders[i]
+		e.Name = strings.ToLower(strings.TrimSpace(e.Name))
+		e.Prefix = normalizeModelPrefix(e.Prefix)
+		e.URL = strings.TrimSpace(e.URL)
+		e.StreamURL = strings.TrimSpace(e.StreamURL)
+		e.Format = strings.ToLower(strings.TrimSpace(e.Format))
+		if e.Format == "" {
+			e.Format = "openai"
+		}
+		e.Stream.Mode = strings.ToLower(strings.TrimSpace(e.Stream.Mode))
+		if e.Stream.Mode == "" {
+			e.Stream.Mode = "sse"
+		}
+		if e.Stream.Done == "" {
+			e.Stream.Done = "[DONE]"
+		}
+		for j := range e.APIKeyEntries {
+			e.APIKeyEntries[j].APIKey = strings.TrimSpace(e.APIKeyEntries[j].APIKey)
+			e.APIKeyEntries[j].ProxyURL = strings.TrimSpace(e.APIKeyEntries[j].ProxyURL)
+		}
+		if e.Name == "" || e.URL == "" {
+			continue
+		}
+		out = append(out, e)
+	}
+	cfg.HTTPProviders = out
+}
+
 // SanitizeCodexKeys removes Codex API key entries missing a BaseURL.
 // It trims whitespace and preserves order for remaining entries.
 func (cfg *Config) SanitizeCodexKeys() {
@@ -899,6 +1364,146 @@ func (cfg *Config) SanitizeEgressDeterminism() {
 	}
 }
 
+// SanitizeUsageStorage normalizes durable usage storage settings.
+func (cfg *Config) SanitizeUsageStorage() {
+	if cfg == nil {
+		return
+	}
+	cfg.UsageStorage.Driver = strings.ToLower(strings.TrimSpace(cfg.UsageStorage.Driver))
+	switch cfg.UsageStorage.Driver {
+	case "sqlite3":
+		cfg.UsageStorage.Driver = "sqlite"
+	case "postgresql", "pg", "pgx":
+		cfg.UsageStorage.Driver = "postgres"
+	}
+	cfg.UsageStorage.Path = strings.TrimSpace(cfg.UsageStorage.Path)
+	cfg.UsageStorage.DSN = strings.TrimSpace(cfg.UsageStorage.DSN)
+	cfg.UsageStorage.Table = strings.TrimSpace(cfg.UsageStorage.Table)
+	if cfg.UsageStorage.RetentionDays < 0 {
+		cfg.UsageStorage.RetentionDays = 0
+	}
+}
+
+// SanitizeCluster normalizes cluster-mode settings.
+func (cfg *Config) SanitizeCluster() {
+	if cfg == nil || !cfg.Cluster.Enabled {
+		return
+	}
+	cfg.Cluster.Driver = strings.ToLower(strings.TrimSpace(cfg.Cluster.Driver))
+	switch cfg.Cluster.Driver {
+	case "", "postgresql", "pg", "pgx":
+		cfg.Cluster.Driver = "postgres"
+	case "sqlite3":
+		cfg.Cluster.Driver = "sqlite"
+	}
+	cfg.Cluster.DSN = strings.TrimSpace(cfg.Cluster.DSN)
+	cfg.Cluster.Path = strings.TrimSpace(cfg.Cluster.Path)
+	cfg.Cluster.NodeID = strings.TrimSpace(cfg.Cluster.NodeID)
+	if cfg.Cluster.SyncIntervalSeconds <= 0 {
+		cfg.Cluster.SyncIntervalSeconds = 2
+	}
+	if cfg.Cluster.LeaseSeconds <= 0 {
+		cfg.Cluster.LeaseSeconds = 30
+	}
+}
+
+// SanitizeRemoteWorkers normalizes remote worker settings.
+func (cfg *Config) SanitizeRemoteWorkers() {
+	if cfg == nil {
+		return
+	}
+	rw := &cfg.RemoteWorkers
+	rw.Token = strings.TrimSpace(rw.Token)
+	rw.ConductorURL = strings.TrimSpace(rw.ConductorURL)
+	rw.Name = strings.ToLower(strings.TrimSpace(rw.Name))
+	rw.APIKey = strings.TrimSpace(rw.APIKey)
+	providers := rw.Providers[:0]
+	for _, provider := range rw.Providers {
+		if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+			providers = append(providers, provider)
+		}
+	}
+	rw.Providers = providers
+}
+
+// SanitizeWebsocketRelay applies websocket relay defaults.
+func (cfg *Config) SanitizeWebsocketRelay() {
+	if cfg == nil {
+		return
+	}
+	relay := &cfg.WebsocketRelay
+	if relay.ResumeGraceSeconds == 0 {
+		relay.ResumeGraceSeconds = 30
+	}
+	if relay.TicketTTLSeconds <= 0 {
+		relay.TicketTTLSeconds = 60
+	}
+	if relay.MaxSessions < 0 {
+		relay.MaxSessions = 0
+	}
+	relay.Token = strings.TrimSpace(relay.Token)
+	relay.TicketSecret = strings.TrimSpace(relay.TicketSecret)
+	for i := range relay.Keys {
+		relay.Keys[i].Key = strings.TrimSpace(relay.Keys[i].Key)
+		relay.Keys[i].Name = strings.ToLower(strings.TrimSpace(relay.Keys[i].Name))
+	}
+	origins := relay.AllowedOrigins[:0]
+	for _, origin := range relay.AllowedOrigins {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	relay.AllowedOrigins = origins
+}
+
+// SanitizeNotifications normalizes webhook notification settings and drops targets without a URL.
+func (cfg *Config) SanitizeNotifications() {
+	if cfg == nil {
+		return
+	}
+	cfg.Notifications.DeadLetterFile = strings.TrimSpace(cfg.Notifications.DeadLetterFile)
+	if cfg.Notifications.RefreshFailureThreshold <= 0 {
+		cfg.Notifications.RefreshFailureThreshold = 3
+	}
+	if len(cfg.Notifications.Webhooks) == 0 {
+		return
+	}
+	out := make([]NotificationWebhook, 0, len(cfg.Notifications.Webhooks))
+	for i := range cfg.Notifications.Webhooks {
+		hook := cfg.Notifications.Webhooks[i]
+		hook.URL = strings.TrimSpace(hook.URL)
+		if hook.URL == "" {
+			continue
+		}
+		hook.Name = strings.TrimSpace(hook.Name)
+		if hook.Name == "" {
+			hook.Name = fmt.Sprintf("webhook-%d", i+1)
+		}
+		hook.Format = strings.ToLower(strings.TrimSpace(hook.Format))
+		switch hook.Format {
+		case "slack", "discord":
+		default:
+			hook.Format = "json"
+		}
+		hook.Secret = strings.TrimSpace(hook.Secret)
+		events := make([]string, 0, len(hook.Events))
+		for _, event := range hook.Events {
+			if trimmed := strings.ToLower(strings.TrimSpace(event)); trimmed != "" {
+				events = append(events, trimmed)
+			}
+		}
+		hook.Events = events
+		if hook.TimeoutSeconds <= 0 {
+			hook.TimeoutSeconds = 10
+		}
+		if hook.MaxRetries == 0 {
+			hook.MaxRetries = 3
+		}
+		out = append(out, hook)
+	}
+	cfg.Notifications.Webhooks = out
+}
+
 // NormalizeModelFamilyProviderAllowlist normalizes model-family -> provider allowlist mapping.
 func NormalizeModelFamilyProviderAllowlist(entries map[string][]string) map[string][]string {
 	if len(entries) == 0 {
@@ -1172,7 +1777,10 @@ func hashSecret(secret string) (string, error) {
 // SaveConfigPreserveComments writes the config back to YAML while preserving existing comments
 // and key ordering by loading the original file into a yaml.Node tree and updating values in-place.
 func SaveConfigPreserveComments(configFile string, cfg *Config) error {
-	persistCfg := cfg
+	if cfg.IsComposed() {
+		return ErrComposedConfig
+	}
+	persistCfg := cfg.withSecretRefsRestored()
 	// Load original YAML as a node tree to preserve comments and ordering.
 	data, err := os.ReadFile(configFile)
 	if err != nil {
@@ -0,0 +1,456 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEnvVar selects the environment overlay. With CONFIG_ENV=prod, config.yaml is
+// overlaid by config.prod.yaml from the same directory when that file exists.
+const ConfigEnvVar = "CONFIG_ENV"
+
+// ConfigVarsEnvVar lists the extra environment variables that ${VAR} may read, separated by
+// commas. An entry ending in * matches every variable with that prefix. Variables starting
+// with CLIPROXY_ are always allowed; any other variable is rejected so that a config value
+// cannot expose the process environment.
+const ConfigVarsEnvVar = "CONFIG_VARS"
+
+// defaultConfigVarPrefix names the variables ${VAR} may always read.
+const defaultConfigVarPrefix = "CLIPROXY_"
+
+// includeKey is the top-level key listing files merged underneath the including file.
+const includeKey = "include"
+
+// Roles of the files a config is composed from.
+const (
+	ConfigSourceMain    = "main"
+	ConfigSourceInclude = "include"
+	ConfigSourceOverlay = "overlay"
+)
+
+// ErrComposedConfig is returned when a write would flatten a config that is composed from
+// several files or uses ${VAR} interpolation back into the main file.
+var ErrComposedConfig = errors.New("config is composed from includes, an overlay or ${VAR} interpolation; edit the source files instead")
+
+// ConfigSource is one file that contributed to a loaded config.
+type ConfigSource struct {
+	Path string `json:"path"`
+	Role string `json:"role"`
+}
+
+// ConfigComposition is a config document after includes, the overlay and interpolation
+// have been applied.
+type ConfigComposition struct {
+	// Data is the merged YAML document. It is the main file unchanged when nothing was composed.
+	Data []byte
+	// Sources lists the files read, main file first.
+	Sources []ConfigSource
+	// WatchPatterns are absolute paths and include globs whose changes affect Data. The
+	// overlay path is listed even when the file does not exist yet.
+	WatchPatterns []string
+	// Composed reports whether Data differs from the main file.
+	Composed bool
+}
+
+// ComposeConfigFile reads configFile and resolves its includes, overlay and interpolation.
+func ComposeConfigFile(configFile string) (*ConfigComposition, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	return composeConfigData(configFile, data)
+}
+
+// composeConfigData composes the main file from data already read from configFile.
+//
+// Merge rules: includes are merged in the order listed, glob matches in lexical order, and
+// the including file is merged last. Mappings merge key by key, scalars from later files
+// win, and lists are concatenated. The overlay is merged over the result with the same
+// rules except that its lists replace the lists they override.
+func composeConfigData(configFile string, data []byte) (*ConfigComposition, error) {
+	mainPath := configFile
+	if abs, errAbs := filepath.Abs(configFile); errAbs == nil {
+		mainPath = abs
+	}
+	comp := &configComposer{visiting: make(map[string]bool), rootDir: filepath.Dir(mainPath)}
+	comp.rootReal = comp.rootDir
+	if real, errReal := filepath.EvalSymlinks(comp.rootDir); errReal == nil {
+		comp.rootReal = real
+	}
+	comp.sources = append(comp.sources, ConfigSource{Path: mainPath, Role: ConfigSourceMain})
+	comp.patterns = append(comp.patterns, mainPath)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		// Leave empty or malformed documents to the regular parser and its error messages.
+		return &ConfigComposition{Data: data, Sources: comp.sources, WatchPatterns: comp.patterns}, nil
+	}
+	root, err := comp.compose(mainPath, doc.Content[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if env := strings.TrimSpace(os.Getenv(ConfigEnvVar)); env != "" {
+		ext := filepath.Ext(mainPath)
+		overlayPath := strings.TrimSuffix(mainPath, ext) + "." + env + ext
+		comp.patterns = append(comp.patterns, overlayPath)
+		overlay, errOverlay := comp.loadFile(overlayPath, ConfigSourceOverlay)
+		if errOverlay != nil && !errors.Is(errOverlay, os.ErrNotExist) {
+			return nil, errOverlay
+		}
+		if overlay != nil {
+			root = mergeConfigNodes(root, overlay, false)
+		}
+	}
+
+	out := &ConfigComposition{Data: data, Sources: comp.sources, WatchPatterns: comp.patterns}
+	out.Composed = comp.changed || len(comp.sources) > 1
+	if !out.Composed {
+		return out, nil
+	}
+	merged, err := yaml.Marshal(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}})
+	if err != nil {
+		return nil, fmt.Errorf("render composed config: %w", err)
+	}
+	out.Data = merged
+	return out, nil
+}
+
+type configComposer struct {
+	// rootDir is the directory of the main file; rootReal is the same with symlinks resolved.
+	// Included and overlay files must stay inside it.
+	rootDir  string
+	rootReal string
+	sources  []ConfigSource
+	patterns []string
+	visiting map[string]bool
+	// changed is set when interpolation or an include key altered the main document.
+	changed bool
+}
+
+// confine reports an error unless path stays inside the config directory, both as written
+// and after resolving symlinks.
+func (c *configComposer) confine(path string) error {
+	if !withinDir(c.rootDir, filepath.Clean(path)) {
+		return fmt.Errorf("%s is outside the config directory %s", path, c.rootDir)
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		// Missing files are reported by the caller.
+		return nil
+	}
+	if !withinDir(c.rootReal, real) {
+		return fmt.Errorf("%s resolves to %s, outside the config directory %s", path, real, c.rootDir)
+	}
+	return nil
+}
+
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || filepath.IsAbs(rel) {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// loadFile parses an included or overlay file and composes its own includes.
+func (c *configComposer) loadFile(path, role string) (*yaml.Node, error) {
+	if err := c.confine(path); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	c.sources = append(c.sources, ConfigSource{Path: path, Role: role})
+	var doc yaml.Node
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: top level must be a mapping", path)
+	}
+	return c.compose(path, root)
+}
+
+// compose interpolates root and merges the files it includes underneath it.
+func (c *configComposer) compose(path string, root *yaml.Node) (*yaml.Node, error) {
+	if c.visiting[path] {
+		return nil, fmt.Errorf("include cycle at %s", path)
+	}
+	c.visiting[path] = true
+	defer delete(c.visiting, path)
+
+	interpolated, err := interpolateNode(root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	c.changed = c.changed || interpolated
+
+	patterns, err := takeIncludes(root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if patterns == nil {
+		return root, nil
+	}
+	c.changed = true
+
+	var merged *yaml.Node
+	baseDir := filepath.Dir(path)
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(baseDir, pattern)
+		}
+		pattern = filepath.Clean(pattern)
+		if err = c.confine(pattern); err != nil {
+			return nil, fmt.Errorf("%s: include %w", path, err)
+		}
+		c.patterns = append(c.patterns, pattern)
+
+		matches := []string{pattern}
+		if hasGlobMeta(pattern) {
+			if matches, err = filepath.Glob(pattern); err != nil {
+				return nil, fmt.Errorf("%s: invalid include pattern %q: %w", path, pattern, err)
+			}
+			sort.Strings(matches)
+		}
+		for _, match := range matches {
+			child, errLoad := c.loadFile(match, ConfigSourceInclude)
+			if errLoad != nil {
+				if errors.Is(errLoad, os.ErrNotExist) {
+					return nil, fmt.Errorf("%s: included file %s does not exist", path, match)
+				}
+				return nil, errLoad
+			}
+			merged = mergeConfigNodes(merged, child, true)
+		}
+	}
+	return mergeConfigNodes(merged, root, true), nil
+}
+
+// takeIncludes removes the include key from root and returns its entries. The key accepts
+// a single path or a list of paths and globs.
+func takeIncludes(root *yaml.Node) ([]string, error) {
+	idx := findMapKeyIndex(root, includeKey)
+	if idx < 0 {
+		return nil, nil
+	}
+	value := root.Content[idx+1]
+	root.Content = append(root.Content[:idx], root.Content[idx+2:]...)
+
+	var entries []string
+	switch value.Kind {
+	case yaml.ScalarNode:
+		if value.Tag != "!!null" {
+			entries = append(entries, value.Value)
+		}
+	case yaml.SequenceNode:
+		for _, item := range value.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: include entries must be strings", item.Line)
+			}
+			entries = append(entries, item.Value)
+		}
+	default:
+		return nil, fmt.Errorf("line %d: include must be a path or a list of paths", value.Line)
+	}
+	out := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if trimmed := strings.TrimSpace(entry); trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	return out, nil
+}
+
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// mergeConfigNodes merges src over dst and returns the result. dst may be modified.
+func mergeConfigNodes(dst, src *yaml.Node, appendLists bool) *yaml.Node {
+	if dst == nil {
+		return src
+	}
+	if src == nil {
+		return dst
+	}
+	switch {
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			key, value := src.Content[i], src.Content[i+1]
+			if idx := findMapKeyIndex(dst, key.Value); idx >= 0 {
+				dst.Content[idx+1] = mergeConfigNodes(dst.Content[idx+1], value, appendLists)
+				continue
+			}
+			dst.Content = append(dst.Content, key, value)
+		}
+		return dst
+	case appendLists && dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode:
+		dst.Content = append(dst.Content, src.Content...)
+		return dst
+	default:
+		return src
+	}
+}
+
+// interpolateNode expands ${VAR} and ${VAR:-default} in every scalar value under node.
+// $${ produces a literal ${. Mapping keys are left alone.
+func interpolateNode(node *yaml.Node) (bool, error) {
+	changed := false
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "$") {
+			return false, nil
+		}
+		expanded, err := interpolateString(node.Value)
+		if err != nil {
+			return false, fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		if expanded != node.Value {
+			node.Value = expanded
+			if node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+				// Let plain values such as port: ${PORT:-8317} resolve to their natural type.
+				node.Tag = ""
+			}
+			changed = true
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			c, err := interpolateNode(node.Content[i])
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+	case yaml.SequenceNode, yaml.DocumentNode:
+		for _, child := range node.Content {
+			c, err := interpolateNode(child)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+	}
+	return changed, nil
+}
+
+func interpolateString(value string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(value); {
+		if strings.HasPrefix(value[i:], "$${") {
+			sb.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(value[i:], "${") {
+			sb.WriteByte(value[i])
+			i++
+			continue
+		}
+		end := strings.IndexByte(value[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated ${ in %q", value)
+		}
+		expr := value[i+2 : i+end]
+		name, fallback, hasDefault := strings.Cut(expr, ":-")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return "", fmt.Errorf("empty variable name in %q", value)
+		}
+		if !configVarAllowed(name) {
+			return "", fmt.Errorf("environment variable %s is not allowed in config values (use a %s name or list it in %s)", name, defaultConfigVarPrefix, ConfigVarsEnvVar)
+		}
+		resolved, ok := os.LookupEnv(name)
+		switch {
+		case ok && (resolved != "" || !hasDefault):
+			sb.WriteString(resolved)
+		case hasDefault:
+			sb.WriteString(fallback)
+		default:
+			return "", fmt.Errorf("environment variable %s is not set (use ${%s:-default} for a fallback)", name, name)
+		}
+		i += end + 1
+	}
+	return sb.String(), nil
+}
+
+// configVarAllowed reports whether ${name} may be expanded; see ConfigVarsEnvVar.
+func configVarAllowed(name string) bool {
+	if strings.HasPrefix(name, defaultConfigVarPrefix) {
+		return true
+	}
+	for _, entry := range strings.Split(os.Getenv(ConfigVarsEnvVar), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// configVarNames returns the variables referenced by ${VAR} expressions in value.
+func configVarNames(value string) []string {
+	var names []string
+	for i := 0; i < len(value); {
+		switch {
+		case strings.HasPrefix(value[i:], "$${"):
+			i += 3
+		case strings.HasPrefix(value[i:], "${"):
+			end := strings.IndexByte(value[i:], '}')
+			if end < 0 {
+				return names
+			}
+			name, _, _ := strings.Cut(value[i+2:i+end], ":-")
+			names = append(names, strings.TrimSpace(name))
+			i += end + 1
+		default:
+			i++
+		}
+	}
+	return names
+}
+
+// Sources returns the files the config was loaded from, main file first. It is nil for
+// configs that were not loaded from disk.
+func (cfg *Config) Sources() []ConfigSource {
+	if cfg == nil || cfg.composition == nil {
+		return nil
+	}
+	return append([]ConfigSource(nil), cfg.composition.Sources...)
+}
+
+// WatchPatterns returns the paths and include globs whose changes require a reload.
+func (cfg *Config) WatchPatterns() []string {
+	if cfg == nil || cfg.composition == nil {
+		return nil
+	}
+	return append([]string(nil), cfg.composition.WatchPatterns...)
+}
+
+// IsComposed reports whether the config was merged from several files or interpolated,
+// in which case it cannot be written back to the main file.
+func (cfg *Config) IsComposed() bool {
+	return cfg != nil && cfg.composition != nil && cfg.composition.Composed
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfigFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll(%s): %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile(%s): %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_MergesIncludesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": `include:
+  - providers/*.yaml
+port: 9000
+api-keys:
+  - main-key
+`,
+		"providers/b.yaml": "port: 7000\napi-keys:\n  - b-key\n",
+		"providers/a.yaml": "port: 8000\ndebug: true\napi-keys:\n  - a-key\n",
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Port != 9000 || !cfg.Debug {
+		t.Fatalf("port=%d debug=%t, want including file to win and include values kept", cfg.Port, cfg.Debug)
+	}
+	if got := strings.Join(cfg.APIKeys, ","); got != "a-key,b-key,main-key" {
+		t.Fatalf("api-keys = %s, want includes in lexical order followed by the main file", got)
+	}
+	if !cfg.IsComposed() || len(cfg.Sources()) != 3 {
+		t.Fatalf("sources = %+v, want main and two includes", cfg.Sources())
+	}
+	if err = SaveConfigPreserveComments(filepath.Join(dir, "config.yaml"), cfg); !errors.Is(err, ErrComposedConfig) {
+		t.Fatalf("SaveConfigPreserveComments error = %v, want ErrComposedConfig", err)
+	}
+}
+
+func TestLoadConfig_OverlayReplacesLists(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml":      "port: 9000\napi-keys:\n  - dev-key\nrouting:\n  strategy: round-robin\n",
+		"config.prod.yaml": "api-keys:\n  - prod-key\nrouting:\n  strategy: fill-first\n",
+	})
+	t.Setenv(ConfigEnvVar, "prod")
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if got := strings.Join(cfg.APIKeys, ","); got != "prod-key" {
+		t.Fatalf("api-keys = %s, want overlay list to replace the base list", got)
+	}
+	if cfg.Port != 9000 || cfg.Routing.Strategy != "fill-first" {
+		t.Fatalf("port=%d strategy=%s, want base port and overlay strategy", cfg.Port, cfg.Routing.Strategy)
+	}
+}
+
+func TestLoadConfig_InterpolatesEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": "port: ${TEST_CFG_PORT:-8317}\nhost: ${TEST_CFG_HOST}\nremote-management:\n  panel-github-repository: \"$${literal}\"\n",
+	})
+	t.Setenv("TEST_CFG_HOST", "127.0.0.1")
+	t.Setenv(ConfigVarsEnvVar, "TEST_CFG_*")
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Port != 8317 || cfg.Host != "127.0.0.1" {
+		t.Fatalf("port=%d host=%q, want default port and host from environment", cfg.Port, cfg.Host)
+	}
+	if cfg.RemoteManagement.PanelGitHubRepository != "${literal}" {
+		t.Fatalf("escaped value = %q, want ${literal}", cfg.RemoteManagement.PanelGitHubRepository)
+	}
+
+	writeConfigFiles(t, dir, map[string]string{"config.yaml": "host: ${TEST_CFG_UNSET_VAR}\n"})
+	if _, err = LoadConfig(filepath.Join(dir, "config.yaml")); err == nil || !strings.Contains(err.Error(), "TEST_CFG_UNSET_VAR") {
+		t.Fatalf("LoadConfig error = %v, want unset variable error", err)
+	}
+
+	t.Setenv("TEST_SECRET_OUTSIDE_ALLOWLIST", "hidden")
+	writeConfigFiles(t, dir, map[string]string{"config.yaml": "host: ${TEST_SECRET_OUTSIDE_ALLOWLIST:-x}\n"})
+	if _, err = LoadConfig(filepath.Join(dir, "config.yaml")); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("LoadConfig error = %v, want allowlist error", err)
+	}
+}
+
+func TestComposeConfigFile_ConfinesIncludesToConfigDirectory(t *testing.T) {
+	outside := t.TempDir()
+	writeConfigFiles(t, outside, map[string]string{"secret.yaml": "api-keys:\n  - outside\n"})
+	dir := t.TempDir()
+	if err := os.Symlink(filepath.Join(outside, "secret.yaml"), filepath.Join(dir, "link.yaml")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	for _, include := range []string{
+		filepath.Join(outside, "secret.yaml"),
+		filepath.Join(outside, "*.yaml"),
+		"../" + filepath.Base(outside) + "/secret.yaml",
+		"link.yaml",
+	} {
+		writeConfigFiles(t, dir, map[string]string{"config.yaml": "include: \"" + include + "\"\n"})
+		if _, err := ComposeConfigFile(filepath.Join(dir, "config.yaml")); err == nil || !strings.Contains(err.Error(), "outside the config directory") {
+			t.Fatalf("include %q: error = %v, want confinement error", include, err)
+		}
+	}
+}
+
+func TestComposeConfigFile_RejectsCyclesAndMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": "include: a.yaml\n",
+		"a.yaml":      "include: config.yaml\n",
+		"other.yaml":  "include: missing.yaml\n",
+	})
+	if _, err := ComposeConfigFile(filepath.Join(dir, "config.yaml")); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("cycle error = %v", err)
+	}
+	if _, err := ComposeConfigFile(filepath.Join(dir, "other.yaml")); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("missing include error = %v", err)
+	}
+}
+
+func TestComposeConfigFile_PlainFileUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	content := "# comment\nport: 9000\n"
+	writeConfigFiles(t, dir, map[string]string{"config.yaml": content})
+	composition, err := ComposeConfigFile(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("ComposeConfigFile: %v", err)
+	}
+	if composition.Composed || string(composition.Data) != content {
+		t.Fatalf("composition = %+v, want the file unchanged", composition)
+	}
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManagedChangeError lists what a config submitted through the management API tried to add
+// that only the config file itself may contain.
+type ManagedChangeError struct {
+	Violations []string
+}
+
+func (e *ManagedChangeError) Error() string {
+	return "not allowed through the management API (edit the config file instead): " + strings.Join(e.Violations, "; ")
+}
+
+// CheckManagedChange compares a config submitted through the management API with the main
+// config file currently on disk, which is nil when it does not exist. Secret references,
+// includes and ${VAR} interpolation read the host's environment, files and commands, so a
+// submission may only keep the ones the file already has and may not enable file: and
+// exec: references. The documents are inspected as YAML before any of that is expanded.
+func CheckManagedChange(current, candidate []byte) error {
+	prev := scanManagedDirectives(current)
+	next := scanManagedDirectives(candidate)
+
+	var violations []string
+	for _, ref := range next.refs {
+		if !slices.Contains(prev.refValues(), ref.value) {
+			violations = append(violations, fmt.Sprintf("new secret reference at %s", ref.path))
+		}
+	}
+	if next.allowFileAndExec && !prev.allowFileAndExec {
+		violations = append(violations, "enabling secret-refs.allow-file-and-exec")
+	}
+	for _, include := range next.includes {
+		if !slices.Contains(prev.includes, include) {
+			violations = append(violations, fmt.Sprintf("new include %q", include))
+		}
+	}
+	for _, name := range next.vars {
+		if !slices.Contains(prev.vars, name) {
+			violations = append(violations, fmt.Sprintf("new variable ${%s}", name))
+		}
+	}
+	if len(violations) > 0 {
+		return &ManagedChangeError{Violations: violations}
+	}
+	return nil
+}
+
+type managedRef struct {
+	path  string
+	value string
+}
+
+// managedDirectives are the parts of a config document that reach outside of it.
+type managedDirectives struct {
+	refs             []managedRef
+	includes         []string
+	vars             []string
+	allowFileAndExec bool
+}
+
+func (d managedDirectives) refValues() []string {
+	values := make([]string, 0, len(d.refs))
+	for _, ref := range d.refs {
+		values = append(values, ref.value)
+	}
+	return values
+}
+
+// scanManagedDirectives collects the secret references, include entries and ${VAR} names of
+// a config document. References are looked for in every value, not only in key fields, so
+// the check does not depend on the document decoding into Config.
+func scanManagedDirectives(data []byte) managedDirectives {
+	var out managedDirectives
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return out
+	}
+	root := doc.Content[0]
+	if refs := childNode(root, "secret-refs"); refs != nil {
+		if node := childNode(refs, "allow-file-and-exec"); node != nil {
+			value := strings.TrimSpace(node.Value)
+			out.allowFileAndExec = value != "" && !strings.EqualFold(value, "false")
+		}
+	}
+
+	var walk func(node *yaml.Node, path yamlPath)
+	walk = func(node *yaml.Node, path yamlPath) {
+		switch node.Kind {
+		case yaml.ScalarNode:
+			if IsSecretRef(node.Value) {
+				out.refs = append(out.refs, managedRef{path: path.String(), value: strings.TrimSpace(node.Value)})
+			}
+			out.vars = append(out.vars, configVarNames(node.Value)...)
+		case yaml.MappingNode:
+			// Like interpolation, only values are scanned.
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], path.with(node.Content[i].Value))
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				walk(child, path.with(i))
+			}
+		}
+	}
+	walk(root, nil)
+	if root.Kind == yaml.MappingNode {
+		out.includes, _ = takeIncludes(root)
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckManagedChange(t *testing.T) {
+	current := []byte("include: providers/*.yaml\nhost: ${CLIPROXY_HOST}\ncodex-api-key:\n  - api-key: \"env:CODEX_KEY\"\n")
+	cases := []struct {
+		name      string
+		candidate string
+		violation string
+	}{
+		{name: "existing reference", candidate: "claude-api-key:\n  - api-key: \"env:CODEX_KEY\"\n"},
+		{name: "literal key", candidate: "codex-api-key:\n  - api-key: sk-literal\n"},
+		{name: "new exec reference", candidate: "codex-api-key:\n  - api-key: \"exec:id\"\n", violation: "codex-api-key[0].api-key"},
+		{name: "new env reference", candidate: "gemini-api-key:\n  - api-key: \"env:AWS_SECRET_ACCESS_KEY\"\n", violation: "gemini-api-key[0].api-key"},
+		{name: "enable file and exec", candidate: "secret-refs:\n  allow-file-and-exec: true\n", violation: "allow-file-and-exec"},
+		{name: "existing include and variable", candidate: "include: providers/*.yaml\nport: ${CLIPROXY_HOST}\n"},
+		{name: "new include", candidate: "include: /etc/passwd\n", violation: `new include "/etc/passwd"`},
+		{name: "new variable", candidate: "port: ${CLIPROXY_PORT}\napi-keys:\n  - \"exec:id\"\n", violation: "new variable ${CLIPROXY_PORT}"},
+		{name: "reference outside key fields", candidate: "port: ${CLIPROXY_HOST}\napi-keys:\n  - \"exec:id\"\n", violation: "new secret reference at api-keys[0]"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckManagedChange(current, []byte(tc.candidate))
+			if tc.violation == "" {
+				if err != nil {
+					t.Fatalf("CheckManagedChange() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.violation) {
+				t.Fatalf("CheckManagedChange() error = %v, want %q", err, tc.violation)
+			}
+		})
+	}
+}
@@ -45,6 +45,92 @@ type SDKConfig struct {
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// PromptQueue serializes requests that belong to the same client session.
+	PromptQueue PromptQueueConfig `yaml:"prompt-queue" json:"prompt-queue"`
+
+	// Scheduler caps concurrent upstream requests and shares the capacity fairly across client keys.
+	Scheduler SchedulerConfig `yaml:"scheduler" json:"scheduler"`
+
+	// ResponseCache replays stored responses for repeated deterministic requests.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache" json:"response-cache"`
+}
+
+// ResponseCacheConfig configures the exact-match response cache. A request is cached when its
+// temperature is 0 or when it carries the opt-in header with the value true; the header set to
+// false bypasses the cache.
+type ResponseCacheConfig struct {
+	// Enabled turns the cache on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Store is memory (an in-process LRU, the default) or disk.
+	Store string `yaml:"store,omitempty" json:"store,omitempty"`
+
+	// Dir is the directory of the disk store.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// TTLSeconds is how long a response is replayed. <= 0 uses 3600.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+
+	// MaxEntries caps the number of responses kept by the memory store. <= 0 uses 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+
+	// MaxSizeMB caps the total size of stored responses. <= 0 uses 256.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+
+	// Header names the request header that opts a request in or out. Default is Cache.
+	Header string `yaml:"header,omitempty" json:"header,omitempty"`
+}
+
+// SchedulerConfig configures admission of upstream requests.
+type SchedulerConfig struct {
+	// Enabled turns on concurrency caps and fair queuing. When disabled, requests are never held.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// MaxConcurrency caps requests in flight across all providers. <= 0 means no global cap.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
+	// ProviderConcurrency caps requests in flight per provider, keyed by provider name (e.g. claude, codex).
+	ProviderConcurrency map[string]int `yaml:"provider-concurrency,omitempty" json:"provider-concurrency,omitempty"`
+
+	// PriorityHeader names the request header that selects the priority class ("interactive" or
+	// "batch") for client keys without a configured priority. Default is X-Request-Priority.
+	PriorityHeader string `yaml:"priority-header,omitempty" json:"priority-header,omitempty"`
+
+	// BatchPromoteAfterSeconds is how long a batch request may wait behind interactive ones
+	// before it is served in fair order. <= 0 uses 30.
+	BatchPromoteAfterSeconds int `yaml:"batch-promote-after-seconds,omitempty" json:"batch-promote-after-seconds,omitempty"`
+
+	// Clients sets the share and priority class of individual client API keys.
+	Clients []SchedulerClient `yaml:"clients,omitempty" json:"clients,omitempty"`
+}
+
+// SchedulerClient sets scheduling for one client API key.
+type SchedulerClient struct {
+	// APIKey is the client key as listed in api-keys.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// Weight is the key's share of contended capacity relative to other keys. <= 0 counts as 1.
+	Weight int `yaml:"weight,omitempty" json:"weight,omitempty"`
+
+	// Priority is "interactive" or "batch". When set, the priority header is ignored for this key.
+	Priority string `yaml:"priority,omitempty" json:"priority,omitempty"`
+}
+
+// PromptQueueConfig configures per-session request serialization.
+type PromptQueueConfig struct {
+	// Enabled runs requests that carry a session key one at a time per session, so turns of
+	// the same agent session never overlap upstream. Requests without a session key are not queued.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// SessionHeader names the request header carrying the session key. Default is X-Session-ID.
+	// Without the header, metadata.user_id from the request body and the Codex execution
+	// session are used.
+	SessionHeader string `yaml:"session-header,omitempty" json:"session-header,omitempty"`
+
+	// MaxQueueDepth limits how many requests of one session may be running or waiting. Further
+	// requests are rejected with 429 and their queue position. <= 0 uses the default of 16.
+	MaxQueueDepth int `yaml:"max-queue-depth,omitempty" json:"max-queue-depth,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
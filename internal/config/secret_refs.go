@@ -0,0 +1,353 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Secret reference prefixes accepted in upstream API key fields.
+const (
+	SecretRefEnv  = "env:"
+	SecretRefFile = "file:"
+	SecretRefExec = "exec:"
+)
+
+const defaultSecretExecTimeout = 10 * time.Second
+
+// SecretRefsConfig controls how secret references in API key fields are resolved.
+type SecretRefsConfig struct {
+	// AllowFileAndExec enables file: and exec: references. They read files and run commands on
+	// the host, so they are only resolved in the config file itself; the management API can
+	// neither add them nor turn this option on.
+	AllowFileAndExec bool `yaml:"allow-file-and-exec,omitempty" json:"allow-file-and-exec,omitempty"`
+	// CacheTTLSeconds caches file: and exec: results across config reloads. 0 disables caching.
+	CacheTTLSeconds int `yaml:"cache-ttl-seconds,omitempty" json:"cache-ttl-seconds,omitempty"`
+	// ExecTimeoutSeconds bounds each exec: command. Defaults to 10.
+	ExecTimeoutSeconds int `yaml:"exec-timeout-seconds,omitempty" json:"exec-timeout-seconds,omitempty"`
+}
+
+// secretRef is a reference and the value it resolved to when the config was loaded.
+type secretRef struct {
+	ref      string
+	resolved string
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+var (
+	secretCacheMu sync.Mutex
+	secretCache   = make(map[string]cachedSecret)
+)
+
+// IsSecretRef reports whether value uses the env:, file: or exec: reference syntax.
+func IsSecretRef(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, SecretRefEnv) || strings.HasPrefix(value, SecretRefFile) || strings.HasPrefix(value, SecretRefExec)
+}
+
+// ResolveSecretRef resolves a single secret reference. Values that are not
+// references are returned unchanged.
+func ResolveSecretRef(ref string, opts SecretRefsConfig) (string, error) {
+	ref = strings.TrimSpace(ref)
+	switch {
+	case strings.HasPrefix(ref, SecretRefEnv):
+		name := strings.TrimSpace(strings.TrimPrefix(ref, SecretRefEnv))
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return strings.TrimSpace(value), nil
+	case strings.HasPrefix(ref, SecretRefFile), strings.HasPrefix(ref, SecretRefExec):
+		ttl := time.Duration(opts.CacheTTLSeconds) * time.Second
+		if ttl > 0 {
+			secretCacheMu.Lock()
+			cached, ok := secretCache[ref]
+			secretCacheMu.Unlock()
+			if ok && time.Now().Before(cached.expires) {
+				return cached.value, nil
+			}
+		}
+		var (
+			value string
+			err   error
+		)
+		if strings.HasPrefix(ref, SecretRefFile) {
+			value, err = readSecretFile(strings.TrimSpace(strings.TrimPrefix(ref, SecretRefFile)))
+		} else {
+			value, err = runSecretCommand(strings.TrimSpace(strings.TrimPrefix(ref, SecretRefExec)), opts)
+		}
+		if err != nil {
+			return "", err
+		}
+		if ttl > 0 {
+			secretCacheMu.Lock()
+			secretCache[ref] = cachedSecret{value: value, expires: time.Now().Add(ttl)}
+			secretCacheMu.Unlock()
+		}
+		return value, nil
+	default:
+		return ref, nil
+	}
+}
+
+// CheckSecretRef reports whether ref is a well-formed reference without resolving it.
+func CheckSecretRef(ref string) error {
+	ref = strings.TrimSpace(ref)
+	for _, prefix := range []string{SecretRefEnv, SecretRefFile, SecretRefExec} {
+		if strings.HasPrefix(ref, prefix) {
+			if strings.TrimSpace(strings.TrimPrefix(ref, prefix)) == "" {
+				return fmt.Errorf("empty %s reference", strings.TrimSuffix(prefix, ":"))
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func readSecretFile(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("empty file path")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read secret file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// runSecretCommand runs the command directly (no shell) and returns its trimmed stdout.
+func runSecretCommand(command string, opts SecretRefsConfig) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+	timeout := time.Duration(opts.ExecTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultSecretExecTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("run %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("run %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// visitSecretFields calls fn with a location label and a pointer to every field
+// that accepts secret references.
+func (cfg *Config) visitSecretFields(fn func(path string, value *string)) {
+	for i := range cfg.GeminiKey {
+		fn(fmt.Sprintf("gemini-api-key[%d].api-key", i), &cfg.GeminiKey[i].APIKey)
+	}
+	for i := range cfg.ClaudeKey {
+		fn(fmt.Sprintf("claude-api-key[%d].api-key", i), &cfg.ClaudeKey[i].APIKey)
+	}
+	for i := range cfg.CodexKey {
+		fn(fmt.Sprintf("codex-api-key[%d].api-key", i), &cfg.CodexKey[i].APIKey)
+	}
+	for i := range cfg.VertexCompatAPIKey {
+		fn(fmt.Sprintf("vertex-api-key[%d].api-key", i), &cfg.VertexCompatAPIKey[i].APIKey)
+	}
+	for i := range cfg.OpenAICompatibility {
+		for j := range cfg.OpenAICompatibility[i].APIKeyEntries {
+			fn(fmt.Sprintf("openai-compatibility[%d].api-key-entries[%d].api-key", i, j), &cfg.OpenAICompatibility[i].APIKeyEntries[j].APIKey)
+		}
+	}
+	for i := range cfg.HTTPProviders {
+		for j := range cfg.HTTPProviders[i].APIKeyEntries {
+			fn(fmt.Sprintf("http-providers[%d].api-key-entries[%d].api-key", i, j), &cfg.HTTPProviders[i].APIKeyEntries[j].APIKey)
+		}
+	}
+	fn("ampcode.upstream-api-key", &cfg.AmpCode.UpstreamAPIKey)
+	for i := range cfg.AmpCode.UpstreamAPIKeys {
+		fn(fmt.Sprintf("ampcode.upstream-api-keys[%d].upstream-api-key", i), &cfg.AmpCode.UpstreamAPIKeys[i].UpstreamAPIKey)
+	}
+}
+
+// ResolveSecretRefs replaces env:, file: and exec: references in upstream API key
+// fields with their values and remembers the references for persistence. file: and
+// exec: references fail unless SecretRefs.AllowFileAndExec is set.
+func (cfg *Config) ResolveSecretRefs() error {
+	if cfg == nil {
+		return nil
+	}
+	var firstErr error
+	refs := make(map[string]secretRef)
+	cfg.visitSecretFields(func(path string, value *string) {
+		if firstErr != nil || !IsSecretRef(*value) {
+			return
+		}
+		ref := strings.TrimSpace(*value)
+		if err := cfg.checkSecretRefAllowed(ref, path); err != nil {
+			firstErr = err
+			return
+		}
+		resolved, err := ResolveSecretRef(ref, cfg.SecretRefs)
+		if err != nil {
+			firstErr = fmt.Errorf("resolve secret reference %q at %s: %w", ref, path, err)
+			return
+		}
+		*value = resolved
+		refs[path] = secretRef{ref: ref, resolved: resolved}
+	})
+	if firstErr != nil {
+		return firstErr
+	}
+	cfg.secretRefs = refs
+	return nil
+}
+
+// CheckSecretRefs reports the first malformed or disabled reference without resolving any
+// of them, so no environment variable, file or command is read.
+func (cfg *Config) CheckSecretRefs() error {
+	if cfg == nil {
+		return nil
+	}
+	var firstErr error
+	cfg.visitSecretFields(func(path string, value *string) {
+		if firstErr != nil || !IsSecretRef(*value) {
+			return
+		}
+		if err := CheckSecretRef(*value); err != nil {
+			firstErr = fmt.Errorf("secret reference at %s: %w", path, err)
+			return
+		}
+		firstErr = cfg.checkSecretRefAllowed(strings.TrimSpace(*value), path)
+	})
+	return firstErr
+}
+
+func (cfg *Config) checkSecretRefAllowed(ref, path string) error {
+	if strings.HasPrefix(ref, SecretRefEnv) || cfg.SecretRefs.AllowFileAndExec {
+		return nil
+	}
+	return fmt.Errorf("secret reference %q at %s: file: and exec: references are disabled (set secret-refs.allow-file-and-exec in the config file)", ref, path)
+}
+
+// AdoptSecretRefs prepares a config edited after loading for saving. A field holding a
+// reference the config file already uses gets the value it resolved to, and the remembered
+// references follow entries that were added, removed or reordered. It returns the fields
+// holding references the file does not use yet and leaves cfg unchanged when there are any.
+func (cfg *Config) AdoptSecretRefs() []string {
+	if cfg == nil {
+		return nil
+	}
+	known := make(map[string]secretRef, len(cfg.secretRefs))
+	for _, ref := range cfg.secretRefs {
+		known[ref.ref] = ref
+	}
+	var unknown []string
+	cfg.visitSecretFields(func(path string, value *string) {
+		if !IsSecretRef(*value) {
+			return
+		}
+		if _, ok := known[strings.TrimSpace(*value)]; !ok {
+			unknown = append(unknown, path)
+		}
+	})
+	if len(unknown) > 0 {
+		return unknown
+	}
+	adopted := make(map[string]secretRef)
+	cfg.visitSecretFields(func(path string, value *string) {
+		if !IsSecretRef(*value) {
+			return
+		}
+		ref := known[strings.TrimSpace(*value)]
+		*value = ref.resolved
+		adopted[path] = ref
+	})
+	cfg.reanchorSecretRefs()
+	for path, ref := range adopted {
+		if cfg.secretRefs == nil {
+			cfg.secretRefs = make(map[string]secretRef)
+		}
+		cfg.secretRefs[path] = ref
+	}
+	return nil
+}
+
+// secretSection returns the top-level key of a field path such as "codex-api-key[1].api-key".
+func secretSection(path string) string {
+	if idx := strings.IndexAny(path, "[."); idx >= 0 {
+		return path[:idx]
+	}
+	return path
+}
+
+// reanchorSecretRefs moves the remembered references to the paths their entries hold after
+// sanitization dropped or reordered entries. A reference stays in its own section and is
+// used once.
+func (cfg *Config) reanchorSecretRefs() {
+	if len(cfg.secretRefs) == 0 {
+		return
+	}
+	paths := make([]string, 0, len(cfg.secretRefs))
+	for path := range cfg.secretRefs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	pending := make(map[string][]*secretRef)
+	for _, path := range paths {
+		ref := cfg.secretRefs[path]
+		pending[secretSection(path)] = append(pending[secretSection(path)], &ref)
+	}
+	refs := make(map[string]secretRef, len(cfg.secretRefs))
+	cfg.visitSecretFields(func(path string, value *string) {
+		candidates := pending[secretSection(path)]
+		for i, ref := range candidates {
+			if ref.resolved == *value {
+				refs[path] = *ref
+				pending[secretSection(path)] = append(candidates[:i:i], candidates[i+1:]...)
+				return
+			}
+		}
+	})
+	cfg.secretRefs = refs
+}
+
+// withSecretRefsRestored returns a copy of cfg whose key fields hold the original
+// references instead of resolved secrets. A field gets its reference back only while it
+// still holds the value the reference resolved to. cfg itself is not modified.
+func (cfg *Config) withSecretRefsRestored() *Config {
+	if cfg == nil || len(cfg.secretRefs) == 0 {
+		return cfg
+	}
+	out := *cfg
+	out.GeminiKey = append([]GeminiKey(nil), cfg.GeminiKey...)
+	out.ClaudeKey = append([]ClaudeKey(nil), cfg.ClaudeKey...)
+	out.CodexKey = append([]CodexKey(nil), cfg.CodexKey...)
+	out.VertexCompatAPIKey = append([]VertexCompatKey(nil), cfg.VertexCompatAPIKey...)
+	out.OpenAICompatibility = append([]OpenAICompatibility(nil), cfg.OpenAICompatibility...)
+	for i := range out.OpenAICompatibility {
+		out.OpenAICompatibility[i].APIKeyEntries = append([]OpenAICompatibilityAPIKey(nil), cfg.OpenAICompatibility[i].APIKeyEntries...)
+	}
+	out.HTTPProviders = append([]HTTPProvider(nil), cfg.HTTPProviders...)
+	for i := range out.HTTPProviders {
+		out.HTTPProviders[i].APIKeyEntries = append([]OpenAICompatibilityAPIKey(nil), cfg.HTTPProviders[i].APIKeyEntries...)
+	}
+	out.AmpCode.UpstreamAPIKeys = append([]AmpUpstreamAPIKeyEntry(nil), cfg.AmpCode.UpstreamAPIKeys...)
+	out.visitSecretFields(func(path string, value *string) {
+		if ref, ok := cfg.secretRefs[path]; ok && ref.resolved == *value {
+			*value = ref.ref
+		}
+	})
+	return &out
+}
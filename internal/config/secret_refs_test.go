@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigResolvesSecretRefs(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "claude.key")
+	if err := os.WriteFile(secretPath, []byte("sk-ant-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	execPath := filepath.Join(dir, "codex.key")
+	if err := os.WriteFile(execPath, []byte("sk-codex-from-exec\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_GEMINI_KEY", "AIza-from-env")
+
+	configPath := filepath.Join(dir, "config.yaml")
+	raw := "secret-refs:\n" +
+		"  allow-file-and-exec: true\n" +
+		"gemini-api-key:\n" +
+		"  - api-key: \"env:TEST_GEMINI_KEY\"\n" +
+		"claude-api-key:\n" +
+		"  - api-key: \"file:" + secretPath + "\"\n" +
+		"codex-api-key:\n" +
+		"  - api-key: \"exec:cat " + execPath + "\"\n" +
+		"    base-url: \"https://api.openai.com/v1\"\n"
+	if err := os.WriteFile(configPath, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if got := cfg.GeminiKey[0].APIKey; got != "AIza-from-env" {
+		t.Fatalf("gemini key = %q", got)
+	}
+	if got := cfg.ClaudeKey[0].APIKey; got != "sk-ant-from-file" {
+		t.Fatalf("claude key = %q", got)
+	}
+	if got := cfg.CodexKey[0].APIKey; got != "sk-codex-from-exec" {
+		t.Fatalf("codex key = %q", got)
+	}
+
+	cfg.GeminiKey = append(cfg.GeminiKey, GeminiKey{APIKey: "AIza-literal"})
+	if err = SaveConfigPreserveComments(configPath, cfg); err != nil {
+		t.Fatalf("SaveConfigPreserveComments() error = %v", err)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leaked := range []string{"AIza-from-env", "sk-ant-from-file", "sk-codex-from-exec"} {
+		if strings.Contains(string(saved), leaked) {
+			t.Fatalf("saved config contains resolved secret %q:\n%s", leaked, saved)
+		}
+	}
+	for _, want := range []string{"env:TEST_GEMINI_KEY", "file:" + secretPath, "exec:cat " + execPath, "AIza-literal"} {
+		if !strings.Contains(string(saved), want) {
+			t.Fatalf("saved config missing %q:\n%s", want, saved)
+		}
+	}
+	if cfg.GeminiKey[0].APIKey != "AIza-from-env" {
+		t.Fatalf("save mutated in-memory key: %q", cfg.GeminiKey[0].APIKey)
+	}
+}
+
+func TestResolveSecretRefsReportsFieldWithoutSecret(t *testing.T) {
+	cfg := &Config{}
+	cfg.AmpCode.UpstreamAPIKeys = []AmpUpstreamAPIKeyEntry{{UpstreamAPIKey: "env:TEST_SECRET_REF_MISSING"}}
+	err := cfg.ResolveSecretRefs()
+	if err == nil {
+		t.Fatal("expected error for unset environment variable")
+	}
+	if !strings.Contains(err.Error(), "ampcode.upstream-api-keys[0].upstream-api-key") {
+		t.Fatalf("error does not name the field: %v", err)
+	}
+}
+
+func TestLoadConfigRequiresOptInForFileAndExecRefs(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "ran")
+	configPath := filepath.Join(dir, "config.yaml")
+	raw := "codex-api-key:\n" +
+		"  - api-key: \"exec:touch " + marker + "\"\n" +
+		"    base-url: \"https://api.openai.com/v1\"\n"
+	if err := os.WriteFile(configPath, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadConfig(configPath); err == nil || !strings.Contains(err.Error(), "allow-file-and-exec") {
+		t.Fatalf("LoadConfig() error = %v, want opt-in error", err)
+	}
+	if _, err := LoadConfigCandidate(configPath); err == nil || !strings.Contains(err.Error(), "allow-file-and-exec") {
+		t.Fatalf("LoadConfigCandidate() error = %v, want opt-in error", err)
+	}
+	if err := os.WriteFile(configPath, []byte("secret-refs:\n  allow-file-and-exec: true\n"+raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfigCandidate(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfigCandidate() error = %v", err)
+	}
+	if got := cfg.CodexKey[0].APIKey; !strings.HasPrefix(got, SecretRefExec) {
+		t.Fatalf("candidate key = %q, want the unresolved reference", got)
+	}
+	if _, err = os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("exec: reference was run (stat err = %v)", err)
+	}
+}
+
+func TestSaveRestoresSecretRefsByPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TEST_CODEX_KEY", "sk-shared")
+	configPath := filepath.Join(dir, "config.yaml")
+	// The first codex entry has no base-url and is dropped, so the reference moves to [0].
+	raw := "codex-api-key:\n" +
+		"  - api-key: \"sk-dropped\"\n" +
+		"  - api-key: \"env:TEST_CODEX_KEY\"\n" +
+		"    base-url: \"https://api.openai.com/v1\"\n" +
+		"gemini-api-key:\n" +
+		"  - api-key: \"AIza-old\"\n"
+	if err := os.WriteFile(configPath, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.CodexKey) != 1 || cfg.CodexKey[0].APIKey != "sk-shared" {
+		t.Fatalf("codex keys = %+v", cfg.CodexKey)
+	}
+
+	// A literal that equals the resolved secret elsewhere must stay a literal.
+	cfg.GeminiKey[0].APIKey = "sk-shared"
+	if err = SaveConfigPreserveComments(configPath, cfg); err != nil {
+		t.Fatalf("SaveConfigPreserveComments() error = %v", err)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(saved), "env:TEST_CODEX_KEY"); got != 1 {
+		t.Fatalf("saved config has %d references, want 1:\n%s", got, saved)
+	}
+	if !strings.Contains(string(saved), "api-key: sk-shared") {
+		t.Fatalf("saved config lost the literal gemini key:\n%s", saved)
+	}
+}
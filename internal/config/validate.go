@@ -0,0 +1,570 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ValidationSeverityError marks a problem that prevents the config from being applied
+	// or that the server would otherwise silently ignore at runtime.
+	ValidationSeverityError = "error"
+	// ValidationSeverityWarning marks a setting that loads but is probably not what was meant.
+	ValidationSeverityWarning = "warning"
+)
+
+// builtinProviders lists the provider identifiers served by built-in executors.
+// OpenAI-compatibility and HTTP providers add their configured names at validation time.
+var builtinProviders = []string{
+	"aistudio", "antigravity", "claude", "codex", "gemini", "gemini-cli", "iflow", "kimi", "qwen", "vertex",
+}
+
+// ValidationIssue is one problem found in a config document.
+type ValidationIssue struct {
+	// Path locates the offending value, e.g. "ampcode.model-mappings[2].from".
+	// It is empty for document-level problems.
+	Path     string `json:"path"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// ValidationReport collects the result of ValidateConfigData.
+type ValidationReport struct {
+	Valid    bool              `json:"valid"`
+	Errors   []ValidationIssue `json:"errors"`
+	Warnings []ValidationIssue `json:"warnings"`
+
+	// Config is the candidate as the server would load it. It is nil when loading failed.
+	Config *Config `json:"-"`
+}
+
+// ValidateConfigData runs the same parsing the server performs on startup plus semantic
+// checks for settings that would otherwise only fail, or be silently dropped, at runtime.
+// Nothing is applied. configFile is where the candidate would live, so includes, the
+// overlay and relative paths resolve as they would for the real config file. Secret
+// references are only checked for syntax and opt-in; none is resolved, so no command runs.
+func ValidateConfigData(data []byte, configFile string) *ValidationReport {
+	v := &configValidator{report: &ValidationReport{Errors: []ValidationIssue{}, Warnings: []ValidationIssue{}}}
+
+	if err := yaml.Unmarshal(data, &v.root); err != nil {
+		v.addf(ValidationSeverityError, nil, "invalid YAML: %v", err)
+		return v.finish()
+	}
+	composition, err := composeConfigData(configFile, data)
+	if err != nil {
+		v.addf(ValidationSeverityError, nil, "%v", err)
+		return v.finish()
+	}
+	if composition.Composed {
+		// Positions in the merged document do not map back to a single file.
+		v.root = yaml.Node{}
+		v.unpositioned = true
+		if err = yaml.Unmarshal(composition.Data, &v.root); err != nil {
+			v.addf(ValidationSeverityError, nil, "invalid composed config: %v", err)
+			return v.finish()
+		}
+	}
+	var raw Config
+	if err = yaml.Unmarshal(composition.Data, &raw); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			for _, msg := range typeErr.Errors {
+				v.addDocumentIssue(msg)
+			}
+		} else {
+			v.addf(ValidationSeverityError, nil, "invalid config: %v", err)
+		}
+		return v.finish()
+	}
+	v.checkSemantics(&raw)
+
+	loaded, err := loadConfigData(configFile, data, loadOptions{})
+	if err != nil {
+		v.addf(ValidationSeverityError, nil, "%v", err)
+	}
+	v.report.Config = loaded
+	return v.finish()
+}
+
+type configValidator struct {
+	root   yaml.Node
+	report *ValidationReport
+	// unpositioned suppresses line numbers when the document was merged from several files.
+	unpositioned bool
+}
+
+// yamlPath is a sequence of mapping keys (string) and sequence indexes (int).
+type yamlPath []any
+
+func (p yamlPath) with(elems ...any) yamlPath {
+	out := make(yamlPath, 0, len(p)+len(elems))
+	return append(append(out, p...), elems...)
+}
+
+func (p yamlPath) String() string {
+	var sb strings.Builder
+	for _, elem := range p {
+		switch typed := elem.(type) {
+		case int:
+			sb.WriteString("[" + strconv.Itoa(typed) + "]")
+		case string:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(typed)
+		}
+	}
+	return sb.String()
+}
+
+func (v *configValidator) addf(severity string, path yamlPath, format string, args ...any) {
+	issue := ValidationIssue{Path: path.String(), Severity: severity, Message: fmt.Sprintf(format, args...)}
+	if node := v.lookup(path); node != nil && !v.unpositioned {
+		issue.Line, issue.Column = node.Line, node.Column
+	}
+	if severity == ValidationSeverityError {
+		v.report.Errors = append(v.report.Errors, issue)
+	} else {
+		v.report.Warnings = append(v.report.Warnings, issue)
+	}
+}
+
+var yamlLinePattern = regexp.MustCompile(`^line (\d+): `)
+
+// addDocumentIssue records a decoder message such as "line 4: cannot unmarshal ..." with its line.
+func (v *configValidator) addDocumentIssue(msg string) {
+	issue := ValidationIssue{Severity: ValidationSeverityError, Message: msg}
+	if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+		if !v.unpositioned {
+			issue.Line, _ = strconv.Atoi(m[1])
+		}
+		issue.Message = strings.TrimPrefix(msg, m[0])
+	}
+	v.report.Errors = append(v.report.Errors, issue)
+}
+
+func (v *configValidator) finish() *ValidationReport {
+	v.report.Valid = len(v.report.Errors) == 0
+	return v.report
+}
+
+// lookup returns the node at path, or the deepest existing ancestor so the issue still
+// carries a useful line number.
+func (v *configValidator) lookup(path yamlPath) *yaml.Node {
+	node := &v.root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind == 0 {
+		return nil
+	}
+	for _, elem := range path {
+		next := childNode(node, elem)
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return node
+}
+
+func childNode(node *yaml.Node, elem any) *yaml.Node {
+	switch typed := elem.(type) {
+	case string:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == typed {
+				return node.Content[i+1]
+			}
+		}
+	case int:
+		if node.Kind == yaml.SequenceNode && typed >= 0 && typed < len(node.Content) {
+			return node.Content[typed]
+		}
+	}
+	return nil
+}
+
+func (v *configValidator) checkSemantics(cfg *Config) {
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		v.addf(ValidationSeverityError, yamlPath{"port"}, "port %d is out of range 0-65535", cfg.Port)
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Routing.Strategy)) {
+	case "", "round-robin", "roundrobin", "rr", "fill-first", "fillfirst", "ff":
+	default:
+		v.addf(ValidationSeverityError, yamlPath{"routing", "strategy"}, "unknown routing strategy %q (supported: round-robin, fill-first)", cfg.Routing.Strategy)
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.ClaudePromptCaching.Mode)) {
+	case "", "missing", "auto", "off":
+	default:
+		v.addf(ValidationSeverityError, yamlPath{"claude-prompt-caching", "mode"}, "unknown prompt caching mode %q (supported: missing, auto, off)", cfg.ClaudePromptCaching.Mode)
+	}
+
+	v.checkProxyURL(yamlPath{"proxy-url"}, cfg.ProxyURL)
+	for i := range cfg.GeminiKey {
+		v.checkProxyURL(yamlPath{"gemini-api-key", i, "proxy-url"}, cfg.GeminiKey[i].ProxyURL)
+	}
+	for i := range cfg.ClaudeKey {
+		v.checkProxyURL(yamlPath{"claude-api-key", i, "proxy-url"}, cfg.ClaudeKey[i].ProxyURL)
+	}
+	for i := range cfg.CodexKey {
+		v.checkProxyURL(yamlPath{"codex-api-key", i, "proxy-url"}, cfg.CodexKey[i].ProxyURL)
+	}
+	for i := range cfg.VertexCompatAPIKey {
+		v.checkProxyURL(yamlPath{"vertex-api-key", i, "proxy-url"}, cfg.VertexCompatAPIKey[i].ProxyURL)
+	}
+
+	v.checkOpenAICompatibility(cfg.OpenAICompatibility)
+	v.checkHTTPProviders(cfg.HTTPProviders)
+	v.checkAmpModelMappings(cfg.AmpCode.ModelMappings)
+	v.checkOAuthModelAlias(cfg.OAuthModelAlias)
+	v.checkModelProviderRouting(cfg)
+	v.checkScheduler(cfg)
+	v.checkResponseCache(cfg.ResponseCache)
+	v.checkRemoteWorkers(cfg.RemoteWorkers)
+	v.checkWebsocketRelay(cfg.WebsocketRelay)
+	v.checkSignatureCache(cfg)
+}
+
+func (v *configValidator) checkProxyURL(path yamlPath, raw string) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		v.addf(ValidationSeverityError, path, "invalid proxy URL: %v", err)
+		return
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+	default:
+		v.addf(ValidationSeverityError, path, "unsupported proxy scheme %q (supported: http, https, socks5)", parsed.Scheme)
+		return
+	}
+	if parsed.Host == "" {
+		v.addf(ValidationSeverityError, path, "proxy URL %q has no host", raw)
+	}
+}
+
+func (v *configValidator) checkOpenAICompatibility(entries []OpenAICompatibility) {
+	seenNames := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		base := yamlPath{"openai-compatibility", i}
+		name := strings.ToLower(strings.TrimSpace(entry.Name))
+		if name == "" {
+			v.addf(ValidationSeverityError, base.with("name"), "openai-compatibility provider name is required")
+		} else if first, dup := seenNames[name]; dup {
+			v.addf(ValidationSeverityError, base.with("name"), "duplicate provider name %q (first defined at openai-compatibility[%d])", entry.Name, first)
+		} else {
+			seenNames[name] = i
+		}
+		if strings.TrimSpace(entry.BaseURL) == "" {
+			v.addf(ValidationSeverityWarning, base.with("base-url"), "provider without base-url is ignored")
+		}
+		for j := range entry.APIKeyEntries {
+			v.checkProxyURL(base.with("api-key-entries", j, "proxy-url"), entry.APIKeyEntries[j].ProxyURL)
+		}
+		seenAliases := make(map[string]int, len(entry.Models))
+		for j, model := range entry.Models {
+			alias := strings.ToLower(strings.TrimSpace(model.Alias))
+			if alias == "" {
+				continue
+			}
+			if first, dup := seenAliases[alias]; dup {
+				v.addf(ValidationSeverityError, base.with("models", j, "alias"), "duplicate alias %q (first defined at models[%d])", model.Alias, first)
+				continue
+			}
+			seenAliases[alias] = j
+		}
+	}
+}
+
+func (v *configValidator) checkAmpModelMappings(mappings []AmpModelMapping) {
+	seenExact := make(map[string]int, len(mappings))
+	for i, mapping := range mappings {
+		base := yamlPath{"ampcode", "model-mappings", i}
+		from := strings.TrimSpace(mapping.From)
+		if from == "" {
+			v.addf(ValidationSeverityError, base.with("from"), "model mapping requires a from value")
+		}
+		if strings.TrimSpace(mapping.To) == "" {
+			v.addf(ValidationSeverityError, base.with("to"), "model mapping requires a to value")
+		}
+		if from == "" {
+			continue
+		}
+		if mapping.Regex {
+			// Mirrors the case-insensitive compilation in the Amp model mapper.
+			if _, err := regexp.Compile("(?i)" + from); err != nil {
+				v.addf(ValidationSeverityError, base.with("from"), "invalid regex %q: %v", from, err)
+			}
+			continue
+		}
+		key := strings.ToLower(from)
+		if first, dup := seenExact[key]; dup {
+			v.addf(ValidationSeverityWarning, base.with("from"), "duplicate mapping for %q overrides model-mappings[%d]", from, first)
+		}
+		seenExact[key] = i
+	}
+}
+
+func (v *configValidator) checkOAuthModelAlias(aliases map[string][]OAuthModelAlias) {
+	for _, channel := range sortedKeys(aliases) {
+		entries := aliases[channel]
+		if strings.TrimSpace(channel) == "" {
+			v.addf(ValidationSeverityWarning, yamlPath{"oauth-model-alias"}, "aliases under an empty channel are ignored")
+			continue
+		}
+		seen := make(map[string]int, len(entries))
+		for i, entry := range entries {
+			base := yamlPath{"oauth-model-alias", channel, i}
+			name, alias := strings.TrimSpace(entry.Name), strings.TrimSpace(entry.Alias)
+			if name == "" || alias == "" {
+				v.addf(ValidationSeverityWarning, base, "alias entry without name or alias is ignored")
+				continue
+			}
+			if strings.EqualFold(name, alias) {
+				v.addf(ValidationSeverityWarning, base.with("alias"), "alias %q equals the model name and is ignored", alias)
+				continue
+			}
+			key := strings.ToLower(alias)
+			if first, dup := seen[key]; dup {
+				v.addf(ValidationSeverityError, base.with("alias"), "duplicate alias %q in channel %q (first defined at index %d)", alias, channel, first)
+				continue
+			}
+			seen[key] = i
+		}
+	}
+}
+
+func (v *configValidator) checkModelProviderRouting(cfg *Config) {
+	allowlist := cfg.ModelProviderRouting.FamilyProviderAllowlist
+	if len(allowlist) == 0 {
+		return
+	}
+	known := make(map[string]struct{}, len(builtinProviders)+len(cfg.OpenAICompatibility)+1)
+	for _, provider := range builtinProviders {
+		known[provider] = struct{}{}
+	}
+	known["openai-compatibility"] = struct{}{}
+	for _, compat := range cfg.OpenAICompatibility {
+		if name := strings.ToLower(strings.TrimSpace(compat.Name)); name != "" {
+			known[name] = struct{}{}
+		}
+	}
+	for _, provider := range cfg.HTTPProviders {
+		if name := strings.ToLower(strings.TrimSpace(provider.Name)); name != "" {
+			known[name] = struct{}{}
+		}
+	}
+	for _, family := range sortedKeys(allowlist) {
+		for i, raw := range allowlist[family] {
+			provider := strings.ToLower(strings.TrimSpace(raw))
+			if provider == "" {
+				continue
+			}
+			if _, ok := known[provider]; !ok {
+				v.addf(ValidationSeverityError, yamlPath{"model-provider-routing", "family-provider-allowlist", family, i},
+					"unknown provider %q", raw)
+			}
+		}
+	}
+}
+
+func (v *configValidator) checkScheduler(cfg *Config) {
+	sched := cfg.Scheduler
+	if sched.MaxConcurrency < 0 {
+		v.addf(ValidationSeverityError, yamlPath{"scheduler", "max-concurrency"}, "max-concurrency must not be negative")
+	}
+	for _, provider := range sortedKeys(sched.ProviderConcurrency) {
+		if sched.ProviderConcurrency[provider] <= 0 {
+			v.addf(ValidationSeverityWarning, yamlPath{"scheduler", "provider-concurrency", provider}, "a limit <= 0 leaves provider %q uncapped", provider)
+		}
+	}
+	seen := make(map[string]int, len(sched.Clients))
+	for i, client := range sched.Clients {
+		base := yamlPath{"scheduler", "clients", i}
+		key := strings.TrimSpace(client.APIKey)
+		if key == "" {
+			v.addf(ValidationSeverityError, base.with("api-key"), "scheduler client requires an api-key")
+		} else if first, dup := seen[key]; dup {
+			v.addf(ValidationSeverityError, base.with("api-key"), "duplicate scheduler client (first defined at clients[%d])", first)
+		} else {
+			seen[key] = i
+		}
+		switch strings.ToLower(strings.TrimSpace(client.Priority)) {
+		case "", "interactive", "batch":
+		default:
+			v.addf(ValidationSeverityError, base.with("priority"), "unknown priority %q (supported: interactive, batch)", client.Priority)
+		}
+	}
+}
+
+func (v *configValidator) checkResponseCache(rc ResponseCacheConfig) {
+	switch strings.ToLower(strings.TrimSpace(rc.Store)) {
+	case "", "memory":
+	case "disk":
+		if rc.Enabled && strings.TrimSpace(rc.Dir) == "" {
+			v.addf(ValidationSeverityError, yamlPath{"response-cache", "dir"}, "dir is required for the disk store")
+		}
+	default:
+		v.addf(ValidationSeverityError, yamlPath{"response-cache", "store"}, "unknown store %q (supported: memory, disk)", rc.Store)
+	}
+}
+
+func (v *configValidator) checkRemoteWorkers(rw RemoteWorkersConfig) {
+	conductorURL := strings.TrimSpace(rw.ConductorURL)
+	if (rw.Accept || conductorURL != "") && strings.TrimSpace(rw.Token) == "" {
+		v.addf(ValidationSeverityError, yamlPath{"remote-workers", "token"}, "token is required when remote workers are used")
+	}
+	if conductorURL == "" {
+		return
+	}
+	parsed, err := url.Parse(conductorURL)
+	if err != nil {
+		v.addf(ValidationSeverityError, yamlPath{"remote-workers", "conductor-url"}, "invalid conductor URL: %v", err)
+		return
+	}
+	if parsed.Scheme != "ws" && parsed.Scheme != "wss" {
+		v.addf(ValidationSeverityError, yamlPath{"remote-workers", "conductor-url"}, "unsupported conductor URL scheme %q (supported: ws, wss)", parsed.Scheme)
+	} else if parsed.Scheme == "ws" {
+		v.addf(ValidationSeverityWarning, yamlPath{"remote-workers", "conductor-url"}, "ws:// sends the worker token and requests unencrypted")
+	}
+}
+
+func (v *configValidator) checkHTTPProviders(entries []HTTPProvider) {
+	seenNames := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		base := yamlPath{"http-providers", i}
+		name := strings.ToLower(strings.TrimSpace(entry.Name))
+		if name == "" {
+			v.addf(ValidationSeverityError, base.with("name"), "http provider name is required")
+		} else if first, dup := seenNames[name]; dup {
+			v.addf(ValidationSeverityError, base.with("name"), "duplicate provider name %q (first defined at http-providers[%d])", entry.Name, first)
+		} else if slices.Contains(builtinProviders, name) {
+			v.addf(ValidationSeverityError, base.with("name"), "provider name %q is reserved for a built-in provider", entry.Name)
+		} else {
+			seenNames[name] = i
+		}
+		for _, field := range []struct {
+			key, raw string
+		}{{"url", entry.URL}, {"stream-url", entry.StreamURL}} {
+			raw := strings.TrimSpace(field.raw)
+			if raw == "" {
+				if field.key == "url" {
+					v.addf(ValidationSeverityError, base.with("url"), "http provider url is required")
+				}
+				continue
+			}
+			if parsed, err := url.Parse(raw); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				v.addf(ValidationSeverityError, base.with(field.key), "%s %q must be an http or https URL", field.key, raw)
+			}
+		}
+		format := strings.ToLower(strings.TrimSpace(entry.Format))
+		switch format {
+		case "", "openai", "claude", "gemini":
+		default:
+			v.addf(ValidationSeverityError, base.with("format"), "unknown format %q (supported: openai, claude, gemini)", entry.Format)
+		}
+		switch strings.ToLower(strings.TrimSpace(entry.Stream.Mode)) {
+		case "", "sse", "ndjson":
+		default:
+			v.addf(ValidationSeverityError, base.with("stream", "mode"), "unknown stream mode %q (supported: sse, ndjson)", entry.Stream.Mode)
+		}
+		if format == "claude" && len(entry.Stream.Fields) > 0 {
+			v.addf(ValidationSeverityError, base.with("stream", "fields"), "mapped streams support the openai and gemini formats only")
+		}
+		for _, mapping := range []struct {
+			path     yamlPath
+			template string
+			fields   []HTTPFieldMapping
+		}{
+			{base.with("request"), entry.Request.Template, entry.Request.Fields},
+			{base.with("response"), entry.Response.Template, entry.Response.Fields},
+			{base.with("stream"), entry.Stream.Template, entry.Stream.Fields},
+		} {
+			if template := strings.TrimSpace(mapping.template); template != "" && !json.Valid([]byte(template)) {
+				v.addf(ValidationSeverityError, mapping.path.with("template"), "template is not valid JSON")
+			}
+			for j, field := range mapping.fields {
+				if strings.TrimSpace(field.To) == "" {
+					v.addf(ValidationSeverityError, mapping.path.with("fields", j, "to"), "field mapping target is required")
+				}
+				if strings.TrimSpace(field.From) == "" && field.Value == nil {
+					v.addf(ValidationSeverityError, mapping.path.with("fields", j), "field mapping needs from or value")
+				}
+			}
+		}
+		for j := range entry.APIKeyEntries {
+			v.checkProxyURL(base.with("api-key-entries", j, "proxy-url"), entry.APIKeyEntries[j].ProxyURL)
+		}
+	}
+}
+
+func (v *configValidator) checkSignatureCache(cfg *Config) {
+	sc := cfg.SignatureCache
+	switch strings.ToLower(strings.TrimSpace(sc.Backend)) {
+	case "", "memory", "file":
+	case "postgres":
+		if !cfg.Cluster.Enabled {
+			v.addf(ValidationSeverityError, yamlPath{"signature-cache", "backend"}, "the postgres backend requires cluster mode")
+		}
+	default:
+		v.addf(ValidationSeverityError, yamlPath{"signature-cache", "backend"}, "unknown backend %q (supported: memory, file, postgres)", sc.Backend)
+	}
+	if sc.MaxEntriesPerGroup < 0 {
+		v.addf(ValidationSeverityError, yamlPath{"signature-cache", "max-entries-per-group"}, "max-entries-per-group must not be negative")
+	}
+}
+
+func (v *configValidator) checkWebsocketRelay(relay WebsocketRelayConfig) {
+	seenKeys := make(map[string]int, len(relay.Keys))
+	for i, key := range relay.Keys {
+		base := yamlPath{"ws-relay", "keys", i}
+		secret := strings.TrimSpace(key.Key)
+		if secret == "" {
+			v.addf(ValidationSeverityError, base.with("key"), "ws-relay key is required")
+		} else if first, dup := seenKeys[secret]; dup {
+			v.addf(ValidationSeverityError, base.with("key"), "duplicate key (first defined at ws-relay.keys[%d])", first)
+		} else {
+			seenKeys[secret] = i
+		}
+		name := strings.TrimSpace(key.Name)
+		if name == "" || strings.ContainsAny(name, "/ ") {
+			v.addf(ValidationSeverityError, base.with("name"), "ws-relay key name %q must be non-empty without spaces or slashes", key.Name)
+		}
+	}
+	for i, origin := range relay.AllowedOrigins {
+		origin = strings.TrimSpace(origin)
+		if origin == "*" {
+			continue
+		}
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" || strings.Trim(parsed.Path, "/") != "" {
+			v.addf(ValidationSeverityError, yamlPath{"ws-relay", "allowed-origins", i}, "origin %q must be scheme://host[:port] or *", origin)
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfigData_ReportsSemanticErrorsWithPaths(t *testing.T) {
+	data := []byte(`port: 8317
+proxy-url: ftp://proxy.local:21
+openai-compatibility:
+  - name: local
+    base-url: http://127.0.0.1:8000/v1
+    models:
+      - name: a
+        alias: fast
+      - name: b
+        alias: FAST
+ampcode:
+  model-mappings:
+    - from: "gpt-(.*"
+      to: claude-sonnet-4
+      regex: true
+model-provider-routing:
+  family-provider-allowlist:
+    gpt:
+      - codex
+      - nonexistent
+scheduler:
+  clients:
+    - api-key: batch-key
+      priority: background
+claude-prompt-caching:
+  mode: always
+signature-cache:
+  backend: postgres
+response-cache:
+  enabled: true
+  store: redis
+remote-workers:
+  conductor-url: http://conductor.local/v1/ws/worker
+ws-relay:
+  keys:
+    - key: laptop-key
+      name: my laptop
+  allowed-origins:
+    - aistudio.google.com
+http-providers:
+  - name: bespoke
+    url: http://models.local/generate
+    format: claude
+    stream:
+      fields:
+        - from: token
+          to: delta.text
+  - name: gemini
+    url: http://models.local/gemini
+`)
+	report := ValidateConfigData(data, filepath.Join(t.TempDir(), "config.yaml"))
+	if report.Valid {
+		t.Fatalf("report.Valid = true, want false")
+	}
+	want := map[string]string{
+		"proxy-url": "unsupported proxy scheme",
+		"openai-compatibility[0].models[1].alias":                 "duplicate alias",
+		"ampcode.model-mappings[0].from":                          "invalid regex",
+		"model-provider-routing.family-provider-allowlist.gpt[1]": "unknown provider",
+		"scheduler.clients[0].priority":                           "unknown priority",
+		"claude-prompt-caching.mode":                              "unknown prompt caching mode",
+		"signature-cache.backend":                                 "requires cluster mode",
+		"response-cache.store":                                    "unknown store",
+		"remote-workers.token":                                    "token is required",
+		"remote-workers.conductor-url":                            "unsupported conductor URL scheme",
+		"ws-relay.keys[0].name":                                   "without spaces or slashes",
+		"ws-relay.allowed-origins[0]":                             "must be scheme://host",
+		"http-providers[0].stream.fields":                         "openai and gemini formats only",
+		"http-providers[1].name":                                  "reserved for a built-in provider",
+	}
+	for _, issue := range report.Errors {
+		if fragment, ok := want[issue.Path]; ok && strings.Contains(issue.Message, fragment) {
+			if issue.Line == 0 {
+				t.Errorf("issue %s has no line number", issue.Path)
+			}
+			delete(want, issue.Path)
+		}
+	}
+	if len(want) > 0 {
+		t.Fatalf("missing issues %v in %+v", want, report.Errors)
+	}
+}
+
+func TestValidateConfigData_ValidConfigLoads(t *testing.T) {
+	report := ValidateConfigData([]byte("port: 9000\nrouting:\n  strategy: fill-first\n"), filepath.Join(t.TempDir(), "config.yaml"))
+	if !report.Valid || len(report.Errors) != 0 {
+		t.Fatalf("report = %+v, want valid", report)
+	}
+	if report.Config == nil || report.Config.Port != 9000 {
+		t.Fatalf("report.Config = %+v, want loaded config with port 9000", report.Config)
+	}
+}
+
+func TestValidateConfigData_TypeErrorsCarryLine(t *testing.T) {
+	report := ValidateConfigData([]byte("port: 9000\ndebug: [1, 2]\n"), filepath.Join(t.TempDir(), "config.yaml"))
+	if report.Valid || len(report.Errors) != 1 {
+		t.Fatalf("report = %+v, want one error", report)
+	}
+	if report.Errors[0].Line != 2 {
+		t.Fatalf("error line = %d, want 2", report.Errors[0].Line)
+	}
+}
+
+func TestValidateConfigData_DoesNotResolveSecretRefs(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "ran")
+	data := []byte("secret-refs:\n  allow-file-and-exec: true\ncodex-api-key:\n  - api-key: \"exec:touch " + marker + "\"\n    base-url: https://example.com\n")
+	report := ValidateConfigData(data, filepath.Join(dir, "config.yaml"))
+	if !report.Valid {
+		t.Fatalf("report = %+v, want valid", report)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("exec reference ran during validation (stat err = %v)", err)
+	}
+
+	report = ValidateConfigData([]byte("codex-api-key:\n  - api-key: \"exec:\"\n    base-url: https://example.com\n  - api-key: \"file:/etc/hostname\"\n    base-url: https://example.com\n"), filepath.Join(dir, "config.yaml"))
+	if report.Valid || len(report.Errors) != 1 || !strings.Contains(report.Errors[0].Message, "empty exec reference") {
+		t.Fatalf("report = %+v, want malformed reference error", report)
+	}
+	report = ValidateConfigData([]byte("codex-api-key:\n  - api-key: \"file:/etc/hostname\"\n    base-url: https://example.com\n"), filepath.Join(dir, "config.yaml"))
+	if report.Valid || len(report.Errors) != 1 || !strings.Contains(report.Errors[0].Message, "disabled") {
+		t.Fatalf("report = %+v, want disabled reference error", report)
+	}
+}
@@ -0,0 +1,257 @@
+// Package confighistory keeps timestamped snapshots of config.yaml written through the
+// management API so that a bad edit can be inspected, diffed and rolled back.
+package confighistory
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultMaxVersions is the number of snapshots kept when none is configured.
+	DefaultMaxVersions = 50
+	defaultDirName     = "config-history"
+	versionIDLayout    = "20060102T150405.000000000Z"
+
+	// SourceBaseline marks the snapshot of the config as it was before the first recorded edit.
+	SourceBaseline = "baseline"
+	// SourceRollback marks a snapshot written by a rollback.
+	SourceRollback = "rollback"
+)
+
+// ErrNotFound reports an unknown or malformed version ID.
+var ErrNotFound = errors.New("config history: version not found")
+
+var versionIDPattern = regexp.MustCompile(`^[0-9]{8}T[0-9]{6}\.[0-9]{9}Z$`)
+
+// Version describes one stored snapshot.
+type Version struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Author    string    `json:"author,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	Summary   []string  `json:"summary,omitempty"`
+	SHA256    string    `json:"sha256"`
+	Size      int       `json:"size"`
+}
+
+// Store keeps snapshots as <id>.yaml files with <id>.meta JSON metadata in one directory.
+type Store struct {
+	dir         string
+	maxVersions int
+	mu          sync.Mutex
+}
+
+// New returns a store rooted at dir that keeps at most maxVersions snapshots.
+func New(dir string, maxVersions int) *Store {
+	if maxVersions <= 0 {
+		maxVersions = DefaultMaxVersions
+	}
+	return &Store{dir: dir, maxVersions: maxVersions}
+}
+
+// ForConfig returns the history store configured for configPath, or nil when history is disabled.
+func ForConfig(configPath string, cfg config.ConfigHistoryConfig) *Store {
+	if cfg.MaxVersions < 0 || strings.TrimSpace(configPath) == "" {
+		return nil
+	}
+	dir := strings.TrimSpace(cfg.Dir)
+	if dir == "" {
+		dir = defaultDirName
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(configPath), dir)
+	}
+	return New(dir, cfg.MaxVersions)
+}
+
+// Dir returns the snapshot directory.
+func (s *Store) Dir() string {
+	if s == nil {
+		return ""
+	}
+	return s.dir
+}
+
+// MaxVersions returns the number of snapshots kept.
+func (s *Store) MaxVersions() int {
+	if s == nil {
+		return 0
+	}
+	return s.maxVersions
+}
+
+// Record snapshots current when it differs from previous. The first recorded write also
+// snapshots previous as a baseline so the edit itself can be rolled back.
+// It returns nil when nothing changed.
+func (s *Store) Record(previous, current []byte, author, source string) (*Version, error) {
+	if s == nil || bytes.Equal(previous, current) {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return nil, fmt.Errorf("config history: create directory: %w", err)
+	}
+	existing, err := s.listLocked()
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) == 0 && len(previous) > 0 {
+		if _, err = s.writeLocked(previous, Version{Source: SourceBaseline}); err != nil {
+			return nil, err
+		}
+	}
+	version, err := s.writeLocked(current, Version{
+		Author:  strings.TrimSpace(author),
+		Source:  strings.TrimSpace(source),
+		Summary: Summarize(previous, current),
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.pruneLocked()
+	return &version, nil
+}
+
+// List returns the stored versions, newest first.
+func (s *Store) List() ([]Version, error) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listLocked()
+}
+
+// Get returns the metadata and content of one version.
+func (s *Store) Get(id string) (Version, []byte, error) {
+	if s == nil || !versionIDPattern.MatchString(id) {
+		return Version{}, nil, ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, err := os.ReadFile(filepath.Join(s.dir, id+".yaml"))
+	if errors.Is(err, os.ErrNotExist) {
+		return Version{}, nil, ErrNotFound
+	}
+	if err != nil {
+		return Version{}, nil, fmt.Errorf("config history: read version: %w", err)
+	}
+	version, err := s.readMeta(id)
+	if err != nil {
+		return Version{}, nil, err
+	}
+	return version, content, nil
+}
+
+func (s *Store) writeLocked(content []byte, version Version) (Version, error) {
+	now := time.Now().UTC()
+	for {
+		version.ID = now.Format(versionIDLayout)
+		if _, err := os.Stat(filepath.Join(s.dir, version.ID+".yaml")); os.IsNotExist(err) {
+			break
+		}
+		now = now.Add(time.Nanosecond)
+	}
+	sum := sha256.Sum256(content)
+	version.Timestamp = now
+	version.SHA256 = hex.EncodeToString(sum[:])
+	version.Size = len(content)
+	meta, err := json.MarshalIndent(version, "", "  ")
+	if err != nil {
+		return Version{}, fmt.Errorf("config history: encode metadata: %w", err)
+	}
+	if err = os.WriteFile(filepath.Join(s.dir, version.ID+".yaml"), content, 0o600); err != nil {
+		return Version{}, fmt.Errorf("config history: write version: %w", err)
+	}
+	if err = os.WriteFile(filepath.Join(s.dir, version.ID+".meta"), meta, 0o600); err != nil {
+		return Version{}, fmt.Errorf("config history: write metadata: %w", err)
+	}
+	return version, nil
+}
+
+func (s *Store) listLocked() ([]Version, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config history: read directory: %w", err)
+	}
+	versions := make([]Version, 0, len(entries))
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".yaml")
+		if !ok || entry.IsDir() || !versionIDPattern.MatchString(id) {
+			continue
+		}
+		version, errMeta := s.readMeta(id)
+		if errMeta != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].ID > versions[j].ID })
+	return versions, nil
+}
+
+func (s *Store) readMeta(id string) (Version, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, id+".meta"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// Snapshot without metadata (e.g. copied in by hand): derive what we can.
+			ts, _ := time.Parse(versionIDLayout, id)
+			return Version{ID: id, Timestamp: ts}, nil
+		}
+		return Version{}, fmt.Errorf("config history: read metadata: %w", err)
+	}
+	var version Version
+	if err = json.Unmarshal(data, &version); err != nil {
+		return Version{}, fmt.Errorf("config history: decode metadata: %w", err)
+	}
+	version.ID = id
+	return version, nil
+}
+
+func (s *Store) pruneLocked() {
+	versions, err := s.listLocked()
+	if err != nil || len(versions) <= s.maxVersions {
+		return
+	}
+	for _, version := range versions[s.maxVersions:] {
+		_ = os.Remove(filepath.Join(s.dir, version.ID+".yaml"))
+		_ = os.Remove(filepath.Join(s.dir, version.ID+".meta"))
+	}
+}
+
+// Summarize describes the material config changes between two YAML documents using the
+// same change details logged on hot reload.
+func Summarize(previous, current []byte) []string {
+	var oldCfg, newCfg config.Config
+	if err := yaml.Unmarshal(previous, &oldCfg); err != nil {
+		return []string{"previous config is not valid YAML"}
+	}
+	if err := yaml.Unmarshal(current, &newCfg); err != nil {
+		return []string{"config is not valid YAML"}
+	}
+	details := diff.BuildConfigChangeDetails(&oldCfg, &newCfg)
+	if len(details) == 0 && !bytes.Equal(previous, current) {
+		return []string{"formatting or comments changed"}
+	}
+	return details
+}
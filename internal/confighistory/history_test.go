@@ -0,0 +1,50 @@
+package confighistory
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecordPrunesOldestVersions(t *testing.T) {
+	store := New(t.TempDir(), 3)
+	docs := []string{"port: 1\n", "port: 2\n", "port: 3\n", "port: 4\n"}
+	for i := 1; i < len(docs); i++ {
+		if _, err := store.Record([]byte(docs[i-1]), []byte(docs[i]), "test", "management"); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if version, err := store.Record([]byte(docs[3]), []byte(docs[3]), "test", "management"); err != nil || version != nil {
+		t.Fatalf("unchanged Record() = %v, %v; want no version", version, err)
+	}
+
+	versions, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("len(versions) = %d, want 3", len(versions))
+	}
+	_, newest, err := store.Get(versions[0].ID)
+	if err != nil || string(newest) != docs[3] {
+		t.Fatalf("newest = %q, %v", newest, err)
+	}
+	if _, oldest, _ := store.Get(versions[2].ID); string(oldest) != docs[1] {
+		t.Fatalf("oldest kept = %q, want %q (baseline pruned)", oldest, docs[1])
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	b := "a\nb\nc\nD\ne\nf\ng\nh\ni\n"
+	got := UnifiedDiff("old", "new", []byte(a), []byte(b))
+	want := "--- old\n+++ new\n@@ -1,8 +1,9 @@\n a\n b\n c\n-d\n+D\n e\n f\n g\n h\n+i\n"
+	if got != want {
+		t.Fatalf("UnifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+	if diff := UnifiedDiff("x", "y", []byte(a), []byte(a)); diff != "" {
+		t.Fatalf("equal inputs produced %q", diff)
+	}
+	if !strings.Contains(UnifiedDiff("x", "y", nil, []byte("a\n")), "@@ -0,0 +1,1 @@") {
+		t.Fatal("missing hunk header for insertion into empty file")
+	}
+}
@@ -0,0 +1,435 @@
+// Code generated by go run ./gen; DO NOT EDIT.
+
+package configschema
+
+// typeDocs holds the doc comments of the config types, keyed by type name.
+var typeDocs = map[string]string{
+	"AccountProxyConstraintConfig": "AccountProxyConstraintConfig defines account-level proxy hard constraints.",
+	"AmpCode":                      "AmpCode groups Amp CLI integration settings including upstream routing, optional overrides, management route restrictions, and model fallback mappings.",
+	"AmpModelMapping":              "AmpModelMapping defines a model name mapping for Amp CLI requests. When Amp requests a model that isn't available locally, this mapping allows routing to an alternative model that IS available.",
+	"AmpUpstreamAPIKeyEntry":       "AmpUpstreamAPIKeyEntry maps a set of client API keys to a specific upstream API key. When a request is authenticated with one of the APIKeys, the corresponding UpstreamAPIKey is used for the upstream Amp request.",
+	"ClaudeHeaderDefaults":         "ClaudeHeaderDefaults configures default header values injected into Claude API requests when the client does not send them. Update these when Claude Code releases a new version.",
+	"ClaudeKey":                    "ClaudeKey represents the configuration for a Claude API key, including the API key itself and an optional base URL for the API endpoint.",
+	"ClaudeModel":                  "ClaudeModel describes a mapping between an alias and the actual upstream model name.",
+	"ClaudePromptCaching":          "ClaudePromptCaching controls automatic prompt-caching breakpoints on requests sent to Claude, whatever format the client used.",
+	"CloakConfig":                  "CloakConfig configures request cloaking for non-Claude-Code clients. Cloaking disguises API requests to appear as originating from the official Claude Code CLI.",
+	"ClusterConfig":                "ClusterConfig configures shared credential runtime state for multi-replica deployments.",
+	"CodexKey":                     "CodexKey represents the configuration for a Codex API key, including the API key itself and an optional base URL for the API endpoint.",
+	"CodexModel":                   "CodexModel describes a mapping between an alias and the actual upstream model name.",
+	"Config":                       "Config represents the application's configuration, loaded from a YAML file.",
+	"ConfigComposition":            "ConfigComposition is a config document after includes, the overlay and interpolation have been applied.",
+	"ConfigHistoryConfig":          "ConfigHistoryConfig configures config version history for management API writes.",
+	"ConfigSource":                 "ConfigSource is one file that contributed to a loaded config.",
+	"EgressDeterminismConfig":      "EgressDeterminismConfig defines account-level egress mapping persistence and drift detection settings.",
+	"GeminiKey":                    "GeminiKey represents the configuration for a Gemini API key, including optional overrides for upstream base URL, proxy routing, and headers.",
+	"GeminiModel":                  "GeminiModel describes a mapping between an alias and the actual upstream model name.",
+	"HTTPFieldMapping":             "HTTPFieldMapping copies one value.",
+	"HTTPMapping":                  "HTTPMapping copies fields from a source JSON document into a template.",
+	"HTTPProvider":                 "HTTPProvider configures an upstream whose wire format is described by field mappings instead of a dedicated executor. Requests are translated into Format, reshaped by the request mappings and sent to URL; responses are mapped back into Format and translated to the client's format.",
+	"HTTPStreamMapping":            "HTTPStreamMapping describes a streamed upstream response.",
+	"HTTPUsagePaths":               "HTTPUsagePaths are gjson paths to token counts in upstream responses and stream events. When unset, usage is read from the mapped Format document.",
+	"ManagedChangeError":           "ManagedChangeError lists what a config submitted through the management API tried to add that only the config file itself may contain.",
+	"ModelProviderRoutingConfig":   "ModelProviderRoutingConfig defines model family -> provider allowlist guard settings.",
+	"ModelVisibilityConfig":        "ModelVisibilityConfig defines model visibility guard settings.",
+	"NotificationWebhook":          "NotificationWebhook describes a single webhook target.",
+	"NotificationsConfig":          "NotificationsConfig configures outbound webhook notifications.",
+	"OAuthModelAlias":              "OAuthModelAlias defines a model ID alias for a specific channel. It maps the upstream model name (Name) to the client-visible alias (Alias). When Fork is true, the alias is added as an additional model in listings while keeping the original model ID available.",
+	"OpenAICompatibility":          "OpenAICompatibility represents the configuration for OpenAI API compatibility with external providers, allowing model aliases to be routed through OpenAI API format.",
+	"OpenAICompatibilityAPIKey":    "OpenAICompatibilityAPIKey represents an API key configuration with optional proxy setting.",
+	"OpenAICompatibilityModel":     "OpenAICompatibilityModel represents a model configuration for OpenAI compatibility, including the actual model name and its alias for API routing.",
+	"PayloadConfig":                "PayloadConfig defines default and override parameter rules applied to provider payloads.",
+	"PayloadFilterRule":            "PayloadFilterRule describes a rule to remove specific JSON paths from matching model payloads.",
+	"PayloadModelRule":             "PayloadModelRule ties a model name pattern to a specific translator protocol.",
+	"PayloadRule":                  "PayloadRule describes a single rule targeting a list of models with parameter updates.",
+	"PprofConfig":                  "PprofConfig holds pprof HTTP server settings.",
+	"PromptQueueConfig":            "PromptQueueConfig configures per-session request serialization.",
+	"QuotaExceeded":                "QuotaExceeded defines the behavior when API quota limits are exceeded. It provides configuration options for automatic failover mechanisms.",
+	"RedactionDetectorRule":        "RedactionDetectorRule enables a pattern detector.",
+	"RedactionPathRule":            "RedactionPathRule masks a JSON body field.",
+	"RemoteManagement":             "RemoteManagement holds management API configuration under 'remote-management'.",
+	"RemoteWorkersConfig":          "RemoteWorkersConfig configures both sides of remote worker mode. A conductor sets Accept; a worker sets ConductorURL.",
+	"RequestLogRedactionConfig":    "RequestLogRedactionConfig configures redaction applied to request logs before they hit disk.",
+	"ResponseCacheConfig":          "ResponseCacheConfig configures the exact-match response cache. A request is cached when its temperature is 0 or when it carries the opt-in header with the value true; the header set to false bypasses the cache.",
+	"RoutingConfig":                "RoutingConfig configures how credentials are selected for requests.",
+	"SDKConfig":                    "SDKConfig represents the application's configuration, loaded from a YAML file.",
+	"SchedulerClient":              "SchedulerClient sets scheduling for one client API key.",
+	"SchedulerConfig":              "SchedulerConfig configures admission of upstream requests.",
+	"SecretRefsConfig":             "SecretRefsConfig controls how secret references in API key fields are resolved.",
+	"SignatureCacheConfig":         "SignatureCacheConfig configures the cache of Claude and Gemini thinking signatures that multi-turn conversations replay.",
+	"StreamingConfig":              "StreamingConfig holds server streaming behavior configuration.",
+	"TLSConfig":                    "TLSConfig holds HTTPS server settings.",
+	"UsageStorageConfig":           "UsageStorageConfig configures the durable usage sink.",
+	"ValidationIssue":              "ValidationIssue is one problem found in a config document.",
+	"ValidationReport":             "ValidationReport collects the result of ValidateConfigData.",
+	"VertexCompatKey":              "VertexCompatKey represents the configuration for Vertex AI-compatible API keys. This supports third-party services that use Vertex AI-style endpoint paths (/publishers/google/models/{model}:streamGenerateContent) but authenticate with simple API keys instead of Google Cloud service account credentials.\n\nExample services: zenmux.ai and similar Vertex-compatible providers.",
+	"VertexCompatModel":            "VertexCompatModel represents a model configuration for Vertex compatibility, including the actual model name and its alias for API routing.",
+	"WebsocketRelayConfig":         "WebsocketRelayConfig configures websocket relay sessions.",
+	"WebsocketRelayKey":            "WebsocketRelayKey binds a relay credential to a provider name.",
+}
+
+// fieldDocs holds the doc comments of config struct fields, keyed by "Type.Field".
+var fieldDocs = map[string]string{
+	"AccountProxyConstraintConfig.Enabled":               "Enabled toggles strict per-account proxy requirement.",
+	"AmpCode.ForceModelMappings":                         "ForceModelMappings when true, model mappings take precedence over local API keys. When false (default), local API keys are used first if available.",
+	"AmpCode.ModelMappings":                              "ModelMappings defines model name mappings for Amp CLI requests. When Amp requests a model that isn't available locally, these mappings allow routing to an alternative model that IS available.",
+	"AmpCode.RestrictManagementToLocalhost":              "RestrictManagementToLocalhost restricts Amp management routes (/api/user, /api/threads, etc.) to only accept connections from localhost (127.0.0.1, ::1). When true, prevents drive-by browser attacks and remote access to management endpoints. Default: false (API key auth is sufficient).",
+	"AmpCode.UpstreamAPIKey":                             "UpstreamAPIKey optionally overrides the Authorization header when proxying Amp upstream calls.",
+	"AmpCode.UpstreamAPIKeys":                            "UpstreamAPIKeys maps client API keys (from top-level api-keys) to upstream API keys. When a client authenticates with a key that matches an entry, that upstream key is used. If no match is found, falls back to UpstreamAPIKey (default behavior).",
+	"AmpCode.UpstreamURL":                                "UpstreamURL defines the upstream Amp control plane used for non-provider calls.",
+	"AmpModelMapping.From":                               "From is the model name that Amp CLI requests (e.g., \"claude-opus-4.5\").",
+	"AmpModelMapping.Regex":                              "Regex indicates whether the 'from' field should be interpreted as a regular expression for matching model names. When true, this mapping is evaluated after exact matches and in the order provided. Defaults to false (exact match).",
+	"AmpModelMapping.To":                                 "To is the target model name to route to (e.g., \"claude-sonnet-4\"). The target model must have available providers in the registry.",
+	"AmpUpstreamAPIKeyEntry.APIKeys":                     "APIKeys are the client API keys (from top-level api-keys) that map to this upstream key.",
+	"AmpUpstreamAPIKeyEntry.UpstreamAPIKey":              "UpstreamAPIKey is the API key to use when proxying to the Amp upstream.",
+	"ClaudeKey.APIKey":                                   "APIKey is the authentication key for accessing Claude API services.",
+	"ClaudeKey.BaseURL":                                  "BaseURL is the base URL for the Claude API endpoint. If empty, the default Claude API URL will be used.",
+	"ClaudeKey.Cloak":                                    "Cloak configures request cloaking for non-Claude-Code clients.",
+	"ClaudeKey.ExcludedModels":                           "ExcludedModels lists model IDs that should be excluded for this provider.",
+	"ClaudeKey.Headers":                                  "Headers optionally adds extra HTTP headers for requests sent with this key.",
+	"ClaudeKey.Models":                                   "Models defines upstream model names and aliases for request routing.",
+	"ClaudeKey.Prefix":                                   "Prefix optionally namespaces models for this credential (e.g., \"teamA/claude-sonnet-4\").",
+	"ClaudeKey.Priority":                                 "Priority controls selection preference when multiple credentials match. Higher values are preferred; defaults to 0.",
+	"ClaudeKey.ProxyURL":                                 "ProxyURL overrides the global proxy setting for this API key if provided.",
+	"ClaudeModel.Alias":                                  "Alias is the client-facing model name that maps to Name.",
+	"ClaudeModel.Name":                                   "Name is the upstream model identifier used when issuing requests.",
+	"ClaudePromptCaching.Mode":                           "Mode selects when breakpoints are added: - \"missing\" (default): only to requests that carry no cache_control at all - \"auto\": also to requests that carry some, on the tools, the system prompt and the last stable conversation turn, within the limit of four breakpoints per request - \"off\": never",
+	"CloakConfig.Mode":                                   "Mode controls cloaking behavior: \"auto\" (default), \"always\", or \"never\". - \"auto\": cloak only when client is not Claude Code (based on User-Agent) - \"always\": always apply cloaking regardless of client - \"never\": never apply cloaking",
+	"CloakConfig.SensitiveWords":                         "SensitiveWords is a list of words to obfuscate with zero-width characters. This can help bypass certain content filters.",
+	"CloakConfig.StrictMode":                             "StrictMode controls how system prompts are handled when cloaking. - false (default): prepend Claude Code prompt to user system messages - true: strip all user system messages, keep only Claude Code prompt",
+	"ClusterConfig.DSN":                                  "DSN is the PostgreSQL connection string. When empty, PGSTORE_DSN is used.",
+	"ClusterConfig.Driver":                               "Driver selects the shared backend: \"postgres\" (default) or \"sqlite\" for replicas on one host.",
+	"ClusterConfig.Enabled":                              "Enabled turns on cluster mode.",
+	"ClusterConfig.LeaseSeconds":                         "LeaseSeconds is how long the token refresh leader keeps leadership without renewing. Defaults to 30.",
+	"ClusterConfig.NodeID":                               "NodeID identifies this replica. Defaults to hostname-pid.",
+	"ClusterConfig.Path":                                 "Path is the SQLite database file. Relative paths are resolved from the config file directory. Defaults to \"cluster.db\".",
+	"ClusterConfig.SyncIntervalSeconds":                  "SyncIntervalSeconds is how often remote state is pulled. Defaults to 2.",
+	"CodexKey.APIKey":                                    "APIKey is the authentication key for accessing Codex API services.",
+	"CodexKey.BaseURL":                                   "BaseURL is the base URL for the Codex API endpoint. If empty, the default Codex API URL will be used.",
+	"CodexKey.ExcludedModels":                            "ExcludedModels lists model IDs that should be excluded for this provider.",
+	"CodexKey.Headers":                                   "Headers optionally adds extra HTTP headers for requests sent with this key.",
+	"CodexKey.Models":                                    "Models defines upstream model names and aliases for request routing.",
+	"CodexKey.Prefix":                                    "Prefix optionally namespaces models for this credential (e.g., \"teamA/gpt-5-codex\").",
+	"CodexKey.Priority":                                  "Priority controls selection preference when multiple credentials match. Higher values are preferred; defaults to 0.",
+	"CodexKey.ProxyURL":                                  "ProxyURL overrides the global proxy setting for this API key if provided.",
+	"CodexKey.Websockets":                                "Websockets enables the Responses API websocket transport for this credential.",
+	"CodexModel.Alias":                                   "Alias is the client-facing model name that maps to Name.",
+	"CodexModel.Name":                                    "Name is the upstream model identifier used when issuing requests.",
+	"Config.AmpCode":                                     "AmpCode contains Amp CLI upstream configuration, management restrictions, and model mappings.",
+	"Config.AuthDir":                                     "AuthDir is the directory where authentication token files are stored.",
+	"Config.ClaudeHeaderDefaults":                        "ClaudeHeaderDefaults configures default header values for Claude API requests. These are used as fallbacks when the client does not send its own headers.",
+	"Config.ClaudeKey":                                   "ClaudeKey defines a list of Claude API key configurations as specified in the YAML configuration file.",
+	"Config.ClaudePromptCaching":                         "ClaudePromptCaching controls the cache_control breakpoints added to Claude requests.",
+	"Config.Cluster":                                     "Cluster shares credential runtime state between proxy replicas. Changes require a restart.",
+	"Config.CodexKey":                                    "Codex defines a list of Codex API key configurations as specified in the YAML configuration file.",
+	"Config.CommercialMode":                              "CommercialMode disables high-overhead HTTP middleware features to minimize per-request memory usage.",
+	"Config.ConfigHistory":                               "ConfigHistory controls the snapshots kept of config writes made through the management API.",
+	"Config.Debug":                                       "Debug enables or disables debug-level logging and other debug features.",
+	"Config.DisableCooling":                              "DisableCooling disables quota cooldown scheduling when true.",
+	"Config.ErrorLogsMaxFiles":                           "ErrorLogsMaxFiles limits the number of error log files retained when request logging is disabled. When exceeded, the oldest error log files are deleted. Default is 10. Set to 0 to disable cleanup.",
+	"Config.GeminiKey":                                   "GeminiKey defines Gemini API key configurations with optional routing overrides.",
+	"Config.HTTPProviders":                               "HTTPProviders defines upstreams with bespoke JSON APIs, mapped to and from a built-in format.",
+	"Config.Host":                                        "Host is the network host/interface on which the API server will bind. Default is empty (\"\") to bind all interfaces (IPv4 + IPv6). Use \"127.0.0.1\" or \"localhost\" for local-only access.",
+	"Config.LoggingToFile":                               "LoggingToFile controls whether application logs are written to rotating files or stdout.",
+	"Config.LogsMaxTotalSizeMB":                          "LogsMaxTotalSizeMB limits the total size (in MB) of log files under the logs directory. When exceeded, the oldest log files are deleted until within the limit. Set to 0 to disable.",
+	"Config.MaxRetryInterval":                            "MaxRetryInterval defines the maximum wait time in seconds before retrying a cooled-down credential.",
+	"Config.Notifications":                               "Notifications configures outbound webhook notifications for operational events.",
+	"Config.OAuthExcludedModels":                         "OAuthExcludedModels defines per-provider global model exclusions applied to OAuth/file-backed auth entries.",
+	"Config.OAuthModelAlias":                             "OAuthModelAlias defines global model name aliases for OAuth/file-backed auth channels. These aliases affect both model listing and model routing for supported channels: gemini-cli, vertex, aistudio, antigravity, claude, codex, qwen, iflow.\n\nNOTE: This does not apply to existing per-credential model alias features under: gemini-api-key, codex-api-key, claude-api-key, openai-compatibility, vertex-api-key, and ampcode.",
+	"Config.OpenAICompatibility":                         "OpenAICompatibility defines OpenAI API compatibility configurations for external providers.",
+	"Config.Payload":                                     "Payload defines default and override rules for provider payload parameters.",
+	"Config.Port":                                        "Port is the network port on which the API server will listen.",
+	"Config.Pprof":                                       "Pprof config controls the optional pprof HTTP debug server.",
+	"Config.QuotaExceeded":                               "QuotaExceeded defines the behavior when a quota is exceeded.",
+	"Config.RemoteManagement":                            "RemoteManagement nests management-related options under 'remote-management'.",
+	"Config.RemoteWorkers":                               "RemoteWorkers lets other proxy processes execute requests with their own credentials over an outbound websocket. Changes require a restart.",
+	"Config.RequestLogRedaction":                         "RequestLogRedaction masks secrets and personal data before request logs are written.",
+	"Config.RequestRetry":                                "RequestRetry defines the retry times when the request failed.",
+	"Config.Routing":                                     "Routing controls credential selection behavior.",
+	"Config.SecretRefs":                                  "SecretRefs controls resolution of env:/file:/exec: references in upstream API key fields.",
+	"Config.SignatureCache":                              "SignatureCache selects where thinking signatures are kept. Backend changes require a restart.",
+	"Config.TLS":                                         "TLS config controls HTTPS server settings.",
+	"Config.UsageStatisticsEnabled":                      "UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.",
+	"Config.UsageStorage":                                "UsageStorage configures durable per-request usage storage in SQLite or PostgreSQL.",
+	"Config.VertexCompatAPIKey":                          "VertexCompatAPIKey defines Vertex AI-compatible API key configurations for third-party providers. Used for services that use Vertex AI-style paths but with simple API key authentication.",
+	"Config.WebsocketAuth":                               "WebsocketAuth enables or disables authentication for the WebSocket API.",
+	"Config.WebsocketRelay":                              "WebsocketRelay tunes the relay sessions behind /v1/ws and the remote worker endpoint.",
+	"ConfigComposition.Composed":                         "Composed reports whether Data differs from the main file.",
+	"ConfigComposition.Data":                             "Data is the merged YAML document. It is the main file unchanged when nothing was composed.",
+	"ConfigComposition.Sources":                          "Sources lists the files read, main file first.",
+	"ConfigComposition.WatchPatterns":                    "WatchPatterns are absolute paths and include globs whose changes affect Data. The overlay path is listed even when the file does not exist yet.",
+	"ConfigHistoryConfig.Dir":                            "Dir is the snapshot directory. Relative paths are resolved from the config file directory. Defaults to \"config-history\".",
+	"ConfigHistoryConfig.MaxVersions":                    "MaxVersions is the number of snapshots kept. 0 keeps the default of 50; a negative value disables history.",
+	"EgressDeterminismConfig.DriftAlertThreshold":        "DriftAlertThreshold marks an account as alerting when drift-count reaches this value. <= 0 is normalized to 1.",
+	"EgressDeterminismConfig.Enabled":                    "Enabled toggles account-level egress mapping persistence and drift detection.",
+	"EgressDeterminismConfig.StateFile":                  "StateFile is the state file path used to persist account -> egress mapping snapshots. Relative paths are resolved from process working directory.",
+	"GeminiKey.APIKey":                                   "APIKey is the authentication key for accessing Gemini API services.",
+	"GeminiKey.BaseURL":                                  "BaseURL optionally overrides the Gemini API endpoint.",
+	"GeminiKey.ExcludedModels":                           "ExcludedModels lists model IDs that should be excluded for this provider.",
+	"GeminiKey.Headers":                                  "Headers optionally adds extra HTTP headers for requests sent with this key.",
+	"GeminiKey.Models":                                   "Models defines upstream model names and aliases for request routing.",
+	"GeminiKey.Prefix":                                   "Prefix optionally namespaces models for this credential (e.g., \"teamA/gemini-3-pro-preview\").",
+	"GeminiKey.Priority":                                 "Priority controls selection preference when multiple credentials match. Higher values are preferred; defaults to 0.",
+	"GeminiKey.ProxyURL":                                 "ProxyURL optionally overrides the global proxy for this API key.",
+	"GeminiModel.Alias":                                  "Alias is the client-facing model name that maps to Name.",
+	"GeminiModel.Name":                                   "Name is the upstream model identifier used when issuing requests.",
+	"HTTPFieldMapping.From":                              "From is a gjson path into the source document. In request mappings $model is the upstream model name and $stream whether the request streams.",
+	"HTTPFieldMapping.To":                                "To is the sjson path written in the template.",
+	"HTTPFieldMapping.Value":                             "Value is written when From is empty or missing from the source.",
+	"HTTPMapping.Fields":                                 "Fields are applied in order. Without fields the source document is used unchanged.",
+	"HTTPMapping.Template":                               "Template is the JSON document the fields are written into. Defaults to {} for requests and to a minimal Format response otherwise.",
+	"HTTPProvider.APIKeyEntries":                         "APIKeyEntries defines API keys with optional per-key proxy configuration.",
+	"HTTPProvider.Format":                                "Format is the built-in format the mappings read from and write to: openai (chat completions, the default), claude or gemini.",
+	"HTTPProvider.Headers":                               "Headers are header templates; {api_key} and {model} are replaced per request. Defaults to \"Authorization: Bearer {api_key}\" when an API key is set.",
+	"HTTPProvider.Models":                                "Models defines the model configurations including aliases for routing.",
+	"HTTPProvider.Name":                                  "Name is the provider key used for routing and usage.",
+	"HTTPProvider.Prefix":                                "Prefix optionally namespaces model aliases for this provider.",
+	"HTTPProvider.Priority":                              "Priority controls selection preference when multiple providers or credentials match.",
+	"HTTPProvider.Request":                               "Request builds the upstream body from the request translated into Format.",
+	"HTTPProvider.Response":                              "Response builds a Format response from the upstream body.",
+	"HTTPProvider.Stream":                                "Stream describes streamed responses and builds a Format chunk from each upstream event.",
+	"HTTPProvider.StreamURL":                             "StreamURL is the endpoint template for streaming requests. Defaults to URL.",
+	"HTTPProvider.URL":                                   "URL is the endpoint template. {model} and {api_key} are replaced per request.",
+	"HTTPProvider.Usage":                                 "Usage lists where token counts are found in upstream responses and stream events.",
+	"HTTPStreamMapping.Done":                             "Done is the event payload that ends the stream. Defaults to [DONE].",
+	"HTTPStreamMapping.Fields":                           "Fields are applied to every event. Events where no From path exists are skipped. Without fields events are used unchanged. Mapped streams support the openai and gemini formats.",
+	"HTTPStreamMapping.Mode":                             "Mode is how events are framed: sse (data: lines, the default) or ndjson.",
+	"HTTPStreamMapping.Template":                         "Template is the JSON chunk the fields are written into. Defaults to a minimal Format chunk.",
+	"ModelProviderRoutingConfig.Enabled":                 "Enabled toggles model-family provider allowlist enforcement.",
+	"ModelProviderRoutingConfig.FamilyProviderAllowlist": "FamilyProviderAllowlist maps model-family IDs to allowed provider-pool IDs.",
+	"ModelVisibilityConfig.Enabled":                      "Enabled toggles model visibility guard enforcement.",
+	"ModelVisibilityConfig.HostNamespaces":               "HostNamespaces maps request host/base-url host to namespace ID. It enables Base URL driven namespace routing (e.g., codex.example -> codex namespace).",
+	"ModelVisibilityConfig.Namespaces":                   "Namespaces maps namespace IDs to visible model IDs.",
+	"NotificationWebhook.Events":                         "Events filters which event types are delivered. Empty or \"*\" delivers all events.",
+	"NotificationWebhook.Format":                         "Format selects the payload shape: \"json\" (default), \"slack\" or \"discord\".",
+	"NotificationWebhook.Headers":                        "Headers adds extra HTTP headers to every delivery.",
+	"NotificationWebhook.MaxRetries":                     "MaxRetries is the number of retries after the first failed attempt. Defaults to 3; a negative value disables retries.",
+	"NotificationWebhook.Name":                           "Name identifies the target in management responses and test requests.",
+	"NotificationWebhook.Secret":                         "Secret enables HMAC-SHA256 signing of the request body when set.",
+	"NotificationWebhook.TimeoutSeconds":                 "TimeoutSeconds bounds a single delivery attempt. Defaults to 10.",
+	"NotificationWebhook.URL":                            "URL is the endpoint receiving POST requests.",
+	"NotificationsConfig.DeadLetterFile":                 "DeadLetterFile appends undeliverable notifications as JSON lines. Relative paths are resolved from the config file directory. Empty keeps dead letters in memory only.",
+	"NotificationsConfig.Enabled":                        "Enabled toggles webhook delivery.",
+	"NotificationsConfig.RefreshFailureThreshold":        "RefreshFailureThreshold is the number of consecutive refresh failures for one auth before a refresh_failed notification is sent. Defaults to 3.",
+	"NotificationsConfig.Webhooks":                       "Webhooks lists the delivery targets.",
+	"OpenAICompatibility.APIKeyEntries":                  "APIKeyEntries defines API keys with optional per-key proxy configuration.",
+	"OpenAICompatibility.BaseURL":                        "BaseURL is the base URL for the external OpenAI-compatible API endpoint.",
+	"OpenAICompatibility.Headers":                        "Headers optionally adds extra HTTP headers for requests sent to this provider.",
+	"OpenAICompatibility.Models":                         "Models defines the model configurations including aliases for routing.",
+	"OpenAICompatibility.Name":                           "Name is the identifier for this OpenAI compatibility configuration.",
+	"OpenAICompatibility.Prefix":                         "Prefix optionally namespaces model aliases for this provider (e.g., \"teamA/kimi-k2\").",
+	"OpenAICompatibility.Priority":                       "Priority controls selection preference when multiple providers or credentials match. Higher values are preferred; defaults to 0.",
+	"OpenAICompatibilityAPIKey.APIKey":                   "APIKey is the authentication key for accessing the external API services.",
+	"OpenAICompatibilityAPIKey.ProxyURL":                 "ProxyURL overrides the global proxy setting for this API key if provided.",
+	"OpenAICompatibilityModel.Alias":                     "Alias is the model name alias that clients will use to reference this model.",
+	"OpenAICompatibilityModel.Name":                      "Name is the actual model name used by the external provider.",
+	"PayloadConfig.Default":                              "Default defines rules that only set parameters when they are missing in the payload.",
+	"PayloadConfig.DefaultRaw":                           "DefaultRaw defines rules that set raw JSON values only when they are missing.",
+	"PayloadConfig.Filter":                               "Filter defines rules that remove parameters from the payload by JSON path.",
+	"PayloadConfig.Override":                             "Override defines rules that always set parameters, overwriting any existing values.",
+	"PayloadConfig.OverrideRaw":                          "OverrideRaw defines rules that always set raw JSON values, overwriting any existing values.",
+	"PayloadFilterRule.Models":                           "Models lists model entries with name pattern and protocol constraint.",
+	"PayloadFilterRule.Params":                           "Params lists JSON paths (gjson/sjson syntax) to remove from the payload.",
+	"PayloadModelRule.Name":                              "Name is the model name or wildcard pattern (e.g., \"gpt-*\", \"*-5\", \"gemini-*-pro\").",
+	"PayloadModelRule.Protocol":                          "Protocol restricts the rule to a specific translator format (e.g., \"gemini\", \"responses\").",
+	"PayloadRule.Models":                                 "Models lists model entries with name pattern and protocol constraint.",
+	"PayloadRule.Params":                                 "Params maps JSON paths (gjson/sjson syntax) to values written into the payload. For *-raw rules, values are treated as raw JSON fragments (strings are used as-is).",
+	"PprofConfig.Addr":                                   "Addr is the host:port address for the pprof HTTP server.",
+	"PprofConfig.Enable":                                 "Enable toggles the pprof HTTP debug server.",
+	"PromptQueueConfig.Enabled":                          "Enabled runs requests that carry a session key one at a time per session, so turns of the same agent session never overlap upstream. Requests without a session key are not queued.",
+	"PromptQueueConfig.MaxQueueDepth":                    "MaxQueueDepth limits how many requests of one session may be running or waiting. Further requests are rejected with 429 and their queue position. <= 0 uses the default of 16.",
+	"PromptQueueConfig.SessionHeader":                    "SessionHeader names the request header carrying the session key. Default is X-Session-ID. Without the header, metadata.user_id from the request body and the Codex execution session are used.",
+	"QuotaExceeded.DisableFatalAccounts":                 "DisableFatalAccounts enables automatic disable when encountering fatal auth errors such as account/workspace deactivation. Keep true to avoid repeatedly selecting dead accounts.",
+	"QuotaExceeded.SwitchPreviewModel":                   "SwitchPreviewModel indicates whether to automatically switch to a preview model when a quota is exceeded.",
+	"QuotaExceeded.SwitchProject":                        "SwitchProject indicates whether to automatically switch to another project when a quota is exceeded.",
+	"RedactionDetectorRule.Action":                       "Action selects \"mask\" (default) or \"hash\".",
+	"RedactionDetectorRule.Name":                         "Name identifies a built-in detector or labels a custom one.",
+	"RedactionDetectorRule.Pattern":                      "Pattern is a regular expression for custom detectors.",
+	"RedactionPathRule.Action":                           "Action selects \"mask\" (default) or \"hash\".",
+	"RedactionPathRule.Path":                             "Path is a dot-separated JSON path; \"#\" iterates arrays.",
+	"RemoteManagement.AllowRemote":                       "AllowRemote toggles remote (non-localhost) access to management API.",
+	"RemoteManagement.DisableControlPanel":               "DisableControlPanel skips serving and syncing the bundled management UI when true.",
+	"RemoteManagement.PanelGitHubRepository":             "PanelGitHubRepository overrides the GitHub repository used to fetch the management panel asset. Accepts either a repository URL (https://github.com/org/repo) or an API releases endpoint.",
+	"RemoteManagement.SecretKey":                         "SecretKey is the management key (plaintext or bcrypt hashed). YAML key intentionally 'secret-key'.",
+	"RemoteWorkersConfig.APIKey":                         "APIKey is sent as a bearer token when the conductor enables ws-auth.",
+	"RemoteWorkersConfig.Accept":                         "Accept serves the worker endpoint on /v1/ws/worker and routes to the auths workers register.",
+	"RemoteWorkersConfig.ConductorURL":                   "ConductorURL is the ws:// or wss:// worker endpoint of the conductor this process serves.",
+	"RemoteWorkersConfig.Name":                           "Name identifies this worker on the conductor. Defaults to the hostname.",
+	"RemoteWorkersConfig.Providers":                      "Providers limits which local auths are offered to the conductor. Empty offers all.",
+	"RemoteWorkersConfig.Token":                          "Token is the shared secret workers present in the X-Worker-Token header. Required on both sides.",
+	"RequestLogRedactionConfig.BodyPaths":                "BodyPaths masks JSON fields by path. \"#\" matches every array element, e.g. \"messages.#.content\".",
+	"RequestLogRedactionConfig.Detectors":                "Detectors lists pattern detectors applied to headers and bodies. Built-in names are \"api_key\", \"jwt\", \"email\" and \"card_number\"; any other name requires Pattern. When empty, all built-in detectors are enabled with the \"mask\" action.",
+	"RequestLogRedactionConfig.Enabled":                  "Enabled turns on redaction for request and error logs.",
+	"RequestLogRedactionConfig.HeaderAction":             "HeaderAction selects \"mask\" (default) or \"hash\" for denylisted headers.",
+	"RequestLogRedactionConfig.Headers":                  "Headers extends the built-in header denylist (Authorization, Cookie, X-Api-Key, ...). Matching is case-insensitive.",
+	"ResponseCacheConfig.Dir":                            "Dir is the directory of the disk store.",
+	"ResponseCacheConfig.Enabled":                        "Enabled turns the cache on.",
+	"ResponseCacheConfig.Header":                         "Header names the request header that opts a request in or out. Default is Cache.",
+	"ResponseCacheConfig.MaxEntries":                     "MaxEntries caps the number of responses kept by the memory store. <= 0 uses 1000.",
+	"ResponseCacheConfig.MaxSizeMB":                      "MaxSizeMB caps the total size of stored responses. <= 0 uses 256.",
+	"ResponseCacheConfig.Store":                          "Store is memory (an in-process LRU, the default) or disk.",
+	"ResponseCacheConfig.TTLSeconds":                     "TTLSeconds is how long a response is replayed. <= 0 uses 3600.",
+	"RoutingConfig.Strategy":                             "Strategy selects the credential selection strategy. Supported values: \"round-robin\" (default), \"fill-first\".",
+	"SDKConfig.APIKeys":                                  "APIKeys is a list of keys for authenticating clients to this proxy server.",
+	"SDKConfig.AccountProxyConstraint":                   "AccountProxyConstraint defines account-level proxy hard constraints. When enabled, every account entry must set its own non-empty proxy-url.",
+	"SDKConfig.EgressDeterminism":                        "EgressDeterminism defines account-level egress mapping persistence and drift detection settings.",
+	"SDKConfig.ForceModelPrefix":                         "ForceModelPrefix requires explicit model prefixes (e.g., \"teamA/gemini-3-pro-preview\") to target prefixed credentials. When false, unprefixed model requests may use prefixed credentials as well.",
+	"SDKConfig.ModelProviderRouting":                     "ModelProviderRouting defines model family -> provider pool hard-routing constraints. When enabled, each model family can only use providers explicitly listed in the allowlist.",
+	"SDKConfig.ModelVisibility":                          "ModelVisibility defines namespace-level model visibility allowlists used by request guards. Each namespace can expose only an explicit set of model IDs to clients.",
+	"SDKConfig.NonStreamKeepAliveInterval":               "NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses. <= 0 disables keep-alives. Value is in seconds.",
+	"SDKConfig.PassthroughHeaders":                       "PassthroughHeaders controls whether upstream response headers are forwarded to downstream clients. Default is false (disabled).",
+	"SDKConfig.PromptQueue":                              "PromptQueue serializes requests that belong to the same client session.",
+	"SDKConfig.ProxyURL":                                 "ProxyURL is the URL of an optional proxy server to use for outbound requests.",
+	"SDKConfig.RequestLog":                               "RequestLog enables or disables detailed request logging functionality.",
+	"SDKConfig.ResponseCache":                            "ResponseCache replays stored responses for repeated deterministic requests.",
+	"SDKConfig.Scheduler":                                "Scheduler caps concurrent upstream requests and shares the capacity fairly across client keys.",
+	"SDKConfig.Streaming":                                "Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).",
+	"SchedulerClient.APIKey":                             "APIKey is the client key as listed in api-keys.",
+	"SchedulerClient.Priority":                           "Priority is \"interactive\" or \"batch\". When set, the priority header is ignored for this key.",
+	"SchedulerClient.Weight":                             "Weight is the key's share of contended capacity relative to other keys. <= 0 counts as 1.",
+	"SchedulerConfig.BatchPromoteAfterSeconds":           "BatchPromoteAfterSeconds is how long a batch request may wait behind interactive ones before it is served in fair order. <= 0 uses 30.",
+	"SchedulerConfig.Clients":                            "Clients sets the share and priority class of individual client API keys.",
+	"SchedulerConfig.Enabled":                            "Enabled turns on concurrency caps and fair queuing. When disabled, requests are never held.",
+	"SchedulerConfig.MaxConcurrency":                     "MaxConcurrency caps requests in flight across all providers. <= 0 means no global cap.",
+	"SchedulerConfig.PriorityHeader":                     "PriorityHeader names the request header that selects the priority class (\"interactive\" or \"batch\") for client keys without a configured priority. Default is X-Request-Priority.",
+	"SchedulerConfig.ProviderConcurrency":                "ProviderConcurrency caps requests in flight per provider, keyed by provider name (e.g. claude, codex).",
+	"SecretRefsConfig.AllowFileAndExec":                  "AllowFileAndExec enables file: and exec: references. They read files and run commands on the host, so they are only resolved in the config file itself; the management API can neither add them nor turn this option on.",
+	"SecretRefsConfig.CacheTTLSeconds":                   "CacheTTLSeconds caches file: and exec: results across config reloads. 0 disables caching.",
+	"SecretRefsConfig.ExecTimeoutSeconds":                "ExecTimeoutSeconds bounds each exec: command. Defaults to 10.",
+	"SignatureCacheConfig.Backend":                       "Backend is \"memory\" (default), \"file\" to snapshot the cache to Path, or \"postgres\" to share it through the cluster database. The postgres backend requires cluster mode.",
+	"SignatureCacheConfig.MaxEntriesPerGroup":            "MaxEntriesPerGroup bounds the in-memory entries of each model group. Defaults to 10000.",
+	"SignatureCacheConfig.Path":                          "Path is the snapshot file of the file backend. Relative paths are resolved from the config file directory. Defaults to \"signature-cache.json\".",
+	"SignatureCacheConfig.SnapshotIntervalSeconds":       "SnapshotIntervalSeconds is how often the file backend writes changes. Defaults to 60.",
+	"StreamingConfig.BootstrapRetries":                   "BootstrapRetries controls how many times the server may retry a streaming request before any bytes are sent, to allow auth rotation / transient recovery. <= 0 disables bootstrap retries. Default is 0.",
+	"StreamingConfig.KeepAliveSeconds":                   "KeepAliveSeconds controls how often the server emits SSE heartbeats (\": keep-alive\\n\\n\"). <= 0 disables keep-alives. Default is 0.",
+	"TLSConfig.Cert":                                     "Cert is the path to the TLS certificate file.",
+	"TLSConfig.Enable":                                   "Enable toggles HTTPS server mode.",
+	"TLSConfig.Key":                                      "Key is the path to the TLS private key file.",
+	"UsageStorageConfig.DSN":                             "DSN is the PostgreSQL connection string. When empty, PGSTORE_DSN is used.",
+	"UsageStorageConfig.Driver":                          "Driver selects the storage backend: \"sqlite\" or \"postgres\". Empty disables durable storage.",
+	"UsageStorageConfig.Path":                            "Path is the SQLite database file. Relative paths are resolved from the config file directory. Defaults to \"usage.db\".",
+	"UsageStorageConfig.RetentionDays":                   "RetentionDays deletes records older than this many days. <= 0 keeps records forever.",
+	"UsageStorageConfig.Table":                           "Table overrides the table name used for usage records. Defaults to \"usage_records\".",
+	"ValidationIssue.Path":                               "Path locates the offending value, e.g. \"ampcode.model-mappings[2].from\". It is empty for document-level problems.",
+	"ValidationReport.Config":                            "Config is the candidate as the server would load it. It is nil when loading failed.",
+	"VertexCompatKey.APIKey":                             "APIKey is the authentication key for accessing the Vertex-compatible API. Maps to the x-goog-api-key header.",
+	"VertexCompatKey.BaseURL":                            "BaseURL is the base URL for the Vertex-compatible API endpoint. The executor will append \"/v1/publishers/google/models/{model}:action\" to this. Example: \"https://zenmux.ai/api\" becomes \"https://zenmux.ai/api/v1/publishers/google/models/...\"",
+	"VertexCompatKey.Headers":                            "Headers optionally adds extra HTTP headers for requests sent with this key. Commonly used for cookies, user-agent, and other authentication headers.",
+	"VertexCompatKey.Models":                             "Models defines the model configurations including aliases for routing.",
+	"VertexCompatKey.Prefix":                             "Prefix optionally namespaces model aliases for this credential (e.g., \"teamA/vertex-pro\").",
+	"VertexCompatKey.Priority":                           "Priority controls selection preference when multiple credentials match. Higher values are preferred; defaults to 0.",
+	"VertexCompatKey.ProxyURL":                           "ProxyURL optionally overrides the global proxy for this API key.",
+	"VertexCompatModel.Alias":                            "Alias is the model name alias that clients will use to reference this model.",
+	"VertexCompatModel.Name":                             "Name is the actual model name used by the external provider.",
+	"WebsocketRelayConfig.AllowedOrigins":                "AllowedOrigins lists the browser origins accepted on /v1/ws. Empty accepts any origin.",
+	"WebsocketRelayConfig.Keys":                          "Keys are per-client credentials. A session opened with a key joins as provider aistudio-<name>, so the client keeps its identity across reconnects.",
+	"WebsocketRelayConfig.MaxSessions":                   "MaxSessions caps concurrently open relay sessions. 0 means no cap.",
+	"WebsocketRelayConfig.ResumeGraceSeconds":            "ResumeGraceSeconds is how long a dropped session waits for its client to reconnect and resume in-flight requests. Defaults to 30; negative fails them as soon as the connection drops.",
+	"WebsocketRelayConfig.TicketSecret":                  "TicketSecret signs the join tickets issued by the management API. Defaults to a random per-process secret; set it when several replicas must accept each other's tickets.",
+	"WebsocketRelayConfig.TicketTTLSeconds":              "TicketTTLSeconds is how long a join ticket stays valid. Defaults to 60.",
+	"WebsocketRelayConfig.Token":                         "Token is a shared secret clients send in the X-Relay-Token header or the token query parameter. Setting Token or Keys makes a credential mandatory on /v1/ws.",
+	"WebsocketRelayKey.Key":                              "Key is the credential the client presents like the relay token.",
+	"WebsocketRelayKey.Name":                             "Name identifies the client; its sessions join as aistudio-<name>.",
+}
+
+// handlerDocs holds the doc comments of management handler methods, keyed by name.
+var handlerDocs = map[string]string{
+	"APICall":                             "APICall makes a generic HTTP request on behalf of the management API caller. It is protected by the management middleware.\n\nEndpoint:\n\nPOST /v0/management/api-call\n\nAuthentication:\n\nSame as other management APIs (requires a management key and remote-management rules). You can provide the key via: - Authorization: Bearer <key> - X-Management-Key: <key>\n\nRequest JSON: - auth_index / authIndex / AuthIndex (optional): The credential \"auth_index\" from GET /v0/management/auth-files (or other endpoints returning it). If omitted or not found, credential-specific proxy/token substitution is skipped. - method (required): HTTP method, e.g. GET, POST, PUT, PATCH, DELETE. - url (required): Absolute URL including scheme and host, e.g. \"https://api.example.com/v1/ping\". - header (optional): Request headers map. Supports magic variable \"$TOKEN$\" which is replaced using the selected credential: 1) metadata.access_token 2) attributes.api_key 3) metadata.token / metadata.id_token / metadata.cookie Example: {\"Authorization\":\"Bearer $TOKEN$\"}. Note: if you need to override the HTTP Host header, set header[\"Host\"]. - data (optional): Raw request body as string (useful for POST/PUT/PATCH).\n\nProxy selection (highest priority first): 1. Selected credential proxy_url 2. Global config proxy-url 3. Direct connect (environment proxies are not used)\n\nResponse JSON (returned with HTTP 200 when the APICall itself succeeds): - status_code: Upstream HTTP status code. - header: Upstream response headers. - body: Upstream response body as string.\n\nExample:\n\ncurl -sS -X POST \"http://127.0.0.1:8317/v0/management/api-call\" \\ -H \"Authorization: Bearer <MANAGEMENT_KEY>\" \\ -H \"Content-Type: application/json\" \\ -d '{\"auth_index\":\"<AUTH_INDEX>\",\"method\":\"GET\",\"url\":\"https://api.example.com/v1/ping\",\"header\":{\"Authorization\":\"Bearer $TOKEN$\"}}'\n\ncurl -sS -X POST \"http://127.0.0.1:8317/v0/management/api-call\" \\ -H \"Authorization: Bearer 831227\" \\ -H \"Content-Type: application/json\" \\ -d '{\"auth_index\":\"<AUTH_INDEX>\",\"method\":\"POST\",\"url\":\"https://api.example.com/v1/fetchAvailableModels\",\"header\":{\"Authorization\":\"Bearer $TOKEN$\",\"Content-Type\":\"application/json\",\"User-Agent\":\"cliproxyapi\"},\"data\":\"{}\"}'",
+	"CreateWebsocketRelayTicket":          "CreateWebsocketRelayTicket issues a single-use join ticket for /v1/ws. The client passes it in the ticket query parameter or the X-Relay-Ticket header. A name binds the session to the provider aistudio-<name>; ttl_seconds defaults to ws-relay.ticket-ttl-seconds.",
+	"DeleteAmpModelMappings":              "DeleteAmpModelMappings removes specified model mappings by \"from\" field.",
+	"DeleteAmpUpstreamAPIKey":             "DeleteAmpUpstreamAPIKey clears the ampcode upstream API key.",
+	"DeleteAmpUpstreamAPIKeys":            "DeleteAmpUpstreamAPIKeys removes specified upstream API keys entries. Body must be JSON: {\"value\": [\"<upstream-api-key>\", ...]}. If \"value\" is an empty array, clears all entries. If JSON is invalid or \"value\" is missing/null, returns 400 and does not persist any change.",
+	"DeleteAmpUpstreamURL":                "DeleteAmpUpstreamURL clears the ampcode upstream URL.",
+	"DeleteAuthFile":                      "Delete auth files: single by name or all",
+	"DeleteLogs":                          "DeleteLogs removes all rotated log files and truncates the active log.",
+	"DeleteSignatureCache":                "DeleteSignatureCache clears the signatures of one model group, or all of them without group, in memory and in the configured backend.",
+	"DiffConfigVersions":                  "DiffConfigVersions compares two snapshots. \"to\" defaults to the current config file, which is also addressable explicitly as \"current\".",
+	"DownloadAuthFile":                    "Download single auth file by name",
+	"DownloadRequestErrorLog":             "DownloadRequestErrorLog downloads a specific error request log file by name.",
+	"ExportUsageStatistics":               "ExportUsageStatistics returns a complete usage snapshot for backup/migration.",
+	"GetAPIKeys":                          "api-keys",
+	"GetAmpCode":                          "GetAmpCode returns the complete ampcode configuration.",
+	"GetAmpForceModelMappings":            "GetAmpForceModelMappings returns whether model mappings are forced.",
+	"GetAmpModelMappings":                 "GetAmpModelMappings returns the ampcode model mappings.",
+	"GetAmpRestrictManagementToLocalhost": "GetAmpRestrictManagementToLocalhost returns the localhost restriction setting.",
+	"GetAmpUpstreamAPIKey":                "GetAmpUpstreamAPIKey returns the ampcode upstream API key.",
+	"GetAmpUpstreamAPIKeys":               "GetAmpUpstreamAPIKeys returns the ampcode upstream API keys mapping.",
+	"GetAmpUpstreamURL":                   "GetAmpUpstreamURL returns the ampcode upstream URL.",
+	"GetAuthEncryptionStatus":             "GetAuthEncryptionStatus reports whether auth files are encrypted at rest and how many files are still plaintext or sealed with a previous master key.",
+	"GetAuthFileModels":                   "GetAuthFileModels returns the models supported by a specific auth file",
+	"GetClaudeKeys":                       "claude-api-key: []ClaudeKey",
+	"GetCodexKeys":                        "codex-api-key: []CodexKey",
+	"GetConfigSchema":                     "GetConfigSchema returns the JSON Schema of config.yaml for editors and form generation.",
+	"GetConfigVersion":                    "GetConfigVersion returns the metadata and raw YAML of one snapshot.",
+	"GetConfigYAML":                       "GetConfigYAML returns the raw config.yaml file bytes without re-encoding. It preserves comments and original formatting/styles.\n\n?view=merged returns the document after includes, the overlay and ${VAR} interpolation, ?view=files lists every source file with its content, and ?file=<path> returns one source file, addressed by its path relative to the config directory.",
+	"GetDebug":                            "Debug",
+	"GetEgressMapping":                    "GetEgressMapping returns a read-only observability snapshot for account egress mappings.",
+	"GetErrorLogsMaxFiles":                "ErrorLogsMaxFiles",
+	"GetForceModelPrefix":                 "ForceModelPrefix",
+	"GetGeminiKeys":                       "gemini-api-key: []GeminiKey",
+	"GetLatestVersion":                    "GetLatestVersion returns the latest release version from GitHub without downloading assets.",
+	"GetLoggingToFile":                    "UsageStatisticsEnabled",
+	"GetLogs":                             "GetLogs returns log lines with optional incremental loading.",
+	"GetLogsMaxTotalSizeMB":               "LogsMaxTotalSizeMB",
+	"GetMaxRetryInterval":                 "Max retry interval",
+	"GetModelVisibility":                  "model-visibility: {enabled, namespaces, host-namespaces}",
+	"GetNotificationDeadLetters":          "GetNotificationDeadLetters returns recent notifications that could not be delivered.",
+	"GetNotificationStatus":               "GetNotificationStatus reports configured webhook targets and delivery counters.",
+	"GetOAuthExcludedModels":              "oauth-excluded-models: map[string][]string",
+	"GetOAuthModelAlias":                  "oauth-model-alias: map[string][]OAuthModelAlias",
+	"GetOpenAICompat":                     "openai-compatibility: []OpenAICompatibility",
+	"GetPromptQueueEvents":                "GetPromptQueueEvents returns queue events after since_seq, optionally for one session_key.",
+	"GetPromptQueueMetrics":               "GetPromptQueueMetrics returns prompt queue counters and the current depth of each session.",
+	"GetPromptQueueSubmissions":           "GetPromptQueueSubmissions lists queued, running and finished submissions, newest first, optionally filtered by status and session_key.",
+	"GetProxyURL":                         "Proxy URL",
+	"GetRequestErrorLogs":                 "GetRequestErrorLogs lists error request log files when RequestLog is disabled. It returns an empty list when RequestLog is enabled.",
+	"GetRequestHistory":                   "GetRequestHistory returns recent request details with pagination. Query params: - limit: Max number of requests to return (default 100, max 1000) - offset: Number of requests to skip (default 0) - model: Filter by model name (optional) - provider: Filter by provider (optional) - success: Filter by success status (optional, \"true\" or \"false\")\n\nWhen durable usage storage is enabled, history is served from the usage store and additionally supports from/to time range filters.",
+	"GetRequestLog":                       "Request log",
+	"GetRequestLogByID":                   "GetRequestLogByID finds and downloads a request log file by its request ID. The ID is matched against the suffix of log file names (format: *-{requestID}.log).",
+	"GetRequestRetry":                     "Request retry",
+	"GetRoutingStrategy":                  "RoutingStrategy",
+	"GetSchedulerStats":                   "GetSchedulerStats returns the fair-share scheduler's in-flight and waiting counts, per provider and per hashed client key.",
+	"GetSignatureCache":                   "GetSignatureCache returns the thinking-signature cache counters and the entry count of each model group.",
+	"GetSignatureCacheEntries":            "GetSignatureCacheEntries lists cached signatures, most recently used first, optionally for one model group. Signatures are shortened to a prefix.",
+	"GetStaticModelDefinitions":           "GetStaticModelDefinitions returns static model metadata for a given channel. Channel is provided via path param (:channel) or query param (?channel=...).",
+	"GetSwitchProject":                    "Quota exceeded toggles",
+	"GetTranslatorRoute":                  "GetTranslatorRoute shows the formats a request passes through from the client format \"from\" to the upstream format \"to\". Responses travel the same path in reverse.",
+	"GetUsageStatistics":                  "GetUsageStatistics returns the in-memory request statistics snapshot.",
+	"GetUsageStatisticsEnabled":           "UsageStatisticsEnabled",
+	"GetUsageStorageStatus":               "GetUsageStorageStatus reports whether durable usage storage is enabled and how it is configured.",
+	"GetVertexCompatKeys":                 "vertex-api-key: []VertexCompatKey",
+	"GetWebsocketAuth":                    "Websocket auth",
+	"GetWebsocketRelayAudit":              "GetWebsocketRelayAudit returns recent relay session joins, resumes, leaves and rejected connection attempts, oldest first.",
+	"GetWebsocketRelaySessions":           "GetWebsocketRelaySessions lists the websocket relay sessions with their health and first-response latency, including detached sessions still inside the resume window.",
+	"ImportUsageStatistics":               "ImportUsageStatistics merges a previously exported usage snapshot into memory.",
+	"ImportVertexCredential":              "ImportVertexCredential handles uploading a Vertex service account JSON and saving it as an auth record.",
+	"ListConfigVersions":                  "ListConfigVersions returns the stored config snapshots, newest first.",
+	"Middleware":                          "Middleware enforces access control for management endpoints. All requests (local and remote) require a valid management key. Additionally, remote access requires allow-remote-management=true.",
+	"PatchAmpModelMappings":               "PatchAmpModelMappings adds or updates model mappings.",
+	"PatchAmpUpstreamAPIKeys":             "PatchAmpUpstreamAPIKeys adds or updates upstream API keys entries. Matching is done by upstream-api-key value.",
+	"PatchAuthFileFields":                 "PatchAuthFileFields updates editable fields (prefix, proxy_url, priority) of an auth file.",
+	"PatchAuthFileStatus":                 "PatchAuthFileStatus toggles the disabled state of an auth file",
+	"PostConfigVerb":                      "PostConfigVerb serves custom-verb routes such as POST /config:validate. Gin has no literal-colon routes, so the route is registered as a wildcard that captures \":<verb>\".",
+	"PruneUsage":                          "PruneUsage deletes durable usage records older than the requested cutoff. The body accepts either \"before\" (RFC3339 or unix seconds) or \"older_than_days\".",
+	"PutAmpForceModelMappings":            "PutAmpForceModelMappings updates the force model mappings setting.",
+	"PutAmpModelMappings":                 "PutAmpModelMappings replaces all ampcode model mappings.",
+	"PutAmpRestrictManagementToLocalhost": "PutAmpRestrictManagementToLocalhost updates the localhost restriction setting.",
+	"PutAmpUpstreamAPIKey":                "PutAmpUpstreamAPIKey updates the ampcode upstream API key.",
+	"PutAmpUpstreamAPIKeys":               "PutAmpUpstreamAPIKeys replaces all ampcode upstream API keys mappings.",
+	"PutAmpUpstreamURL":                   "PutAmpUpstreamURL updates the ampcode upstream URL.",
+	"QueryUsage":                          "QueryUsage aggregates durable usage records. Query params: - from, to: Time range (RFC3339 or unix seconds); to is exclusive - group_by: Comma-separated dimensions (key, model, provider, auth, source) - granularity: \"hour\" or \"day\" rollups (optional) - provider, model, api_key, auth_index, source: Equality filters (optional) - limit: Max number of rows to return (default 1000, max 10000)",
+	"ReencryptAuthFiles":                  "ReencryptAuthFiles rewrites every stale auth file with the current master key and pushes the changed files to the token store. Rotate the master key by moving the old key to AUTH_ENCRYPTION_PREVIOUS_KEYS, restarting, and calling this endpoint.",
+	"ReplayRequestLog":                    "ReplayRequestLog re-issues a captured request log and returns a structured diff between the recorded and the new response. Body: - file or request_id: Log file name in the log directory, or its request ID - target: Base URL to replay against (default: this proxy) - model: Optional model override - api_key: Client API key to send; captured credentials are never replayed. Required when target is not this proxy - headers: Extra headers for the replayed request - ignore_paths: JSON paths excluded from the diff - timeout_seconds: Replay timeout (default 300)",
+	"RollbackConfigVersion":               "RollbackConfigVersion validates a snapshot and writes it back to config.yaml. The file watcher picks up the write and hot reloads the server like any other config change.",
+	"SearchRequestLogs":                   "SearchRequestLogs searches the request log index. Query params: - from, to: Time range (RFC3339, unix seconds or YYYY-MM-DD); to is exclusive - status: Exact status code (\"429\") or class (\"4xx\") - model, provider, auth_index, request_id, method: Equality filters - client_key: Client API key or its index hash - url: Substring match on the request URL - q: Case-insensitive full-text match over the log file contents - offset, limit: Pagination (default limit 100, max 1000)",
+	"SendTestNotification":                "SendTestNotification delivers a test event synchronously and reports the per-target outcome. The optional body selects a single \"target\" by name and overrides the \"message\".",
+	"SetAuthManager":                      "SetAuthManager updates the auth manager reference used by management endpoints.",
+	"SetConfig":                           "SetConfig updates the in-memory config reference when the server hot-reloads.",
+	"SetLocalPassword":                    "SetLocalPassword configures the runtime-local password accepted for localhost requests.",
+	"SetLogDirectory":                     "SetLogDirectory updates the directory where main.log should be looked up.",
+	"SetUsageStatistics":                  "SetUsageStatistics allows replacing the usage statistics reference.",
+	"SetWebsocketRelay":                   "SetWebsocketRelay supplies the websocket relay hooks used by the ws-relay endpoints.",
+	"StreamPromptQueueEvents":             "StreamPromptQueueEvents streams queue events as Server-Sent Events. Events after since_seq or the Last-Event-ID header are replayed first; session_key limits the stream to one session.\n\nEvent types: submission_queued, queue_overloaded, submission_started, submission_succeeded, submission_failed.",
+	"StreamUsageEvents":                   "StreamUsageEvents provides a Server-Sent Events stream for real-time usage monitoring. Clients can subscribe to receive live request events as they occur.\n\nEvent types: - request: A normal API request was processed - quota_exceeded: An account's quota was exceeded - error: An error occurred during request processing",
+	"UploadAuthFile":                      "Upload auth file: multipart or raw JSON with ?name=",
+	"ValidateConfigYAML":                  "ValidateConfigYAML runs full semantic validation of a candidate config.yaml and previews how it differs from the running config. Nothing is written or reloaded.",
+}
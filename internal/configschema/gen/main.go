@@ -0,0 +1,184 @@
+// Command gen extracts the doc comments of the config types and management handlers into
+// docs_gen.go so the JSON Schema and OpenAPI document can carry them as descriptions. Run
+// it with go generate from the configschema package directory.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	configDir     = "../config"
+	managementDir = "../api/handlers/management"
+	outputFile    = "docs_gen.go"
+)
+
+type docSet struct {
+	types    map[string]string
+	fields   map[string]string
+	handlers map[string]string
+}
+
+func main() {
+	docs, err := extractAll(".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "configschema gen: %v\n", err)
+		os.Exit(1)
+	}
+	out, err := render(docs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "configschema gen: %v\n", err)
+		os.Exit(1)
+	}
+	if err = os.WriteFile(outputFile, out, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "configschema gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// extractAll reads both source packages relative to base, the configschema directory.
+func extractAll(base string) (*docSet, error) {
+	typeDocs, fieldDocs, err := extractDocs(filepath.Join(base, configDir))
+	if err != nil {
+		return nil, err
+	}
+	handlerDocs, err := extractHandlerDocs(filepath.Join(base, managementDir))
+	if err != nil {
+		return nil, err
+	}
+	return &docSet{types: typeDocs, fields: fieldDocs, handlers: handlerDocs}, nil
+}
+
+func parseDir(dir string) (map[string]*ast.Package, error) {
+	fset := token.NewFileSet()
+	return parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+}
+
+// extractHandlerDocs returns the doc comments of exported *Handler methods keyed by name.
+func extractHandlerDocs(dir string) (map[string]string, error) {
+	pkgs, err := parseDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	docs := make(map[string]string)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 || !fn.Name.IsExported() {
+					continue
+				}
+				star, isPtr := fn.Recv.List[0].Type.(*ast.StarExpr)
+				if !isPtr {
+					continue
+				}
+				if ident, isIdent := star.X.(*ast.Ident); !isIdent || ident.Name != "Handler" {
+					continue
+				}
+				if text := commentText(fn.Doc); text != "" {
+					docs[fn.Name.Name] = text
+				}
+			}
+		}
+	}
+	return docs, nil
+}
+
+// extractDocs returns type doc comments keyed by type name and field doc comments keyed
+// by "Type.Field" for every struct type declared in dir, test files excluded.
+func extractDocs(dir string) (map[string]string, map[string]string, error) {
+	pkgs, err := parseDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	typeDocs := make(map[string]string)
+	fieldDocs := make(map[string]string)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					structType, isStruct := typeSpec.Type.(*ast.StructType)
+					if !isStruct || !typeSpec.Name.IsExported() {
+						continue
+					}
+					doc := typeSpec.Doc
+					if doc == nil && len(gen.Specs) == 1 {
+						doc = gen.Doc
+					}
+					if text := commentText(doc); text != "" {
+						typeDocs[typeSpec.Name.Name] = text
+					}
+					for _, field := range structType.Fields.List {
+						text := commentText(field.Doc)
+						if text == "" {
+							text = commentText(field.Comment)
+						}
+						if text == "" {
+							continue
+						}
+						for _, name := range field.Names {
+							if name.IsExported() {
+								fieldDocs[typeSpec.Name.Name+"."+name.Name] = text
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return typeDocs, fieldDocs, nil
+}
+
+// commentText joins a comment into paragraphs of single-spaced text.
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	paragraphs := strings.Split(strings.TrimSpace(group.Text()), "\n\n")
+	for i, paragraph := range paragraphs {
+		paragraphs[i] = strings.Join(strings.Fields(paragraph), " ")
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+func render(docs *docSet) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by go run ./gen; DO NOT EDIT.\n\npackage configschema\n\n")
+	buf.WriteString("// typeDocs holds the doc comments of the config types, keyed by type name.\n")
+	writeMap(&buf, "typeDocs", docs.types)
+	buf.WriteString("\n// fieldDocs holds the doc comments of config struct fields, keyed by \"Type.Field\".\n")
+	writeMap(&buf, "fieldDocs", docs.fields)
+	buf.WriteString("\n// handlerDocs holds the doc comments of management handler methods, keyed by name.\n")
+	writeMap(&buf, "handlerDocs", docs.handlers)
+	return format.Source(buf.Bytes())
+}
+
+func writeMap(buf *bytes.Buffer, name string, entries map[string]string) {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(buf, "var %s = map[string]string{\n", name)
+	for _, key := range keys {
+		fmt.Fprintf(buf, "\t%s: %s,\n", strconv.Quote(key), strconv.Quote(entries[key]))
+	}
+	buf.WriteString("}\n")
+}
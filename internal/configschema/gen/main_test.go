@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDocsGenUpToDate(t *testing.T) {
+	docs, err := extractAll("..")
+	if err != nil {
+		t.Fatalf("extractAll: %v", err)
+	}
+	want, err := render(docs)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", outputFile))
+	if err != nil {
+		t.Fatalf("read %s: %v", outputFile, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s is stale; run go generate ./internal/configschema", outputFile)
+	}
+}
@@ -0,0 +1,325 @@
+package configschema
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// ManagementBasePath is the prefix shared by all management routes.
+const ManagementBasePath = "/v0/management"
+
+// Route is one registered management route, as reported by the router.
+type Route struct {
+	Method string
+	// Path is the router path, e.g. "/v0/management/config/versions/:id".
+	Path string
+	// Handler is the fully qualified handler function name.
+	Handler string
+}
+
+// routeExpansions lists the concrete paths served by wildcard routes that stand in for
+// literal-colon custom verbs.
+var routeExpansions = map[string][]string{
+	ManagementBasePath + "/config:verb": {ManagementBasePath + "/config:validate"},
+}
+
+// operationOverrides describes payloads the route shape cannot reveal, keyed by
+// "METHOD path" in OpenAPI path syntax.
+var operationOverrides = map[string]func(*openAPIBuilder) map[string]any{
+	"GET /config": func(b *openAPIBuilder) map[string]any {
+		return map[string]any{"responses": jsonResponse(b.ref(reflect.TypeOf(config.Config{})))}
+	},
+	"GET /config.yaml": func(*openAPIBuilder) map[string]any {
+		return map[string]any{
+			"parameters": []any{
+				queryParam("view", "merged returns the composed document; files lists every source file.", map[string]any{"type": "string", "enum": []string{"merged", "files"}}),
+				queryParam("file", "Returns one source file, relative to the config directory.", map[string]any{"type": "string"}),
+			},
+			"responses": yamlResponse(),
+		}
+	},
+	"PUT /config.yaml": func(*openAPIBuilder) map[string]any {
+		return map[string]any{"requestBody": yamlBody()}
+	},
+	"POST /config:validate": func(b *openAPIBuilder) map[string]any {
+		issues := map[string]any{"type": "array", "items": b.ref(reflect.TypeOf(config.ValidationIssue{}))}
+		return map[string]any{
+			"requestBody": yamlBody(),
+			"responses": jsonResponse(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"valid":    map[string]any{"type": "boolean"},
+					"errors":   issues,
+					"warnings": issues,
+					"changes":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+					"diff":     map[string]any{"type": "string"},
+				},
+			}),
+		}
+	},
+}
+
+// ManagementOpenAPI builds an OpenAPI 3.1 document for the given management routes.
+// Request and response bodies are described where they follow from the config types;
+// other operations document a generic JSON object.
+func ManagementOpenAPI(routes []Route, version string) map[string]any {
+	b := &openAPIBuilder{gen: newGenerator("json", "#/components/schemas/"), operationIDs: make(map[string]int)}
+	paths := make(map[string]any)
+
+	sorted := append([]Route(nil), routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+	for _, route := range sorted {
+		if !strings.HasPrefix(route.Path, ManagementBasePath) {
+			continue
+		}
+		concrete := routeExpansions[route.Path]
+		if concrete == nil {
+			concrete = []string{route.Path}
+		}
+		for _, path := range concrete {
+			openAPIPath := toOpenAPIPath(path)
+			item, _ := paths[openAPIPath].(map[string]any)
+			if item == nil {
+				item = make(map[string]any)
+				paths[openAPIPath] = item
+			}
+			item[strings.ToLower(route.Method)] = b.operation(route, openAPIPath)
+		}
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "CLIProxyAPI Management API",
+			"version": version,
+		},
+		"servers": []any{map[string]any{"url": "/"}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": b.gen.defs,
+			"securitySchemes": map[string]any{
+				"bearerAuth":       map[string]any{"type": "http", "scheme": "bearer"},
+				"managementKeyHdr": map[string]any{"type": "apiKey", "in": "header", "name": "X-Management-Key"},
+			},
+		},
+		"security": []any{
+			map[string]any{"bearerAuth": []any{}},
+			map[string]any{"managementKeyHdr": []any{}},
+		},
+	}
+}
+
+type openAPIBuilder struct {
+	gen          *generator
+	operationIDs map[string]int
+}
+
+func (b *openAPIBuilder) ref(t reflect.Type) map[string]any {
+	return b.gen.schemaFor(t)
+}
+
+var pathParamPattern = regexp.MustCompile(`/[:*]([A-Za-z0-9_]+)`)
+
+func toOpenAPIPath(path string) string {
+	return pathParamPattern.ReplaceAllString(path, "/{$1}")
+}
+
+func (b *openAPIBuilder) operation(route Route, openAPIPath string) map[string]any {
+	handler := handlerName(route.Handler)
+	rel := strings.TrimPrefix(openAPIPath, ManagementBasePath)
+
+	op := map[string]any{
+		"operationId": b.operationID(handler, route.Method),
+		"tags":        []string{operationTag(rel)},
+		"responses": map[string]any{
+			"200": map[string]any{
+				"description": "OK",
+				"content":     map[string]any{"application/json": map[string]any{"schema": map[string]any{"type": "object"}}},
+			},
+		},
+	}
+	if doc := handlerDocs[handler]; doc != "" {
+		op["summary"] = firstSentence(doc)
+		op["description"] = doc
+	}
+	var params []any
+	for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+		params = append(params, map[string]any{
+			"name": match[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"},
+		})
+	}
+
+	b.describeConfigField(op, route.Method, rel)
+	if override := operationOverrides[route.Method+" "+rel]; override != nil {
+		for key, value := range override(b) {
+			if key == "parameters" {
+				params = append(params, value.([]any)...)
+				continue
+			}
+			op[key] = value
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	switch route.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		if _, ok := op["requestBody"]; !ok {
+			op["requestBody"] = map[string]any{
+				"content": map[string]any{"application/json": map[string]any{"schema": map[string]any{"type": "object"}}},
+			}
+		}
+	}
+	return op
+}
+
+// describeConfigField types the routes that read or replace a single config field, such as
+// GET /debug or PUT /gemini-api-key. GET responses wrap the value under the last path
+// segment, PUT bodies take {"value": ...} for scalars and a plain array for lists.
+func (b *openAPIBuilder) describeConfigField(op map[string]any, method, rel string) {
+	segments := strings.Split(strings.Trim(rel, "/"), "/")
+	field, ok := configFieldByYAMLPath(reflect.TypeOf(config.Config{}), segments)
+	if !ok {
+		return
+	}
+	schema := b.gen.schemaFor(field)
+	switch method {
+	case http.MethodGet:
+		op["responses"] = jsonResponse(map[string]any{
+			"type":       "object",
+			"properties": map[string]any{segments[len(segments)-1]: schema},
+		})
+	case http.MethodPut:
+		kind := field.Kind()
+		if kind == reflect.Slice || kind == reflect.Array {
+			op["requestBody"] = jsonBody(schema)
+			return
+		}
+		if kind == reflect.Map || kind == reflect.Struct {
+			return
+		}
+		op["requestBody"] = jsonBody(map[string]any{
+			"type":       "object",
+			"required":   []string{"value"},
+			"properties": map[string]any{"value": schema},
+		})
+	}
+}
+
+// configFieldByYAMLPath resolves a sequence of yaml keys to a field type.
+func configFieldByYAMLPath(t reflect.Type, path []string) (reflect.Type, bool) {
+	if len(path) == 0 {
+		return nil, false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if strings.Contains(","+opts+",", ",inline,") {
+			if found, ok := configFieldByYAMLPath(field.Type, path); ok {
+				return found, true
+			}
+			continue
+		}
+		if name != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return field.Type, true
+		}
+		return configFieldByYAMLPath(field.Type, path[1:])
+	}
+	return nil, false
+}
+
+func (b *openAPIBuilder) operationID(handler, method string) string {
+	id := handler
+	if id == "" {
+		id = strings.ToLower(method)
+	}
+	b.operationIDs[id]++
+	if n := b.operationIDs[id]; n > 1 {
+		// PATCH often shares the PUT handler; keep operation IDs unique.
+		return id + strings.ToUpper(method[:1]) + strings.ToLower(method[1:])
+	}
+	return id
+}
+
+// handlerName reduces "pkg.(*Handler).GetConfig-fm" to "GetConfig".
+func handlerName(fullName string) string {
+	name := fullName[strings.LastIndex(fullName, ".")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
+
+func operationTag(rel string) string {
+	rel = strings.TrimPrefix(rel, "/")
+	if idx := strings.IndexAny(rel, "/.:"); idx >= 0 {
+		rel = rel[:idx]
+	}
+	if rel == "" {
+		return "management"
+	}
+	return rel
+}
+
+func firstSentence(doc string) string {
+	doc, _, _ = strings.Cut(doc, "\n")
+	if idx := strings.Index(doc, ". "); idx >= 0 {
+		return doc[:idx+1]
+	}
+	return doc
+}
+
+func jsonResponse(schema map[string]any) map[string]any {
+	return map[string]any{
+		"200": map[string]any{
+			"description": "OK",
+			"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+		},
+	}
+}
+
+func jsonBody(schema map[string]any) map[string]any {
+	return map[string]any{
+		"required": true,
+		"content":  map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+func yamlBody() map[string]any {
+	return map[string]any{
+		"required": true,
+		"content":  map[string]any{"application/yaml": map[string]any{"schema": map[string]any{"type": "string"}}},
+	}
+}
+
+func yamlResponse() map[string]any {
+	return map[string]any{
+		"200": map[string]any{
+			"description": "OK",
+			"content":     map[string]any{"application/yaml": map[string]any{"schema": map[string]any{"type": "string"}}},
+		},
+	}
+}
+
+func queryParam(name, description string, schema map[string]any) map[string]any {
+	return map[string]any{"name": name, "in": "query", "description": description, "schema": schema}
+}
@@ -0,0 +1,174 @@
+// Package configschema derives a JSON Schema for config.yaml from the Go config types and
+// an OpenAPI document for the management API. Field descriptions come from the doc
+// comments of internal/config, extracted into docs_gen.go by go generate.
+package configschema
+
+//go:generate go run ./gen
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// SchemaID identifies the config schema. Editors match it through yaml-language-server
+// modelines or their schema settings.
+const SchemaID = "https://github.com/router-for-me/CLIProxyAPI/config.schema.json"
+
+// fieldOverrides adds constraints that the Go types cannot express, keyed by "Type.Field".
+var fieldOverrides = map[string]map[string]any{
+	"RoutingConfig.Strategy":       {"enum": []string{"", "round-robin", "roundrobin", "rr", "fill-first", "fillfirst", "ff"}},
+	"UsageStorageConfig.Driver":    {"enum": []string{"", "sqlite", "sqlite3", "postgres", "postgresql", "pg", "pgx"}},
+	"ClusterConfig.Driver":         {"enum": []string{"", "sqlite", "sqlite3", "postgres", "postgresql", "pg", "pgx"}},
+	"CloakConfig.Mode":             {"enum": []string{"", "auto", "always", "never"}},
+	"ClaudePromptCaching.Mode":     {"enum": []string{"", "missing", "auto", "off"}},
+	"SchedulerClient.Priority":     {"enum": []string{"", "interactive", "batch"}},
+	"HTTPProvider.Format":          {"enum": []string{"", "openai", "claude", "gemini"}},
+	"HTTPStreamMapping.Mode":       {"enum": []string{"", "sse", "ndjson"}},
+	"ResponseCacheConfig.Store":    {"enum": []string{"", "memory", "disk"}},
+	"SignatureCacheConfig.Backend": {"enum": []string{"", "memory", "file", "postgres"}},
+	"Config.Port":                  {"minimum": 0, "maximum": 65535},
+}
+
+// ConfigSchema returns the JSON Schema (draft 2020-12) of config.yaml.
+func ConfigSchema() map[string]any {
+	gen := newGenerator("yaml", "#/$defs/")
+	root := gen.structSchema(reflect.TypeOf(config.Config{}))
+	properties := root["properties"].(map[string]any)
+	properties["include"] = map[string]any{
+		"description": "Files merged underneath this file: a path or a list of paths and globs, relative to this file.",
+		"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = SchemaID
+	root["title"] = "CLIProxyAPI config.yaml"
+	root["$defs"] = gen.defs
+	return root
+}
+
+// ConfigSchemaJSON returns ConfigSchema encoded as indented JSON.
+func ConfigSchemaJSON() ([]byte, error) {
+	return json.MarshalIndent(ConfigSchema(), "", "  ")
+}
+
+// generator converts Go types to JSON Schema. Named struct types become shared
+// definitions so that recursive and repeated types are described once.
+type generator struct {
+	tag     string
+	refBase string
+	defs    map[string]any
+}
+
+func newGenerator(tag, refBase string) *generator {
+	return &generator{tag: tag, refBase: refBase, defs: make(map[string]any)}
+}
+
+func (g *generator) schemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return g.refFor(t)
+	default:
+		// Interfaces such as payload params accept any value.
+		return map[string]any{}
+	}
+}
+
+// refFor returns a reference to the definition of a named struct type, generating the
+// definition on first use.
+func (g *generator) refFor(t reflect.Type) map[string]any {
+	name := t.Name()
+	if name == "" {
+		return g.structSchema(t)
+	}
+	if _, ok := g.defs[name]; !ok {
+		g.defs[name] = map[string]any{} // placeholder that stops recursion
+		g.defs[name] = g.structSchema(t)
+	}
+	return map[string]any{"$ref": g.refBase + name}
+}
+
+func (g *generator) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	g.collectFields(t, properties)
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if doc := typeDocs[t.Name()]; doc != "" {
+		schema["description"] = doc
+	}
+	return schema
+}
+
+// collectFields adds the properties of t, flattening inline and embedded structs the way
+// the yaml and json encoders do.
+func (g *generator) collectFields(t reflect.Type, properties map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get(g.tag), ",")
+		if name == "-" {
+			continue
+		}
+		inline := strings.Contains(","+opts+",", ",inline,") || (field.Anonymous && name == "")
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if inline && fieldType.Kind() == reflect.Struct {
+			g.collectFields(fieldType, properties)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+			if g.tag == "yaml" {
+				name = strings.ToLower(name)
+			}
+		}
+		key := t.Name() + "." + field.Name
+		schema := g.schemaFor(field.Type)
+		if doc := fieldDocs[key]; doc != "" || len(fieldOverrides[key]) > 0 {
+			schema = withExtras(schema, doc, fieldOverrides[key])
+		}
+		properties[name] = schema
+	}
+}
+
+// withExtras attaches a description and overrides. References cannot carry siblings in
+// every draft, so they are wrapped in allOf.
+func withExtras(schema map[string]any, description string, overrides map[string]any) map[string]any {
+	if _, isRef := schema["$ref"]; isRef {
+		schema = map[string]any{"allOf": []any{schema}}
+	}
+	if description != "" {
+		schema["description"] = description
+	}
+	for key, value := range overrides {
+		schema[key] = value
+	}
+	return schema
+}
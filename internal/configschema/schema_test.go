@@ -0,0 +1,96 @@
+package configschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigSchema_DescribesExampleConfig(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "config.example.yaml"))
+	if err != nil {
+		t.Fatalf("read config.example.yaml: %v", err)
+	}
+	var doc any
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("parse config.example.yaml: %v", err)
+	}
+	// Round-trip through JSON so the schema has the shape editors see.
+	raw, err := ConfigSchemaJSON()
+	if err != nil {
+		t.Fatalf("ConfigSchemaJSON: %v", err)
+	}
+	var schema map[string]any
+	if err = json.Unmarshal(raw, &schema); err != nil {
+		t.Fatalf("decode schema: %v", err)
+	}
+	defs, _ := schema["$defs"].(map[string]any)
+	var problems []string
+	checkKeys(schema, defs, doc, "", &problems)
+	if len(problems) > 0 {
+		t.Fatalf("config.example.yaml keys missing from the schema:\n%s", strings.Join(problems, "\n"))
+	}
+}
+
+// checkKeys reports mapping keys that a closed object schema does not declare.
+func checkKeys(schema map[string]any, defs map[string]any, value any, path string, problems *[]string) {
+	schema = resolveSchema(schema, defs)
+	switch typed := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		additional, _ := schema["additionalProperties"].(map[string]any)
+		for key, child := range typed {
+			childPath := strings.TrimPrefix(path+"."+key, ".")
+			if prop, ok := properties[key].(map[string]any); ok {
+				checkKeys(prop, defs, child, childPath, problems)
+			} else if additional != nil {
+				checkKeys(additional, defs, child, childPath, problems)
+			} else if schema["additionalProperties"] == false {
+				*problems = append(*problems, childPath)
+			}
+		}
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		for i, child := range typed {
+			if items != nil {
+				checkKeys(items, defs, child, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	}
+}
+
+func resolveSchema(schema map[string]any, defs map[string]any) map[string]any {
+	if allOf, ok := schema["allOf"].([]any); ok && len(allOf) == 1 {
+		schema, _ = allOf[0].(map[string]any)
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, _ := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		return resolved
+	}
+	return schema
+}
+
+func TestConfigSchema_UsesDocCommentsAndOverrides(t *testing.T) {
+	schema := ConfigSchema()
+	properties := schema["properties"].(map[string]any)
+	port := properties["port"].(map[string]any)
+	if port["type"] != "integer" || !strings.HasPrefix(port["description"].(string), "Port is") || port["maximum"] != 65535 {
+		t.Fatalf("port schema = %#v", port)
+	}
+	if _, ok := properties["proxy-url"]; !ok {
+		t.Fatal("inline SDKConfig fields are missing")
+	}
+	if _, ok := properties["include"]; !ok {
+		t.Fatal("include key is missing")
+	}
+	routing := schema["$defs"].(map[string]any)["RoutingConfig"].(map[string]any)
+	strategy := routing["properties"].(map[string]any)["strategy"].(map[string]any)
+	if _, ok := strategy["enum"]; !ok {
+		t.Fatalf("routing.strategy schema = %#v, want enum", strategy)
+	}
+}
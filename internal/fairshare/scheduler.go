@@ -0,0 +1,393 @@
+// Package fairshare admits upstream requests under a global and per-provider concurrency
+// cap. Requests that have to wait are served with start-time fair queuing across client
+// keys, so a client sending many concurrent requests cannot crowd out the others.
+package fairshare
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+// Priority is the scheduling class of a request.
+type Priority int
+
+const (
+	// PriorityInteractive requests are served before batch requests.
+	PriorityInteractive Priority = iota
+	// PriorityBatch requests wait behind interactive ones until they age past
+	// Limits.BatchPromoteAfter.
+	PriorityBatch
+)
+
+const defaultBatchPromoteAfter = 30 * time.Second
+
+// ParsePriority maps "interactive" and "batch" to a priority class.
+func ParsePriority(raw string) (Priority, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "interactive":
+		return PriorityInteractive, true
+	case "batch":
+		return PriorityBatch, true
+	default:
+		return PriorityInteractive, false
+	}
+}
+
+func (p Priority) String() string {
+	if p == PriorityBatch {
+		return "batch"
+	}
+	return "interactive"
+}
+
+// Limits configures admission. A zero Limits admits everything immediately.
+type Limits struct {
+	Enabled bool
+	// MaxConcurrent caps requests in flight across all providers. <= 0 means no global cap.
+	MaxConcurrent int
+	// ProviderConcurrency caps requests in flight per provider.
+	ProviderConcurrency map[string]int
+	// BatchPromoteAfter is how long a batch request waits before it competes with
+	// interactive requests on equal terms. <= 0 uses 30s.
+	BatchPromoteAfter time.Duration
+}
+
+// Ticket describes a request asking for admission.
+type Ticket struct {
+	ClientKey string
+	// Providers lists the providers that may serve the request, in order of preference.
+	Providers []string
+	Priority  Priority
+	// Weight is the client's share relative to other clients. <= 0 counts as 1.
+	Weight float64
+}
+
+// Scheduler hands out execution slots. The zero value is not usable; use New.
+type Scheduler struct {
+	mu               sync.Mutex
+	limits           Limits
+	inFlight         int
+	providerInFlight map[string]int
+	clients          map[string]*clientState
+	waiters          []*waiter
+	virtualTime      float64
+	admittedTotal    int64
+	now              func() time.Time
+}
+
+type clientState struct {
+	lastFinish float64
+	waiting    int
+}
+
+// Slot is an admitted request.
+type Slot struct {
+	// Provider is the provider the slot is charged to. It is empty when none of the
+	// ticket's providers has a cap, in which case any of them may serve the request.
+	Provider string
+	// Wait is the time spent queued.
+	Wait time.Duration
+	// Release returns the slot and must be called once the request has finished.
+	Release func()
+}
+
+type waiter struct {
+	ticket     Ticket
+	providers  []string
+	provider   string
+	start      float64
+	finish     float64
+	enqueuedAt time.Time
+	ready      chan struct{}
+	granted    bool
+}
+
+// Stats is a point-in-time view of the scheduler.
+type Stats struct {
+	Enabled  bool `json:"enabled"`
+	InFlight int  `json:"in_flight"`
+	Waiting  int  `json:"waiting"`
+	// ProviderInFlight counts the requests charged to capped providers.
+	ProviderInFlight map[string]int `json:"provider_in_flight"`
+	// WaitingByClient is keyed by logging.HashClientKey of the client key, never the key itself.
+	WaitingByClient map[string]int `json:"waiting_by_client"`
+	AdmittedTotal   int64          `json:"admitted_total"`
+}
+
+var (
+	defaultScheduler     *Scheduler
+	defaultSchedulerOnce sync.Once
+)
+
+// Default returns the process-wide scheduler shared by all API handlers.
+func Default() *Scheduler {
+	defaultSchedulerOnce.Do(func() { defaultScheduler = New(Limits{}) })
+	return defaultScheduler
+}
+
+// New returns a scheduler with the given limits.
+func New(limits Limits) *Scheduler {
+	return &Scheduler{
+		limits:           limits,
+		providerInFlight: make(map[string]int),
+		clients:          make(map[string]*clientState),
+		now:              time.Now,
+	}
+}
+
+// SetLimits replaces the limits. Raised caps admit waiting requests right away; lowered caps
+// take effect as in-flight requests finish.
+func (s *Scheduler) SetLimits(limits Limits) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = limits
+	if !limits.Enabled {
+		for _, w := range s.waiters {
+			s.admitLocked(w)
+		}
+		s.waiters = nil
+		return
+	}
+	s.dispatchLocked()
+}
+
+// Acquire blocks until the ticket may execute on one of its providers or ctx is done. A
+// capped provider only admits the request while it has room; the caller must then send the
+// request to Slot.Provider, which is the provider charged for it.
+func (s *Scheduler) Acquire(ctx context.Context, ticket Ticket) (Slot, error) {
+	if s == nil {
+		return Slot{Release: func() {}}, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.mu.Lock()
+	if !s.limits.Enabled {
+		s.mu.Unlock()
+		return Slot{Release: func() {}}, nil
+	}
+	w := s.enqueueLocked(ticket)
+	s.dispatchLocked()
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		s.mu.Lock()
+		if !w.granted {
+			s.removeLocked(w)
+			s.mu.Unlock()
+			return Slot{Wait: s.now().Sub(w.enqueuedAt)}, ctx.Err()
+		}
+		s.mu.Unlock()
+		// Granted while the caller gave up: hand the slot straight back.
+		s.releaseFunc(w)()
+		return Slot{Wait: s.now().Sub(w.enqueuedAt)}, ctx.Err()
+	}
+	return Slot{Provider: w.provider, Wait: s.now().Sub(w.enqueuedAt), Release: s.releaseFunc(w)}, nil
+}
+
+// Stats returns current counters.
+func (s *Scheduler) Stats() Stats {
+	stats := Stats{ProviderInFlight: make(map[string]int), WaitingByClient: make(map[string]int)}
+	if s == nil {
+		return stats
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats.Enabled = s.limits.Enabled
+	stats.InFlight = s.inFlight
+	stats.Waiting = len(s.waiters)
+	stats.AdmittedTotal = s.admittedTotal
+	for provider, n := range s.providerInFlight {
+		stats.ProviderInFlight[provider] = n
+	}
+	for key, client := range s.clients {
+		if client.waiting > 0 {
+			stats.WaitingByClient[logging.HashClientKey(key)] += client.waiting
+		}
+	}
+	return stats
+}
+
+// enqueueLocked tags the ticket with its virtual start and finish times. Each request costs
+// 1/weight of virtual time, so a client's requests are spaced out in proportion to its share.
+func (s *Scheduler) enqueueLocked(ticket Ticket) *waiter {
+	weight := ticket.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+	client := s.clients[ticket.ClientKey]
+	if client == nil {
+		client = &clientState{}
+		s.clients[ticket.ClientKey] = client
+	}
+	start := s.virtualTime
+	if client.lastFinish > start {
+		start = client.lastFinish
+	}
+	providers := make([]string, 0, len(ticket.Providers))
+	for _, provider := range ticket.Providers {
+		if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+			providers = append(providers, provider)
+		}
+	}
+	w := &waiter{
+		ticket:     ticket,
+		providers:  providers,
+		start:      start,
+		finish:     start + 1/weight,
+		enqueuedAt: s.now(),
+		ready:      make(chan struct{}),
+	}
+	client.lastFinish = w.finish
+	client.waiting++
+	s.waiters = append(s.waiters, w)
+	return w
+}
+
+// dispatchLocked admits waiters while capacity allows. Among the waiters with a provider that
+// has room, interactive (and aged batch) requests go first, then the smallest finish tag.
+func (s *Scheduler) dispatchLocked() {
+	for len(s.waiters) > 0 {
+		if s.limits.MaxConcurrent > 0 && s.inFlight >= s.limits.MaxConcurrent {
+			return
+		}
+		now := s.now()
+		best := -1
+		for i, w := range s.waiters {
+			if _, ok := s.pickProviderLocked(w); !ok {
+				continue
+			}
+			if best < 0 || s.before(w, s.waiters[best], now) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return
+		}
+		w := s.waiters[best]
+		s.waiters = append(s.waiters[:best], s.waiters[best+1:]...)
+		s.admitLocked(w)
+	}
+}
+
+// admitLocked charges w against the caps and wakes its caller. w must already be removed
+// from the waiters list.
+func (s *Scheduler) admitLocked(w *waiter) {
+	if w.start > s.virtualTime {
+		s.virtualTime = w.start
+	}
+	w.provider, _ = s.pickProviderLocked(w)
+	s.inFlight++
+	if w.provider != "" {
+		s.providerInFlight[w.provider]++
+	}
+	s.admittedTotal++
+	w.granted = true
+	s.clientDoneWaitingLocked(w)
+	close(w.ready)
+}
+
+func (s *Scheduler) before(a, b *waiter, now time.Time) bool {
+	ca, cb := s.effectiveClass(a, now), s.effectiveClass(b, now)
+	if ca != cb {
+		return ca < cb
+	}
+	if a.finish != b.finish {
+		return a.finish < b.finish
+	}
+	return a.enqueuedAt.Before(b.enqueuedAt)
+}
+
+func (s *Scheduler) effectiveClass(w *waiter, now time.Time) Priority {
+	if w.ticket.Priority != PriorityBatch {
+		return PriorityInteractive
+	}
+	promoteAfter := s.limits.BatchPromoteAfter
+	if promoteAfter <= 0 {
+		promoteAfter = defaultBatchPromoteAfter
+	}
+	if now.Sub(w.enqueuedAt) >= promoteAfter {
+		return PriorityInteractive
+	}
+	return PriorityBatch
+}
+
+// pickProviderLocked returns the first of w's providers with room. It returns "" when none
+// of them is capped, so the request is not pinned to any one provider.
+func (s *Scheduler) pickProviderLocked(w *waiter) (string, bool) {
+	capped := false
+	for _, provider := range w.providers {
+		if s.limits.ProviderConcurrency[provider] > 0 {
+			capped = true
+			break
+		}
+	}
+	if !capped {
+		return "", true
+	}
+	for _, provider := range w.providers {
+		if s.providerHasRoomLocked(provider) {
+			return provider, true
+		}
+	}
+	return "", false
+}
+
+func (s *Scheduler) providerHasRoomLocked(provider string) bool {
+	limit := s.limits.ProviderConcurrency[provider]
+	return limit <= 0 || s.providerInFlight[provider] < limit
+}
+
+func (s *Scheduler) removeLocked(w *waiter) {
+	for i, candidate := range s.waiters {
+		if candidate == w {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			break
+		}
+	}
+	s.clientDoneWaitingLocked(w)
+}
+
+// clientDoneWaitingLocked forgets clients that have nothing queued and no credit ahead of
+// the virtual clock, keeping the client map bounded.
+func (s *Scheduler) clientDoneWaitingLocked(w *waiter) {
+	client := s.clients[w.ticket.ClientKey]
+	if client == nil {
+		return
+	}
+	client.waiting--
+	if client.waiting <= 0 && client.lastFinish <= s.virtualTime {
+		delete(s.clients, w.ticket.ClientKey)
+	}
+}
+
+func (s *Scheduler) releaseFunc(w *waiter) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.inFlight > 0 {
+				s.inFlight--
+			}
+			if w.provider != "" {
+				if s.providerInFlight[w.provider] > 0 {
+					s.providerInFlight[w.provider]--
+				}
+				if s.providerInFlight[w.provider] == 0 {
+					delete(s.providerInFlight, w.provider)
+				}
+			}
+			s.dispatchLocked()
+		})
+	}
+}
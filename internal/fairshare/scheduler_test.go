@@ -0,0 +1,231 @@
+package fairshare
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+type grant struct {
+	name     string
+	provider string
+	release  func()
+}
+
+// enqueue starts an Acquire in the background and waits until it is queued.
+func enqueue(t *testing.T, s *Scheduler, name string, ticket Ticket, granted chan<- grant) {
+	t.Helper()
+	before := s.Stats().Waiting
+	go func() {
+		slot, err := s.Acquire(context.Background(), ticket)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			return
+		}
+		granted <- grant{name: name, provider: slot.Provider, release: slot.Release}
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Stats().Waiting == before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s was not queued", name)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func acquire(t *testing.T, s *Scheduler, ticket Ticket) func() {
+	t.Helper()
+	slot, err := s.Acquire(context.Background(), ticket)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	return slot.Release
+}
+
+func nextGrant(t *testing.T, granted <-chan grant) grant {
+	t.Helper()
+	select {
+	case g := <-granted:
+		return g
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no request was admitted")
+		return grant{}
+	}
+}
+
+func TestSchedulerSharesFairlyAcrossClients(t *testing.T) {
+	s := New(Limits{Enabled: true, MaxConcurrent: 1})
+	slot, err := s.Acquire(context.Background(), Ticket{ClientKey: "a"})
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	hold := slot.Release
+
+	granted := make(chan grant, 8)
+	for _, name := range []string{"a1", "a2", "a3", "a4"} {
+		enqueue(t, s, name, Ticket{ClientKey: "a"}, granted)
+	}
+	enqueue(t, s, "b1", Ticket{ClientKey: "b"}, granted)
+	if waiting := s.Stats().WaitingByClient; waiting[logging.HashClientKey("a")] != 4 || waiting["a"] != 0 {
+		t.Fatalf("waiting_by_client = %v, want 4 under the hashed key of a", waiting)
+	}
+
+	hold()
+	var order []string
+	for i := 0; i < 5; i++ {
+		g := nextGrant(t, granted)
+		order = append(order, g.name)
+		g.release()
+	}
+	position := -1
+	for i, name := range order {
+		if name == "b1" {
+			position = i
+		}
+	}
+	if position < 0 || position > 1 {
+		t.Fatalf("order=%v, client b should not wait behind client a's backlog", order)
+	}
+}
+
+func TestSchedulerWeightsShare(t *testing.T) {
+	s := New(Limits{Enabled: true, MaxConcurrent: 1})
+	hold := acquire(t, s, Ticket{ClientKey: "light"})
+
+	granted := make(chan grant, 8)
+	for _, name := range []string{"h1", "h2", "h3"} {
+		enqueue(t, s, name, Ticket{ClientKey: "heavy", Weight: 3}, granted)
+	}
+	for _, name := range []string{"l1", "l2"} {
+		enqueue(t, s, name, Ticket{ClientKey: "light", Weight: 1}, granted)
+	}
+
+	hold()
+	var order []string
+	for i := 0; i < 5; i++ {
+		g := nextGrant(t, granted)
+		order = append(order, g.name)
+		g.release()
+	}
+	if order[0] != "h1" || order[1] != "h2" || order[2] != "h3" {
+		t.Fatalf("order=%v, the weight-3 client should get three turns first", order)
+	}
+}
+
+func TestSchedulerBatchYieldsUntilPromoted(t *testing.T) {
+	s := New(Limits{Enabled: true, MaxConcurrent: 1, BatchPromoteAfter: time.Minute})
+	var clockMu sync.Mutex
+	now := time.Now()
+	s.now = func() time.Time {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		return now
+	}
+	hold := acquire(t, s, Ticket{ClientKey: "x"})
+
+	granted := make(chan grant, 8)
+	enqueue(t, s, "batch", Ticket{ClientKey: "batch-key", Priority: PriorityBatch}, granted)
+	enqueue(t, s, "interactive", Ticket{ClientKey: "ide-key"}, granted)
+
+	hold()
+	g := nextGrant(t, granted)
+	if g.name != "interactive" {
+		t.Fatalf("first admitted = %s, want interactive", g.name)
+	}
+	enqueue(t, s, "interactive-2", Ticket{ClientKey: "ide-key-2"}, granted)
+
+	// Once the batch request has waited past BatchPromoteAfter it competes on finish tags and
+	// wins against the newer interactive request.
+	clockMu.Lock()
+	now = now.Add(2 * time.Minute)
+	clockMu.Unlock()
+	g.release()
+	if g = nextGrant(t, granted); g.name != "batch" {
+		t.Fatalf("admitted = %s, want promoted batch request", g.name)
+	}
+	g.release()
+	nextGrant(t, granted).release()
+}
+
+func TestSchedulerProviderCapDoesNotBlockOtherProviders(t *testing.T) {
+	s := New(Limits{Enabled: true, ProviderConcurrency: map[string]int{"claude": 1}})
+	hold := acquire(t, s, Ticket{Providers: []string{"claude"}})
+
+	granted := make(chan grant, 4)
+	enqueue(t, s, "claude-2", Ticket{Providers: []string{"Claude"}}, granted)
+	slot, err := s.Acquire(context.Background(), Ticket{Providers: []string{"codex"}})
+	if err != nil || slot.Wait > time.Second {
+		t.Fatalf("codex request should be admitted immediately, wait=%v err=%v", slot.Wait, err)
+	}
+	slot.Release()
+
+	hold()
+	nextGrant(t, granted).release()
+	if stats := s.Stats(); stats.InFlight != 0 || stats.Waiting != 0 {
+		t.Fatalf("stats after drain = %+v", stats)
+	}
+}
+
+func TestSchedulerChargesTheProviderWithRoom(t *testing.T) {
+	s := New(Limits{Enabled: true, ProviderConcurrency: map[string]int{"claude": 1, "codex": 1}})
+	first, err := s.Acquire(context.Background(), Ticket{Providers: []string{"claude", "codex"}})
+	if err != nil || first.Provider != "claude" {
+		t.Fatalf("first slot = %+v, err=%v, want claude", first, err)
+	}
+	second, err := s.Acquire(context.Background(), Ticket{Providers: []string{"claude", "codex"}})
+	if err != nil || second.Provider != "codex" {
+		t.Fatalf("second slot = %+v, err=%v, want codex", second, err)
+	}
+	if stats := s.Stats(); stats.ProviderInFlight["claude"] != 1 || stats.ProviderInFlight["codex"] != 1 {
+		t.Fatalf("provider_in_flight = %v", stats.ProviderInFlight)
+	}
+
+	granted := make(chan grant, 1)
+	enqueue(t, s, "third", Ticket{Providers: []string{"claude", "codex"}}, granted)
+	second.Release()
+	if g := nextGrant(t, granted); g.provider != "codex" {
+		t.Fatalf("third charged to %q, want codex", g.provider)
+	} else {
+		g.release()
+	}
+	first.Release()
+
+	uncapped, _ := s.Acquire(context.Background(), Ticket{Providers: []string{"gemini", "vertex"}})
+	if uncapped.Provider != "" {
+		t.Fatalf("uncapped providers pinned to %q", uncapped.Provider)
+	}
+	uncapped.Release()
+	if stats := s.Stats(); stats.InFlight != 0 || len(stats.ProviderInFlight) != 0 {
+		t.Fatalf("stats after drain = %+v", stats)
+	}
+}
+
+func TestSchedulerCancelledWaiterLeavesQueue(t *testing.T) {
+	s := New(Limits{Enabled: true, MaxConcurrent: 1})
+	hold := acquire(t, s, Ticket{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Acquire(ctx, Ticket{ClientKey: "gone"})
+		done <- err
+	}()
+	for s.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v, want context.Canceled", err)
+	}
+	if stats := s.Stats(); stats.Waiting != 0 {
+		t.Fatalf("waiting=%d after cancel", stats.Waiting)
+	}
+	hold()
+	if stats := s.Stats(); stats.InFlight != 0 {
+		t.Fatalf("in_flight=%d after release", stats.InFlight)
+	}
+}
@@ -57,6 +57,9 @@ func runLogDirCleaner(ctx context.Context, logDir string, maxBytes int64, protec
 		}
 		if deleted > 0 {
 			log.Debugf("logging: removed %d old log file(s) to enforce log directory size limit", deleted)
+			if _, errCompact := compactRequestIndex(logDir); errCompact != nil {
+				log.WithError(errCompact).Warn("logging: failed to compact request log index")
+			}
 		}
 	}
 
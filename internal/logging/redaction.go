@@ -0,0 +1,316 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Redaction actions.
+const (
+	RedactionActionMask = "mask"
+	RedactionActionHash = "hash"
+)
+
+// defaultRedactedHeaders are always redacted when redaction is enabled.
+var defaultRedactedHeaders = []string{
+	"authorization",
+	"proxy-authorization",
+	"cookie",
+	"set-cookie",
+	"x-api-key",
+	"api-key",
+	"x-goog-api-key",
+	"x-management-key",
+}
+
+// builtinDetectors maps detector names to their patterns.
+var builtinDetectors = map[string]string{
+	"api_key":     `(?i)\b(?:sk-(?:ant-|proj-)?[A-Za-z0-9_-]{16,}|AIza[0-9A-Za-z_-]{35}|gh[pousr]_[A-Za-z0-9]{30,}|xox[baprs]-[A-Za-z0-9-]{10,}|ya29\.[A-Za-z0-9_-]{20,})|\b[Bb]earer\s+[A-Za-z0-9._~+/=-]{16,}`,
+	"jwt":         `\beyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}`,
+	"email":       `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`,
+	"card_number": `\b(?:\d[ -]?){12,18}\d\b`,
+}
+
+var builtinDetectorOrder = []string{"jwt", "api_key", "email", "card_number"}
+
+// headerLinePattern matches "Name: value" lines inside pre-formatted log sections.
+var headerLinePattern = regexp.MustCompile(`^(\s*)([A-Za-z0-9][A-Za-z0-9_-]*):[ \t]?(.*)$`)
+
+type redactionDetector struct {
+	name    string
+	pattern *regexp.Regexp
+	action  string
+	luhn    bool
+}
+
+type redactionPath struct {
+	segments []string
+	action   string
+}
+
+// Redactor masks secrets and personal data in request log content.
+// A nil Redactor leaves content untouched.
+type Redactor struct {
+	headers      map[string]struct{}
+	headerAction string
+	paths        []redactionPath
+	detectors    []redactionDetector
+}
+
+// NewRedactor compiles a redaction configuration. It returns nil when redaction is disabled.
+func NewRedactor(cfg config.RequestLogRedactionConfig) (*Redactor, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	headerAction, err := normalizeRedactionAction(cfg.HeaderAction)
+	if err != nil {
+		return nil, fmt.Errorf("header-action: %w", err)
+	}
+	r := &Redactor{
+		headers:      make(map[string]struct{}, len(defaultRedactedHeaders)+len(cfg.Headers)),
+		headerAction: headerAction,
+	}
+	for _, name := range defaultRedactedHeaders {
+		r.headers[name] = struct{}{}
+	}
+	for _, name := range cfg.Headers {
+		if trimmed := strings.ToLower(strings.TrimSpace(name)); trimmed != "" {
+			r.headers[trimmed] = struct{}{}
+		}
+	}
+	for _, rule := range cfg.BodyPaths {
+		path := strings.TrimSpace(rule.Path)
+		if path == "" {
+			continue
+		}
+		action, errAction := normalizeRedactionAction(rule.Action)
+		if errAction != nil {
+			return nil, fmt.Errorf("body path %q: %w", path, errAction)
+		}
+		r.paths = append(r.paths, redactionPath{segments: strings.Split(path, "."), action: action})
+	}
+
+	rules := cfg.Detectors
+	if len(rules) == 0 {
+		for _, name := range builtinDetectorOrder {
+			rules = append(rules, config.RedactionDetectorRule{Name: name})
+		}
+	}
+	for _, rule := range rules {
+		name := strings.ToLower(strings.TrimSpace(rule.Name))
+		pattern := strings.TrimSpace(rule.Pattern)
+		if pattern == "" {
+			pattern = builtinDetectors[name]
+		}
+		if pattern == "" {
+			return nil, fmt.Errorf("detector %q: unknown detector and no pattern", rule.Name)
+		}
+		compiled, errCompile := regexp.Compile(pattern)
+		if errCompile != nil {
+			return nil, fmt.Errorf("detector %q: %w", rule.Name, errCompile)
+		}
+		action, errAction := normalizeRedactionAction(rule.Action)
+		if errAction != nil {
+			return nil, fmt.Errorf("detector %q: %w", rule.Name, errAction)
+		}
+		if name == "" {
+			name = "custom"
+		}
+		r.detectors = append(r.detectors, redactionDetector{
+			name:    name,
+			pattern: compiled,
+			action:  action,
+			luhn:    name == "card_number" && strings.TrimSpace(rule.Pattern) == "",
+		})
+	}
+	return r, nil
+}
+
+func normalizeRedactionAction(action string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case "", RedactionActionMask:
+		return RedactionActionMask, nil
+	case RedactionActionHash:
+		return RedactionActionHash, nil
+	default:
+		return "", fmt.Errorf("unsupported redaction action %q", action)
+	}
+}
+
+func applyRedactionAction(action, label, value string) string {
+	if action == RedactionActionHash {
+		sum := sha256.Sum256([]byte(value))
+		return "[HASH:" + hex.EncodeToString(sum[:6]) + "]"
+	}
+	return "[REDACTED:" + label + "]"
+}
+
+// Headers returns a redacted copy of headers.
+func (r *Redactor) Headers(headers map[string][]string) map[string][]string {
+	if r == nil || headers == nil {
+		return headers
+	}
+	out := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		redacted := make([]string, len(values))
+		for i, value := range values {
+			redacted[i] = r.headerValue(key, value)
+		}
+		out[key] = redacted
+	}
+	return out
+}
+
+func (r *Redactor) headerValue(key, value string) string {
+	if _, denied := r.headers[strings.ToLower(strings.TrimSpace(key))]; denied {
+		return applyRedactionAction(r.headerAction, "header", value)
+	}
+	return r.detect(value)
+}
+
+// Body redacts a request or response payload. JSON documents and SSE "data:" lines
+// have body path rules applied; detectors run over everything.
+func (r *Redactor) Body(body []byte) []byte {
+	if r == nil || len(body) == 0 {
+		return body
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(r.paths) > 0 && gjson.ValidBytes(trimmed) {
+		return []byte(r.detect(string(r.applyPaths(trimmed))))
+	}
+	return r.Text(body)
+}
+
+// Text redacts pre-formatted log sections line by line: "Name: value" lines with a
+// denylisted name are redacted, JSON lines get body path rules, and detectors run last.
+func (r *Redactor) Text(text []byte) []byte {
+	if r == nil || len(text) == 0 {
+		return text
+	}
+	var out bytes.Buffer
+	out.Grow(len(text))
+	for len(text) > 0 {
+		line := text
+		if idx := bytes.IndexByte(text, '\n'); idx >= 0 {
+			line = text[:idx+1]
+			text = text[idx+1:]
+		} else {
+			text = nil
+		}
+		out.Write(r.line(line))
+	}
+	return out.Bytes()
+}
+
+func (r *Redactor) line(line []byte) []byte {
+	content := bytes.TrimRight(line, "\r\n")
+	ending := line[len(content):]
+	text := string(content)
+
+	if match := headerLinePattern.FindStringSubmatch(text); match != nil {
+		if _, denied := r.headers[strings.ToLower(match[2])]; denied {
+			return append([]byte(match[1]+match[2]+": "+applyRedactionAction(r.headerAction, "header", match[3])), ending...)
+		}
+	}
+	if len(r.paths) > 0 {
+		prefix, payload := "", text
+		if strings.HasPrefix(payload, "data:") {
+			prefix, payload = "data:", strings.TrimPrefix(payload, "data:")
+			if strings.HasPrefix(payload, " ") {
+				prefix, payload = "data: ", payload[1:]
+			}
+		}
+		if trimmed := strings.TrimSpace(payload); strings.HasPrefix(trimmed, "{") && gjson.Valid(trimmed) {
+			text = prefix + string(r.applyPaths([]byte(trimmed)))
+		}
+	}
+	return append([]byte(r.detect(text)), ending...)
+}
+
+func (r *Redactor) detect(value string) string {
+	for _, detector := range r.detectors {
+		value = detector.pattern.ReplaceAllStringFunc(value, func(match string) string {
+			if detector.luhn && !luhnValid(match) {
+				return match
+			}
+			return applyRedactionAction(detector.action, detector.name, match)
+		})
+	}
+	return value
+}
+
+func (r *Redactor) applyPaths(doc []byte) []byte {
+	for _, rule := range r.paths {
+		for _, path := range expandJSONPath(doc, rule.segments) {
+			value := gjson.GetBytes(doc, path)
+			if !value.Exists() || value.Type == gjson.Null {
+				continue
+			}
+			if updated, err := sjson.SetBytes(doc, path, applyRedactionAction(rule.action, "field", value.Raw)); err == nil {
+				doc = updated
+			}
+		}
+	}
+	return doc
+}
+
+// expandJSONPath resolves "#" segments into concrete array indexes.
+func expandJSONPath(doc []byte, segments []string) []string {
+	paths := []string{""}
+	for _, segment := range segments {
+		var next []string
+		for _, prefix := range paths {
+			if segment != "#" {
+				next = append(next, joinJSONPath(prefix, segment))
+				continue
+			}
+			count := gjson.GetBytes(doc, joinJSONPath(prefix, "#")).Int()
+			for i := int64(0); i < count; i++ {
+				next = append(next, joinJSONPath(prefix, strconv.FormatInt(i, 10)))
+			}
+		}
+		paths = next
+		if len(paths) == 0 {
+			return nil
+		}
+	}
+	return paths
+}
+
+func joinJSONPath(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "." + segment
+}
+
+func luhnValid(candidate string) bool {
+	sum, digits := 0, 0
+	double := false
+	for i := len(candidate) - 1; i >= 0; i-- {
+		c := candidate[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,376 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIndexFileName is the JSON-lines index written next to request log files.
+const RequestIndexFileName = "request-index.jsonl"
+
+const (
+	ginRequestRouteKey        = "__request_route__"
+	defaultRequestSearchLimit = 100
+	maxRequestSearchLimit     = 1000
+)
+
+// ErrInvalidRequestLogQuery reports a malformed request log search filter.
+var ErrInvalidRequestLogQuery = errors.New("invalid request log query")
+
+// requestIndexMu serializes appends and compactions across all index files.
+var requestIndexMu sync.Mutex
+
+// RequestRoute describes how a request was routed upstream.
+type RequestRoute struct {
+	Provider  string
+	Model     string
+	AuthIndex string
+}
+
+// SetGinRequestRoute stores the upstream route selected for a request in the Gin context.
+// Later attempts overwrite earlier ones so the index reflects the final route.
+func SetGinRequestRoute(c *gin.Context, route RequestRoute) {
+	if c != nil {
+		c.Set(ginRequestRouteKey, route)
+	}
+}
+
+// GetGinRequestRoute retrieves the upstream route from the Gin context.
+func GetGinRequestRoute(c *gin.Context) RequestRoute {
+	if c == nil {
+		return RequestRoute{}
+	}
+	if value, exists := c.Get(ginRequestRouteKey); exists {
+		if route, ok := value.(RequestRoute); ok {
+			return route
+		}
+	}
+	return RequestRoute{}
+}
+
+// RequestLogMetadata carries request attributes that are not part of the log body
+// but are recorded in the request index.
+type RequestLogMetadata struct {
+	Model     string
+	Provider  string
+	AuthIndex string
+	ClientKey string
+	Latency   time.Duration
+}
+
+// RequestIndexEntry is a single line of the request index.
+type RequestIndexEntry struct {
+	Timestamp     time.Time `json:"timestamp"`
+	RequestID     string    `json:"request_id,omitempty"`
+	File          string    `json:"file"`
+	URL           string    `json:"url"`
+	Method        string    `json:"method"`
+	Status        int       `json:"status"`
+	Model         string    `json:"model,omitempty"`
+	Provider      string    `json:"provider,omitempty"`
+	AuthIndex     string    `json:"auth_index,omitempty"`
+	ClientKeyHash string    `json:"client_key_hash,omitempty"`
+	LatencyMs     int64     `json:"latency_ms"`
+	RequestBytes  int64     `json:"request_bytes"`
+	ResponseBytes int64     `json:"response_bytes"`
+	Streaming     bool      `json:"streaming,omitempty"`
+	ErrorLog      bool      `json:"error_log,omitempty"`
+}
+
+// HashClientKey returns the short digest used to identify client keys in the index
+// without storing the key itself.
+func HashClientKey(key string) string {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+func newRequestIndexEntry(file, url, method string, status int, requestID string, requestTimestamp time.Time, requestBytes, responseBytes int64, meta RequestLogMetadata) RequestIndexEntry {
+	if requestTimestamp.IsZero() {
+		requestTimestamp = time.Now()
+	}
+	return RequestIndexEntry{
+		Timestamp:     requestTimestamp.UTC(),
+		RequestID:     requestID,
+		File:          filepath.Base(file),
+		URL:           url,
+		Method:        method,
+		Status:        status,
+		Model:         meta.Model,
+		Provider:      meta.Provider,
+		AuthIndex:     meta.AuthIndex,
+		ClientKeyHash: HashClientKey(meta.ClientKey),
+		LatencyMs:     meta.Latency.Milliseconds(),
+		RequestBytes:  requestBytes,
+		ResponseBytes: responseBytes,
+	}
+}
+
+// appendRequestIndex appends an entry to the index in logsDir.
+func appendRequestIndex(logsDir string, entry RequestIndexEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	requestIndexMu.Lock()
+	defer requestIndexMu.Unlock()
+	f, err := os.OpenFile(filepath.Join(logsDir, RequestIndexFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// compactRequestIndex rewrites the index without entries whose log files no longer exist.
+// It is called after retention removes log files so the index never outlives its logs.
+func compactRequestIndex(logsDir string) (int, error) {
+	requestIndexMu.Lock()
+	defer requestIndexMu.Unlock()
+
+	path := filepath.Join(logsDir, RequestIndexFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	var (
+		kept    bytes.Buffer
+		removed int
+		exists  = make(map[string]bool)
+	)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var entry RequestIndexEntry
+		if errUnmarshal := json.Unmarshal(line, &entry); errUnmarshal != nil || entry.File == "" {
+			removed++
+			continue
+		}
+		present, checked := exists[entry.File]
+		if !checked {
+			_, errStat := os.Stat(filepath.Join(logsDir, entry.File))
+			present = errStat == nil
+			exists[entry.File] = present
+		}
+		if !present {
+			removed++
+			continue
+		}
+		kept.Write(line)
+		kept.WriteByte('\n')
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	tmp, err := os.CreateTemp(logsDir, "request-index-*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	tmpPath := tmp.Name()
+	if _, err = tmp.Write(kept.Bytes()); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return 0, err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, err
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, err
+	}
+	return removed, nil
+}
+
+// RequestLogQuery filters request index entries.
+type RequestLogQuery struct {
+	From      time.Time
+	To        time.Time
+	Status    string // exact code ("429") or class ("4xx")
+	Model     string
+	Provider  string
+	AuthIndex string
+	ClientKey string // raw key or its hash
+	RequestID string
+	Method    string
+	URL       string // substring match
+	Text      string // case-insensitive substring match over the log file contents
+	Offset    int
+	Limit     int
+}
+
+// SearchRequestLogs scans the request index in logsDir and returns matching entries,
+// newest first, together with the total number of matches before pagination.
+func SearchRequestLogs(logsDir string, query RequestLogQuery) ([]RequestIndexEntry, int, error) {
+	matchStatus, err := statusMatcher(query.Status)
+	if err != nil {
+		return nil, 0, err
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultRequestSearchLimit
+	}
+	if limit > maxRequestSearchLimit {
+		limit = maxRequestSearchLimit
+	}
+	offset := query.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	clientKey := strings.TrimSpace(query.ClientKey)
+	clientHash := HashClientKey(clientKey)
+	text := strings.ToLower(strings.TrimSpace(query.Text))
+
+	requestIndexMu.Lock()
+	f, err := os.Open(filepath.Join(logsDir, RequestIndexFileName))
+	if err != nil {
+		requestIndexMu.Unlock()
+		if os.IsNotExist(err) {
+			return []RequestIndexEntry{}, 0, nil
+		}
+		return nil, 0, err
+	}
+	var candidates []RequestIndexEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry RequestIndexEntry
+		if errUnmarshal := json.Unmarshal(scanner.Bytes(), &entry); errUnmarshal != nil {
+			continue
+		}
+		if !query.From.IsZero() && entry.Timestamp.Before(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && !entry.Timestamp.Before(query.To) {
+			continue
+		}
+		if !matchStatus(entry.Status) {
+			continue
+		}
+		if !equalFoldIfSet(query.Model, entry.Model) || !equalFoldIfSet(query.Provider, entry.Provider) ||
+			!equalFoldIfSet(query.AuthIndex, entry.AuthIndex) || !equalFoldIfSet(query.RequestID, entry.RequestID) ||
+			!equalFoldIfSet(query.Method, entry.Method) {
+			continue
+		}
+		if clientKey != "" && entry.ClientKeyHash != clientKey && entry.ClientKeyHash != clientHash {
+			continue
+		}
+		if query.URL != "" && !strings.Contains(entry.URL, query.URL) {
+			continue
+		}
+		candidates = append(candidates, entry)
+	}
+	errScan := scanner.Err()
+	_ = f.Close()
+	requestIndexMu.Unlock()
+	if errScan != nil {
+		return nil, 0, errScan
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Timestamp.After(candidates[j].Timestamp)
+	})
+
+	results := make([]RequestIndexEntry, 0, limit)
+	total := 0
+	for _, entry := range candidates {
+		path := filepath.Join(logsDir, entry.File)
+		if text != "" {
+			found, errMatch := fileContainsFold(path, text)
+			if errMatch != nil || !found {
+				continue
+			}
+		} else if _, errStat := os.Stat(path); errStat != nil {
+			continue
+		}
+		total++
+		if total > offset && len(results) < limit {
+			results = append(results, entry)
+		}
+	}
+	return results, total, nil
+}
+
+func equalFoldIfSet(want, got string) bool {
+	want = strings.TrimSpace(want)
+	return want == "" || strings.EqualFold(want, got)
+}
+
+func statusMatcher(raw string) (func(int) bool, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if raw == "" {
+		return func(int) bool { return true }, nil
+	}
+	if len(raw) == 3 && strings.HasSuffix(raw, "xx") && raw[0] >= '1' && raw[0] <= '5' {
+		class := int(raw[0] - '0')
+		return func(status int) bool { return status/100 == class }, nil
+	}
+	code, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid status filter %q", ErrInvalidRequestLogQuery, raw)
+	}
+	return func(status int) bool { return status == code }, nil
+}
+
+// fileContainsFold reports whether the file contains needle (already lower-cased).
+// The file is streamed in chunks with an overlap so matches spanning chunk boundaries are found.
+func fileContainsFold(path, needle string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	target := []byte(needle)
+	overlap := len(target) - 1
+	buf := make([]byte, 64*1024+overlap)
+	carry := 0
+	for {
+		n, errRead := f.Read(buf[carry:])
+		if n > 0 {
+			window := bytes.ToLower(buf[:carry+n])
+			if bytes.Contains(window, target) {
+				return true, nil
+			}
+			if filled := carry + n; filled > overlap {
+				copy(buf, buf[filled-overlap:filled])
+				carry = overlap
+			} else {
+				carry = filled
+			}
+		}
+		if errRead == io.EOF {
+			return false, nil
+		}
+		if errRead != nil {
+			return false, errRead
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRequestIndexSearchFiltersAndFullText(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewFileRequestLogger(true, dir, "", 0)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	logs := []struct {
+		id     string
+		status int
+		model  string
+		key    string
+		body   string
+	}{
+		{id: "r1", status: http.StatusTooManyRequests, model: "gpt-5", key: "key-x", body: `{"model":"gpt-5","input":"alpha"}`},
+		{id: "r2", status: http.StatusTooManyRequests, model: "claude-sonnet-4", key: "key-x", body: `{"input":"beta"}`},
+		{id: "r3", status: http.StatusOK, model: "gpt-5", key: "key-x", body: `{"input":"gamma"}`},
+		{id: "r4", status: http.StatusTooManyRequests, model: "gpt-5", key: "key-y", body: `{"input":"Needle here"}`},
+	}
+	for i, entry := range logs {
+		err := logger.LogRequestWithMetadata("/v1/responses", http.MethodPost, nil, []byte(entry.body), entry.status, nil, []byte(`{}`), nil, nil, nil, false, entry.id, base.Add(time.Duration(i)*time.Minute), time.Time{}, RequestLogMetadata{
+			Model:     entry.model,
+			Provider:  "codex",
+			AuthIndex: "idx-1",
+			ClientKey: entry.key,
+			Latency:   150 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("LogRequestWithMetadata(%s) error = %v", entry.id, err)
+		}
+	}
+
+	results, total, err := SearchRequestLogs(dir, RequestLogQuery{Status: "429", ClientKey: "key-x", Model: "gpt-5"})
+	if err != nil {
+		t.Fatalf("SearchRequestLogs() error = %v", err)
+	}
+	if total != 1 || len(results) != 1 || results[0].RequestID != "r1" {
+		t.Fatalf("results = %+v total=%d, want r1", results, total)
+	}
+	if results[0].ClientKeyHash != HashClientKey("key-x") || results[0].LatencyMs != 150 || results[0].RequestBytes == 0 {
+		t.Fatalf("entry = %+v", results[0])
+	}
+
+	results, total, err = SearchRequestLogs(dir, RequestLogQuery{Status: "4xx", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("SearchRequestLogs() error = %v", err)
+	}
+	if total != 3 || len(results) != 1 || results[0].RequestID != "r2" {
+		t.Fatalf("paged results = %+v total=%d, want r2 of 3", results, total)
+	}
+
+	results, _, err = SearchRequestLogs(dir, RequestLogQuery{Text: "needle"})
+	if err != nil {
+		t.Fatalf("SearchRequestLogs() error = %v", err)
+	}
+	if len(results) != 1 || results[0].RequestID != "r4" {
+		t.Fatalf("full-text results = %+v, want r4", results)
+	}
+
+	if _, _, err = SearchRequestLogs(dir, RequestLogQuery{Status: "bad"}); err == nil {
+		t.Fatal("expected invalid status filter error")
+	}
+}
+
+func TestCompactRequestIndexDropsRemovedLogs(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewFileRequestLogger(true, dir, "", 0)
+	for _, id := range []string{"keep", "drop"} {
+		if err := logger.LogRequest("/v1/chat/completions", http.MethodPost, nil, nil, http.StatusOK, nil, nil, nil, nil, nil, id, time.Now(), time.Time{}); err != nil {
+			t.Fatalf("LogRequest(%s) error = %v", id, err)
+		}
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*-drop.log"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("glob drop log = %v, %v", matches, err)
+	}
+	if err = os.Remove(matches[0]); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	removed, err := compactRequestIndex(dir)
+	if err != nil {
+		t.Fatalf("compactRequestIndex() error = %v", err)
+	}
+	if removed != 1 {
+		t.Fatalf("removed = %d, want 1", removed)
+	}
+	results, total, err := SearchRequestLogs(dir, RequestLogQuery{})
+	if err != nil {
+		t.Fatalf("SearchRequestLogs() error = %v", err)
+	}
+	if total != 1 || results[0].RequestID != "keep" {
+		t.Fatalf("results = %+v, want keep only", results)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -22,6 +23,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/queuehealth"
 )
@@ -135,6 +137,9 @@ type FileRequestLogger struct {
 
 	// errorLogsMaxFiles limits the number of error log files retained.
 	errorLogsMaxFiles int
+
+	// redactor masks secrets and personal data before logs are written; nil disables redaction.
+	redactor atomic.Pointer[Redactor]
 }
 
 // NewFileRequestLogger creates a new file-based request logger.
@@ -185,6 +190,17 @@ func (l *FileRequestLogger) SetErrorLogsMaxFiles(maxFiles int) {
 	l.errorLogsMaxFiles = maxFiles
 }
 
+// SetRedaction compiles and installs the redaction rules applied to subsequent logs.
+// On error the previous rules remain active.
+func (l *FileRequestLogger) SetRedaction(cfg config.RequestLogRedactionConfig) error {
+	redactor, err := NewRedactor(cfg)
+	if err != nil {
+		return err
+	}
+	l.redactor.Store(redactor)
+	return nil
+}
+
 // LogRequest logs a complete non-streaming request/response cycle to a file.
 //
 // Parameters:
@@ -204,16 +220,22 @@ func (l *FileRequestLogger) SetErrorLogsMaxFiles(maxFiles int) {
 // Returns:
 //   - error: An error if logging fails, nil otherwise
 func (l *FileRequestLogger) LogRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
-	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, false, requestID, requestTimestamp, apiResponseTimestamp)
+	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, false, requestID, requestTimestamp, apiResponseTimestamp, RequestLogMetadata{})
 }
 
 // LogRequestWithOptions logs a request with optional forced logging behavior.
 // The force flag allows writing error logs even when regular request logging is disabled.
 func (l *FileRequestLogger) LogRequestWithOptions(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
-	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, force, requestID, requestTimestamp, apiResponseTimestamp)
+	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, force, requestID, requestTimestamp, apiResponseTimestamp, RequestLogMetadata{})
 }
 
-func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
+// LogRequestWithMetadata behaves like LogRequestWithOptions and additionally records
+// routing metadata (model, provider, auth index, client key, latency) in the request index.
+func (l *FileRequestLogger) LogRequestWithMetadata(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time, metadata RequestLogMetadata) error {
+	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, force, requestID, requestTimestamp, apiResponseTimestamp, metadata)
+}
+
+func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time, metadata RequestLogMetadata) error {
 	if !l.enabled && !force {
 		return nil
 	}
@@ -230,6 +252,16 @@ func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[st
 	}
 	filePath := filepath.Join(l.logsDir, filename)
 
+	requestBytes := int64(len(body))
+	redactor := l.redactor.Load()
+	if redactor != nil {
+		requestHeaders = redactor.Headers(requestHeaders)
+		body = redactor.Body(body)
+		apiRequest = redactor.Text(apiRequest)
+		apiResponse = redactor.Text(apiResponse)
+		apiResponseErrors = redactErrorMessages(redactor, apiResponseErrors)
+	}
+
 	requestBodyPath, errTemp := l.writeRequestBodyTempFile(body)
 	if errTemp != nil {
 		log.WithError(errTemp).Warn("failed to create request body temp file, falling back to direct write")
@@ -247,6 +279,11 @@ func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[st
 		// If decompression fails, continue with original response and annotate the log output.
 		responseToWrite = response
 	}
+	responseBytes := int64(len(responseToWrite))
+	if redactor != nil {
+		responseHeaders = redactor.Headers(responseHeaders)
+		responseToWrite = redactor.Body(responseToWrite)
+	}
 
 	logFile, errOpen := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if errOpen != nil {
@@ -280,6 +317,12 @@ func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[st
 		return fmt.Errorf("failed to write log file: %w", writeErr)
 	}
 
+	entry := newRequestIndexEntry(filePath, url, method, statusCode, requestID, requestTimestamp, requestBytes, responseBytes, metadata)
+	entry.ErrorLog = force && !l.enabled
+	if errIndex := appendRequestIndex(l.logsDir, entry); errIndex != nil {
+		log.WithError(errIndex).Warn("failed to update request log index")
+	}
+
 	if force && !l.enabled {
 		if errCleanup := l.cleanupOldErrorLogs(); errCleanup != nil {
 			log.WithError(errCleanup).Warn("failed to clean up old error logs")
@@ -322,6 +365,13 @@ func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[
 		requestHeaders[key] = headerValues
 	}
 
+	requestBytes := int64(len(body))
+	redactor := l.redactor.Load()
+	if redactor != nil {
+		requestHeaders = redactor.Headers(requestHeaders)
+		body = redactor.Body(body)
+	}
+
 	requestBodyPath, errTemp := l.writeRequestBodyTempFile(body)
 	if errTemp != nil {
 		return nil, fmt.Errorf("failed to create request body temp file: %w", errTemp)
@@ -337,6 +387,10 @@ func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[
 	// Create streaming writer
 	writer := &FileStreamingLogWriter{
 		logFilePath:      filePath,
+		logsDir:          l.logsDir,
+		requestID:        requestID,
+		requestBytes:     requestBytes,
+		redactor:         redactor,
 		url:              url,
 		method:           method,
 		timestamp:        time.Now(),
@@ -490,6 +544,9 @@ func (l *FileRequestLogger) cleanupOldErrorLogs() error {
 		}
 	}
 
+	if _, errCompact := compactRequestIndex(l.logsDir); errCompact != nil {
+		log.WithError(errCompact).Warn("failed to compact request log index")
+	}
 	return nil
 }
 
@@ -964,6 +1021,21 @@ type FileStreamingLogWriter struct {
 	// logFilePath is the final log file path.
 	logFilePath string
 
+	// logsDir is the directory holding the request index.
+	logsDir string
+
+	// requestID is the request identifier recorded in the index.
+	requestID string
+
+	// requestBytes is the size of the captured request body.
+	requestBytes int64
+
+	// metadata carries routing details recorded in the request index.
+	metadata RequestLogMetadata
+
+	// redactor masks secrets in buffered sections and spooled chunks when the log is assembled.
+	redactor *Redactor
+
 	// url is the request URL (captured upstream in middleware).
 	url string
 
@@ -1091,6 +1163,11 @@ func (w *FileStreamingLogWriter) WriteAPIResponse(apiResponse []byte) error {
 	return nil
 }
 
+// SetMetadata records routing details that are written to the request index on Close.
+func (w *FileStreamingLogWriter) SetMetadata(metadata RequestLogMetadata) {
+	w.metadata = metadata
+}
+
 func (w *FileStreamingLogWriter) SetFirstChunkTimestamp(timestamp time.Time) {
 	if !timestamp.IsZero() {
 		w.apiResponseTimestamp = timestamp
@@ -1140,19 +1217,42 @@ func (w *FileStreamingLogWriter) Close() error {
 		}
 	}
 
+	if writeErr == nil && w.logsDir != "" {
+		var responseBytes int64
+		if info, errStat := os.Stat(w.responseBodyPath); errStat == nil {
+			responseBytes = info.Size()
+		}
+		entry := newRequestIndexEntry(w.logFilePath, w.url, w.method, w.responseStatus, w.requestID, w.timestamp, w.requestBytes, responseBytes, w.metadata)
+		entry.Streaming = true
+		if errIndex := appendRequestIndex(w.logsDir, entry); errIndex != nil {
+			log.WithError(errIndex).Warn("failed to update request log index")
+		}
+	}
+
 	w.cleanupTempFiles()
 	return writeErr
 }
 
 // asyncWriter runs in a goroutine to buffer chunks from the channel.
 // It continuously reads chunks from the channel and appends them to a temp file for later assembly.
+// When redaction is enabled, chunks are redacted line by line before they are spooled.
 func (w *FileStreamingLogWriter) asyncWriter() {
 	defer close(w.closeChan)
 
+	var pending []byte
 	for chunk := range w.chunkChan {
 		if w.responseBodyFile == nil {
 			continue
 		}
+		if w.redactor != nil {
+			pending = append(pending, chunk...)
+			idx := bytes.LastIndexByte(pending, '\n')
+			if idx < 0 {
+				continue
+			}
+			chunk = w.redactor.Text(pending[:idx+1])
+			pending = append([]byte(nil), pending[idx+1:]...)
+		}
 		if _, errWrite := w.responseBodyFile.Write(chunk); errWrite != nil {
 			select {
 			case w.errorChan <- errWrite:
@@ -1171,6 +1271,14 @@ func (w *FileStreamingLogWriter) asyncWriter() {
 	if w.responseBodyFile == nil {
 		return
 	}
+	if len(pending) > 0 {
+		if _, errWrite := w.responseBodyFile.Write(w.redactor.Text(pending)); errWrite != nil {
+			select {
+			case w.errorChan <- errWrite:
+			default:
+			}
+		}
+	}
 	if errClose := w.responseBodyFile.Close(); errClose != nil {
 		select {
 		case w.errorChan <- errClose:
@@ -1184,10 +1292,10 @@ func (w *FileStreamingLogWriter) writeFinalLog(logFile *os.File) error {
 	if errWrite := writeRequestInfoWithBody(logFile, w.url, w.method, w.requestHeaders, nil, w.requestBodyPath, w.timestamp); errWrite != nil {
 		return errWrite
 	}
-	if errWrite := writeAPISection(logFile, "=== API REQUEST ===\n", "=== API REQUEST", w.apiRequest, time.Time{}); errWrite != nil {
+	if errWrite := writeAPISection(logFile, "=== API REQUEST ===\n", "=== API REQUEST", w.redactor.Text(w.apiRequest), time.Time{}); errWrite != nil {
 		return errWrite
 	}
-	if errWrite := writeAPISection(logFile, "=== API RESPONSE ===\n", "=== API RESPONSE", w.apiResponse, w.apiResponseTimestamp); errWrite != nil {
+	if errWrite := writeAPISection(logFile, "=== API RESPONSE ===\n", "=== API RESPONSE", w.redactor.Text(w.apiResponse), w.apiResponseTimestamp); errWrite != nil {
 		return errWrite
 	}
 
@@ -1201,7 +1309,25 @@ func (w *FileStreamingLogWriter) writeFinalLog(logFile *os.File) error {
 		}
 	}()
 
-	return writeResponseSection(logFile, w.responseStatus, w.statusWritten, w.responseHeaders, responseBodyFile, nil, false)
+	return writeResponseSection(logFile, w.responseStatus, w.statusWritten, w.redactor.Headers(w.responseHeaders), responseBodyFile, nil, false)
+}
+
+// redactErrorMessages returns copies of upstream errors with their messages redacted.
+func redactErrorMessages(redactor *Redactor, messages []*interfaces.ErrorMessage) []*interfaces.ErrorMessage {
+	if redactor == nil || len(messages) == 0 {
+		return messages
+	}
+	out := make([]*interfaces.ErrorMessage, len(messages))
+	for i, message := range messages {
+		if message == nil || message.Error == nil {
+			out[i] = message
+			continue
+		}
+		redacted := *message
+		redacted.Error = errors.New(string(redactor.Text([]byte(message.Error.Error()))))
+		out[i] = &redacted
+	}
+	return out
 }
 
 func (w *FileStreamingLogWriter) cleanupTempFiles() {
@@ -0,0 +1,526 @@
+// Package notify delivers operational events (auth failures, quota exhaustion,
+// egress drift, refresh failures, queue overload) to configured webhook targets.
+// Deliveries are asynchronous, signed when a secret is configured, retried with
+// exponential backoff and dead-lettered when every attempt fails.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// EventType identifies the kind of operational event.
+type EventType string
+
+const (
+	EventAuthDisabled    EventType = "auth_disabled"
+	EventAuthFatal       EventType = "auth_fatal"
+	EventQuotaExceeded   EventType = "quota_exceeded"
+	EventEgressDrift     EventType = "egress_drift"
+	EventRefreshFailed   EventType = "refresh_failed"
+	EventQueueOverloaded EventType = "queue_overloaded"
+	EventTest            EventType = "test"
+)
+
+// Severity levels attached to events.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+const (
+	// SignatureHeader carries "sha256=<hex>" computed over "<timestamp>.<body>".
+	SignatureHeader = "X-CLIProxy-Signature"
+	// TimestampHeader carries the unix timestamp used when signing.
+	TimestampHeader = "X-CLIProxy-Timestamp"
+	// EventHeader carries the event type.
+	EventHeader = "X-CLIProxy-Event"
+
+	defaultQueueSize      = 256
+	defaultDeadLetterKeep = 100
+	defaultRetryBase      = time.Second
+	maxRetryDelay         = 30 * time.Second
+)
+
+// Event is an operational notification.
+type Event struct {
+	Type      EventType      `json:"type"`
+	Severity  string         `json:"severity"`
+	Timestamp time.Time      `json:"timestamp"`
+	Provider  string         `json:"provider,omitempty"`
+	Model     string         `json:"model,omitempty"`
+	AuthID    string         `json:"auth_id,omitempty"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// DeadLetter records an event that could not be delivered to a target.
+type DeadLetter struct {
+	Target   string    `json:"target"`
+	Event    Event     `json:"event"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// DeliveryResult describes the outcome of a synchronous delivery.
+type DeliveryResult struct {
+	Target     string `json:"target"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Attempts   int    `json:"attempts"`
+	Error      string `json:"error,omitempty"`
+}
+
+// TargetStatus summarizes a configured target without exposing secrets.
+type TargetStatus struct {
+	Name       string   `json:"name"`
+	Format     string   `json:"format"`
+	Events     []string `json:"events,omitempty"`
+	Signed     bool     `json:"signed"`
+	MaxRetries int      `json:"max_retries"`
+}
+
+// Status summarizes notifier state for management responses.
+type Status struct {
+	Enabled                 bool           `json:"enabled"`
+	Targets                 []TargetStatus `json:"targets"`
+	Delivered               int64          `json:"delivered"`
+	Failed                  int64          `json:"failed"`
+	Dropped                 int64          `json:"dropped"`
+	DeadLetters             int            `json:"dead_letters"`
+	DeadLetterFile          string         `json:"dead_letter_file,omitempty"`
+	RefreshFailureThreshold int            `json:"refresh_failure_threshold"`
+}
+
+type target struct {
+	cfg     config.NotificationWebhook
+	events  map[string]struct{}
+	timeout time.Duration
+	// queue feeds the target's own delivery worker, so a slow or failing target never
+	// delays the others.
+	queue chan Event
+}
+
+func (t *target) accepts(eventType EventType) bool {
+	if eventType == EventTest || len(t.events) == 0 {
+		return true
+	}
+	if _, ok := t.events["*"]; ok {
+		return true
+	}
+	_, ok := t.events[string(eventType)]
+	return ok
+}
+
+// Notifier fans events out to webhook targets.
+type Notifier struct {
+	targets          []*target
+	client           *http.Client
+	retryBase        time.Duration
+	deadLetterFile   string
+	refreshThreshold int
+
+	deadMu      sync.Mutex
+	deadLetters []DeadLetter
+
+	delivered atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New builds a notifier from configuration and starts one delivery worker per target.
+// Relative dead-letter paths are resolved against baseDir.
+func New(cfg config.NotificationsConfig, baseDir string) *Notifier {
+	n := &Notifier{
+		client:           &http.Client{},
+		retryBase:        defaultRetryBase,
+		refreshThreshold: cfg.RefreshFailureThreshold,
+		stop:             make(chan struct{}),
+	}
+	if n.refreshThreshold <= 0 {
+		n.refreshThreshold = 3
+	}
+	if path := strings.TrimSpace(cfg.DeadLetterFile); path != "" {
+		if !filepath.IsAbs(path) && baseDir != "" {
+			path = filepath.Join(baseDir, path)
+		}
+		n.deadLetterFile = path
+	}
+	for _, hook := range cfg.Webhooks {
+		if strings.TrimSpace(hook.URL) == "" {
+			continue
+		}
+		t := &target{cfg: hook, timeout: time.Duration(hook.TimeoutSeconds) * time.Second, queue: make(chan Event, defaultQueueSize)}
+		if t.timeout <= 0 {
+			t.timeout = 10 * time.Second
+		}
+		if len(hook.Events) > 0 {
+			t.events = make(map[string]struct{}, len(hook.Events))
+			for _, event := range hook.Events {
+				t.events[strings.ToLower(strings.TrimSpace(event))] = struct{}{}
+			}
+		}
+		n.targets = append(n.targets, t)
+	}
+	for _, t := range n.targets {
+		n.wg.Add(1)
+		go n.run(t)
+	}
+	return n
+}
+
+// Close stops the delivery workers. Pending deliveries are abandoned.
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+	n.stopOnce.Do(func() { close(n.stop) })
+	n.wg.Wait()
+}
+
+// RefreshFailureThreshold returns the consecutive refresh failures required before notifying.
+func (n *Notifier) RefreshFailureThreshold() int {
+	if n == nil {
+		return 0
+	}
+	return n.refreshThreshold
+}
+
+// Publish queues an event for every target whose filter accepts it. It never blocks;
+// when a target's queue is full the event is dead-lettered for that target.
+func (n *Notifier) Publish(event Event) {
+	if n == nil || len(n.targets) == 0 {
+		return
+	}
+	event = normalizeEvent(event)
+	for _, t := range n.targets {
+		if !t.accepts(event.Type) {
+			continue
+		}
+		select {
+		case t.queue <- event:
+		default:
+			n.dropped.Add(1)
+			n.addDeadLetter(DeadLetter{Target: t.cfg.Name, Event: event, Error: "notification queue full", FailedAt: time.Now().UTC()})
+		}
+	}
+}
+
+// Send delivers an event synchronously to the named target, or to every target when
+// name is empty. Filters are bypassed so that test events always go out.
+func (n *Notifier) Send(ctx context.Context, name string, event Event) ([]DeliveryResult, error) {
+	if n == nil {
+		return nil, fmt.Errorf("notifications not enabled")
+	}
+	event = normalizeEvent(event)
+	name = strings.TrimSpace(name)
+	results := make([]DeliveryResult, 0, len(n.targets))
+	for _, t := range n.targets {
+		if name != "" && !strings.EqualFold(t.cfg.Name, name) {
+			continue
+		}
+		status, attempts, err := n.deliver(ctx, t, event, 0)
+		result := DeliveryResult{Target: t.cfg.Name, StatusCode: status, Attempts: attempts}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	if name != "" && len(results) == 0 {
+		return nil, fmt.Errorf("unknown notification target %q", name)
+	}
+	return results, nil
+}
+
+// Status reports the notifier configuration and delivery counters.
+func (n *Notifier) Status() Status {
+	if n == nil {
+		return Status{Targets: []TargetStatus{}}
+	}
+	targets := make([]TargetStatus, 0, len(n.targets))
+	for _, t := range n.targets {
+		retries := t.cfg.MaxRetries
+		if retries < 0 {
+			retries = 0
+		}
+		targets = append(targets, TargetStatus{
+			Name:       t.cfg.Name,
+			Format:     formatOf(t.cfg.Format),
+			Events:     append([]string(nil), t.cfg.Events...),
+			Signed:     t.cfg.Secret != "",
+			MaxRetries: retries,
+		})
+	}
+	n.deadMu.Lock()
+	deadCount := len(n.deadLetters)
+	n.deadMu.Unlock()
+	return Status{
+		Enabled:                 true,
+		Targets:                 targets,
+		Delivered:               n.delivered.Load(),
+		Failed:                  n.failed.Load(),
+		Dropped:                 n.dropped.Load(),
+		DeadLetters:             deadCount,
+		DeadLetterFile:          n.deadLetterFile,
+		RefreshFailureThreshold: n.refreshThreshold,
+	}
+}
+
+// DeadLetters returns the most recent undeliverable events, oldest first.
+func (n *Notifier) DeadLetters() []DeadLetter {
+	if n == nil {
+		return nil
+	}
+	n.deadMu.Lock()
+	defer n.deadMu.Unlock()
+	return append([]DeadLetter(nil), n.deadLetters...)
+}
+
+func (n *Notifier) run(t *target) {
+	defer n.wg.Done()
+	retries := t.cfg.MaxRetries
+	if retries < 0 {
+		retries = 0
+	}
+	for {
+		select {
+		case <-n.stop:
+			return
+		case event := <-t.queue:
+			_, attempts, err := n.deliver(context.Background(), t, event, retries)
+			if err != nil {
+				log.WithError(err).Warnf("notification %s to %s failed after %d attempt(s)", event.Type, t.cfg.Name, attempts)
+				n.addDeadLetter(DeadLetter{Target: t.cfg.Name, Event: event, Error: err.Error(), Attempts: attempts, FailedAt: time.Now().UTC()})
+			}
+		}
+	}
+}
+
+// deliver posts the event, retrying retryable failures up to retries times.
+func (n *Notifier) deliver(ctx context.Context, t *target, event Event, retries int) (int, int, error) {
+	body, err := encodePayload(t.cfg.Format, event)
+	if err != nil {
+		n.failed.Add(1)
+		return 0, 0, err
+	}
+	var (
+		status   int
+		attempts int
+		lastErr  error
+	)
+	delay := n.retryBase
+	for attempts < retries+1 {
+		if attempts > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				n.failed.Add(1)
+				return status, attempts, ctx.Err()
+			case <-n.stop:
+				n.failed.Add(1)
+				return status, attempts, fmt.Errorf("notifier stopped: %w", lastErr)
+			}
+			delay *= 2
+			if delay > maxRetryDelay {
+				delay = maxRetryDelay
+			}
+		}
+		attempts++
+		var retryable bool
+		status, retryable, lastErr = n.post(ctx, t, event.Type, body)
+		if lastErr == nil {
+			n.delivered.Add(1)
+			return status, attempts, nil
+		}
+		if !retryable {
+			break
+		}
+	}
+	n.failed.Add(1)
+	return status, attempts, lastErr
+}
+
+func (n *Notifier) post(ctx context.Context, t *target, eventType EventType, body []byte) (int, bool, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, t.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CLIProxyAPI-Notifier")
+	req.Header.Set(EventHeader, string(eventType))
+	for key, value := range t.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	if t.cfg.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(t.cfg.Secret, timestamp, body))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, true, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if errClose := resp.Body.Close(); errClose != nil {
+		log.Debugf("notify: close response body: %v", errClose)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode >= 500
+	return resp.StatusCode, retryable, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+}
+
+func (n *Notifier) addDeadLetter(entry DeadLetter) {
+	n.deadMu.Lock()
+	n.deadLetters = append(n.deadLetters, entry)
+	if over := len(n.deadLetters) - defaultDeadLetterKeep; over > 0 {
+		n.deadLetters = append([]DeadLetter(nil), n.deadLetters[over:]...)
+	}
+	path := n.deadLetterFile
+	n.deadMu.Unlock()
+	if path == "" {
+		return
+	}
+	if err := appendDeadLetterFile(path, entry); err != nil {
+		log.WithError(err).Warnf("failed to write notification dead letter to %s", path)
+	}
+}
+
+func appendDeadLetterFile(path string, entry DeadLetter) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Sign computes the signature header value for a payload.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func normalizeEvent(event Event) Event {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	if event.Severity == "" {
+		event.Severity = SeverityWarning
+	}
+	return event
+}
+
+func formatOf(format string) string {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "slack":
+		return "slack"
+	case "discord":
+		return "discord"
+	default:
+		return "json"
+	}
+}
+
+func encodePayload(format string, event Event) ([]byte, error) {
+	switch formatOf(format) {
+	case "slack":
+		return json.Marshal(map[string]string{"text": Summary(event)})
+	case "discord":
+		return json.Marshal(map[string]string{"content": Summary(event)})
+	default:
+		return json.Marshal(event)
+	}
+}
+
+// Summary renders a single-line human readable description of an event.
+func Summary(event Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", strings.ToUpper(event.Severity), event.Type)
+	if event.Message != "" {
+		b.WriteString(": ")
+		b.WriteString(event.Message)
+	}
+	var details []string
+	if event.Provider != "" {
+		details = append(details, "provider="+event.Provider)
+	}
+	if event.Model != "" {
+		details = append(details, "model="+event.Model)
+	}
+	if event.AuthID != "" {
+		details = append(details, "auth="+event.AuthID)
+	}
+	if len(details) > 0 {
+		b.WriteString(" (")
+		b.WriteString(strings.Join(details, ", "))
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+var defaultNotifier atomic.Pointer[Notifier]
+
+// Configure replaces the global notifier. Disabled or empty configurations stop delivery.
+func Configure(cfg config.NotificationsConfig, baseDir string) {
+	var next *Notifier
+	if cfg.Enabled && len(cfg.Webhooks) > 0 {
+		next = New(cfg, baseDir)
+	}
+	if prev := defaultNotifier.Swap(next); prev != nil {
+		go prev.Close()
+	}
+}
+
+// Default returns the global notifier, or nil when notifications are disabled.
+func Default() *Notifier { return defaultNotifier.Load() }
+
+// Publish queues an event on the global notifier when one is configured.
+func Publish(event Event) {
+	if n := defaultNotifier.Load(); n != nil {
+		n.Publish(event)
+	}
+}
+
+// RefreshFailureThreshold returns the global threshold, or 0 when notifications are disabled.
+func RefreshFailureThreshold() int {
+	return defaultNotifier.Load().RefreshFailureThreshold()
+}
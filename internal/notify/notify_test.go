@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+type capturedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newSink(t *testing.T, status func(attempt int) int) (*httptest.Server, func() []capturedRequest) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		requests []capturedRequest
+		attempts atomic.Int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, capturedRequest{header: r.Header.Clone(), body: body})
+		mu.Unlock()
+		w.WriteHeader(status(int(attempts.Add(1))))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []capturedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]capturedRequest(nil), requests...)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("condition not met before deadline")
+}
+
+func TestNotifierSignsAndFiltersEvents(t *testing.T) {
+	srv, captured := newSink(t, func(int) int { return http.StatusNoContent })
+	n := New(config.NotificationsConfig{Webhooks: []config.NotificationWebhook{{
+		Name:   "sink",
+		URL:    srv.URL,
+		Secret: "s3cret",
+		Events: []string{"quota_exceeded"},
+	}}}, "")
+	defer n.Close()
+
+	n.Publish(Event{Type: EventEgressDrift, Message: "ignored"})
+	n.Publish(Event{Type: EventQuotaExceeded, Provider: "claude", AuthID: "a1", Message: "quota exceeded"})
+	waitFor(t, func() bool { return n.Status().Delivered == 1 })
+
+	requests := captured()
+	if len(requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(requests))
+	}
+	req := requests[0]
+	if got := req.header.Get(EventHeader); got != string(EventQuotaExceeded) {
+		t.Fatalf("event header = %q", got)
+	}
+	if want := Sign("s3cret", req.header.Get(TimestampHeader), req.body); req.header.Get(SignatureHeader) != want {
+		t.Fatalf("signature = %q, want %q", req.header.Get(SignatureHeader), want)
+	}
+	var event Event
+	if err := json.Unmarshal(req.body, &event); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	if event.Type != EventQuotaExceeded || event.AuthID != "a1" || event.Severity != SeverityWarning {
+		t.Fatalf("payload = %+v", event)
+	}
+}
+
+func TestNotifierRetriesThenDeadLetters(t *testing.T) {
+	srv, captured := newSink(t, func(int) int { return http.StatusServiceUnavailable })
+	deadLetterFile := filepath.Join(t.TempDir(), "dead.jsonl")
+	n := New(config.NotificationsConfig{
+		DeadLetterFile: deadLetterFile,
+		Webhooks:       []config.NotificationWebhook{{Name: "sink", URL: srv.URL, MaxRetries: 2}},
+	}, "")
+	n.retryBase = time.Millisecond
+	defer n.Close()
+
+	n.Publish(Event{Type: EventAuthFatal, Message: "boom"})
+	waitFor(t, func() bool { return len(n.DeadLetters()) == 1 })
+
+	if got := len(captured()); got != 3 {
+		t.Fatalf("attempts = %d, want 3", got)
+	}
+	dead := n.DeadLetters()[0]
+	if dead.Attempts != 3 || dead.Target != "sink" || dead.Event.Type != EventAuthFatal {
+		t.Fatalf("dead letter = %+v", dead)
+	}
+	if n.Status().Failed != 1 {
+		t.Fatalf("failed = %d, want 1", n.Status().Failed)
+	}
+}
+
+func TestNotifierRecoversAfterTransientFailure(t *testing.T) {
+	srv, captured := newSink(t, func(attempt int) int {
+		if attempt == 1 {
+			return http.StatusBadGateway
+		}
+		return http.StatusOK
+	})
+	n := New(config.NotificationsConfig{Webhooks: []config.NotificationWebhook{{Name: "sink", URL: srv.URL, MaxRetries: 3}}}, "")
+	n.retryBase = time.Millisecond
+	defer n.Close()
+
+	n.Publish(Event{Type: EventRefreshFailed})
+	waitFor(t, func() bool { return n.Status().Delivered == 1 })
+	if got := len(captured()); got != 2 {
+		t.Fatalf("attempts = %d, want 2", got)
+	}
+	if len(n.DeadLetters()) != 0 {
+		t.Fatalf("unexpected dead letters: %+v", n.DeadLetters())
+	}
+}
+
+func TestNotifierSlowTargetDoesNotDelayOthers(t *testing.T) {
+	release := make(chan struct{})
+	slowSrv, _ := newSink(t, func(int) int {
+		<-release
+		return http.StatusOK
+	})
+	fastSrv, fastCaptured := newSink(t, func(int) int { return http.StatusOK })
+	n := New(config.NotificationsConfig{Webhooks: []config.NotificationWebhook{
+		{Name: "slow", URL: slowSrv.URL, TimeoutSeconds: 30},
+		{Name: "fast", URL: fastSrv.URL},
+	}}, "")
+	defer n.Close()
+	defer close(release)
+
+	n.Publish(Event{Type: EventAuthFatal, Message: "first"})
+	n.Publish(Event{Type: EventAuthFatal, Message: "second"})
+	waitFor(t, func() bool { return len(fastCaptured()) == 2 })
+}
+
+func TestNotifierSendFormatsSlackAndDiscord(t *testing.T) {
+	slackSrv, slackCaptured := newSink(t, func(int) int { return http.StatusOK })
+	discordSrv, discordCaptured := newSink(t, func(int) int { return http.StatusOK })
+	n := New(config.NotificationsConfig{Webhooks: []config.NotificationWebhook{
+		{Name: "slack", URL: slackSrv.URL, Format: "slack", Events: []string{"auth_fatal"}},
+		{Name: "discord", URL: discordSrv.URL, Format: "discord"},
+	}}, "")
+	defer n.Close()
+
+	results, err := n.Send(context.Background(), "", Event{Type: EventTest, Severity: SeverityInfo, Message: "hello"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if len(results) != 2 || results[0].StatusCode != http.StatusOK || results[1].StatusCode != http.StatusOK {
+		t.Fatalf("results = %+v", results)
+	}
+
+	var slack map[string]string
+	if err = json.Unmarshal(slackCaptured()[0].body, &slack); err != nil || !strings.Contains(slack["text"], "hello") {
+		t.Fatalf("slack payload = %s (%v)", slackCaptured()[0].body, err)
+	}
+	var discord map[string]string
+	if err = json.Unmarshal(discordCaptured()[0].body, &discord); err != nil || !strings.Contains(discord["content"], "[INFO] test") {
+		t.Fatalf("discord payload = %s (%v)", discordCaptured()[0].body, err)
+	}
+
+	if _, err = n.Send(context.Background(), "missing", Event{Type: EventTest}); err == nil {
+		t.Fatal("Send() to unknown target should fail")
+	}
+}
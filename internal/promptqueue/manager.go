@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/queuehealth"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 )
@@ -22,6 +24,7 @@ const (
 	defaultSessionQueueSize = 256
 	defaultMaxEvents        = 4096
 	defaultMaxSubmissions   = 20000
+	defaultWorkerIdleTime   = time.Minute
 )
 
 type SubmissionStatus string
@@ -72,8 +75,26 @@ type SubmitRequest struct {
 	Handler      string
 	Model        string
 	RequestID    string
+	// MaxQueueDepth rejects the submission with an *OverloadedError instead of waiting when the
+	// session already has that many submissions queued or running. <= 0 always waits.
+	MaxQueueDepth int
 }
 
+// OverloadedError reports a submission rejected because its session queue is full.
+type OverloadedError struct {
+	SessionKey string
+	// Position is the place the submission would have taken; 1 means it would run next.
+	Position int
+	Limit    int
+}
+
+func (e *OverloadedError) Error() string {
+	return fmt.Sprintf("prompt queue for session %s is full: queue position %d exceeds limit %d", e.SessionKey, e.Position, e.Limit)
+}
+
+// StatusCode lets HTTP handlers map the rejection to 429 Too Many Requests.
+func (e *OverloadedError) StatusCode() int { return http.StatusTooManyRequests }
+
 type ListOptions struct {
 	SessionKey string
 	Status     SubmissionStatus
@@ -86,6 +107,9 @@ type Config struct {
 	SessionQueueSize int
 	MaxEvents        int
 	MaxSubmissions   int
+	// WorkerIdleTime is how long a session worker waits with nothing pending before it stops
+	// and is removed, so one-off session keys do not keep a goroutine and queue forever.
+	WorkerIdleTime time.Duration
 }
 
 type Manager struct {
@@ -110,12 +134,21 @@ type Manager struct {
 	sessionQueueSize int
 	maxEvents        int
 	maxSubmissions   int
+	workerIdleTime   time.Duration
+
+	nextSubscriberID int64
+	subscribers      map[string]chan Event
 }
 
 type sessionWorker struct {
 	sessionKey string
 	jobs       chan *job
 	manager    *Manager
+	// pending counts accepted submissions that have not finished. Guarded by Manager.mu.
+	pending int
+	// overloaded is set once an overload was published and cleared when the session drains,
+	// so a burst of rejections notifies once. Guarded by Manager.mu.
+	overloaded bool
 }
 
 type job struct {
@@ -177,15 +210,20 @@ func NewManager(cfg Config) *Manager {
 	if cfg.MaxSubmissions <= 0 {
 		cfg.MaxSubmissions = defaultMaxSubmissions
 	}
+	if cfg.WorkerIdleTime <= 0 {
+		cfg.WorkerIdleTime = defaultWorkerIdleTime
+	}
 
 	m := &Manager{
 		workers:          make(map[string]*sessionWorker),
 		events:           make([]Event, 0, cfg.MaxEvents),
 		submissions:      make(map[string]*Submission),
 		submissionOrder:  make([]string, 0, cfg.MaxSubmissions),
+		subscribers:      make(map[string]chan Event),
 		sessionQueueSize: cfg.SessionQueueSize,
 		maxEvents:        cfg.MaxEvents,
 		maxSubmissions:   cfg.MaxSubmissions,
+		workerIdleTime:   cfg.WorkerIdleTime,
 	}
 	if strings.TrimSpace(cfg.StoreDir) != "" {
 		if store, err := newDiskStore(cfg.StoreDir); err == nil {
@@ -240,7 +278,29 @@ func (m *Manager) SubmitAndWait(req SubmitRequest, run func(submissionID string)
 		req.SubmissionID = submissionID
 		s.ID = submissionID
 	}
+	worker := m.ensureWorkerLocked(sessionKey)
+	if limit := req.MaxQueueDepth; limit > 0 && worker.pending >= limit {
+		depth := worker.pending
+		overloaded := &OverloadedError{SessionKey: sessionKey, Position: depth + 1, Limit: limit}
+		m.overloadedTotal++
+		queuehealth.Inc("prompt_queue_session_rejected")
+		_ = m.appendEventLocked("queue_overloaded", submissionID, sessionKey, map[string]any{
+			"queue_depth":    depth,
+			"queue_limit":    limit,
+			"queue_position": overloaded.Position,
+			"rejected":       true,
+		})
+		publish := !worker.overloaded
+		worker.overloaded = true
+		m.mu.Unlock()
+		if publish {
+			publishOverloaded(s, depth, limit)
+		}
+		return submissionID, overloaded
+	}
+	worker.pending++
 	if err := m.storeSubmissionLocked(s); err != nil {
+		worker.pending--
 		m.mu.Unlock()
 		return "", err
 	}
@@ -249,14 +309,17 @@ func (m *Manager) SubmitAndWait(req SubmitRequest, run func(submissionID string)
 	m.submittedTotal++
 	m.evictSubmissionsIfNeededLocked()
 	_ = m.appendEventLocked("submission_queued", submissionID, sessionKey, map[string]any{
-		"handler": s.Handler,
-		"model":   s.Model,
+		"handler":        s.Handler,
+		"model":          s.Model,
+		"queue_position": worker.pending,
 	})
 
-	worker := m.ensureWorkerLocked(sessionKey)
 	queueLen := len(worker.jobs)
 	queueCap := cap(worker.jobs)
+	publish := false
 	if queueCap > 0 && queueLen >= queueCap {
+		publish = !worker.overloaded
+		worker.overloaded = true
 		m.overloadedTotal++
 		queuehealth.Inc("prompt_queue_session_channel_full")
 		_ = m.appendEventLocked("queue_overloaded", submissionID, sessionKey, map[string]any{
@@ -265,6 +328,9 @@ func (m *Manager) SubmitAndWait(req SubmitRequest, run func(submissionID string)
 		})
 	}
 	m.mu.Unlock()
+	if publish {
+		publishOverloaded(s, queueLen, queueCap)
+	}
 
 	j := &job{
 		req: req,
@@ -348,6 +414,36 @@ func (m *Manager) EventsSince(sessionKey string, sinceSeq int64, limit int) []Ev
 	return out
 }
 
+// Subscribe returns a channel that receives every event appended after the call. Slow
+// subscribers miss events rather than block the queue; EventsSince fills such gaps. The
+// caller must call Unsubscribe when done.
+func (m *Manager) Subscribe() (id string, events <-chan Event) {
+	ch := make(chan Event, 256)
+	if m == nil {
+		close(ch)
+		return "", ch
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextSubscriberID++
+	id = fmt.Sprintf("sub-%d", m.nextSubscriberID)
+	m.subscribers[id] = ch
+	return id, ch
+}
+
+// Unsubscribe removes a subscriber and closes its channel.
+func (m *Manager) Unsubscribe(id string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ch, ok := m.subscribers[id]; ok {
+		close(ch)
+		delete(m.subscribers, id)
+	}
+}
+
 func (m *Manager) MetricsSnapshot() MetricsSnapshot {
 	snap := MetricsSnapshot{
 		QueueDepthBySK: make(map[string]int),
@@ -433,6 +529,20 @@ func (m *Manager) ensureWorkerLocked(sessionKey string) *sessionWorker {
 	return worker
 }
 
+// retireWorker removes an idle worker. It fails while submissions are pending: they were
+// counted under m.mu before being sent, so a worker that retires never has a sender.
+func (m *Manager) retireWorker(worker *sessionWorker) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if worker.pending > 0 {
+		return false
+	}
+	if current, ok := m.workers[worker.sessionKey]; ok && current == worker {
+		delete(m.workers, worker.sessionKey)
+	}
+	return true
+}
+
 func (m *Manager) evictSubmissionsIfNeededLocked() {
 	if m.maxSubmissions <= 0 {
 		return
@@ -466,6 +576,12 @@ func (m *Manager) markRunning(submissionID, sessionKey string) {
 func (m *Manager) markFinished(submissionID, sessionKey string, err error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if worker, ok := m.workers[sessionKey]; ok && worker != nil && worker.pending > 0 {
+		worker.pending--
+		if worker.pending == 0 {
+			worker.overloaded = false
+		}
+	}
 	sub, ok := m.submissions[submissionID]
 	if !ok || sub == nil {
 		return
@@ -505,9 +621,32 @@ func (m *Manager) appendEventLocked(eventType, submissionID, sessionKey string,
 	if len(m.events) > m.maxEvents {
 		m.events = append([]Event(nil), m.events[len(m.events)-m.maxEvents:]...)
 	}
+	for _, ch := range m.subscribers {
+		select {
+		case ch <- cloneEvent(ev):
+		default:
+			queuehealth.Inc("prompt_queue_subscriber_channel_full")
+		}
+	}
 	return nil
 }
 
+func publishOverloaded(s Submission, depth, limit int) {
+	notify.Publish(notify.Event{
+		Type:     notify.EventQueueOverloaded,
+		Severity: notify.SeverityWarning,
+		Model:    s.Model,
+		Message:  fmt.Sprintf("prompt queue for session %s is full (%d/%d)", s.SessionKey, depth, limit),
+		Fields: map[string]any{
+			"session_key":   s.SessionKey,
+			"submission_id": s.ID,
+			"handler":       s.Handler,
+			"queue_depth":   depth,
+			"queue_cap":     limit,
+		},
+	})
+}
+
 func (m *Manager) storeSubmissionLocked(s Submission) error {
 	if m.store == nil {
 		return nil
@@ -522,26 +661,44 @@ func (m *Manager) storeEventLocked(ev Event) error {
 	return m.store.appendEvent(ev)
 }
 
+// run processes jobs in order and stops once the worker has been idle for workerIdleTime.
 func (w *sessionWorker) run() {
-	for j := range w.jobs {
+	idle := time.NewTimer(w.manager.workerIdleTime)
+	defer idle.Stop()
+	for {
+		var j *job
+		select {
+		case j = <-w.jobs:
+		case <-idle.C:
+			if w.manager.retireWorker(w) {
+				return
+			}
+			idle.Reset(w.manager.workerIdleTime)
+			continue
+		}
 		if j == nil {
 			continue
 		}
-		w.manager.markRunning(j.req.SubmissionID, w.sessionKey)
-		var execErr error
-		func() {
-			defer func() {
-				if recovered := recover(); recovered != nil {
-					execErr = fmt.Errorf("prompt queue worker panic: %v", recovered)
-				}
-			}()
-			execErr = j.run(j.req.SubmissionID)
-		}()
-		w.manager.markFinished(j.req.SubmissionID, w.sessionKey, execErr)
-		j.ack <- execErr
+		w.runJob(j)
+		idle.Reset(w.manager.workerIdleTime)
 	}
 }
 
+func (w *sessionWorker) runJob(j *job) {
+	w.manager.markRunning(j.req.SubmissionID, w.sessionKey)
+	var execErr error
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				execErr = fmt.Errorf("prompt queue worker panic: %v", recovered)
+			}
+		}()
+		execErr = j.run(j.req.SubmissionID)
+	}()
+	w.manager.markFinished(j.req.SubmissionID, w.sessionKey, execErr)
+	j.ack <- execErr
+}
+
 func newDiskStore(dir string) (*diskStore, error) {
 	dir = strings.TrimSpace(dir)
 	if dir == "" {
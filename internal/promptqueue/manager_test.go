@@ -1,11 +1,19 @@
 package promptqueue
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
+	"github.com/tidwall/gjson"
 )
 
 func TestFiveSessionsThirtyEachNoLoss(t *testing.T) {
@@ -135,3 +143,185 @@ func TestOverloadedMetricObserved(t *testing.T) {
 		t.Fatalf("overloaded metric not incremented")
 	}
 }
+
+func TestMaxQueueDepthRejectsWithPosition(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(Config{StoreDir: t.TempDir()})
+	subID, events := manager.Subscribe()
+	defer manager.Unsubscribe(subID)
+
+	start := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := manager.SubmitAndWait(SubmitRequest{SessionKey: "s", MaxQueueDepth: 1}, func(_ string) error {
+			close(start)
+			<-release
+			return nil
+		})
+		done <- err
+	}()
+	<-start
+
+	ran := false
+	_, err := manager.SubmitAndWait(SubmitRequest{SessionKey: "s", MaxQueueDepth: 1}, func(_ string) error {
+		ran = true
+		return nil
+	})
+	var overloaded *OverloadedError
+	if !errors.As(err, &overloaded) {
+		t.Fatalf("err=%v, want *OverloadedError", err)
+	}
+	if ran {
+		t.Fatalf("rejected submission must not run")
+	}
+	if overloaded.Position != 2 || overloaded.Limit != 1 || overloaded.StatusCode() != 429 {
+		t.Fatalf("overloaded=%+v", overloaded)
+	}
+
+	// Other sessions are not affected.
+	if _, err = manager.SubmitAndWait(SubmitRequest{SessionKey: "other", MaxQueueDepth: 1}, func(_ string) error { return nil }); err != nil {
+		t.Fatalf("other session: %v", err)
+	}
+
+	close(release)
+	if err = <-done; err != nil {
+		t.Fatalf("first submission: %v", err)
+	}
+	if _, err = manager.SubmitAndWait(SubmitRequest{SessionKey: "s", MaxQueueDepth: 1}, func(_ string) error { return nil }); err != nil {
+		t.Fatalf("submission after drain: %v", err)
+	}
+
+	rejected := false
+	timeout := time.After(2 * time.Second)
+	for !rejected {
+		select {
+		case ev := <-events:
+			if ev.Type == "queue_overloaded" && ev.Payload["rejected"] == true {
+				rejected = true
+			}
+		case <-timeout:
+			t.Fatalf("subscriber did not receive the rejection event")
+		}
+	}
+}
+
+func TestIdleWorkersAreReclaimed(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(Config{WorkerIdleTime: 20 * time.Millisecond})
+	for i := 0; i < 10; i++ {
+		if _, err := manager.SubmitAndWait(SubmitRequest{SessionKey: fmt.Sprintf("session-%d", i)}, func(string) error { return nil }); err != nil {
+			t.Fatalf("SubmitAndWait: %v", err)
+		}
+	}
+
+	workerCount := func() int {
+		manager.mu.RLock()
+		defer manager.mu.RUnlock()
+		return len(manager.workers)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for workerCount() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("workers = %d after idle time, want 0", workerCount())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// A session whose worker was reclaimed gets a new one.
+	ran := false
+	if _, err := manager.SubmitAndWait(SubmitRequest{SessionKey: "session-0"}, func(string) error { ran = true; return nil }); err != nil || !ran {
+		t.Fatalf("SubmitAndWait after reclaim: ran = %v, err = %v", ran, err)
+	}
+}
+
+func TestBusyWorkerIsNotReclaimed(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(Config{WorkerIdleTime: 5 * time.Millisecond})
+	release := make(chan struct{})
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := manager.SubmitAndWait(SubmitRequest{SessionKey: "busy"}, func(string) error {
+				<-release
+				return nil
+			})
+			done <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	manager.mu.RLock()
+	_, ok := manager.workers["busy"]
+	manager.mu.RUnlock()
+	if !ok {
+		t.Fatal("worker with pending submissions was reclaimed")
+	}
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("SubmitAndWait: %v", err)
+		}
+	}
+}
+
+func TestOverloadNotifiesOncePerEpisode(t *testing.T) {
+	var published atomic.Int32
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if gjson.GetBytes(body, "fields.session_key").String() == "notify-once" {
+			published.Add(1)
+		}
+	}))
+	defer sink.Close()
+	notify.Configure(config.NotificationsConfig{Enabled: true, Webhooks: []config.NotificationWebhook{{URL: sink.URL}}}, "")
+	defer notify.Configure(config.NotificationsConfig{}, "")
+
+	manager := NewManager(Config{StoreDir: t.TempDir()})
+	overload := func() {
+		start := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			_, err := manager.SubmitAndWait(SubmitRequest{SessionKey: "notify-once", MaxQueueDepth: 1}, func(_ string) error {
+				close(start)
+				<-release
+				return nil
+			})
+			done <- err
+		}()
+		<-start
+		for i := 0; i < 3; i++ {
+			if _, err := manager.SubmitAndWait(SubmitRequest{SessionKey: "notify-once", MaxQueueDepth: 1}, func(_ string) error { return nil }); err == nil {
+				t.Fatal("submission over the limit was accepted")
+			}
+		}
+		close(release)
+		if err := <-done; err != nil {
+			t.Fatalf("first submission: %v", err)
+		}
+	}
+
+	overload()
+	waitForCount(t, &published, 1)
+	// The session drained, so the next overload is a new episode.
+	overload()
+	waitForCount(t, &published, 2)
+	time.Sleep(50 * time.Millisecond)
+	if got := published.Load(); got != 2 {
+		t.Fatalf("published %d overload notifications, want 2", got)
+	}
+}
+
+func waitForCount(t *testing.T, counter *atomic.Int32, want int32) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for counter.Load() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("count = %d, want %d", counter.Load(), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,176 @@
+// Package replay re-issues requests captured by the file request logger and diffs
+// the new response against the recorded one, so production incidents can be turned
+// into reproducible regression cases.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sectionRequestInfo = "=== REQUEST INFO ==="
+	sectionHeaders     = "=== HEADERS ==="
+	sectionRequestBody = "=== REQUEST BODY ==="
+	sectionResponse    = "=== RESPONSE ==="
+)
+
+// sectionPrefixes lists markers that terminate the request body section.
+var sectionPrefixes = []string{"=== API REQUEST", "=== API ERROR RESPONSE", "=== API RESPONSE", sectionResponse}
+
+// Capture is a request/response pair parsed from a request log file.
+type Capture struct {
+	URL              string              `json:"url"`
+	Method           string              `json:"method"`
+	Timestamp        time.Time           `json:"timestamp,omitempty"`
+	Headers          map[string][]string `json:"headers,omitempty"`
+	Body             []byte              `json:"-"`
+	ResponseStatus   int                 `json:"response_status"`
+	ResponseHeaders  map[string][]string `json:"response_headers,omitempty"`
+	ResponseBody     []byte              `json:"-"`
+	ResponseRecorded bool                `json:"response_recorded"`
+}
+
+// ParseLogFile reads and parses a request log file.
+func ParseLogFile(path string) (*Capture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLog(bytes.NewReader(data))
+}
+
+// ParseLog parses the request log format written by the file request logger.
+func ParseLog(r io.Reader) (*Capture, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte(sectionRequestInfo)) {
+		return nil, fmt.Errorf("not a request log: missing %s", sectionRequestInfo)
+	}
+	capture := &Capture{Headers: map[string][]string{}}
+
+	info, rest := splitSection(data[len(sectionRequestInfo):], sectionHeaders)
+	for _, line := range strings.Split(string(info), "\n") {
+		key, value, ok := splitHeaderLine(line)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "URL":
+			capture.URL = value
+		case "Method":
+			capture.Method = value
+		case "Timestamp":
+			if ts, errParse := time.Parse(time.RFC3339Nano, value); errParse == nil {
+				capture.Timestamp = ts
+			}
+		}
+	}
+	if capture.URL == "" {
+		return nil, fmt.Errorf("request log has no URL")
+	}
+	if capture.Method == "" {
+		capture.Method = "POST"
+	}
+
+	headers, rest := splitSection(rest, sectionRequestBody)
+	capture.Headers = parseHeaderBlock(headers)
+
+	bodyEnd := len(rest)
+	for _, prefix := range sectionPrefixes {
+		if idx := indexAtLineStart(rest, prefix); idx >= 0 && idx < bodyEnd {
+			bodyEnd = idx
+		}
+	}
+	capture.Body = bytes.TrimSuffix(bytes.TrimPrefix(rest[:bodyEnd], []byte("\n")), []byte("\n\n"))
+
+	if idx := indexAtLineStart(rest, sectionResponse); idx >= 0 {
+		parseResponseSection(capture, rest[idx+len(sectionResponse):])
+	}
+	return capture, nil
+}
+
+func parseResponseSection(capture *Capture, section []byte) {
+	capture.ResponseRecorded = true
+	capture.ResponseHeaders = map[string][]string{}
+	section = bytes.TrimPrefix(section, []byte("\n"))
+	reader := bufio.NewReader(bytes.NewReader(section))
+	consumed := 0
+	for {
+		line, err := reader.ReadString('\n')
+		consumed += len(line)
+		trimmed := strings.TrimRight(line, "\r\n")
+		if trimmed == "" {
+			break
+		}
+		if key, value, ok := splitHeaderLine(trimmed); ok {
+			if key == "Status" {
+				capture.ResponseStatus, _ = strconv.Atoi(value)
+			} else {
+				capture.ResponseHeaders[key] = append(capture.ResponseHeaders[key], value)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	if consumed < len(section) {
+		capture.ResponseBody = bytes.TrimRight(section[consumed:], "\n")
+	}
+}
+
+func parseHeaderBlock(block []byte) map[string][]string {
+	headers := map[string][]string{}
+	for _, line := range strings.Split(string(block), "\n") {
+		if key, value, ok := splitHeaderLine(line); ok {
+			headers[key] = append(headers[key], value)
+		}
+	}
+	return headers
+}
+
+func splitHeaderLine(line string) (string, string, bool) {
+	line = strings.TrimRight(line, "\r")
+	idx := strings.Index(line, ":")
+	if idx <= 0 {
+		return "", "", false
+	}
+	key := strings.TrimSpace(line[:idx])
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(line[idx+1:]), true
+}
+
+// splitSection returns the content before marker and the content after it.
+func splitSection(data []byte, marker string) ([]byte, []byte) {
+	idx := indexAtLineStart(data, marker)
+	if idx < 0 {
+		return data, nil
+	}
+	return data[:idx], data[idx+len(marker):]
+}
+
+func indexAtLineStart(data []byte, marker string) int {
+	needle := []byte(marker)
+	offset := 0
+	for {
+		idx := bytes.Index(data[offset:], needle)
+		if idx < 0 {
+			return -1
+		}
+		pos := offset + idx
+		if pos == 0 || data[pos-1] == '\n' {
+			return pos
+		}
+		offset = pos + len(needle)
+	}
+}
@@ -0,0 +1,222 @@
+package replay
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Change kinds reported in a Diff.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// DefaultIgnoreKeys are volatile leaf keys excluded from body diffs.
+var DefaultIgnoreKeys = []string{"id", "created", "created_at", "system_fingerprint", "responseId", "createTime", "msg_id", "signature"}
+
+// Change is a single difference between the recorded and replayed responses.
+type Change struct {
+	Path     string `json:"path"`
+	Kind     string `json:"kind"`
+	Recorded string `json:"recorded,omitempty"`
+	Replayed string `json:"replayed,omitempty"`
+}
+
+// Diff is a structured comparison of two responses.
+type Diff struct {
+	Equal          bool     `json:"equal"`
+	StatusChanged  bool     `json:"status_changed"`
+	RecordedStatus int      `json:"recorded_status"`
+	ReplayedStatus int      `json:"replayed_status"`
+	BodyFormat     string   `json:"body_format"`
+	Changes        []Change `json:"changes,omitempty"`
+}
+
+// Compare diffs two responses. JSON bodies are compared field by field, SSE
+// bodies event by event, and anything else as text.
+func Compare(recordedStatus int, recorded []byte, replayedStatus int, replayed []byte, ignorePaths []string) Diff {
+	diff := Diff{
+		RecordedStatus: recordedStatus,
+		ReplayedStatus: replayedStatus,
+		StatusChanged:  recordedStatus != replayedStatus,
+	}
+	ignore := newIgnoreSet(ignorePaths)
+	recorded, replayed = bytes.TrimSpace(recorded), bytes.TrimSpace(replayed)
+
+	switch {
+	case isSSE(recorded) || isSSE(replayed):
+		diff.BodyFormat = "sse"
+		recordedEvents, replayedEvents := sseEvents(recorded), sseEvents(replayed)
+		if len(recordedEvents) != len(replayedEvents) {
+			diff.Changes = append(diff.Changes, Change{
+				Path:     "events.#",
+				Kind:     ChangeChanged,
+				Recorded: strconv.Itoa(len(recordedEvents)),
+				Replayed: strconv.Itoa(len(replayedEvents)),
+			})
+		}
+		for i := 0; i < len(recordedEvents) || i < len(replayedEvents); i++ {
+			var a, b []byte
+			if i < len(recordedEvents) {
+				a = recordedEvents[i]
+			}
+			if i < len(replayedEvents) {
+				b = replayedEvents[i]
+			}
+			diff.Changes = append(diff.Changes, compareDocuments("events."+strconv.Itoa(i), a, b, ignore)...)
+		}
+	case gjson.ValidBytes(recorded) && gjson.ValidBytes(replayed) && len(recorded) > 0 && len(replayed) > 0:
+		diff.BodyFormat = "json"
+		diff.Changes = compareDocuments("", recorded, replayed, ignore)
+	default:
+		diff.BodyFormat = "text"
+		if !bytes.Equal(recorded, replayed) {
+			diff.Changes = []Change{{Path: "body", Kind: ChangeChanged, Recorded: string(recorded), Replayed: string(replayed)}}
+		}
+	}
+	diff.Equal = !diff.StatusChanged && len(diff.Changes) == 0
+	return diff
+}
+
+func compareDocuments(prefix string, recorded, replayed []byte, ignore ignoreSet) []Change {
+	a, b := flattenJSON(prefix, recorded), flattenJSON(prefix, replayed)
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []Change
+	for _, key := range keys {
+		if ignore.matches(key) {
+			continue
+		}
+		va, inA := a[key]
+		vb, inB := b[key]
+		switch {
+		case inA && !inB:
+			changes = append(changes, Change{Path: key, Kind: ChangeRemoved, Recorded: va})
+		case !inA && inB:
+			changes = append(changes, Change{Path: key, Kind: ChangeAdded, Replayed: vb})
+		case va != vb:
+			changes = append(changes, Change{Path: key, Kind: ChangeChanged, Recorded: va, Replayed: vb})
+		}
+	}
+	return changes
+}
+
+// flattenJSON maps every leaf of a JSON document to its raw value.
+func flattenJSON(prefix string, doc []byte) map[string]string {
+	out := map[string]string{}
+	if len(doc) == 0 {
+		return out
+	}
+	if !gjson.ValidBytes(doc) {
+		out[joinPath(prefix, "raw")] = string(doc)
+		return out
+	}
+	flattenValue(prefix, gjson.ParseBytes(doc), out)
+	return out
+}
+
+func flattenValue(path string, value gjson.Result, out map[string]string) {
+	switch {
+	case value.IsObject():
+		empty := true
+		value.ForEach(func(key, child gjson.Result) bool {
+			empty = false
+			flattenValue(joinPath(path, key.String()), child, out)
+			return true
+		})
+		if empty {
+			out[path] = "{}"
+		}
+	case value.IsArray():
+		items := value.Array()
+		if len(items) == 0 {
+			out[path] = "[]"
+		}
+		for i, item := range items {
+			flattenValue(joinPath(path, strconv.Itoa(i)), item, out)
+		}
+	default:
+		out[path] = value.Raw
+	}
+}
+
+func joinPath(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "." + segment
+}
+
+func isSSE(body []byte) bool {
+	return bytes.HasPrefix(body, []byte("data:")) || bytes.HasPrefix(body, []byte("event:"))
+}
+
+// sseEvents returns the payload of every "data:" line, skipping the [DONE] sentinel.
+func sseEvents(body []byte) [][]byte {
+	var events [][]byte
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		payload := bytes.TrimSpace(line[len("data:"):])
+		if len(payload) == 0 || bytes.Equal(payload, []byte("[DONE]")) {
+			continue
+		}
+		events = append(events, payload)
+	}
+	return events
+}
+
+type ignoreSet struct {
+	keys  map[string]struct{}
+	paths []string
+}
+
+func newIgnoreSet(paths []string) ignoreSet {
+	set := ignoreSet{keys: make(map[string]struct{}, len(DefaultIgnoreKeys))}
+	for _, key := range DefaultIgnoreKeys {
+		set.keys[key] = struct{}{}
+	}
+	for _, path := range paths {
+		if trimmed := strings.TrimSpace(path); trimmed != "" {
+			set.paths = append(set.paths, trimmed)
+		}
+	}
+	return set
+}
+
+// matches reports whether path has an ignored leaf key, equals an ignored path,
+// or lies below one. Ignored paths match with or without the "events.N." prefix.
+func (s ignoreSet) matches(path string) bool {
+	segments := strings.Split(path, ".")
+	if _, ok := s.keys[segments[len(segments)-1]]; ok {
+		return true
+	}
+	candidates := []string{path}
+	if len(segments) > 2 && segments[0] == "events" {
+		candidates = append(candidates, strings.Join(segments[2:], "."))
+	}
+	for _, ignored := range s.paths {
+		for _, candidate := range candidates {
+			if candidate == ignored || strings.HasPrefix(candidate, ignored+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
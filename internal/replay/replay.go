@@ -0,0 +1,229 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// DefaultTarget is the proxy address used when no target is given.
+const DefaultTarget = "http://127.0.0.1:8317"
+
+// maxResponseBytes caps how much of a replayed response is read.
+const maxResponseBytes = 32 << 20
+
+// skippedHeaders are not forwarded when re-issuing a captured request.
+var skippedHeaders = map[string]struct{}{
+	"host":              {},
+	"content-length":    {},
+	"connection":        {},
+	"keep-alive":        {},
+	"transfer-encoding": {},
+	"accept-encoding":   {},
+	"upgrade":           {},
+}
+
+// credentialHeaders are never replayed from a capture; Options.APIKey takes their place.
+var credentialHeaders = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"x-api-key":           {},
+	"x-goog-api-key":      {},
+	"cookie":              {},
+}
+
+// Options controls how a captured request is re-issued.
+type Options struct {
+	// Target is the base URL the request path is appended to.
+	Target string
+	// Model overrides the model in the request body and Gemini-style URL paths.
+	Model string
+	// APIKey is the client credential sent with the replayed request. Captured credential
+	// headers and "key" query parameters are always dropped, so without it none is sent.
+	APIKey string
+	// Headers are set on the replayed request after the captured ones.
+	Headers map[string]string
+	// IgnorePaths lists JSON paths excluded from the diff, in addition to DefaultIgnoreKeys.
+	IgnorePaths []string
+	// Timeout bounds the whole replay. Zero means 5 minutes.
+	Timeout time.Duration
+	// Client is the HTTP client used. Nil means a default client.
+	Client *http.Client
+}
+
+// Response is the outcome of a replayed request.
+type Response struct {
+	Status    int                 `json:"status"`
+	Headers   map[string][]string `json:"headers,omitempty"`
+	Body      string              `json:"body"`
+	LatencyMs int64               `json:"latency_ms"`
+}
+
+// Result bundles the replayed request, both responses and their diff.
+type Result struct {
+	Method   string   `json:"method"`
+	URL      string   `json:"url"`
+	Model    string   `json:"model,omitempty"`
+	Recorded Response `json:"recorded"`
+	Replayed Response `json:"replayed"`
+	Diff     Diff     `json:"diff"`
+}
+
+// Replay re-issues the captured request against opts.Target and diffs the response.
+func Replay(ctx context.Context, capture *Capture, opts Options) (*Result, error) {
+	if capture == nil {
+		return nil, fmt.Errorf("replay: nil capture")
+	}
+	target := strings.TrimRight(strings.TrimSpace(opts.Target), "/")
+	if target == "" {
+		target = DefaultTarget
+	}
+	base, err := url.Parse(target)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("replay: invalid target %q", opts.Target)
+	}
+	path, body := applyModelOverride(capture.URL, capture.Body, opts.Model)
+	path, hadQueryKey := stripQueryKey(path)
+	if hadQueryKey && opts.APIKey != "" {
+		path = setQueryKey(path, opts.APIKey)
+	}
+	reqURL := target + path
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, capture.Method, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("replay: build request: %w", err)
+	}
+	var keyHeaders []string
+	for key, values := range capture.Headers {
+		lower := strings.ToLower(key)
+		if _, skip := skippedHeaders[lower]; skip {
+			continue
+		}
+		if _, credential := credentialHeaders[lower]; credential {
+			if lower == "x-api-key" || lower == "x-goog-api-key" {
+				keyHeaders = append(keyHeaders, key)
+			}
+			continue
+		}
+		for _, value := range values {
+			if isRedactedValue(value) {
+				continue
+			}
+			req.Header.Add(key, value)
+		}
+	}
+	if opts.APIKey != "" {
+		for _, key := range keyHeaders {
+			req.Header.Set(key, opts.APIKey)
+		}
+		req.Header.Set("Authorization", "Bearer "+opts.APIKey)
+	}
+	for key, value := range opts.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("replay: read response: %w", err)
+	}
+
+	result := &Result{
+		Method: capture.Method,
+		URL:    reqURL,
+		Model:  gjson.GetBytes(body, "model").String(),
+		Recorded: Response{
+			Status:  capture.ResponseStatus,
+			Headers: capture.ResponseHeaders,
+			Body:    string(capture.ResponseBody),
+		},
+		Replayed: Response{
+			Status:    resp.StatusCode,
+			Headers:   resp.Header,
+			Body:      string(respBody),
+			LatencyMs: time.Since(start).Milliseconds(),
+		},
+	}
+	if opts.Model != "" {
+		result.Model = opts.Model
+	}
+	result.Diff = Compare(capture.ResponseStatus, capture.ResponseBody, resp.StatusCode, respBody, opts.IgnorePaths)
+	return result, nil
+}
+
+// applyModelOverride rewrites the model in the JSON body and in Gemini-style
+// "/models/<model>:<action>" URL paths.
+func applyModelOverride(path string, body []byte, model string) (string, []byte) {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return path, body
+	}
+	if gjson.GetBytes(body, "model").Exists() {
+		if updated, err := sjson.SetBytes(body, "model", model); err == nil {
+			body = updated
+		}
+	}
+	if idx := strings.Index(path, "/models/"); idx >= 0 {
+		start := idx + len("/models/")
+		end := len(path)
+		if colon := strings.IndexAny(path[start:], ":?/"); colon >= 0 {
+			end = start + colon
+		}
+		path = path[:start] + model + path[end:]
+	}
+	return path, body
+}
+
+// stripQueryKey removes the "key" query parameter Gemini clients may authenticate with.
+func stripQueryKey(path string) (string, bool) {
+	parsed, err := url.Parse(path)
+	if err != nil {
+		return path, false
+	}
+	query := parsed.Query()
+	if !query.Has("key") {
+		return path, false
+	}
+	query.Del("key")
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), true
+}
+
+func setQueryKey(path, key string) string {
+	parsed, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	query := parsed.Query()
+	query.Set("key", key)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+func isRedactedValue(value string) bool {
+	return strings.HasPrefix(value, "[REDACTED:") || strings.HasPrefix(value, "[HASH:")
+}
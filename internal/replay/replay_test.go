@@ -0,0 +1,152 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/tidwall/gjson"
+)
+
+func TestReplayParsesLogAndDiffsResponse(t *testing.T) {
+	dir := t.TempDir()
+	logger := logging.NewFileRequestLogger(true, dir, "", 0)
+	headers := map[string][]string{
+		"Authorization": {"Bearer recorded-key"},
+		"Content-Type":  {"application/json"},
+	}
+	err := logger.LogRequest("/v1/chat/completions?debug=1", http.MethodPost, headers,
+		[]byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hi"}]}`), http.StatusOK,
+		map[string][]string{"Content-Type": {"application/json"}},
+		[]byte(`{"id":"a","choices":[{"message":{"content":"hello"}}],"usage":{"total_tokens":5}}`),
+		nil, nil, nil, "cap", time.Now(), time.Time{})
+	if err != nil {
+		t.Fatalf("LogRequest() error = %v", err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*-cap.log"))
+	if len(matches) != 1 {
+		t.Fatalf("log file not found")
+	}
+	capture, err := ParseLogFile(matches[0])
+	if err != nil {
+		t.Fatalf("ParseLogFile() error = %v", err)
+	}
+	if capture.URL != "/v1/chat/completions?debug=1" || capture.Method != http.MethodPost || capture.ResponseStatus != http.StatusOK {
+		t.Fatalf("capture = %+v", capture)
+	}
+	if gjson.GetBytes(capture.Body, "messages.0.content").String() != "hi" {
+		t.Fatalf("body = %s", capture.Body)
+	}
+
+	var gotAuth, gotModel, gotPath string
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotAuth, gotModel, gotPath = r.Header.Get("Authorization"), gjson.GetBytes(body, "model").String(), r.URL.String()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"b","choices":[{"message":{"content":"goodbye"}}],"usage":{"total_tokens":5},"extra":true}`))
+	}))
+	defer stub.Close()
+
+	_, err = Replay(context.Background(), capture, Options{Target: stub.URL})
+	if err != nil {
+		t.Fatalf("Replay() without key error = %v", err)
+	}
+	if gotAuth != "" {
+		t.Fatalf("captured credential was replayed: %q", gotAuth)
+	}
+
+	result, err := Replay(context.Background(), capture, Options{Target: stub.URL, Model: "gpt-5-mini", APIKey: "replay-key"})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if gotAuth != "Bearer replay-key" || gotModel != "gpt-5-mini" || gotPath != "/v1/chat/completions?debug=1" {
+		t.Fatalf("stub saw auth=%q model=%q path=%q", gotAuth, gotModel, gotPath)
+	}
+	diff := result.Diff
+	if diff.Equal || diff.StatusChanged || diff.BodyFormat != "json" {
+		t.Fatalf("diff = %+v", diff)
+	}
+	want := map[string]string{"choices.0.message.content": ChangeChanged, "extra": ChangeAdded}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("changes = %+v, want %v", diff.Changes, want)
+	}
+	for _, change := range diff.Changes {
+		if want[change.Path] != change.Kind {
+			t.Fatalf("unexpected change %+v", change)
+		}
+	}
+}
+
+func TestCompareSSEAndIgnorePaths(t *testing.T) {
+	recorded := []byte("data: {\"id\":\"1\",\"delta\":\"a\",\"usage\":{\"t\":1}}\n\ndata: [DONE]\n")
+	replayed := []byte("data: {\"id\":\"2\",\"delta\":\"a\",\"usage\":{\"t\":2}}\n\ndata: {\"delta\":\"b\"}\n\ndata: [DONE]\n")
+
+	diff := Compare(http.StatusOK, recorded, http.StatusOK, replayed, []string{"usage"})
+	if diff.BodyFormat != "sse" || diff.Equal {
+		t.Fatalf("diff = %+v", diff)
+	}
+	if len(diff.Changes) != 2 || diff.Changes[0].Path != "events.#" || diff.Changes[1].Path != "events.1.delta" {
+		t.Fatalf("changes = %+v", diff.Changes)
+	}
+
+	if same := Compare(http.StatusOK, recorded, http.StatusOK, recorded, nil); !same.Equal {
+		t.Fatalf("identical bodies not equal: %+v", same)
+	}
+	if status := Compare(http.StatusOK, []byte("ok"), http.StatusBadGateway, []byte("ok"), nil); status.Equal || !status.StatusChanged {
+		t.Fatalf("status diff = %+v", status)
+	}
+}
+
+func TestParseLogRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.log")
+	if err := os.WriteFile(path, []byte("plain log line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseLogFile(path); err == nil {
+		t.Fatal("expected error for non request log")
+	}
+}
+
+func TestReplayDropsCapturedCredentials(t *testing.T) {
+	capture := &Capture{
+		Method: http.MethodPost,
+		URL:    "/v1beta/models/gemini-2.5-pro:generateContent?alt=sse&key=recorded-key",
+		Headers: map[string][]string{
+			"X-Goog-Api-Key": {"recorded-key"},
+			"Cookie":         {"session=recorded"},
+			"Content-Type":   {"application/json"},
+		},
+		Body: []byte(`{"contents":[]}`),
+	}
+	var got *http.Request
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Clone(context.Background())
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer stub.Close()
+
+	if _, err := Replay(context.Background(), capture, Options{Target: stub.URL}); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	for _, key := range []string{"Authorization", "X-Goog-Api-Key", "Cookie"} {
+		if value := got.Header.Get(key); value != "" {
+			t.Fatalf("%s = %q, want it dropped", key, value)
+		}
+	}
+	if got.URL.Query().Has("key") || got.URL.Query().Get("alt") != "sse" {
+		t.Fatalf("query = %q, want alt only", got.URL.RawQuery)
+	}
+
+	if _, err := Replay(context.Background(), capture, Options{Target: stub.URL, APIKey: "replay-key"}); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if got.Header.Get("X-Goog-Api-Key") != "replay-key" || got.URL.Query().Get("key") != "replay-key" {
+		t.Fatalf("header = %q, query = %q, want the replay key", got.Header.Get("X-Goog-Api-Key"), got.URL.RawQuery)
+	}
+}
@@ -0,0 +1,244 @@
+// Package respcache stores upstream responses of deterministic requests so that repeating
+// the same request can be answered without calling the provider again. Entries live in a
+// pluggable Store (an in-process LRU or a directory on disk) and expire after a TTL.
+package respcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultTTL        = time.Hour
+	defaultMaxEntries = 1000
+	defaultMaxBytes   = 256 << 20
+)
+
+// Entry is one cached response. Non-streaming responses set Payload; streaming responses set
+// Chunks, the stream as the client received it.
+type Entry struct {
+	Payload   []byte      `json:"payload,omitempty"`
+	Chunks    [][]byte    `json:"chunks,omitempty"`
+	Headers   http.Header `json:"headers,omitempty"`
+	StoredAt  time.Time   `json:"stored_at"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+// Stream reports whether the entry holds a streamed response.
+func (e *Entry) Stream() bool { return e != nil && e.Chunks != nil }
+
+func (e *Entry) size() int64 {
+	n := int64(len(e.Payload))
+	for _, chunk := range e.Chunks {
+		n += int64(len(chunk))
+	}
+	for name, values := range e.Headers {
+		n += int64(len(name))
+		for _, value := range values {
+			n += int64(len(value))
+		}
+	}
+	return n
+}
+
+// Store keeps cache entries. Implementations are safe for concurrent use and enforce their
+// own size limits; expiry is checked by the Cache.
+type Store interface {
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry)
+	Delete(key string)
+}
+
+// Options configures a Cache. Zero limits use the defaults.
+type Options struct {
+	Enabled bool
+	// Store is "memory" (default) or "disk".
+	Store string
+	// Dir is the directory of the disk store.
+	Dir        string
+	TTL        time.Duration
+	MaxEntries int
+	MaxBytes   int64
+}
+
+func (o Options) normalized() Options {
+	o.Store = strings.ToLower(strings.TrimSpace(o.Store))
+	if o.Store == "" {
+		o.Store = "memory"
+	}
+	o.Dir = strings.TrimSpace(o.Dir)
+	if o.TTL <= 0 {
+		o.TTL = defaultTTL
+	}
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = defaultMaxEntries
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = defaultMaxBytes
+	}
+	return o
+}
+
+// Cache looks up and stores responses with a TTL and counts hits and misses.
+type Cache struct {
+	mu     sync.RWMutex
+	opts   Options
+	store  Store
+	hits   atomic.Int64
+	misses atomic.Int64
+	now    func() time.Time
+}
+
+var (
+	defaultCache     *Cache
+	defaultCacheOnce sync.Once
+)
+
+// Default returns the process-wide cache. It is disabled until configured.
+func Default() *Cache {
+	defaultCacheOnce.Do(func() { defaultCache = New() })
+	return defaultCache
+}
+
+// New returns a disabled cache.
+func New() *Cache {
+	return &Cache{now: time.Now}
+}
+
+// Configure applies opts. The store is rebuilt only when its kind, directory or limits change,
+// so reloads keep cached entries.
+func (c *Cache) Configure(opts Options) error {
+	if c == nil {
+		return nil
+	}
+	opts = opts.normalized()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !opts.Enabled {
+		c.opts = opts
+		c.store = nil
+		return nil
+	}
+	if c.store != nil && c.opts.Store == opts.Store && c.opts.Dir == opts.Dir &&
+		c.opts.MaxEntries == opts.MaxEntries && c.opts.MaxBytes == opts.MaxBytes {
+		c.opts = opts
+		return nil
+	}
+	var store Store
+	switch opts.Store {
+	case "disk":
+		disk, err := NewDiskStore(opts.Dir, opts.MaxBytes)
+		if err != nil {
+			return err
+		}
+		store = disk
+	default:
+		store = NewMemoryStore(opts.MaxEntries, opts.MaxBytes)
+	}
+	c.opts = opts
+	c.store = store
+	return nil
+}
+
+// Enabled reports whether the cache has a store.
+func (c *Cache) Enabled() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.store != nil
+}
+
+// Get returns the live entry stored under key.
+func (c *Cache) Get(key string) (*Entry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	store := c.store
+	c.mu.RUnlock()
+	if store == nil {
+		return nil, false
+	}
+	entry, ok := store.Get(key)
+	if ok && !c.now().Before(entry.ExpiresAt) {
+		store.Delete(key)
+		ok = false
+	}
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return entry, true
+}
+
+// Set stores entry under key for the configured TTL.
+func (c *Cache) Set(key string, entry *Entry) {
+	if c == nil || entry == nil {
+		return
+	}
+	c.mu.RLock()
+	store, ttl := c.store, c.opts.TTL
+	c.mu.RUnlock()
+	if store == nil {
+		return
+	}
+	entry.StoredAt = c.now()
+	entry.ExpiresAt = entry.StoredAt.Add(ttl)
+	store.Set(key, entry)
+}
+
+// Stats returns the number of hits and misses since the process started.
+func (c *Cache) Stats() (hits, misses int64) {
+	if c == nil {
+		return 0, 0
+	}
+	return c.hits.Load(), c.misses.Load()
+}
+
+// Key returns the cache key of a request: a hash of the client, client format, model, alt,
+// stream flag and the upstream payload in canonical form. client identifies the caller, such
+// as a digest of its API key, so clients never see each other's entries. Object keys are
+// sorted and the stream and stream_options fields are dropped, so formatting differences do
+// not split entries.
+func Key(client, handlerType, model, alt string, stream bool, payload []byte) string {
+	h := sha256.New()
+	for _, part := range []string{client, handlerType, model, alt} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	if stream {
+		h.Write([]byte{1})
+	} else {
+		h.Write([]byte{0})
+	}
+	h.Write(canonicalJSON(payload))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func canonicalJSON(payload []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return payload
+	}
+	if object, ok := value.(map[string]any); ok {
+		delete(object, "stream")
+		delete(object, "stream_options")
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return payload
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package respcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyIgnoresFormattingAndStreamFlag(t *testing.T) {
+	a := Key("client", "openai", "gpt-4o", "", true, []byte(`{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"hi"}],"temperature":0}`))
+	b := Key("client", "openai", "gpt-4o", "", true, []byte(`{ "temperature": 0, "messages": [ {"content":"hi","role":"user"} ], "model":"gpt-4o" }`))
+	if a != b {
+		t.Fatalf("canonical payloads hashed differently")
+	}
+	if a == Key("client", "openai", "gpt-4o", "", false, []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"temperature":0}`)) {
+		t.Fatalf("stream and non-stream requests share a key")
+	}
+	if a == Key("client", "claude", "gpt-4o", "", true, []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"temperature":0}`)) {
+		t.Fatalf("client formats share a key")
+	}
+	if a == Key("other-client", "openai", "gpt-4o", "", true, []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"temperature":0}`)) {
+		t.Fatalf("clients share a key")
+	}
+}
+
+func TestCacheExpiresEntries(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := New()
+	c.now = func() time.Time { return now }
+	if err := c.Configure(Options{Enabled: true, TTL: time.Minute}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	c.Set("k", &Entry{Payload: []byte("v")})
+	if entry, ok := c.Get("k"); !ok || string(entry.Payload) != "v" {
+		t.Fatalf("Get = %v, %v", entry, ok)
+	}
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("k"); ok {
+		t.Fatalf("expired entry returned")
+	}
+	if hits, misses := c.Stats(); hits != 1 || misses != 1 {
+		t.Fatalf("stats = %d hits, %d misses", hits, misses)
+	}
+}
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	s := NewMemoryStore(2, 10)
+	s.Set("a", &Entry{Payload: []byte("1")})
+	s.Set("b", &Entry{Payload: []byte("2")})
+	s.Get("a")
+	s.Set("c", &Entry{Payload: []byte("3")})
+	if _, ok := s.Get("b"); ok {
+		t.Fatalf("b should have been evicted by the entry cap")
+	}
+	s.Set("d", &Entry{Payload: []byte("0123456789")})
+	if _, ok := s.Get("a"); ok || s.Len() != 1 {
+		t.Fatalf("size cap not enforced, len = %d", s.Len())
+	}
+}
+
+func TestDiskStoreSurvivesReopenAndEvicts(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskStore(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("NewDiskStore: %v", err)
+	}
+	s.Set("a", &Entry{Chunks: [][]byte{[]byte("x"), []byte("y")}})
+	reopened, err := NewDiskStore(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	entry, ok := reopened.Get("a")
+	if !ok || !entry.Stream() || string(entry.Chunks[1]) != "y" {
+		t.Fatalf("Get after reopen = %+v, %v", entry, ok)
+	}
+
+	small, err := NewDiskStore(dir, 150)
+	if err != nil {
+		t.Fatalf("reopen small: %v", err)
+	}
+	small.Set("b", &Entry{Payload: []byte("0123456789")})
+	if _, ok = small.Get("a"); ok {
+		t.Fatalf("oldest entry should have been evicted")
+	}
+	if _, ok = small.Get("b"); !ok {
+		t.Fatalf("newest entry missing")
+	}
+}
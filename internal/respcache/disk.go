@@ -0,0 +1,174 @@
+package respcache
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const diskEntrySuffix = ".json"
+
+// DiskStore keeps one JSON file per entry in a directory, so cached responses survive
+// restarts. An in-memory index tracks file sizes and use order; the least recently used
+// files are removed when the directory grows past the size limit.
+type DiskStore struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	bytes    int64
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type diskItem struct {
+	key  string
+	size int64
+}
+
+// NewDiskStore opens dir, creating it when missing, and indexes the entries already in it.
+func NewDiskStore(dir string, maxBytes int64) (*DiskStore, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("respcache: disk store requires a directory")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("respcache: create %s: %w", dir, err)
+	}
+	s := &DiskStore{
+		dir:      dir,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("respcache: read %s: %w", dir, err)
+	}
+	type existing struct {
+		key  string
+		size int64
+		mod  int64
+	}
+	var found []existing
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(name, diskEntrySuffix) {
+			continue
+		}
+		info, errInfo := dirEntry.Info()
+		if errInfo != nil {
+			continue
+		}
+		found = append(found, existing{key: strings.TrimSuffix(name, diskEntrySuffix), size: info.Size(), mod: info.ModTime().UnixNano()})
+	}
+	// Oldest first, so the most recently written entries end up at the front.
+	sort.Slice(found, func(i, j int) bool { return found[i].mod < found[j].mod })
+	for _, item := range found {
+		s.items[item.key] = s.order.PushFront(&diskItem{key: item.key, size: item.size})
+		s.bytes += item.size
+	}
+	s.evictLocked()
+	return s, nil
+}
+
+// Get reads the entry stored under key and marks it recently used.
+func (s *DiskStore) Get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		s.removeLocked(key)
+		return nil, false
+	}
+	var entry Entry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		log.Warnf("respcache: dropping unreadable entry %s: %v", key, err)
+		s.removeLocked(key)
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return &entry, true
+}
+
+// Set writes entry under key, replacing the file atomically.
+func (s *DiskStore) Set(key string, entry *Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Warnf("respcache: encode entry %s: %v", key, err)
+		return
+	}
+	size := int64(len(data))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(key)
+	if s.maxBytes > 0 && size > s.maxBytes {
+		return
+	}
+	tmp, err := os.CreateTemp(s.dir, key+".*.tmp")
+	if err != nil {
+		log.Warnf("respcache: write entry %s: %v", key, err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		log.Warnf("respcache: write entry %s: %v", key, err)
+		return
+	}
+	s.items[key] = s.order.PushFront(&diskItem{key: key, size: size})
+	s.bytes += size
+	s.evictLocked()
+}
+
+// Delete removes the entry stored under key.
+func (s *DiskStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(key)
+}
+
+// Len returns the number of stored entries.
+func (s *DiskStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+func (s *DiskStore) path(key string) string {
+	return filepath.Join(s.dir, key+diskEntrySuffix)
+}
+
+func (s *DiskStore) evictLocked() {
+	for s.maxBytes > 0 && s.bytes > s.maxBytes && s.order.Len() > 0 {
+		s.removeLocked(s.order.Back().Value.(*diskItem).key)
+	}
+}
+
+func (s *DiskStore) removeLocked(key string) {
+	elem, ok := s.items[key]
+	if !ok {
+		return
+	}
+	s.order.Remove(elem)
+	delete(s.items, key)
+	s.bytes -= elem.Value.(*diskItem).size
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		log.Warnf("respcache: remove entry %s: %v", key, err)
+	}
+}
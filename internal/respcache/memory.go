@@ -0,0 +1,85 @@
+package respcache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// MemoryStore is an in-process LRU store bounded by entry count and total size.
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry *Entry
+	size  int64
+}
+
+// NewMemoryStore returns an LRU store holding at most maxEntries entries and maxBytes bytes.
+func NewMemoryStore(maxEntries int, maxBytes int64) *MemoryStore {
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry stored under key and marks it recently used.
+func (s *MemoryStore) Get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return elem.Value.(*memoryItem).entry, true
+}
+
+// Set stores entry under key, evicting the least recently used entries over the limits.
+// Entries larger than the size limit are not stored.
+func (s *MemoryStore) Set(key string, entry *Entry) {
+	size := entry.size()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(key)
+	if s.maxBytes > 0 && size > s.maxBytes {
+		return
+	}
+	s.items[key] = s.order.PushFront(&memoryItem{key: key, entry: entry, size: size})
+	s.bytes += size
+	for s.order.Len() > 0 && ((s.maxEntries > 0 && s.order.Len() > s.maxEntries) || (s.maxBytes > 0 && s.bytes > s.maxBytes)) {
+		s.removeLocked(s.order.Back().Value.(*memoryItem).key)
+	}
+}
+
+// Delete removes the entry stored under key.
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(key)
+}
+
+// Len returns the number of stored entries.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+func (s *MemoryStore) removeLocked(key string) {
+	elem, ok := s.items[key]
+	if !ok {
+		return
+	}
+	s.order.Remove(elem)
+	delete(s.items, key)
+	s.bytes -= elem.Value.(*memoryItem).size
+}
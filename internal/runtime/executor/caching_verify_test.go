@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/tidwall/gjson"
 )
 
@@ -256,3 +257,57 @@ func TestCacheControlOrder(t *testing.T) {
 
 	t.Log("cache order correct: tools -> system")
 }
+
+func TestApplyClaudePromptCachingAutoRespectsLimit(t *testing.T) {
+	auto := &config.Config{ClaudePromptCaching: config.ClaudePromptCaching{Mode: "auto"}}
+	input := []byte(`{
+		"tools": [{"name": "t1"}, {"name": "t2"}],
+		"system": "System prompt",
+		"messages": [
+			{"role": "user", "content": "first"},
+			{"role": "assistant", "content": [{"type": "thinking", "thinking": "hm"}, {"type": "text", "text": "answer"}, {"type": "thinking", "thinking": "more"}]},
+			{"role": "user", "content": "second"}
+		]
+	}`)
+
+	output := applyClaudePromptCaching(auto, input)
+	if gjson.GetBytes(output, "tools.1.cache_control.type").String() != "ephemeral" {
+		t.Errorf("last tool should have cache_control: %s", output)
+	}
+	if gjson.GetBytes(output, "system.0.cache_control.type").String() != "ephemeral" {
+		t.Errorf("system should have cache_control: %s", output)
+	}
+	if gjson.GetBytes(output, "messages.1.content.1.cache_control.type").String() != "ephemeral" {
+		t.Errorf("last non-thinking block of the stable turn should have cache_control: %s", output)
+	}
+	if gjson.GetBytes(output, "messages.2.content.cache_control").Exists() || countCacheControls(output) != 3 {
+		t.Errorf("unexpected breakpoints (%d): %s", countCacheControls(output), output)
+	}
+
+	// Three client breakpoints leave room for one more, which goes to the tools.
+	clientSet := []byte(`{
+		"tools": [{"name": "t1"}],
+		"system": [{"type": "text", "text": "a", "cache_control": {"type": "ephemeral"}}],
+		"messages": [
+			{"role": "user", "content": [{"type": "text", "text": "1", "cache_control": {"type": "ephemeral"}}]},
+			{"role": "assistant", "content": [{"type": "text", "text": "2", "cache_control": {"type": "ephemeral"}}]},
+			{"role": "assistant", "content": "3"},
+			{"role": "user", "content": "4"}
+		]
+	}`)
+	output = applyClaudePromptCaching(auto, clientSet)
+	if countCacheControls(output) != claudeMaxCacheBreakpoints {
+		t.Fatalf("breakpoints = %d, want %d: %s", countCacheControls(output), claudeMaxCacheBreakpoints, output)
+	}
+	if gjson.GetBytes(output, "tools.0.cache_control.type").String() != "ephemeral" || gjson.GetBytes(output, "messages.2.content").Type != gjson.String {
+		t.Errorf("remaining breakpoint should go to the tools: %s", output)
+	}
+
+	// The default mode leaves requests with client breakpoints alone; off never adds any.
+	if string(applyClaudePromptCaching(&config.Config{}, clientSet)) != string(clientSet) {
+		t.Errorf("default mode changed a request that has breakpoints")
+	}
+	if off := applyClaudePromptCaching(&config.Config{ClaudePromptCaching: config.ClaudePromptCaching{Mode: "off"}}, input); countCacheControls(off) != 0 {
+		t.Errorf("off mode added breakpoints: %s", off)
+	}
+}
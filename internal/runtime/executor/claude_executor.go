@@ -125,10 +125,8 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 	// Disable thinking if tool_choice forces tool use (Anthropic API constraint)
 	body = disableThinkingIfToolChoiceForced(body)
 
-	// Auto-inject cache_control if missing (optimization for ClawdBot/clients without caching support)
-	if countCacheControls(body) == 0 {
-		body = ensureCacheControl(body)
-	}
+	// Add prompt-caching breakpoints according to the configured policy
+	body = applyClaudePromptCaching(e.cfg, body)
 
 	// Extract betas from body and convert to header
 	var extraBetas []string
@@ -266,10 +264,8 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 	// Disable thinking if tool_choice forces tool use (Anthropic API constraint)
 	body = disableThinkingIfToolChoiceForced(body)
 
-	// Auto-inject cache_control if missing (optimization for ClawdBot/clients without caching support)
-	if countCacheControls(body) == 0 {
-		body = ensureCacheControl(body)
-	}
+	// Add prompt-caching breakpoints according to the configured policy
+	body = applyClaudePromptCaching(e.cfg, body)
 
 	// Extract betas from body and convert to header
 	var extraBetas []string
@@ -1159,6 +1155,38 @@ func ensureCacheControl(payload []byte) []byte {
 	return payload
 }
 
+// claudeMaxCacheBreakpoints is the number of cache_control breakpoints Anthropic accepts per request.
+const claudeMaxCacheBreakpoints = 4
+
+// applyClaudePromptCaching adds cache_control breakpoints according to the claude-prompt-caching
+// mode. By default only requests without any breakpoint get them; "auto" also tops up requests
+// that carry some, without going past the four-breakpoint limit; "off" leaves requests as sent.
+func applyClaudePromptCaching(cfg *config.Config, payload []byte) []byte {
+	mode := ""
+	if cfg != nil {
+		mode = strings.ToLower(strings.TrimSpace(cfg.ClaudePromptCaching.Mode))
+	}
+	switch mode {
+	case "off":
+		return payload
+	case "auto":
+		// Tools, system and messages form the cache prefix in that order, so earlier sections
+		// get the remaining breakpoints first.
+		for _, inject := range []func([]byte) []byte{injectToolsCacheControl, injectSystemCacheControl, injectStableTurnCacheControl} {
+			if countCacheControls(payload) >= claudeMaxCacheBreakpoints {
+				break
+			}
+			payload = inject(payload)
+		}
+		return payload
+	default:
+		if countCacheControls(payload) == 0 {
+			payload = ensureCacheControl(payload)
+		}
+		return payload
+	}
+}
+
 func countCacheControls(payload []byte) int {
 	count := 0
 
@@ -1291,6 +1319,80 @@ func injectMessagesCacheControl(payload []byte) []byte {
 	return payload
 }
 
+// injectStableTurnCacheControl adds cache_control to the message before the final user turn,
+// the end of the conversation prefix that the next request repeats. Thinking blocks and empty
+// text blocks cannot carry cache_control, so the last other block of that message is used.
+// Nothing is added when the message already has a breakpoint.
+func injectStableTurnCacheControl(payload []byte) []byte {
+	messages := gjson.GetBytes(payload, "messages")
+	if !messages.IsArray() {
+		return payload
+	}
+	items := messages.Array()
+	lastUser := -1
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Get("role").String() == "user" {
+			lastUser = i
+			break
+		}
+	}
+	if lastUser < 1 {
+		return payload
+	}
+	stable := lastUser - 1
+	contentPath := fmt.Sprintf("messages.%d.content", stable)
+	content := items[stable].Get("content")
+
+	if content.Type == gjson.String {
+		if content.String() == "" {
+			return payload
+		}
+		newContent := []map[string]interface{}{
+			{
+				"type": "text",
+				"text": content.String(),
+				"cache_control": map[string]string{
+					"type": "ephemeral",
+				},
+			},
+		}
+		result, err := sjson.SetBytes(payload, contentPath, newContent)
+		if err != nil {
+			log.Warnf("failed to inject cache_control into message string content: %v", err)
+			return payload
+		}
+		return result
+	}
+	if !content.IsArray() {
+		return payload
+	}
+
+	target := -1
+	for i, block := range content.Array() {
+		if block.Get("cache_control").Exists() {
+			return payload
+		}
+		switch block.Get("type").String() {
+		case "thinking", "redacted_thinking":
+			continue
+		case "text":
+			if block.Get("text").String() == "" {
+				continue
+			}
+		}
+		target = i
+	}
+	if target < 0 {
+		return payload
+	}
+	result, err := sjson.SetBytes(payload, fmt.Sprintf("%s.%d.cache_control", contentPath, target), map[string]string{"type": "ephemeral"})
+	if err != nil {
+		log.Warnf("failed to inject cache_control into messages: %v", err)
+		return payload
+	}
+	return result
+}
+
 // injectToolsCacheControl adds cache_control to the last tool in the tools array.
 // Per Anthropic docs: "The cache_control parameter on the last tool definition caches all tool definitions."
 // This only adds cache_control if NO tool in the array already has it.
@@ -0,0 +1,534 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// httpProviderResponseTemplates are the minimal responses mapped fields are written into
+// when a provider does not set response.template.
+var httpProviderResponseTemplates = map[string]string{
+	"openai": `{"id":"","object":"chat.completion","created":0,"model":"","choices":[{"index":0,"message":{"role":"assistant","content":""},"finish_reason":"stop"}]}`,
+	"claude": `{"id":"","type":"message","role":"assistant","model":"","content":[{"type":"text","text":""}],"stop_reason":"end_turn","stop_sequence":null,"usage":{"input_tokens":0,"output_tokens":0}}`,
+	"gemini": `{"candidates":[{"content":{"role":"model","parts":[{"text":""}]},"finishReason":"STOP","index":0}]}`,
+}
+
+// httpProviderChunkTemplates are the minimal stream chunks mapped fields are written into
+// when a provider does not set stream.template.
+var httpProviderChunkTemplates = map[string]string{
+	"openai": `{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[{"index":0,"delta":{"content":""},"finish_reason":null}]}`,
+	"gemini": `{"candidates":[{"content":{"role":"model","parts":[{"text":""}]},"index":0}]}`,
+}
+
+// HTTPProviderExecutor executes requests against an upstream described by an http-providers
+// entry. The request is translated into the entry's format and reshaped by its field
+// mappings; responses and stream events are mapped back into that format and translated to
+// the client's format, so any JSON API can be plugged in from configuration.
+type HTTPProviderExecutor struct {
+	provider string
+	cfg      *config.Config
+}
+
+// NewHTTPProviderExecutor creates an executor bound to an http-providers entry name.
+func NewHTTPProviderExecutor(provider string, cfg *config.Config) *HTTPProviderExecutor {
+	return &HTTPProviderExecutor{provider: provider, cfg: cfg}
+}
+
+// Identifier implements cliproxyauth.ProviderExecutor.
+func (e *HTTPProviderExecutor) Identifier() string { return e.provider }
+
+// PrepareRequest applies the provider's header templates to the outgoing HTTP request.
+func (e *HTTPProviderExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	provider := e.resolveProvider(auth)
+	if provider == nil {
+		return nil
+	}
+	applyHTTPProviderHeaders(req, provider, "", httpProviderAPIKey(auth))
+	return nil
+}
+
+// HttpRequest applies the provider's header templates to the request and executes it.
+func (e *HTTPProviderExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("http provider executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+func (e *HTTPProviderExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	provider := e.resolveProvider(auth)
+	if provider == nil {
+		err = statusErr{code: http.StatusInternalServerError, msg: fmt.Sprintf("http provider %q is not configured", e.provider)}
+		return resp, err
+	}
+	from := opts.SourceFormat
+	to := sdktranslator.FromString(provider.Format)
+	translated, body, err := e.buildRequest(provider, from, to, req, opts, false)
+	if err != nil {
+		return resp, err
+	}
+
+	httpResp, err := e.send(ctx, auth, provider, provider.URL, baseModel, body, false)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("http provider executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, data)
+
+	mapped := data
+	if len(provider.Response.Fields) > 0 {
+		template := provider.Response.Template
+		if strings.TrimSpace(template) == "" {
+			template = httpProviderResponseTemplates[provider.Format]
+		}
+		mapped, _, err = mapHTTPFields(template, provider.Response.Fields, data, nil)
+		if err != nil {
+			return resp, statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("http provider %s: map response: %v", e.provider, err)}
+		}
+		mapped = fillHTTPProviderDefaults(provider.Format, mapped, baseModel)
+	}
+	if detail, ok := httpProviderUsage(provider, data, mapped, false); ok {
+		reporter.publish(ctx, detail)
+	}
+	reporter.ensurePublished(ctx)
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, mapped, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out), Headers: httpResp.Header.Clone()}
+	return resp, nil
+}
+
+func (e *HTTPProviderExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	provider := e.resolveProvider(auth)
+	if provider == nil {
+		err = statusErr{code: http.StatusInternalServerError, msg: fmt.Sprintf("http provider %q is not configured", e.provider)}
+		return nil, err
+	}
+	from := opts.SourceFormat
+	to := sdktranslator.FromString(provider.Format)
+	translated, body, err := e.buildRequest(provider, from, to, req, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := provider.StreamURL
+	if endpoint == "" {
+		endpoint = provider.URL
+	}
+
+	httpResp, err := e.send(ctx, auth, provider, endpoint, baseModel, body, true)
+	if err != nil {
+		return nil, err
+	}
+
+	template := provider.Stream.Template
+	if strings.TrimSpace(template) == "" {
+		template = httpProviderChunkTemplates[provider.Format]
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("http provider executor: close response body error: %v", errClose)
+			}
+		}()
+		var param any
+		emit := func(payload []byte) {
+			if provider.Format != "gemini" {
+				payload = append([]byte("data: "), payload...)
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, payload, &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, streamScannerBuffer)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			event := httpProviderStreamEvent(provider.Stream.Mode, line)
+			if len(event) == 0 {
+				continue
+			}
+			if string(event) == provider.Stream.Done {
+				break
+			}
+			chunk := bytes.Clone(event)
+			if len(provider.Stream.Fields) > 0 {
+				mapped, found, errMap := mapHTTPFields(template, provider.Stream.Fields, event, nil)
+				if errMap != nil {
+					log.Debugf("http provider executor: skip unmappable stream event: %v", errMap)
+					continue
+				}
+				if detail, ok := httpProviderUsage(provider, event, mapped, true); ok {
+					reporter.publish(ctx, detail)
+				}
+				if !found {
+					continue
+				}
+				chunk = fillHTTPProviderDefaults(provider.Format, mapped, baseModel)
+			} else if detail, ok := httpProviderUsage(provider, event, chunk, true); ok {
+				reporter.publish(ctx, detail)
+			}
+			emit(chunk)
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			recordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+			return
+		}
+		// Claude streams end with message_stop; the other formats end with a [DONE] marker
+		// whether or not the upstream sent one.
+		if provider.Format != "claude" {
+			emit([]byte("[DONE]"))
+		}
+		reporter.ensurePublished(ctx)
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+func (e *HTTPProviderExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+
+	enc, err := tokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("http provider executor: tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("http provider executor: token counting failed: %w", err)
+	}
+
+	usageJSON := buildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translatedUsage)}, nil
+}
+
+// Refresh is a no-op for API-key based HTTP providers.
+func (e *HTTPProviderExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("http provider executor: refresh called")
+	_ = ctx
+	return auth, nil
+}
+
+// buildRequest returns the request translated into the provider format, used to translate
+// responses back, and the upstream body built from it by the request mappings.
+func (e *HTTPProviderExecutor) buildRequest(provider *config.HTTPProvider, from, to sdktranslator.Format, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) ([]byte, []byte, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	translated = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel)
+
+	translated, err := thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(provider.Request.Fields) == 0 {
+		return translated, translated, nil
+	}
+	template := provider.Request.Template
+	if strings.TrimSpace(template) == "" {
+		template = "{}"
+	}
+	vars := map[string]any{"$model": baseModel, "$stream": stream}
+	body, _, err := mapHTTPFields(template, provider.Request.Fields, translated, vars)
+	if err != nil {
+		return nil, nil, statusErr{code: http.StatusBadRequest, msg: fmt.Sprintf("http provider %s: map request: %v", e.provider, err)}
+	}
+	return translated, body, nil
+}
+
+// send posts body to the expanded endpoint template and returns the response when the
+// upstream answers with a 2xx status.
+func (e *HTTPProviderExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, provider *config.HTTPProvider, endpoint, model string, body []byte, stream bool) (*http.Response, error) {
+	apiKey := httpProviderAPIKey(auth)
+	target := expandHTTPProviderEndpoint(endpoint, model, apiKey)
+	// The logged URL masks the key like the sensitive headers are masked.
+	loggedTarget := expandHTTPProviderEndpoint(endpoint, model, util.HideAPIKey(apiKey))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "cli-proxy-http-provider")
+	if stream && provider.Stream.Mode == "sse" {
+		httpReq.Header.Set("Accept", "text/event-stream")
+		httpReq.Header.Set("Cache-Control", "no-cache")
+	}
+	applyHTTPProviderHeaders(httpReq, provider, model, apiKey)
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       loggedTarget,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = loggedTarget
+		}
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("http provider executor: close response body error: %v", errClose)
+		}
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return httpResp, nil
+}
+
+func expandHTTPProviderEndpoint(endpoint, model, apiKey string) string {
+	return strings.NewReplacer("{model}", url.PathEscape(model), "{api_key}", url.QueryEscape(apiKey)).Replace(endpoint)
+}
+
+func (e *HTTPProviderExecutor) resolveProvider(auth *cliproxyauth.Auth) *config.HTTPProvider {
+	if e.cfg == nil {
+		return nil
+	}
+	name := e.provider
+	if auth != nil && auth.Attributes != nil {
+		if v := strings.TrimSpace(auth.Attributes["http_provider"]); v != "" {
+			name = v
+		}
+	}
+	for i := range e.cfg.HTTPProviders {
+		if strings.EqualFold(e.cfg.HTTPProviders[i].Name, name) {
+			return &e.cfg.HTTPProviders[i]
+		}
+	}
+	return nil
+}
+
+func httpProviderAPIKey(auth *cliproxyauth.Auth) string {
+	if auth == nil || auth.Attributes == nil {
+		return ""
+	}
+	return strings.TrimSpace(auth.Attributes["api_key"])
+}
+
+// applyHTTPProviderHeaders sets the provider's header templates, or a bearer token when
+// none are configured.
+func applyHTTPProviderHeaders(req *http.Request, provider *config.HTTPProvider, model, apiKey string) {
+	if len(provider.Headers) == 0 {
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		return
+	}
+	replacer := strings.NewReplacer("{model}", model, "{api_key}", apiKey)
+	for name, value := range provider.Headers {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		req.Header.Set(name, replacer.Replace(value))
+	}
+}
+
+// mapHTTPFields writes the mapped fields of source into template. found reports whether any
+// From path existed in source; vars resolve From names before source paths are consulted.
+func mapHTTPFields(template string, fields []config.HTTPFieldMapping, source []byte, vars map[string]any) ([]byte, bool, error) {
+	out := []byte(template)
+	found := false
+	for _, field := range fields {
+		var raw []byte
+		if from := strings.TrimSpace(field.From); from != "" {
+			if v, ok := vars[from]; ok {
+				encoded, err := json.Marshal(v)
+				if err != nil {
+					return nil, false, err
+				}
+				raw = encoded
+			} else if value := gjson.GetBytes(source, from); value.Exists() {
+				raw = []byte(value.Raw)
+				found = true
+			}
+		}
+		if raw == nil && field.Value != nil {
+			encoded, err := json.Marshal(field.Value)
+			if err != nil {
+				return nil, false, fmt.Errorf("field %s: %w", field.To, err)
+			}
+			raw = encoded
+		}
+		if raw == nil {
+			continue
+		}
+		updated, err := sjson.SetRawBytes(out, field.To, raw)
+		if err != nil {
+			return nil, false, fmt.Errorf("field %s: %w", field.To, err)
+		}
+		out = updated
+	}
+	return out, found, nil
+}
+
+// fillHTTPProviderDefaults sets identifiers the mappings left empty so translators see a
+// well-formed document.
+func fillHTTPProviderDefaults(format string, doc []byte, model string) []byte {
+	switch format {
+	case "openai":
+		if gjson.GetBytes(doc, "id").String() == "" {
+			doc, _ = sjson.SetBytes(doc, "id", "chatcmpl-"+uuid.NewString())
+		}
+		if gjson.GetBytes(doc, "created").Int() == 0 {
+			doc, _ = sjson.SetBytes(doc, "created", time.Now().Unix())
+		}
+		if gjson.GetBytes(doc, "model").String() == "" {
+			doc, _ = sjson.SetBytes(doc, "model", model)
+		}
+	case "claude":
+		if gjson.GetBytes(doc, "id").String() == "" {
+			doc, _ = sjson.SetBytes(doc, "id", "msg_"+strings.ReplaceAll(uuid.NewString(), "-", ""))
+		}
+		if gjson.GetBytes(doc, "model").String() == "" {
+			doc, _ = sjson.SetBytes(doc, "model", model)
+		}
+	case "gemini":
+		if gjson.GetBytes(doc, "modelVersion").String() == "" {
+			doc, _ = sjson.SetBytes(doc, "modelVersion", model)
+		}
+	}
+	return doc
+}
+
+// httpProviderStreamEvent returns the JSON payload framed by line, or nil for lines that
+// carry none.
+func httpProviderStreamEvent(mode string, line []byte) []byte {
+	trimmed := bytes.TrimSpace(line)
+	if mode == "ndjson" {
+		return trimmed
+	}
+	if !bytes.HasPrefix(trimmed, []byte("data:")) {
+		return nil
+	}
+	return bytes.TrimSpace(trimmed[len("data:"):])
+}
+
+// httpProviderUsage reads token counts from the configured usage paths in the upstream
+// document, or from the mapped format document when no paths are configured.
+func httpProviderUsage(provider *config.HTTPProvider, upstream, mapped []byte, stream bool) (usage.Detail, bool) {
+	paths := provider.Usage
+	if paths != (config.HTTPUsagePaths{}) {
+		var detail usage.Detail
+		found := false
+		read := func(path string, dst *int64) {
+			if path == "" {
+				return
+			}
+			if value := gjson.GetBytes(upstream, path); value.Exists() {
+				*dst = value.Int()
+				found = true
+			}
+		}
+		read(paths.InputTokens, &detail.InputTokens)
+		read(paths.OutputTokens, &detail.OutputTokens)
+		read(paths.TotalTokens, &detail.TotalTokens)
+		read(paths.ReasoningTokens, &detail.ReasoningTokens)
+		read(paths.CachedTokens, &detail.CachedTokens)
+		if found && detail.TotalTokens == 0 {
+			detail.TotalTokens = detail.InputTokens + detail.OutputTokens
+		}
+		return detail, found
+	}
+	switch provider.Format {
+	case "claude":
+		if stream {
+			return parseClaudeStreamUsage(mapped)
+		}
+		return parseClaudeUsage(mapped), true
+	case "gemini":
+		if stream {
+			return parseGeminiStreamUsage(mapped)
+		}
+		return parseGeminiUsage(mapped), true
+	default:
+		if stream {
+			return parseOpenAIStreamUsage(mapped)
+		}
+		return parseOpenAIUsage(mapped), true
+	}
+}
@@ -0,0 +1,165 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func httpProviderTestConfig(url string) *config.Config {
+	cfg := &config.Config{HTTPProviders: []config.HTTPProvider{{
+		Name:      "acme",
+		URL:       url + "/models/{model}/generate",
+		StreamURL: url + "/models/{model}/stream",
+		Headers:   map[string]string{"X-Api-Key": "{api_key}"},
+		Request: config.HTTPMapping{
+			Template: `{"options":{"safe":true}}`,
+			Fields: []config.HTTPFieldMapping{
+				{From: "messages.0.content", To: "input.text"},
+				{From: "$model", To: "engine"},
+				{From: "max_tokens", To: "options.limit", Value: 256},
+			},
+		},
+		Response: config.HTTPMapping{Fields: []config.HTTPFieldMapping{
+			{From: "output.text", To: "choices.0.message.content"},
+		}},
+		Stream: config.HTTPStreamMapping{
+			Mode: "ndjson",
+			Done: `{"done":true}`,
+			Fields: []config.HTTPFieldMapping{
+				{From: "token", To: "choices.0.delta.content"},
+			},
+		},
+		Usage: config.HTTPUsagePaths{InputTokens: "meta.in", OutputTokens: "meta.out"},
+	}}}
+	cfg.SanitizeHTTPProviders()
+	return cfg
+}
+
+func TestHTTPProviderExecutorMapsRequestAndResponse(t *testing.T) {
+	var gotPath, gotKey string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotKey = r.Header.Get("X-Api-Key")
+		gotBody, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"output":{"text":"hello"},"meta":{"in":3,"out":5}}`))
+	}))
+	defer server.Close()
+
+	exec := NewHTTPProviderExecutor("acme", httpProviderTestConfig(server.URL))
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"http_provider": "acme", "api_key": "k-1"}}
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "acme-large",
+		Payload: []byte(`{"model":"acme-large","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if gotPath != "/models/acme-large/generate" || gotKey != "k-1" {
+		t.Fatalf("path = %q, key = %q", gotPath, gotKey)
+	}
+	want := `{"options":{"safe":true,"limit":256},"input":{"text":"hi"},"engine":"acme-large"}`
+	if string(gotBody) != want {
+		t.Fatalf("upstream body = %s, want %s", gotBody, want)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "hello" {
+		t.Fatalf("content = %q in %s", got, resp.Payload)
+	}
+	if gjson.GetBytes(resp.Payload, "model").String() != "acme-large" || gjson.GetBytes(resp.Payload, "id").String() == "" {
+		t.Fatalf("defaults not filled: %s", resp.Payload)
+	}
+	detail, ok := httpProviderUsage(&exec.cfg.HTTPProviders[0], []byte(`{"meta":{"in":3,"out":5}}`), nil, false)
+	if !ok || detail.InputTokens != 3 || detail.OutputTokens != 5 || detail.TotalTokens != 8 {
+		t.Fatalf("usage = %+v, %v", detail, ok)
+	}
+}
+
+func TestHTTPProviderExecutorMapsNDJSONStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/acme-large/stream" {
+			http.Error(w, "wrong path "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("{\"token\":\"Hel\"}\n\n{\"token\":\"lo\"}\n{\"meta\":{\"in\":1,\"out\":2}}\n{\"done\":true}\n{\"token\":\"ignored\"}\n"))
+	}))
+	defer server.Close()
+
+	exec := NewHTTPProviderExecutor("acme", httpProviderTestConfig(server.URL))
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"http_provider": "acme"}}
+	result, err := exec.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "acme-large",
+		Payload: []byte(`{"model":"acme-large","stream":true,"messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	var text strings.Builder
+	var last string
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		last = string(chunk.Payload)
+		if payload := jsonPayload(chunk.Payload); payload != nil {
+			text.WriteString(gjson.GetBytes(payload, "choices.0.delta.content").String())
+		}
+	}
+	if text.String() != "Hello" {
+		t.Fatalf("streamed text = %q", text.String())
+	}
+	if last != "data: [DONE]" {
+		t.Fatalf("last chunk = %q, want data: [DONE]", last)
+	}
+}
+
+func TestHTTPProviderExecutorMasksKeyInLoggedURL(t *testing.T) {
+	const apiKey = "sk-provider-secret-key"
+	var gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.URL.Query().Get("key")
+		_, _ = w.Write([]byte(`{"output":{"text":"hello"}}`))
+	}))
+	defer server.Close()
+
+	cfg := httpProviderTestConfig(server.URL)
+	cfg.RequestLog = true
+	cfg.HTTPProviders[0].URL = server.URL + "/models/{model}/generate?key={api_key}"
+	exec := NewHTTPProviderExecutor("acme", cfg)
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"http_provider": "acme", "api_key": apiKey}}
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+	if _, err := exec.Execute(ctx, auth, cliproxyexecutor.Request{
+		Model:   "acme-large",
+		Payload: []byte(`{"model":"acme-large","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if gotKey != apiKey {
+		t.Fatalf("upstream key = %q, want %q", gotKey, apiKey)
+	}
+	attempts := getAttempts(ginCtx)
+	if len(attempts) != 1 {
+		t.Fatalf("attempts = %d, want 1", len(attempts))
+	}
+	logged := attempts[0].request
+	start := strings.Index(logged, "Upstream URL: ")
+	if start < 0 {
+		t.Fatalf("logged request has no upstream URL:\n%s", logged)
+	}
+	line := logged[start : start+strings.Index(logged[start:], "\n")]
+	if strings.Contains(line, apiKey) || !strings.HasSuffix(line, "key="+util.HideAPIKey(apiKey)) {
+		t.Fatalf("logged URL does not mask the key: %s", line)
+	}
+}
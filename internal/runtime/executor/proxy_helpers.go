@@ -49,7 +49,7 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 		transport := buildProxyTransport(proxyURL)
 		if transport != nil {
 			httpClient.Transport = transport
-			return httpClient
+			return withUpstreamRequestHooks(ctx, httpClient)
 		}
 		// If proxy setup failed, log and fall through to context RoundTripper
 		log.Debugf("failed to setup proxy from URL: %s, falling back to context transport", proxyURL)
@@ -60,9 +60,38 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 		httpClient.Transport = rt
 	}
 
+	return withUpstreamRequestHooks(ctx, httpClient)
+}
+
+// withUpstreamRequestHooks routes the client's requests through the executor middleware
+// UpstreamRequest hooks installed on ctx, if any.
+func withUpstreamRequestHooks(ctx context.Context, httpClient *http.Client) *http.Client {
+	rewrite := cliproxyauth.UpstreamRequestRewriter(ctx)
+	if rewrite == nil {
+		return httpClient
+	}
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	httpClient.Transport = upstreamRequestTransport{base: base, rewrite: rewrite}
 	return httpClient
 }
 
+// upstreamRequestTransport lets executor middlewares rewrite the final upstream body.
+type upstreamRequestTransport struct {
+	base    http.RoundTripper
+	rewrite func(*http.Request) (*http.Request, error)
+}
+
+func (t upstreamRequestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rewritten, err := t.rewrite(req)
+	if err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(rewritten)
+}
+
 // buildProxyTransport creates an HTTP transport configured for the given proxy URL.
 // It supports SOCKS5, HTTP, and HTTPS proxy protocols.
 //
@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func TestUpstreamRequestMiddlewareRewritesFinalBody(t *testing.T) {
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[]}`))
+	}))
+	defer server.Close()
+
+	mgr := cliproxyauth.NewManager(nil, nil, nil)
+	mgr.RegisterExecutor(NewOpenAICompatExecutor("openai-compatibility", &config.Config{}))
+	if _, err := mgr.Register(context.Background(), &cliproxyauth.Auth{ID: "compat", Provider: "openai-compatibility", Attributes: map[string]string{
+		"base_url": server.URL + "/v1",
+		"api_key":  "test",
+	}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient("compat", "openai-compatibility", []*registry.ModelInfo{{ID: "gpt-test"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("compat") })
+	var seen []byte
+	mgr.UseExecutorMiddleware(cliproxyauth.ExecutorMiddleware{
+		Name: "stamp",
+		UpstreamRequest: func(_ context.Context, call cliproxyauth.ExecutorCall, req *http.Request, body []byte) ([]byte, error) {
+			seen = body
+			return sjson.SetBytes(body, "metadata.auth", call.Auth.ID)
+		},
+	})
+
+	_, err := mgr.Execute(context.Background(), []string{"openai-compatibility"}, cliproxyexecutor.Request{
+		Model:   "gpt-test",
+		Payload: []byte(`{"model":"gpt-test","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !gjson.GetBytes(seen, "messages").Exists() {
+		t.Fatalf("hook saw %s, want the upstream body", seen)
+	}
+	want, _ := sjson.SetBytes(seen, "metadata.auth", "compat")
+	if string(gotBody) != string(want) {
+		t.Fatalf("upstream body = %s, want %s", gotBody, want)
+	}
+}
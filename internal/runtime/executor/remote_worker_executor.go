@@ -0,0 +1,183 @@
+// Package executor provides runtime execution capabilities for various AI service providers.
+// This file implements the executor that forwards requests to remote worker processes
+// connected over the websocket relay.
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const (
+	// RemoteWorkerProvider is the provider key of auths served by remote workers.
+	RemoteWorkerProvider = "remote-worker"
+	// RemoteWorkerAttribute names the worker that owns a remote auth.
+	RemoteWorkerAttribute = "remote_worker"
+	// RemoteProviderAttribute is the provider of the auth on the worker.
+	RemoteProviderAttribute = "remote_provider"
+	// RemoteAuthIDAttribute is the ID of the auth on the worker.
+	RemoteAuthIDAttribute = "remote_auth_id"
+)
+
+// RemoteWorkerExecutor runs requests on remote workers. The worker translates the request and
+// calls the upstream with its own credential, so payloads are forwarded untouched.
+type RemoteWorkerExecutor struct {
+	relay *wsrelay.Manager
+	cfg   *config.Config
+}
+
+// NewRemoteWorkerExecutor creates an executor that forwards through the worker relay.
+func NewRemoteWorkerExecutor(cfg *config.Config, relay *wsrelay.Manager) *RemoteWorkerExecutor {
+	return &RemoteWorkerExecutor{relay: relay, cfg: cfg}
+}
+
+// Identifier returns the executor identifier.
+func (e *RemoteWorkerExecutor) Identifier() string { return RemoteWorkerProvider }
+
+// Execute runs a non-streaming request on the worker that owns auth.
+func (e *RemoteWorkerExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return e.execute(ctx, auth, req, opts, false)
+}
+
+// CountTokens runs a token count on the worker that owns auth.
+func (e *RemoteWorkerExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return e.execute(ctx, auth, req, opts, true)
+}
+
+func (e *RemoteWorkerExecutor) execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, countTokens bool) (cliproxyexecutor.Response, error) {
+	worker, remoteReq, err := e.remoteRequest(auth, req, opts)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	remoteReq.Stream = false
+	remoteReq.CountTokens = countTokens
+	resp, err := e.relay.Execute(ctx, worker, remoteReq)
+	if err != nil {
+		return cliproxyexecutor.Response{}, remoteWorkerError(ctx, err)
+	}
+	if resp.Status < 200 || resp.Status >= 300 {
+		return cliproxyexecutor.Response{}, statusErr{code: resp.Status, msg: string(resp.Body)}
+	}
+	return cliproxyexecutor.Response{Payload: resp.Body, Headers: resp.Headers.Clone()}, nil
+}
+
+// ExecuteStream runs a streaming request on the worker that owns auth. Errors reported before
+// the worker starts streaming are returned directly so the conductor can try another auth.
+func (e *RemoteWorkerExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	worker, remoteReq, err := e.remoteRequest(auth, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	remoteReq.Stream = true
+	events, err := e.relay.ExecuteStream(ctx, worker, remoteReq)
+	if err != nil {
+		return nil, remoteWorkerError(ctx, err)
+	}
+	first, ok := <-events
+	if !ok {
+		if errCtx := ctx.Err(); errCtx != nil {
+			return nil, errCtx
+		}
+		return nil, statusErr{code: http.StatusBadGateway, msg: "remote worker: stream closed before start"}
+	}
+	if first.Err != nil {
+		return nil, remoteWorkerError(ctx, first.Err)
+	}
+	if first.Type == wsrelay.MessageTypeHTTPResp && (first.Status < 200 || first.Status >= 300) {
+		return nil, statusErr{code: first.Status, msg: string(first.Payload)}
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		emit := func(chunk cliproxyexecutor.StreamChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		process := func(event wsrelay.StreamEvent) bool {
+			if event.Err != nil {
+				emit(cliproxyexecutor.StreamChunk{Err: remoteWorkerError(ctx, event.Err)})
+				return false
+			}
+			switch event.Type {
+			case wsrelay.MessageTypeStreamChunk:
+				if len(event.Payload) > 0 {
+					return emit(cliproxyexecutor.StreamChunk{Payload: event.Payload})
+				}
+			case wsrelay.MessageTypeHTTPResp:
+				if len(event.Payload) > 0 {
+					emit(cliproxyexecutor.StreamChunk{Payload: event.Payload})
+				}
+				return false
+			case wsrelay.MessageTypeStreamEnd:
+				return false
+			}
+			return true
+		}
+		if !process(first) {
+			return
+		}
+		for event := range events {
+			if !process(event) {
+				return
+			}
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: first.Headers.Clone(), Chunks: out}, nil
+}
+
+// Refresh is a no-op; the worker refreshes its own credentials.
+func (e *RemoteWorkerExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	return auth, nil
+}
+
+// HttpRequest is not supported: remote credentials never leave the worker.
+func (e *RemoteWorkerExecutor) HttpRequest(_ context.Context, _ *cliproxyauth.Auth, _ *http.Request) (*http.Response, error) {
+	return nil, statusErr{code: http.StatusNotImplemented, msg: "remote worker executor: raw HTTP requests are not supported"}
+}
+
+func (e *RemoteWorkerExecutor) remoteRequest(auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (string, *wsrelay.ExecuteRequest, error) {
+	if e.relay == nil {
+		return "", nil, fmt.Errorf("remote worker executor: relay is nil")
+	}
+	if auth == nil || auth.Attributes == nil {
+		return "", nil, fmt.Errorf("remote worker executor: missing auth")
+	}
+	worker := strings.TrimSpace(auth.Attributes[RemoteWorkerAttribute])
+	provider := strings.TrimSpace(auth.Attributes[RemoteProviderAttribute])
+	remoteID := strings.TrimSpace(auth.Attributes[RemoteAuthIDAttribute])
+	if worker == "" || provider == "" || remoteID == "" {
+		return "", nil, fmt.Errorf("remote worker executor: auth %s is not bound to a worker", auth.ID)
+	}
+	return worker, wsrelay.NewExecuteRequest(provider, remoteID, req, opts), nil
+}
+
+// remoteWorkerError keeps the worker's status code and retry hint so the conductor applies the
+// usual cooldowns. Transport failures count as 502.
+func remoteWorkerError(ctx context.Context, err error) error {
+	if ctx != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var remote *wsrelay.RemoteError
+	if errors.As(err, &remote) && remote.Status > 0 {
+		out := statusErr{code: remote.Status, msg: remote.Message}
+		if remote.RetryAfter > 0 {
+			retryAfter := remote.RetryAfter
+			out.retryAfter = &retryAfter
+		}
+		return out
+	}
+	return statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("remote worker: %v", err)}
+}
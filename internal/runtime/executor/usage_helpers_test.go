@@ -41,3 +41,17 @@ func TestParseOpenAIUsageResponses(t *testing.T) {
 		t.Fatalf("reasoning tokens = %d, want %d", detail.ReasoningTokens, 9)
 	}
 }
+
+func TestParseClaudeUsageCacheReadAndWrite(t *testing.T) {
+	data := []byte(`{"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":300,"cache_creation_input_tokens":40}}`)
+	detail := parseClaudeUsage(data)
+	if detail.CachedTokens != 300 {
+		t.Fatalf("cached tokens = %d, want %d", detail.CachedTokens, 300)
+	}
+	if detail.CacheCreationTokens != 40 {
+		t.Fatalf("cache creation tokens = %d, want %d", detail.CacheCreationTokens, 40)
+	}
+	if detail.TotalTokens != 15 {
+		t.Fatalf("total tokens = %d, want %d", detail.TotalTokens, 15)
+	}
+}
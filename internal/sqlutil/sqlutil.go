@@ -0,0 +1,39 @@
+// Package sqlutil holds the helpers shared by the stores that run on either SQLite or
+// PostgreSQL.
+package sqlutil
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PostgresDSNFromEnv returns the PostgreSQL connection string from PGSTORE_DSN, or "" when it
+// is not set.
+func PostgresDSNFromEnv() string {
+	for _, key := range []string{"PGSTORE_DSN", "pgstore_dsn"} {
+		if value, ok := os.LookupEnv(key); ok {
+			if trimmed := strings.TrimSpace(value); trimmed != "" {
+				return trimmed
+			}
+		}
+	}
+	return ""
+}
+
+// RebindPostgres converts '?' placeholders into the positional form expected by PostgreSQL.
+func RebindPostgres(query string) string {
+	var sb strings.Builder
+	sb.Grow(len(query) + 8)
+	n := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] == '?' {
+			n++
+			sb.WriteByte('$')
+			sb.WriteString(strconv.Itoa(n))
+			continue
+		}
+		sb.WriteByte(query[i])
+	}
+	return sb.String()
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/go-git/go-git/v6/plumbing/object"
 	"github.com/go-git/go-git/v6/plumbing/transport"
 	"github.com/go-git/go-git/v6/plumbing/transport/http"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
@@ -254,14 +255,18 @@ func (s *GitTokenStore) Save(_ context.Context, auth *cliproxyauth.Auth) (string
 			return "", fmt.Errorf("auth filestore: marshal metadata failed: %w", errMarshal)
 		}
 		if existing, errRead := os.ReadFile(path); errRead == nil {
-			if jsonEqual(existing, raw) {
+			if storedAuthEqual(existing, raw) {
 				return path, nil
 			}
 		} else if !os.IsNotExist(errRead) {
 			return "", fmt.Errorf("auth filestore: read existing failed: %w", errRead)
 		}
+		sealed, errSeal := authcrypt.Seal(raw)
+		if errSeal != nil {
+			return "", fmt.Errorf("auth filestore: encrypt metadata: %w", errSeal)
+		}
 		tmp := path + ".tmp"
-		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
+		if errWrite := os.WriteFile(tmp, sealed, 0o600); errWrite != nil {
 			return "", fmt.Errorf("auth filestore: write temp failed: %w", errWrite)
 		}
 		if errRename := os.Rename(tmp, path); errRename != nil {
@@ -410,7 +415,7 @@ func (s *GitTokenStore) resolveDeletePath(id string) (string, error) {
 }
 
 func (s *GitTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth, error) {
-	data, err := os.ReadFile(path)
+	data, err := authcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
@@ -708,6 +713,16 @@ func ensureEmptyFile(path string) error {
 	return nil
 }
 
+// storedAuthEqual reports whether an auth file on disk already holds raw in the
+// form the current encryption settings would write it.
+func storedAuthEqual(existing, raw []byte) bool {
+	if authcrypt.NeedsReseal(existing) {
+		return false
+	}
+	plaintext, err := authcrypt.Open(existing)
+	return err == nil && jsonEqual(plaintext, raw)
+}
+
 func jsonEqual(a, b []byte) bool {
 	var objA any
 	var objB any
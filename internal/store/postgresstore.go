@@ -14,6 +14,7 @@ import (
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
@@ -43,6 +44,14 @@ type PostgresStore struct {
 	configPath string
 	authDir    string
 	mu         sync.Mutex
+
+	// authVersions maps mirrored auth IDs to their database updated_at; guarded by mu.
+	authVersions map[string]time.Time
+	// configVersion is the database updated_at of the mirrored config; guarded by mu.
+	configVersion time.Time
+
+	syncMu     sync.Mutex
+	syncCancel context.CancelFunc
 }
 
 // NewPostgresStore establishes a connection to PostgreSQL and prepares the local workspace.
@@ -99,6 +108,12 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 	return store, nil
 }
 
+// SharedAcrossReplicas reports that every instance using the same database sees the auth
+// files written here, so in cluster mode only the refresh leader needs to refresh tokens.
+func (s *PostgresStore) SharedAcrossReplicas() bool {
+	return s != nil && s.db != nil
+}
+
 // Close releases the underlying database connection.
 func (s *PostgresStore) Close() error {
 	if s == nil || s.db == nil {
@@ -140,6 +155,9 @@ func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
 	`, authTable)); err != nil {
 		return fmt.Errorf("postgres store: create auth table: %w", err)
 	}
+	if err := s.ensureChangeNotifications(ctx); err != nil {
+		log.WithError(err).Warn("postgres store: change notifications unavailable, remote changes are detected by polling")
+	}
 	return nil
 }
 
@@ -223,14 +241,18 @@ func (s *PostgresStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (stri
 			return "", fmt.Errorf("postgres store: marshal metadata: %w", errMarshal)
 		}
 		if existing, errRead := os.ReadFile(path); errRead == nil {
-			if jsonEqual(existing, raw) {
+			if storedAuthEqual(existing, raw) {
 				return path, nil
 			}
 		} else if errRead != nil && !errors.Is(errRead, fs.ErrNotExist) {
 			return "", fmt.Errorf("postgres store: read existing metadata: %w", errRead)
 		}
+		sealed, errSeal := authcrypt.Seal(raw)
+		if errSeal != nil {
+			return "", fmt.Errorf("postgres store: encrypt metadata: %w", errSeal)
+		}
 		tmp := path + ".tmp"
-		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
+		if errWrite := os.WriteFile(tmp, sealed, 0o600); errWrite != nil {
 			return "", fmt.Errorf("postgres store: write temp auth file: %w", errWrite)
 		}
 		if errRename := os.Rename(tmp, path); errRename != nil {
@@ -284,8 +306,13 @@ func (s *PostgresStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 			log.WithError(errPath).Warnf("postgres store: skipping auth %s outside spool", id)
 			continue
 		}
+		plaintext, errOpen := authcrypt.Open([]byte(payload))
+		if errOpen != nil {
+			log.WithError(errOpen).Warnf("postgres store: skipping auth %s that cannot be decrypted", id)
+			continue
+		}
 		metadata := make(map[string]any)
-		if err = json.Unmarshal([]byte(payload), &metadata); err != nil {
+		if err = json.Unmarshal(plaintext, &metadata); err != nil {
 			log.WithError(err).Warnf("postgres store: skipping auth %s with invalid json", id)
 			continue
 		}
@@ -435,7 +462,7 @@ func (s *PostgresStore) syncConfigFromDatabase(ctx context.Context, exampleConfi
 
 // syncAuthFromDatabase populates the local auth directory from PostgreSQL data.
 func (s *PostgresStore) syncAuthFromDatabase(ctx context.Context) error {
-	query := fmt.Sprintf("SELECT id, content FROM %s", s.fullTableName(s.cfg.AuthTable))
+	query := fmt.Sprintf("SELECT id, content, updated_at FROM %s", s.fullTableName(s.cfg.AuthTable))
 	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("postgres store: load auth from database: %w", err)
@@ -449,12 +476,16 @@ func (s *PostgresStore) syncAuthFromDatabase(ctx context.Context) error {
 		return fmt.Errorf("postgres store: recreate auth directory: %w", err)
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authVersions = make(map[string]time.Time)
 	for rows.Next() {
 		var (
-			id      string
-			payload string
+			id        string
+			payload   string
+			updatedAt time.Time
 		)
-		if err = rows.Scan(&id, &payload); err != nil {
+		if err = rows.Scan(&id, &payload, &updatedAt); err != nil {
 			return fmt.Errorf("postgres store: scan auth row: %w", err)
 		}
 		path, errPath := s.absoluteAuthPath(id)
@@ -468,6 +499,7 @@ func (s *PostgresStore) syncAuthFromDatabase(ctx context.Context) error {
 		if err = os.WriteFile(path, []byte(payload), 0o600); err != nil {
 			return fmt.Errorf("postgres store: write auth file: %w", err)
 		}
+		s.rememberAuthVersion(id, updatedAt)
 	}
 	if err = rows.Err(); err != nil {
 		return fmt.Errorf("postgres store: iterate auth rows: %w", err)
@@ -502,12 +534,14 @@ func (s *PostgresStore) upsertAuthRecord(ctx context.Context, relID, path string
 
 func (s *PostgresStore) persistAuth(ctx context.Context, relID string, data []byte) error {
 	jsonPayload := json.RawMessage(data)
+	// Unchanged content keeps updated_at so mirrored writes do not bounce between instances.
 	query := fmt.Sprintf(`
 		INSERT INTO %s (id, content, created_at, updated_at)
 		VALUES ($1, $2, NOW(), NOW())
 		ON CONFLICT (id)
 		DO UPDATE SET content = EXCLUDED.content, updated_at = NOW()
-	`, s.fullTableName(s.cfg.AuthTable))
+		WHERE %s.content IS DISTINCT FROM EXCLUDED.content
+	`, s.fullTableName(s.cfg.AuthTable), s.fullTableName(s.cfg.AuthTable))
 	if _, err := s.db.ExecContext(ctx, query, relID, jsonPayload); err != nil {
 		return fmt.Errorf("postgres store: upsert auth record: %w", err)
 	}
@@ -519,6 +553,7 @@ func (s *PostgresStore) deleteAuthRecord(ctx context.Context, relID string) erro
 	if _, err := s.db.ExecContext(ctx, query, relID); err != nil {
 		return fmt.Errorf("postgres store: delete auth record: %w", err)
 	}
+	delete(s.authVersions, relID)
 	return nil
 }
 
@@ -528,7 +563,8 @@ func (s *PostgresStore) persistConfig(ctx context.Context, data []byte) error {
 		VALUES ($1, $2, NOW(), NOW())
 		ON CONFLICT (id)
 		DO UPDATE SET content = EXCLUDED.content, updated_at = NOW()
-	`, s.fullTableName(s.cfg.ConfigTable))
+		WHERE %s.content IS DISTINCT FROM EXCLUDED.content
+	`, s.fullTableName(s.cfg.ConfigTable), s.fullTableName(s.cfg.ConfigTable))
 	normalized := normalizeLineEndings(string(data))
 	if _, err := s.db.ExecContext(ctx, query, defaultConfigKey, normalized); err != nil {
 		return fmt.Errorf("postgres store: upsert config: %w", err)
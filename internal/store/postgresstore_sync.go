@@ -0,0 +1,326 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// postgresNotifyChannel receives a notification whenever a config or auth row changes.
+	postgresNotifyChannel       = "cliproxy_store_changes"
+	postgresNotifyFunction      = "cliproxy_notify_store_change"
+	postgresNotifyTrigger       = "cliproxy_notify_store_change"
+	defaultPostgresSyncInterval = 30 * time.Second
+	postgresListenRetryDelay    = 5 * time.Second
+	postgresSyncTimeout         = 30 * time.Second
+)
+
+// StartSync keeps the local workspace in step with changes other instances make in
+// PostgreSQL. Row changes are announced through LISTEN/NOTIFY when the triggers could
+// be installed; the database is also polled every interval as a fallback. Files written
+// here are picked up by the file watcher like local edits, which reloads the config and
+// queues auth updates. Starting again replaces the previous loop.
+func (s *PostgresStore) StartSync(parent context.Context, interval time.Duration) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = defaultPostgresSyncInterval
+	}
+	s.StopSync()
+	ctx, cancel := context.WithCancel(parent)
+	s.syncMu.Lock()
+	s.syncCancel = cancel
+	s.syncMu.Unlock()
+
+	wake := make(chan struct{}, 1)
+	go s.listenForChanges(ctx, wake)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-wake:
+			}
+			syncCtx, cancelSync := context.WithTimeout(ctx, postgresSyncTimeout)
+			if err := s.syncRemoteChanges(syncCtx); err != nil && ctx.Err() == nil {
+				log.WithError(err).Warn("postgres store: sync remote changes")
+			}
+			cancelSync()
+		}
+	}()
+}
+
+// StopSync stops the background sync started by StartSync.
+func (s *PostgresStore) StopSync() {
+	if s == nil {
+		return
+	}
+	s.syncMu.Lock()
+	cancel := s.syncCancel
+	s.syncCancel = nil
+	s.syncMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// listenForChanges holds a dedicated connection in LISTEN mode and signals wake on
+// every notification, reconnecting after failures until ctx is cancelled.
+func (s *PostgresStore) listenForChanges(ctx context.Context, wake chan<- struct{}) {
+	for {
+		err := s.listenOnce(ctx, wake)
+		if ctx.Err() != nil {
+			return
+		}
+		log.WithError(err).Debug("postgres store: change listener stopped, relying on polling until it reconnects")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(postgresListenRetryDelay):
+		}
+	}
+}
+
+func (s *PostgresStore) listenOnce(ctx context.Context, wake chan<- struct{}) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	return conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+		pgConn := stdConn.Conn()
+		if _, errListen := pgConn.Exec(ctx, "LISTEN "+postgresNotifyChannel); errListen != nil {
+			return errListen
+		}
+		// Catch up on anything that changed while the listener was down.
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+		for {
+			if _, errWait := pgConn.WaitForNotification(ctx); errWait != nil {
+				return errWait
+			}
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	})
+}
+
+// ensureChangeNotifications installs the triggers that NOTIFY on config and auth changes.
+// Missing privileges are not fatal: StartSync falls back to polling.
+func (s *PostgresStore) ensureChangeNotifications(ctx context.Context) error {
+	function := s.fullTableName(postgresNotifyFunction)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$
+		BEGIN
+			PERFORM pg_notify('%s', TG_TABLE_NAME);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql
+	`, function, postgresNotifyChannel)); err != nil {
+		return fmt.Errorf("create notify function: %w", err)
+	}
+	for _, table := range []string{s.cfg.ConfigTable, s.cfg.AuthTable} {
+		fullTable := s.fullTableName(table)
+		var exists bool
+		query := "SELECT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = $1 AND tgrelid = $2::regclass)"
+		if err := s.db.QueryRowContext(ctx, query, postgresNotifyTrigger, fullTable).Scan(&exists); err != nil {
+			return fmt.Errorf("inspect trigger on %s: %w", table, err)
+		}
+		if exists {
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf(
+			"CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH STATEMENT EXECUTE PROCEDURE %s()",
+			quoteIdentifier(postgresNotifyTrigger), fullTable, function,
+		)); err != nil {
+			return fmt.Errorf("create trigger on %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// syncRemoteChanges pulls config and auth rows whose updated_at differs from the
+// version last mirrored and applies them to the local workspace.
+func (s *PostgresStore) syncRemoteChanges(ctx context.Context) error {
+	if err := s.syncRemoteConfig(ctx); err != nil {
+		return err
+	}
+	return s.syncRemoteAuths(ctx)
+}
+
+// syncRemoteConfig reads the config row under s.mu, the lock PersistConfig holds while it
+// writes the row, so a local change is never overwritten by an older row.
+func (s *PostgresStore) syncRemoteConfig(ctx context.Context) error {
+	query := fmt.Sprintf("SELECT content, updated_at FROM %s WHERE id = $1", s.fullTableName(s.cfg.ConfigTable))
+	var (
+		content   string
+		updatedAt time.Time
+	)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.db.QueryRowContext(ctx, query, defaultConfigKey).Scan(&content, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("postgres store: poll config: %w", err)
+	}
+	if updatedAt.Equal(s.configVersion) {
+		return nil
+	}
+	s.configVersion = updatedAt
+	normalized := []byte(normalizeLineEndings(content))
+	if existing, errRead := os.ReadFile(s.configPath); errRead == nil && bytes.Equal([]byte(normalizeLineEndings(string(existing))), normalized) {
+		return nil
+	}
+	if err = os.WriteFile(s.configPath, normalized, 0o600); err != nil {
+		return fmt.Errorf("postgres store: write remote config: %w", err)
+	}
+	log.Info("postgres store: pulled config change from database")
+	return nil
+}
+
+func (s *PostgresStore) syncRemoteAuths(ctx context.Context) error {
+	authTable := s.fullTableName(s.cfg.AuthTable)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT id, updated_at FROM %s", authTable))
+	if err != nil {
+		return fmt.Errorf("postgres store: poll auth versions: %w", err)
+	}
+	remote := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			id        string
+			updatedAt time.Time
+		)
+		if err = rows.Scan(&id, &updatedAt); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("postgres store: scan auth version: %w", err)
+		}
+		remote[id] = updatedAt
+	}
+	_ = rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("postgres store: iterate auth versions: %w", err)
+	}
+
+	s.mu.Lock()
+	var changed, removed []string
+	for id, updatedAt := range remote {
+		if known, ok := s.authVersions[id]; !ok || !known.Equal(updatedAt) {
+			changed = append(changed, id)
+		}
+	}
+	for id := range s.authVersions {
+		if _, ok := remote[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, id := range changed {
+		if errApply := s.applyRemoteAuth(ctx, id); errApply != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.WithError(errApply).Warnf("postgres store: skipping remote auth %s", id)
+		}
+	}
+	for _, id := range removed {
+		if errRemove := s.removeRemoteAuth(id); errRemove != nil {
+			log.WithError(errRemove).Warnf("postgres store: removing auth %s deleted remotely", id)
+		}
+	}
+	return nil
+}
+
+// applyRemoteAuth mirrors one auth row into the workspace unless the local file already
+// holds the same JSON document. The row is read under s.mu, the lock Save holds while it
+// writes the file and the row, so a local save is never overwritten by an older row.
+func (s *PostgresStore) applyRemoteAuth(ctx context.Context, id string) error {
+	path, err := s.absoluteAuthPath(id)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("SELECT content, updated_at FROM %s WHERE id = $1", s.fullTableName(s.cfg.AuthTable))
+	var (
+		content   string
+		updatedAt time.Time
+	)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err = s.db.QueryRowContext(ctx, query, id).Scan(&content, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load auth: %w", err)
+	}
+	payload := []byte(content)
+	s.rememberAuthVersion(id, updatedAt)
+	if existing, errRead := os.ReadFile(path); errRead == nil && jsonEqual(existing, payload) {
+		return nil
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create auth subdir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, payload, 0o600); err != nil {
+		return fmt.Errorf("write temp auth file: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename auth file: %w", err)
+	}
+	log.Infof("postgres store: pulled auth %s from database", id)
+	return nil
+}
+
+// removeRemoteAuth deletes the local copy of an auth that another instance removed.
+// Only auths previously mirrored from the database are removed, so files created
+// locally and not yet persisted are left alone.
+func (s *PostgresStore) removeRemoteAuth(id string) error {
+	path, err := s.absoluteAuthPath(id)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.authVersions[id]; !ok {
+		return nil
+	}
+	delete(s.authVersions, id)
+	if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	log.Infof("postgres store: removed auth %s deleted in database", id)
+	return nil
+}
+
+// rememberAuthVersion records the database version of a mirrored auth. Callers hold s.mu.
+func (s *PostgresStore) rememberAuthVersion(id string, updatedAt time.Time) {
+	if s.authVersions == nil {
+		s.authVersions = make(map[string]time.Time)
+	}
+	s.authVersions[id] = updatedAt
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// newSyncTestStore backs a PostgresStore with SQLite, which understands the queries the
+// sync loop issues.
+func newSyncTestStore(t *testing.T) *PostgresStore {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "store.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	for _, table := range []string{defaultConfigTable, defaultAuthTable} {
+		if _, err = db.Exec(`CREATE TABLE "` + table + `" (id TEXT PRIMARY KEY, content TEXT NOT NULL, updated_at TIMESTAMP NOT NULL)`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	workDir := t.TempDir()
+	return &PostgresStore{
+		db:         db,
+		cfg:        PostgresStoreConfig{ConfigTable: defaultConfigTable, AuthTable: defaultAuthTable},
+		configPath: filepath.Join(workDir, "config.yaml"),
+		authDir:    filepath.Join(workDir, "auths"),
+	}
+}
+
+func putRow(t *testing.T, s *PostgresStore, table, id, content string, updatedAt time.Time) {
+	t.Helper()
+	query := `INSERT INTO "` + table + `" (id, content, updated_at) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET content = excluded.content, updated_at = excluded.updated_at`
+	if _, err := s.db.Exec(query, id, content, updatedAt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPostgresStoreAppliesRemoteAuthChanges(t *testing.T) {
+	s := newSyncTestStore(t)
+	ctx := context.Background()
+	version := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	putRow(t, s, defaultAuthTable, "team/claude.json", `{"type":"claude","access_token":"at-1"}`, version)
+	if err := s.syncRemoteAuths(ctx); err != nil {
+		t.Fatalf("syncRemoteAuths() error = %v", err)
+	}
+	path := filepath.Join(s.authDir, "team", "claude.json")
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != `{"type":"claude","access_token":"at-1"}` {
+		t.Fatalf("mirrored file = %s, %v", data, err)
+	}
+
+	// Same document with different formatting (as returned by JSONB) must not rewrite the file.
+	info, _ := os.Stat(path)
+	putRow(t, s, defaultAuthTable, "team/claude.json", `{"access_token": "at-1", "type": "claude"}`, version.Add(time.Second))
+	if err = s.syncRemoteAuths(ctx); err != nil {
+		t.Fatalf("syncRemoteAuths() error = %v", err)
+	}
+	if again, _ := os.Stat(path); !again.ModTime().Equal(info.ModTime()) {
+		t.Fatal("semantically equal payload rewrote the local file")
+	}
+	if data, _ = os.ReadFile(path); string(data) != `{"type":"claude","access_token":"at-1"}` {
+		t.Fatalf("file changed to %s", data)
+	}
+
+	if err = s.applyRemoteAuth(ctx, "../escape.json"); err == nil {
+		t.Fatal("expected error for auth id outside the spool")
+	}
+
+	local := filepath.Join(s.authDir, "local-only.json")
+	if err = os.WriteFile(local, []byte(`{"type":"gemini"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.removeRemoteAuth("local-only.json"); err != nil {
+		t.Fatalf("removeRemoteAuth() error = %v", err)
+	}
+	if _, err = os.Stat(local); err != nil {
+		t.Fatalf("file never mirrored from the database was removed: %v", err)
+	}
+	if _, err = s.db.Exec(`DELETE FROM "` + defaultAuthTable + `"`); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.syncRemoteAuths(ctx); err != nil {
+		t.Fatalf("syncRemoteAuths() error = %v", err)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("remotely deleted auth still present: %v", err)
+	}
+}
+
+// A local save that lands after the sync listed a remote change but before it applied the
+// change must win: the sync has to mirror the row as it is once it holds the lock.
+func TestPostgresStoreSyncDoesNotOverwriteLaterLocalSave(t *testing.T) {
+	s := newSyncTestStore(t)
+	ctx := context.Background()
+	version := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(s.authDir, "claude.json")
+
+	// Another replica wrote the row; this replica listed it as changed.
+	putRow(t, s, defaultAuthTable, "claude.json", `{"access_token":"remote"}`, version)
+
+	// Before the change is applied, a local Save writes the file and the row under s.mu.
+	s.mu.Lock()
+	if err := os.MkdirAll(s.authDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"access_token":"local"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	putRow(t, s, defaultAuthTable, "claude.json", `{"access_token":"local"}`, version.Add(time.Second))
+	s.mu.Unlock()
+
+	if err := s.applyRemoteAuth(ctx, "claude.json"); err != nil {
+		t.Fatalf("applyRemoteAuth() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"access_token":"local"}` {
+		t.Fatalf("local save overwritten with %s", data)
+	}
+	if got := s.authVersions["claude.json"]; !got.Equal(version.Add(time.Second)) {
+		t.Fatalf("remembered version = %v, want the row written by the local save", got)
+	}
+
+	// The config follows the same rule.
+	putRow(t, s, defaultConfigTable, defaultConfigKey, "port: 1\n", version)
+	s.mu.Lock()
+	if err := os.WriteFile(s.configPath, []byte("port: 2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	putRow(t, s, defaultConfigTable, defaultConfigKey, "port: 2\n", version.Add(time.Second))
+	s.mu.Unlock()
+	if err := s.syncRemoteConfig(ctx); err != nil {
+		t.Fatalf("syncRemoteConfig() error = %v", err)
+	}
+	if data, _ := os.ReadFile(s.configPath); string(data) != "port: 2\n" {
+		t.Fatalf("local config overwritten with %s", data)
+	}
+}
@@ -17,4 +17,6 @@ func init() {
 			TokenCount: GeminiTokenCount,
 		},
 	)
+	// Gemini CLI requests only differ from Gemini ones by their envelope.
+	translator.PreferRoute(GeminiCLI, Gemini, Antigravity)
 }
@@ -25,6 +25,19 @@ func Register(from, to string, request interfaces.TranslateRequestFunc, response
 	registry.Register(sdktranslator.FromString(from), sdktranslator.FromString(to), request, response)
 }
 
+// PreferRoute sets the formats a request passes through between the first and last format of
+// route when no translator is registered for that pair.
+//
+// Parameters:
+//   - route: The API format identifiers from source to target
+func PreferRoute(route ...string) {
+	formats := make([]sdktranslator.Format, 0, len(route))
+	for _, format := range route {
+		formats = append(formats, sdktranslator.FromString(format))
+	}
+	registry.PreferRoute(formats...)
+}
+
 // Request translates a request from one API format to another.
 //
 // Parameters:
@@ -0,0 +1,758 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/sqlutil"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// SQLDriverSQLite stores usage records in an embedded SQLite file.
+	SQLDriverSQLite = "sqlite"
+	// SQLDriverPostgres stores usage records in PostgreSQL.
+	SQLDriverPostgres = "postgres"
+
+	defaultUsageTable        = "usage_records"
+	defaultUsageSQLiteFile   = "usage.db"
+	usageInsertTimeout       = 5 * time.Second
+	usageRetentionInterval   = time.Hour
+	usageQueryDefaultLimit   = 1000
+	usageQueryMaxLimit       = 10000
+	usageGranularityHourMs   = int64(time.Hour / time.Millisecond)
+	usageGranularityDayMs    = int64(24 * time.Hour / time.Millisecond)
+	usageHistoryDefaultLimit = 100
+)
+
+// ErrInvalidUsageQuery reports a malformed aggregate query such as an unknown group-by dimension.
+var ErrInvalidUsageQuery = errors.New("usage store: invalid query")
+
+var usageTableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// usageGroupColumns maps query group-by dimensions to table columns.
+var usageGroupColumns = map[string]string{
+	"key":      "api_key",
+	"model":    "model",
+	"provider": "provider",
+	"auth":     "auth_index",
+	"source":   "source",
+}
+
+// SQLStoreConfig captures the settings required to open a durable usage store.
+type SQLStoreConfig struct {
+	// Driver is either SQLDriverSQLite or SQLDriverPostgres.
+	Driver string
+	// Path is the SQLite database file; relative paths are resolved against BaseDir.
+	Path string
+	// BaseDir anchors relative SQLite paths, usually the config file directory.
+	BaseDir string
+	// DSN is the PostgreSQL connection string. When empty, PGSTORE_DSN is used.
+	DSN string
+	// Table overrides the usage table name.
+	Table string
+	// Retention deletes records older than this duration. <= 0 keeps records forever.
+	Retention time.Duration
+}
+
+// SQLStore persists every usage record to a SQL database and serves queries over them.
+// It implements coreusage.Plugin.
+type SQLStore struct {
+	db        *sql.DB
+	driver    string
+	table     string
+	location  string
+	retention time.Duration
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// StoredRecord is a single persisted usage record.
+type StoredRecord struct {
+	ID          int64      `json:"id"`
+	RequestedAt time.Time  `json:"timestamp"`
+	RequestID   string     `json:"request_id,omitempty"`
+	Provider    string     `json:"provider"`
+	Model       string     `json:"model"`
+	APIKey      string     `json:"api_key"`
+	AuthID      string     `json:"auth_id,omitempty"`
+	AuthIndex   string     `json:"auth_index"`
+	Source      string     `json:"source"`
+	Failed      bool       `json:"failed"`
+	Tokens      TokenStats `json:"tokens"`
+	QueueWaitMs int64      `json:"queue_wait_ms"`
+}
+
+// UsageQuery describes an aggregate query over stored usage records.
+type UsageQuery struct {
+	From time.Time
+	To   time.Time
+	// GroupBy lists dimensions to aggregate by: key, model, provider, auth, source.
+	GroupBy []string
+	// Granularity buckets results by "hour" or "day". Empty disables time bucketing.
+	Granularity string
+
+	Provider  string
+	Model     string
+	APIKey    string
+	AuthIndex string
+	Source    string
+
+	Limit int
+}
+
+// UsageBucket is one aggregated row returned by Query.
+type UsageBucket struct {
+	Bucket       *time.Time        `json:"bucket,omitempty"`
+	Group        map[string]string `json:"group,omitempty"`
+	Requests     int64             `json:"requests"`
+	SuccessCount int64             `json:"success_count"`
+	FailureCount int64             `json:"failure_count"`
+	Tokens       TokenStats        `json:"tokens"`
+}
+
+// HistoryQuery filters individual stored records.
+type HistoryQuery struct {
+	From      time.Time
+	To        time.Time
+	Provider  string
+	Model     string
+	APIKey    string
+	AuthIndex string
+	Source    string
+	RequestID string
+	Success   *bool
+	Limit     int
+	Offset    int
+}
+
+var defaultSQLStore atomic.Pointer[SQLStore]
+
+// GetSQLStore returns the shared durable usage store, or nil when durable storage is disabled.
+func GetSQLStore() *SQLStore { return defaultSQLStore.Load() }
+
+// InitSQLStore opens the durable usage store described by cfg and installs it as the shared store.
+// A previously installed store is closed. An empty driver disables durable storage.
+func InitSQLStore(ctx context.Context, cfg SQLStoreConfig) (*SQLStore, error) {
+	if strings.TrimSpace(cfg.Driver) == "" {
+		if previous := defaultSQLStore.Swap(nil); previous != nil {
+			_ = previous.Close()
+		}
+		return nil, nil
+	}
+	store, err := OpenSQLStore(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if previous := defaultSQLStore.Swap(store); previous != nil {
+		_ = previous.Close()
+	}
+	store.startRetention()
+	return store, nil
+}
+
+// CloseSQLStore closes and uninstalls the shared durable usage store.
+func CloseSQLStore() {
+	if previous := defaultSQLStore.Swap(nil); previous != nil {
+		if err := previous.Close(); err != nil {
+			log.WithError(err).Warn("usage store: failed to close database")
+		}
+	}
+}
+
+// OpenSQLStore connects to the configured database and ensures the usage schema exists.
+func OpenSQLStore(ctx context.Context, cfg SQLStoreConfig) (*SQLStore, error) {
+	driver := strings.ToLower(strings.TrimSpace(cfg.Driver))
+	table := strings.TrimSpace(cfg.Table)
+	if table == "" {
+		table = defaultUsageTable
+	}
+	if !usageTableNamePattern.MatchString(table) {
+		return nil, fmt.Errorf("usage store: invalid table name %q", table)
+	}
+
+	var (
+		db       *sql.DB
+		location string
+		err      error
+	)
+	switch driver {
+	case SQLDriverSQLite:
+		location = strings.TrimSpace(cfg.Path)
+		if location == "" {
+			location = defaultUsageSQLiteFile
+		}
+		if !filepath.IsAbs(location) && cfg.BaseDir != "" {
+			location = filepath.Join(cfg.BaseDir, location)
+		}
+		if err = os.MkdirAll(filepath.Dir(location), 0o755); err != nil {
+			return nil, fmt.Errorf("usage store: create directory: %w", err)
+		}
+		dsn := "file:" + location + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+		db, err = sql.Open("sqlite", dsn)
+		if err != nil {
+			return nil, fmt.Errorf("usage store: open sqlite database: %w", err)
+		}
+		// SQLite serialises writers; a single connection avoids SQLITE_BUSY churn.
+		db.SetMaxOpenConns(1)
+	case SQLDriverPostgres:
+		dsn := strings.TrimSpace(cfg.DSN)
+		if dsn == "" {
+			dsn = sqlutil.PostgresDSNFromEnv()
+		}
+		if dsn == "" {
+			return nil, fmt.Errorf("usage store: postgres DSN is required (set usage-storage.dsn or PGSTORE_DSN)")
+		}
+		db, err = sql.Open("pgx", dsn)
+		if err != nil {
+			return nil, fmt.Errorf("usage store: open postgres connection: %w", err)
+		}
+		location = "postgres"
+	default:
+		return nil, fmt.Errorf("usage store: unsupported driver %q", cfg.Driver)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err = db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("usage store: ping database: %w", err)
+	}
+
+	store := &SQLStore{
+		db:        db,
+		driver:    driver,
+		table:     table,
+		location:  location,
+		retention: cfg.Retention,
+		stopCh:    make(chan struct{}),
+	}
+	if err = store.EnsureSchema(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// Close stops background retention and releases the database connection.
+func (s *SQLStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Driver reports the database driver backing the store.
+func (s *SQLStore) Driver() string {
+	if s == nil {
+		return ""
+	}
+	return s.driver
+}
+
+// Location reports the SQLite file path, or "postgres" for PostgreSQL-backed stores.
+func (s *SQLStore) Location() string {
+	if s == nil {
+		return ""
+	}
+	return s.location
+}
+
+// Retention reports the configured retention window. Zero means records are kept forever.
+func (s *SQLStore) Retention() time.Duration {
+	if s == nil {
+		return 0
+	}
+	return s.retention
+}
+
+// EnsureSchema creates the usage table and its indexes when missing.
+func (s *SQLStore) EnsureSchema(ctx context.Context) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("usage store: not initialized")
+	}
+	idColumn := "id INTEGER PRIMARY KEY AUTOINCREMENT"
+	if s.driver == SQLDriverPostgres {
+		idColumn = "id BIGSERIAL PRIMARY KEY"
+		if idx := strings.Index(s.table, "."); idx > 0 {
+			schema := s.table[:idx]
+			if _, err := s.db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+quoteUsageIdentifier(schema)); err != nil {
+				return fmt.Errorf("usage store: create schema: %w", err)
+			}
+		}
+	}
+	table := quoteUsageIdentifier(s.table)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			%s,
+			requested_at BIGINT NOT NULL,
+			request_id TEXT NOT NULL DEFAULT '',
+			provider TEXT NOT NULL DEFAULT '',
+			model TEXT NOT NULL DEFAULT '',
+			api_key TEXT NOT NULL DEFAULT '',
+			auth_id TEXT NOT NULL DEFAULT '',
+			auth_index TEXT NOT NULL DEFAULT '',
+			source TEXT NOT NULL DEFAULT '',
+			failed INTEGER NOT NULL DEFAULT 0,
+			input_tokens BIGINT NOT NULL DEFAULT 0,
+			output_tokens BIGINT NOT NULL DEFAULT 0,
+			reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+			cached_tokens BIGINT NOT NULL DEFAULT 0,
+			total_tokens BIGINT NOT NULL DEFAULT 0,
+			queue_wait_ms BIGINT NOT NULL DEFAULT 0,
+			cache_creation_tokens BIGINT NOT NULL DEFAULT 0
+		)
+	`, table, idColumn)); err != nil {
+		return fmt.Errorf("usage store: create table: %w", err)
+	}
+	// Tables created before queue wait and cache write tracking lack these columns.
+	for _, column := range []string{"queue_wait_ms", "cache_creation_tokens"} {
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE 1 = 0", column, table)); err != nil {
+			if _, err = s.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s BIGINT NOT NULL DEFAULT 0", table, column)); err != nil {
+				return fmt.Errorf("usage store: add %s column: %w", column, err)
+			}
+		}
+	}
+	baseName := s.table
+	if idx := strings.LastIndex(baseName, "."); idx >= 0 {
+		baseName = baseName[idx+1:]
+	}
+	for _, column := range []string{"requested_at", "request_id"} {
+		indexName := quoteUsageIdentifier(baseName + "_" + column + "_idx")
+		query := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", indexName, table, column)
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("usage store: create index on %s: %w", column, err)
+		}
+	}
+	return nil
+}
+
+// HandleUsage implements coreusage.Plugin and persists the record.
+func (s *SQLStore) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if s == nil || s.db == nil {
+		return
+	}
+	stored := StoredRecord{
+		RequestedAt: record.RequestedAt,
+		RequestID:   resolveRequestID(ctx),
+		Provider:    record.Provider,
+		Model:       record.Model,
+		APIKey:      record.APIKey,
+		AuthID:      record.AuthID,
+		AuthIndex:   record.AuthIndex,
+		Source:      record.Source,
+		Failed:      record.Failed || !resolveSuccess(ctx),
+		Tokens:      normaliseDetail(record.Detail),
+		QueueWaitMs: record.QueueWait.Milliseconds(),
+	}
+	if stored.APIKey == "" {
+		stored.APIKey = resolveAPIIdentifier(ctx, record)
+	}
+	insertCtx, cancel := context.WithTimeout(context.Background(), usageInsertTimeout)
+	defer cancel()
+	if err := s.Insert(insertCtx, stored); err != nil {
+		log.WithError(err).Warn("usage store: failed to persist usage record")
+	}
+}
+
+// Insert writes a single usage record.
+func (s *SQLStore) Insert(ctx context.Context, record StoredRecord) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("usage store: not initialized")
+	}
+	if record.RequestedAt.IsZero() {
+		record.RequestedAt = time.Now()
+	}
+	if strings.TrimSpace(record.Model) == "" {
+		record.Model = "unknown"
+	}
+	tokens := normaliseTokenStats(record.Tokens)
+	failed := 0
+	if record.Failed {
+		failed = 1
+	}
+	query := s.rebind(fmt.Sprintf(`INSERT INTO %s (
+		requested_at, request_id, provider, model, api_key, auth_id, auth_index, source, failed,
+		input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, queue_wait_ms,
+		cache_creation_tokens
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, quoteUsageIdentifier(s.table)))
+	_, err := s.db.ExecContext(ctx, query,
+		record.RequestedAt.UnixMilli(),
+		record.RequestID,
+		record.Provider,
+		record.Model,
+		record.APIKey,
+		record.AuthID,
+		record.AuthIndex,
+		record.Source,
+		failed,
+		tokens.InputTokens,
+		tokens.OutputTokens,
+		tokens.ReasoningTokens,
+		tokens.CachedTokens,
+		tokens.TotalTokens,
+		record.QueueWaitMs,
+		tokens.CacheCreationTokens,
+	)
+	if err != nil {
+		return fmt.Errorf("usage store: insert record: %w", err)
+	}
+	return nil
+}
+
+// Query aggregates stored records by the requested dimensions and time granularity.
+func (s *SQLStore) Query(ctx context.Context, q UsageQuery) ([]UsageBucket, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("usage store: not initialized")
+	}
+
+	var bucketSize int64
+	switch strings.ToLower(strings.TrimSpace(q.Granularity)) {
+	case "":
+	case "hour", "hourly":
+		bucketSize = usageGranularityHourMs
+	case "day", "daily":
+		bucketSize = usageGranularityDayMs
+	default:
+		return nil, fmt.Errorf("%w: unsupported granularity %q", ErrInvalidUsageQuery, q.Granularity)
+	}
+
+	groups := make([]string, 0, len(q.GroupBy))
+	seen := make(map[string]struct{}, len(q.GroupBy))
+	for _, raw := range q.GroupBy {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" {
+			continue
+		}
+		if _, ok := usageGroupColumns[name]; !ok {
+			return nil, fmt.Errorf("%w: unsupported group-by dimension %q", ErrInvalidUsageQuery, raw)
+		}
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		seen[name] = struct{}{}
+		groups = append(groups, name)
+	}
+
+	selectCols := make([]string, 0, len(groups)+1)
+	groupCols := make([]string, 0, len(groups)+1)
+	if bucketSize > 0 {
+		bucketExpr := fmt.Sprintf("(requested_at / %d) * %d", bucketSize, bucketSize)
+		selectCols = append(selectCols, bucketExpr+" AS bucket")
+		groupCols = append(groupCols, bucketExpr)
+	}
+	for _, name := range groups {
+		column := usageGroupColumns[name]
+		selectCols = append(selectCols, column)
+		groupCols = append(groupCols, column)
+	}
+	selectCols = append(selectCols,
+		"COUNT(*)",
+		"COALESCE(CAST(SUM(failed) AS BIGINT), 0)",
+		"COALESCE(CAST(SUM(input_tokens) AS BIGINT), 0)",
+		"COALESCE(CAST(SUM(output_tokens) AS BIGINT), 0)",
+		"COALESCE(CAST(SUM(reasoning_tokens) AS BIGINT), 0)",
+		"COALESCE(CAST(SUM(cached_tokens) AS BIGINT), 0)",
+		"COALESCE(CAST(SUM(total_tokens) AS BIGINT), 0)",
+		"COALESCE(CAST(SUM(cache_creation_tokens) AS BIGINT), 0)",
+	)
+
+	where, args := buildUsageFilter(q.From, q.To, map[string]string{
+		"provider":   q.Provider,
+		"model":      q.Model,
+		"api_key":    q.APIKey,
+		"auth_index": q.AuthIndex,
+		"source":     q.Source,
+	})
+
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	sb.WriteString(strings.Join(selectCols, ", "))
+	sb.WriteString(" FROM ")
+	sb.WriteString(quoteUsageIdentifier(s.table))
+	sb.WriteString(where)
+	if len(groupCols) > 0 {
+		sb.WriteString(" GROUP BY ")
+		sb.WriteString(strings.Join(groupCols, ", "))
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(strings.Join(groupCols, ", "))
+	}
+	sb.WriteString(" LIMIT ")
+	sb.WriteString(strconv.Itoa(clampLimit(q.Limit, usageQueryDefaultLimit, usageQueryMaxLimit)))
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(sb.String()), args...)
+	if err != nil {
+		return nil, fmt.Errorf("usage store: query: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	result := make([]UsageBucket, 0)
+	for rows.Next() {
+		var (
+			bucketMs int64
+			bucket   UsageBucket
+			failures int64
+		)
+		groupValues := make([]string, len(groups))
+		dest := make([]any, 0, len(selectCols))
+		if bucketSize > 0 {
+			dest = append(dest, &bucketMs)
+		}
+		for i := range groupValues {
+			dest = append(dest, &groupValues[i])
+		}
+		dest = append(dest,
+			&bucket.Requests,
+			&failures,
+			&bucket.Tokens.InputTokens,
+			&bucket.Tokens.OutputTokens,
+			&bucket.Tokens.ReasoningTokens,
+			&bucket.Tokens.CachedTokens,
+			&bucket.Tokens.TotalTokens,
+			&bucket.Tokens.CacheCreationTokens,
+		)
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("usage store: scan aggregate row: %w", err)
+		}
+		if bucketSize > 0 {
+			ts := time.UnixMilli(bucketMs).UTC()
+			bucket.Bucket = &ts
+		}
+		if len(groups) > 0 {
+			bucket.Group = make(map[string]string, len(groups))
+			for i, name := range groups {
+				bucket.Group[name] = groupValues[i]
+			}
+		}
+		bucket.FailureCount = failures
+		bucket.SuccessCount = bucket.Requests - failures
+		result = append(result, bucket)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("usage store: iterate aggregate rows: %w", err)
+	}
+	return result, nil
+}
+
+// History returns individual records matching q, most recent first, along with the total match count.
+func (s *SQLStore) History(ctx context.Context, q HistoryQuery) ([]StoredRecord, int64, error) {
+	if s == nil || s.db == nil {
+		return nil, 0, fmt.Errorf("usage store: not initialized")
+	}
+	where, args := buildUsageFilter(q.From, q.To, map[string]string{
+		"provider":   q.Provider,
+		"model":      q.Model,
+		"api_key":    q.APIKey,
+		"auth_index": q.AuthIndex,
+		"source":     q.Source,
+		"request_id": q.RequestID,
+	})
+	if q.Success != nil {
+		if where == "" {
+			where = " WHERE "
+		} else {
+			where += " AND "
+		}
+		where += "failed = ?"
+		if *q.Success {
+			args = append(args, 0)
+		} else {
+			args = append(args, 1)
+		}
+	}
+	table := quoteUsageIdentifier(s.table)
+
+	var total int64
+	countQuery := s.rebind("SELECT COUNT(*) FROM " + table + where)
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("usage store: count history: %w", err)
+	}
+
+	limit := clampLimit(q.Limit, usageHistoryDefaultLimit, usageQueryMaxLimit)
+	offset := q.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	listQuery := s.rebind(fmt.Sprintf(`SELECT id, requested_at, request_id, provider, model, api_key, auth_id, auth_index, source, failed,
+		input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, queue_wait_ms,
+		cache_creation_tokens
+		FROM %s%s ORDER BY requested_at DESC, id DESC LIMIT %d OFFSET %d`, table, where, limit, offset))
+	rows, err := s.db.QueryContext(ctx, listQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("usage store: query history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	records := make([]StoredRecord, 0, limit)
+	for rows.Next() {
+		var (
+			record      StoredRecord
+			requestedAt int64
+			failed      int64
+		)
+		if err = rows.Scan(
+			&record.ID,
+			&requestedAt,
+			&record.RequestID,
+			&record.Provider,
+			&record.Model,
+			&record.APIKey,
+			&record.AuthID,
+			&record.AuthIndex,
+			&record.Source,
+			&failed,
+			&record.Tokens.InputTokens,
+			&record.Tokens.OutputTokens,
+			&record.Tokens.ReasoningTokens,
+			&record.Tokens.CachedTokens,
+			&record.Tokens.TotalTokens,
+			&record.QueueWaitMs,
+			&record.Tokens.CacheCreationTokens,
+		); err != nil {
+			return nil, 0, fmt.Errorf("usage store: scan history row: %w", err)
+		}
+		record.RequestedAt = time.UnixMilli(requestedAt)
+		record.Failed = failed != 0
+		records = append(records, record)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("usage store: iterate history rows: %w", err)
+	}
+	return records, total, nil
+}
+
+// Prune deletes records older than before and returns the number of rows removed.
+func (s *SQLStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, fmt.Errorf("usage store: not initialized")
+	}
+	if before.IsZero() {
+		return 0, errors.New("usage store: prune cutoff is required")
+	}
+	query := s.rebind("DELETE FROM " + quoteUsageIdentifier(s.table) + " WHERE requested_at < ?")
+	res, err := s.db.ExecContext(ctx, query, before.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("usage store: prune: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+	return affected, nil
+}
+
+func (s *SQLStore) startRetention() {
+	if s == nil || s.retention <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(usageRetentionInterval)
+		defer ticker.Stop()
+		for {
+			s.applyRetention()
+			select {
+			case <-ticker.C:
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *SQLStore) applyRetention() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	removed, err := s.Prune(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		log.WithError(err).Warn("usage store: retention prune failed")
+		return
+	}
+	if removed > 0 {
+		log.WithField("removed", removed).Debug("usage store: pruned expired usage records")
+	}
+}
+
+// rebind converts '?' placeholders into the positional form expected by PostgreSQL.
+func (s *SQLStore) rebind(query string) string {
+	if s.driver != SQLDriverPostgres {
+		return query
+	}
+	return sqlutil.RebindPostgres(query)
+}
+
+func buildUsageFilter(from, to time.Time, equals map[string]string) (string, []any) {
+	clauses := make([]string, 0, len(equals)+2)
+	args := make([]any, 0, len(equals)+2)
+	if !from.IsZero() {
+		clauses = append(clauses, "requested_at >= ?")
+		args = append(args, from.UnixMilli())
+	}
+	if !to.IsZero() {
+		clauses = append(clauses, "requested_at < ?")
+		args = append(args, to.UnixMilli())
+	}
+	// Iterate in a fixed order so generated SQL is stable.
+	for _, column := range []string{"provider", "model", "api_key", "auth_index", "source", "request_id"} {
+		value := strings.TrimSpace(equals[column])
+		if value == "" {
+			continue
+		}
+		clauses = append(clauses, column+" = ?")
+		args = append(args, value)
+	}
+	if len(clauses) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func clampLimit(limit, fallback, maximum int) int {
+	if limit <= 0 {
+		return fallback
+	}
+	if limit > maximum {
+		return maximum
+	}
+	return limit
+}
+
+func quoteUsageIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+// SQLStorePlugin forwards usage records to the shared durable store when one is installed.
+type SQLStorePlugin struct{}
+
+// NewSQLStorePlugin constructs a plugin bound to the shared durable store.
+func NewSQLStorePlugin() *SQLStorePlugin { return &SQLStorePlugin{} }
+
+// HandleUsage implements coreusage.Plugin.
+func (p *SQLStorePlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if store := GetSQLStore(); store != nil {
+		store.HandleUsage(ctx, record)
+	}
+}
+
+func init() {
+	coreusage.RegisterPlugin(NewSQLStorePlugin())
+}
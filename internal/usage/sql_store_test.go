@@ -0,0 +1,172 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func openTestSQLStore(t *testing.T) *SQLStore {
+	t.Helper()
+	store, err := OpenSQLStore(context.Background(), SQLStoreConfig{
+		Driver: SQLDriverSQLite,
+		Path:   filepath.Join(t.TempDir(), "usage.db"),
+	})
+	if err != nil {
+		t.Fatalf("OpenSQLStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func TestSQLStoreQueryRollsUpByDayAndModel(t *testing.T) {
+	store := openTestSQLStore(t)
+	ctx := context.Background()
+
+	day1 := time.Date(2026, 3, 1, 10, 15, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	records := []coreusage.Record{
+		{Provider: "claude", Model: "claude-sonnet-4", APIKey: "key-a", AuthIndex: "1", RequestedAt: day1, Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 5}},
+		{Provider: "claude", Model: "claude-sonnet-4", APIKey: "key-a", AuthIndex: "1", RequestedAt: day1.Add(time.Hour), Failed: true},
+		{Provider: "gemini", Model: "gemini-2.5-pro", APIKey: "key-b", AuthIndex: "2", RequestedAt: day1, Detail: coreusage.Detail{TotalTokens: 7}},
+		{Provider: "claude", Model: "claude-sonnet-4", APIKey: "key-a", AuthIndex: "1", RequestedAt: day2, Detail: coreusage.Detail{InputTokens: 1, OutputTokens: 1}},
+	}
+	for _, record := range records {
+		store.HandleUsage(ctx, record)
+	}
+
+	rows, err := store.Query(ctx, UsageQuery{GroupBy: []string{"model"}, Granularity: "day"})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("len(rows) = %d, want 3", len(rows))
+	}
+	first := rows[0]
+	if first.Bucket == nil || !first.Bucket.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("first bucket = %v, want 2026-03-01", first.Bucket)
+	}
+	if first.Group["model"] != "claude-sonnet-4" {
+		t.Fatalf("first group = %v, want claude-sonnet-4", first.Group)
+	}
+	if first.Requests != 2 || first.FailureCount != 1 || first.SuccessCount != 1 {
+		t.Fatalf("first counts = %+v, want 2 requests with 1 failure", first)
+	}
+	if first.Tokens.TotalTokens != 15 {
+		t.Fatalf("first total tokens = %d, want 15", first.Tokens.TotalTokens)
+	}
+
+	rows, err = store.Query(ctx, UsageQuery{From: day2, GroupBy: []string{"key", "auth"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Group["key"] != "key-a" || rows[0].Group["auth"] != "1" || rows[0].Requests != 1 {
+		t.Fatalf("ranged rows = %+v, want single key-a row", rows)
+	}
+
+	if _, err = store.Query(ctx, UsageQuery{GroupBy: []string{"region"}}); !errors.Is(err, ErrInvalidUsageQuery) {
+		t.Fatalf("Query() with unknown dimension error = %v, want ErrInvalidUsageQuery", err)
+	}
+}
+
+func TestSQLStoreHistoryAndPrune(t *testing.T) {
+	store := openTestSQLStore(t)
+	ctx := context.Background()
+
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		if err := store.Insert(ctx, StoredRecord{
+			RequestedAt: base.Add(time.Duration(i) * time.Hour),
+			Provider:    "codex",
+			Model:       "gpt-5",
+			APIKey:      "key-a",
+			Failed:      i%2 == 1,
+			Tokens:      TokenStats{InputTokens: int64(i)},
+		}); err != nil {
+			t.Fatalf("Insert() error = %v", err)
+		}
+	}
+
+	success := false
+	records, total, err := store.History(ctx, HistoryQuery{Success: &success})
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if total != 2 || len(records) != 2 {
+		t.Fatalf("failed history total=%d len=%d, want 2", total, len(records))
+	}
+	if !records[0].RequestedAt.After(records[1].RequestedAt) {
+		t.Fatalf("history not ordered newest first: %v then %v", records[0].RequestedAt, records[1].RequestedAt)
+	}
+
+	removed, err := store.Prune(ctx, base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if removed != 2 {
+		t.Fatalf("Prune() removed = %d, want 2", removed)
+	}
+	_, total, err = store.History(ctx, HistoryQuery{Limit: 1})
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if total != 3 {
+		t.Fatalf("total after prune = %d, want 3", total)
+	}
+}
+
+func TestSQLStoreRebindPostgresPlaceholders(t *testing.T) {
+	store := &SQLStore{driver: SQLDriverPostgres}
+	got := store.rebind("SELECT 1 WHERE a = ? AND b = ?")
+	if want := "SELECT 1 WHERE a = $1 AND b = $2"; got != want {
+		t.Fatalf("rebind() = %q, want %q", got, want)
+	}
+}
+
+func TestSQLStoreAddsQueueWaitColumnToExistingTable(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "usage.db")
+	legacy, err := OpenSQLStore(ctx, SQLStoreConfig{Driver: SQLDriverSQLite, Path: path})
+	if err != nil {
+		t.Fatalf("OpenSQLStore() error = %v", err)
+	}
+	if _, err = legacy.db.ExecContext(ctx, `CREATE TABLE legacy_usage (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		requested_at BIGINT NOT NULL,
+		request_id TEXT NOT NULL DEFAULT '',
+		provider TEXT NOT NULL DEFAULT '',
+		model TEXT NOT NULL DEFAULT '',
+		api_key TEXT NOT NULL DEFAULT '',
+		auth_id TEXT NOT NULL DEFAULT '',
+		auth_index TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL DEFAULT '',
+		failed INTEGER NOT NULL DEFAULT 0,
+		input_tokens BIGINT NOT NULL DEFAULT 0,
+		output_tokens BIGINT NOT NULL DEFAULT 0,
+		reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+		cached_tokens BIGINT NOT NULL DEFAULT 0,
+		total_tokens BIGINT NOT NULL DEFAULT 0
+	)`); err != nil {
+		t.Fatalf("create legacy table: %v", err)
+	}
+	_ = legacy.Close()
+
+	store, err := OpenSQLStore(ctx, SQLStoreConfig{Driver: SQLDriverSQLite, Path: path, Table: "legacy_usage"})
+	if err != nil {
+		t.Fatalf("OpenSQLStore() on legacy table error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	store.HandleUsage(ctx, coreusage.Record{Provider: "claude", Model: "claude-sonnet-4", QueueWait: 1500 * time.Millisecond})
+
+	records, _, err := store.History(ctx, HistoryQuery{})
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(records) != 1 || records[0].QueueWaitMs != 1500 {
+		t.Fatalf("records = %+v, want one record with queue_wait_ms 1500", records)
+	}
+}
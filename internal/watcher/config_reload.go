@@ -5,7 +5,6 @@ package watcher
 import (
 	"crypto/sha256"
 	"encoding/hex"
-	"os"
 	"reflect"
 	"time"
 
@@ -40,8 +39,18 @@ func (w *Watcher) scheduleConfigReload() {
 	})
 }
 
+// readComposedConfig returns the config document with includes and the overlay merged in,
+// so that a change to any source file changes the hash.
+func (w *Watcher) readComposedConfig() ([]byte, error) {
+	composition, err := config.ComposeConfigFile(w.configPath)
+	if err != nil {
+		return nil, err
+	}
+	return composition.Data, nil
+}
+
 func (w *Watcher) reloadConfigIfChanged() {
-	data, err := os.ReadFile(w.configPath)
+	data, err := w.readComposedConfig()
 	if err != nil {
 		log.Errorf("failed to read config file for hash check: %v", err)
 		return
@@ -64,7 +73,7 @@ func (w *Watcher) reloadConfigIfChanged() {
 	log.Infof("config file changed, reloading: %s", w.configPath)
 	if w.reloadConfig() {
 		finalHash := newHash
-		if updatedData, errRead := os.ReadFile(w.configPath); errRead == nil && len(updatedData) > 0 {
+		if updatedData, errRead := w.readComposedConfig(); errRead == nil && len(updatedData) > 0 {
 			sumUpdated := sha256.Sum256(updatedData)
 			finalHash = hex.EncodeToString(sumUpdated[:])
 		} else if errRead != nil {
@@ -97,6 +106,8 @@ func (w *Watcher) reloadConfig() bool {
 		}
 	}
 
+	w.updateConfigWatches(newConfig.WatchPatterns())
+
 	w.clientsMutex.Lock()
 	var oldConfig *config.Config
 	_ = yaml.Unmarshal(w.oldConfigYaml, &oldConfig)
@@ -63,12 +63,62 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.WebsocketAuth != newCfg.WebsocketAuth {
 		changes = append(changes, fmt.Sprintf("ws-auth: %t -> %t", oldCfg.WebsocketAuth, newCfg.WebsocketAuth))
 	}
+	if oldCfg.WebsocketRelay.ResumeGraceSeconds != newCfg.WebsocketRelay.ResumeGraceSeconds {
+		changes = append(changes, fmt.Sprintf("ws-relay.resume-grace-seconds: %d -> %d", oldCfg.WebsocketRelay.ResumeGraceSeconds, newCfg.WebsocketRelay.ResumeGraceSeconds))
+	}
+	if oldCfg.WebsocketRelay.Token != newCfg.WebsocketRelay.Token {
+		changes = append(changes, "ws-relay.token: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.WebsocketRelay.Keys, newCfg.WebsocketRelay.Keys) {
+		changes = append(changes, fmt.Sprintf("ws-relay.keys: %d -> %d", len(oldCfg.WebsocketRelay.Keys), len(newCfg.WebsocketRelay.Keys)))
+	}
+	if oldCfg.WebsocketRelay.TicketSecret != newCfg.WebsocketRelay.TicketSecret {
+		changes = append(changes, "ws-relay.ticket-secret: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.WebsocketRelay.AllowedOrigins, newCfg.WebsocketRelay.AllowedOrigins) {
+		changes = append(changes, fmt.Sprintf("ws-relay.allowed-origins: %v -> %v", oldCfg.WebsocketRelay.AllowedOrigins, newCfg.WebsocketRelay.AllowedOrigins))
+	}
+	if oldCfg.WebsocketRelay.MaxSessions != newCfg.WebsocketRelay.MaxSessions {
+		changes = append(changes, fmt.Sprintf("ws-relay.max-sessions: %d -> %d", oldCfg.WebsocketRelay.MaxSessions, newCfg.WebsocketRelay.MaxSessions))
+	}
+	if oldCfg.SignatureCache.Backend != newCfg.SignatureCache.Backend {
+		changes = append(changes, fmt.Sprintf("signature-cache.backend: %s -> %s (restart required)", oldCfg.SignatureCache.Backend, newCfg.SignatureCache.Backend))
+	}
+	if oldCfg.SignatureCache.MaxEntriesPerGroup != newCfg.SignatureCache.MaxEntriesPerGroup {
+		changes = append(changes, fmt.Sprintf("signature-cache.max-entries-per-group: %d -> %d", oldCfg.SignatureCache.MaxEntriesPerGroup, newCfg.SignatureCache.MaxEntriesPerGroup))
+	}
 	if oldCfg.ForceModelPrefix != newCfg.ForceModelPrefix {
 		changes = append(changes, fmt.Sprintf("force-model-prefix: %t -> %t", oldCfg.ForceModelPrefix, newCfg.ForceModelPrefix))
 	}
 	if oldCfg.NonStreamKeepAliveInterval != newCfg.NonStreamKeepAliveInterval {
 		changes = append(changes, fmt.Sprintf("nonstream-keepalive-interval: %d -> %d", oldCfg.NonStreamKeepAliveInterval, newCfg.NonStreamKeepAliveInterval))
 	}
+	if oldCfg.PromptQueue.Enabled != newCfg.PromptQueue.Enabled {
+		changes = append(changes, fmt.Sprintf("prompt-queue.enabled: %t -> %t", oldCfg.PromptQueue.Enabled, newCfg.PromptQueue.Enabled))
+	}
+	if oldCfg.PromptQueue.SessionHeader != newCfg.PromptQueue.SessionHeader {
+		changes = append(changes, fmt.Sprintf("prompt-queue.session-header: %s -> %s", oldCfg.PromptQueue.SessionHeader, newCfg.PromptQueue.SessionHeader))
+	}
+	if oldCfg.PromptQueue.MaxQueueDepth != newCfg.PromptQueue.MaxQueueDepth {
+		changes = append(changes, fmt.Sprintf("prompt-queue.max-queue-depth: %d -> %d", oldCfg.PromptQueue.MaxQueueDepth, newCfg.PromptQueue.MaxQueueDepth))
+	}
+	if oldCfg.Scheduler.Enabled != newCfg.Scheduler.Enabled {
+		changes = append(changes, fmt.Sprintf("scheduler.enabled: %t -> %t", oldCfg.Scheduler.Enabled, newCfg.Scheduler.Enabled))
+	}
+	if oldCfg.Scheduler.MaxConcurrency != newCfg.Scheduler.MaxConcurrency {
+		changes = append(changes, fmt.Sprintf("scheduler.max-concurrency: %d -> %d", oldCfg.Scheduler.MaxConcurrency, newCfg.Scheduler.MaxConcurrency))
+	}
+	if !reflect.DeepEqual(oldCfg.Scheduler.ProviderConcurrency, newCfg.Scheduler.ProviderConcurrency) {
+		changes = append(changes, "scheduler.provider-concurrency: updated")
+	}
+	if len(oldCfg.Scheduler.Clients) != len(newCfg.Scheduler.Clients) {
+		changes = append(changes, fmt.Sprintf("scheduler.clients: %d -> %d", len(oldCfg.Scheduler.Clients), len(newCfg.Scheduler.Clients)))
+	} else if !reflect.DeepEqual(oldCfg.Scheduler.Clients, newCfg.Scheduler.Clients) {
+		changes = append(changes, "scheduler.clients: updated")
+	}
+	if oldCfg.ResponseCache != newCfg.ResponseCache {
+		changes = append(changes, fmt.Sprintf("response-cache: enabled %t -> %t, store %s -> %s", oldCfg.ResponseCache.Enabled, newCfg.ResponseCache.Enabled, oldCfg.ResponseCache.Store, newCfg.ResponseCache.Store))
+	}
 
 	// Quota-exceeded behavior
 	if oldCfg.QuotaExceeded.SwitchProject != newCfg.QuotaExceeded.SwitchProject {
@@ -84,6 +134,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.Routing.Strategy != newCfg.Routing.Strategy {
 		changes = append(changes, fmt.Sprintf("routing.strategy: %s -> %s", oldCfg.Routing.Strategy, newCfg.Routing.Strategy))
 	}
+	if oldCfg.ClaudePromptCaching.Mode != newCfg.ClaudePromptCaching.Mode {
+		changes = append(changes, fmt.Sprintf("claude-prompt-caching.mode: %s -> %s", oldCfg.ClaudePromptCaching.Mode, newCfg.ClaudePromptCaching.Mode))
+	}
 
 	// API keys (redacted) and counts
 	if len(oldCfg.APIKeys) != len(newCfg.APIKeys) {
@@ -280,6 +333,17 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	// Declarative HTTP providers
+	if len(oldCfg.HTTPProviders) != len(newCfg.HTTPProviders) {
+		changes = append(changes, fmt.Sprintf("http-providers count: %d -> %d", len(oldCfg.HTTPProviders), len(newCfg.HTTPProviders)))
+	} else {
+		for i := range oldCfg.HTTPProviders {
+			if !reflect.DeepEqual(oldCfg.HTTPProviders[i], newCfg.HTTPProviders[i]) {
+				changes = append(changes, fmt.Sprintf("http-providers[%d] (%s): updated", i, newCfg.HTTPProviders[i].Name))
+			}
+		}
+	}
+
 	// Vertex-compatible API keys
 	if len(oldCfg.VertexCompatAPIKey) != len(newCfg.VertexCompatAPIKey) {
 		changes = append(changes, fmt.Sprintf("vertex-api-key count: %d -> %d", len(oldCfg.VertexCompatAPIKey), len(newCfg.VertexCompatAPIKey)))
@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -32,6 +34,9 @@ func (w *Watcher) start(ctx context.Context) error {
 		return errAddConfig
 	}
 	log.Debugf("watching config file: %s", w.configPath)
+	if composition, errCompose := config.ComposeConfigFile(w.configPath); errCompose == nil {
+		w.updateConfigWatches(composition.WatchPatterns)
+	}
 
 	if errAddAuthDir := w.watcher.Add(w.authDir); errAddAuthDir != nil {
 		log.Errorf("failed to watch auth directory %s: %v", w.authDir, errAddAuthDir)
@@ -45,6 +50,56 @@ func (w *Watcher) start(ctx context.Context) error {
 	return nil
 }
 
+// updateConfigWatches watches the directories of included files, include globs and the
+// overlay so that edits, new glob matches and deletions trigger a reload.
+func (w *Watcher) updateConfigWatches(patterns []string) {
+	w.clientsMutex.Lock()
+	defer w.clientsMutex.Unlock()
+	w.configPatterns = patterns
+	if w.configWatchDirs == nil {
+		w.configWatchDirs = make(map[string]struct{})
+	}
+	if w.watcher == nil || len(patterns) < 2 {
+		return
+	}
+	// The first pattern is the main config file, which is watched directly.
+	for _, pattern := range patterns[1:] {
+		dir := filepath.Dir(pattern)
+		if strings.ContainsAny(dir, "*?[") {
+			continue
+		}
+		if _, ok := w.configWatchDirs[dir]; ok {
+			continue
+		}
+		if errAdd := w.watcher.Add(dir); errAdd != nil {
+			log.Debugf("failed to watch config include directory %s: %v", dir, errAdd)
+			continue
+		}
+		w.configWatchDirs[dir] = struct{}{}
+		log.Debugf("watching config include directory: %s", dir)
+	}
+}
+
+// isConfigSourceEvent reports whether path is an included file, matches an include glob
+// or is the overlay file.
+func (w *Watcher) isConfigSourceEvent(path string) bool {
+	w.clientsMutex.RLock()
+	patterns := w.configPatterns
+	w.clientsMutex.RUnlock()
+	if len(patterns) == 0 {
+		return false
+	}
+	if abs, errAbs := filepath.Abs(path); errAbs == nil {
+		path = abs
+	}
+	for _, pattern := range patterns {
+		if matched, errMatch := filepath.Match(pattern, path); errMatch == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
 func (w *Watcher) processEvents(ctx context.Context) {
 	for {
 		select {
@@ -71,6 +126,9 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 	normalizedConfigPath := w.normalizeAuthPath(w.configPath)
 	normalizedAuthDir := w.normalizeAuthPath(w.authDir)
 	isConfigEvent := normalizedName == normalizedConfigPath && event.Op&configOps != 0
+	if !isConfigEvent && event.Op&(configOps|fsnotify.Remove) != 0 {
+		isConfigEvent = w.isConfigSourceEvent(event.Name)
+	}
 	authOps := fsnotify.Create | fsnotify.Write | fsnotify.Remove | fsnotify.Rename
 	isAuthJSON := strings.HasPrefix(normalizedName, normalizedAuthDir) && strings.HasSuffix(normalizedName, ".json") && event.Op&authOps != 0
 	if !isConfigEvent && !isAuthJSON {
@@ -125,7 +183,7 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 }
 
 func (w *Watcher) authFileUnchanged(path string) (bool, error) {
-	data, errRead := os.ReadFile(path)
+	data, errRead := authcrypt.ReadFile(path)
 	if errRead != nil {
 		return false, errRead
 	}
@@ -5,12 +5,13 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
 // ConfigSynthesizer generates Auth entries from configuration API keys.
-// It handles Gemini, Claude, Codex, OpenAI-compat, and Vertex-compat providers.
+// It handles Gemini, Claude, Codex, OpenAI-compat, HTTP, and Vertex-compat providers.
 type ConfigSynthesizer struct{}
 
 // NewConfigSynthesizer creates a new ConfigSynthesizer instance.
@@ -33,6 +34,8 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	out = append(out, s.synthesizeCodexKeys(ctx)...)
 	// OpenAI-compat
 	out = append(out, s.synthesizeOpenAICompat(ctx)...)
+	// Declarative HTTP providers
+	out = append(out, s.synthesizeHTTPProviders(ctx)...)
 	// Vertex-compat
 	out = append(out, s.synthesizeVertexCompat(ctx)...)
 
@@ -272,6 +275,58 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 	return out
 }
 
+// synthesizeHTTPProviders creates Auth entries for declarative HTTP providers, one per API key
+// or a single keyless entry when none are configured.
+func (s *ConfigSynthesizer) synthesizeHTTPProviders(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0)
+	for i := range cfg.HTTPProviders {
+		provider := &cfg.HTTPProviders[i]
+		providerName := strings.ToLower(strings.TrimSpace(provider.Name))
+		if providerName == "" {
+			continue
+		}
+		entries := provider.APIKeyEntries
+		if len(entries) == 0 {
+			entries = []config.OpenAICompatibilityAPIKey{{}}
+		}
+		for j := range entries {
+			key := strings.TrimSpace(entries[j].APIKey)
+			proxyURL := strings.TrimSpace(entries[j].ProxyURL)
+			id, token := idGen.Next("http-provider:"+providerName, key, provider.URL, proxyURL)
+			attrs := map[string]string{
+				"source":        fmt.Sprintf("config:%s[%s]", providerName, token),
+				"provider_key":  providerName,
+				"http_provider": providerName,
+			}
+			if provider.Priority != 0 {
+				attrs["priority"] = strconv.Itoa(provider.Priority)
+			}
+			if key != "" {
+				attrs["api_key"] = key
+			}
+			if hash := diff.ComputeOpenAICompatModelsHash(provider.Models); hash != "" {
+				attrs["models_hash"] = hash
+			}
+			out = append(out, &coreauth.Auth{
+				ID:         id,
+				Provider:   providerName,
+				Label:      provider.Name,
+				Prefix:     strings.TrimSpace(provider.Prefix),
+				Status:     coreauth.StatusActive,
+				ProxyURL:   proxyURL,
+				Attributes: attrs,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			})
+		}
+	}
+	return out
+}
+
 // synthesizeVertexCompat creates Auth entries for Vertex-compatible providers.
 func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*coreauth.Auth {
 	cfg := ctx.Config
@@ -615,3 +615,46 @@ func TestConfigSynthesizer_AllProviders(t *testing.T) {
 		}
 	}
 }
+
+func TestConfigSynthesizer_HTTPProviders(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			HTTPProviders: []config.HTTPProvider{
+				{
+					Name:   "Acme",
+					URL:    "https://acme.example/generate",
+					Models: []config.OpenAICompatibilityModel{{Name: "acme-large", Alias: "large"}},
+					APIKeyEntries: []config.OpenAICompatibilityAPIKey{
+						{APIKey: "k-1"},
+						{APIKey: "k-2", ProxyURL: "http://proxy.local"},
+					},
+				},
+				{Name: "keyless", URL: "https://keyless.example/generate"},
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 3 {
+		t.Fatalf("expected 3 auths, got %d", len(auths))
+	}
+	first := auths[0]
+	if first.Provider != "acme" || first.Attributes["http_provider"] != "acme" || first.Attributes["api_key"] != "k-1" {
+		t.Errorf("unexpected first auth: provider=%s attrs=%v", first.Provider, first.Attributes)
+	}
+	if first.Attributes["models_hash"] == "" {
+		t.Error("expected models_hash in attributes")
+	}
+	if auths[1].ProxyURL != "http://proxy.local" {
+		t.Errorf("expected proxy url on second auth, got %q", auths[1].ProxyURL)
+	}
+	if _, ok := auths[2].Attributes["api_key"]; ok || auths[2].Attributes["http_provider"] != "keyless" {
+		t.Errorf("unexpected keyless auth attrs: %v", auths[2].Attributes)
+	}
+}
@@ -41,6 +41,8 @@ type Watcher struct {
 	lastAuthContents  map[string]*coreauth.Auth
 	lastRemoveTimes   map[string]time.Time
 	lastConfigHash    string
+	configWatchDirs   map[string]struct{}
+	configPatterns    []string
 	authQueue         chan<- AuthUpdate
 	currentAuths      map[string]*coreauth.Auth
 	runtimeAuths      map[string]*coreauth.Auth
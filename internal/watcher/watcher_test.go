@@ -829,6 +829,47 @@ func TestHandleEventConfigChangeSchedulesReload(t *testing.T) {
 	}
 }
 
+func TestHandleEventIncludedConfigChangeTriggersReload(t *testing.T) {
+	tmpDir := t.TempDir()
+	authDir := filepath.Join(tmpDir, "auth")
+	providersDir := filepath.Join(tmpDir, "providers")
+	for _, dir := range []string{authDir, providersDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("auth_dir: "+authDir+"\ninclude: providers/*.yaml\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	includePath := filepath.Join(providersDir, "claude.yaml")
+	if err := os.WriteFile(includePath, []byte("debug: false\n"), 0o644); err != nil {
+		t.Fatalf("failed to write include file: %v", err)
+	}
+
+	var reloads int32
+	w := &Watcher{
+		authDir:        authDir,
+		configPath:     configPath,
+		lastAuthHashes: make(map[string]string),
+		reloadCallback: func(*config.Config) { atomic.AddInt32(&reloads, 1) },
+	}
+	w.SetConfig(&config.Config{AuthDir: authDir})
+	composition, err := config.ComposeConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("ComposeConfigFile: %v", err)
+	}
+	w.updateConfigWatches(composition.WatchPatterns)
+
+	w.handleEvent(fsnotify.Event{Name: filepath.Join(providersDir, "notes.txt"), Op: fsnotify.Write})
+	w.handleEvent(fsnotify.Event{Name: includePath, Op: fsnotify.Write})
+
+	time.Sleep(400 * time.Millisecond)
+	if atomic.LoadInt32(&reloads) != 1 {
+		t.Fatalf("expected included file change to trigger reload once, got %d", reloads)
+	}
+}
+
 func TestHandleEventAuthWriteTriggersUpdate(t *testing.T) {
 	tmpDir := t.TempDir()
 	authDir := filepath.Join(tmpDir, "auth")
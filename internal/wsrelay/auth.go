@@ -0,0 +1,280 @@
+package wsrelay
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// TokenHeader carries the relay token or a join key. The token query parameter works too.
+	TokenHeader = "X-Relay-Token"
+	// TicketHeader carries a join ticket. The ticket query parameter works too.
+	TicketHeader = "X-Relay-Ticket"
+
+	auditLogSize = 200
+)
+
+var (
+	errUnauthorized   = errors.New("missing or invalid relay credential")
+	errTicketInvalid  = errors.New("invalid join ticket")
+	errTicketExpired  = errors.New("join ticket expired")
+	errTicketConsumed = errors.New("join ticket already used")
+)
+
+// JoinKey is a client credential bound to a provider name. Sessions joined with the key are
+// named after it, so the same client keeps its identity across reconnects.
+type JoinKey struct {
+	Key  string
+	Name string
+}
+
+// AuthConfig controls who may open a relay session.
+type AuthConfig struct {
+	// Token is a shared secret accepted from any client. Setting Token or Keys makes a
+	// credential mandatory.
+	Token string
+	Keys  []JoinKey
+	// AllowedOrigins lists the browser origins accepted, e.g. https://aistudio.google.com.
+	// Empty accepts any origin; requests without an Origin header are always accepted.
+	AllowedOrigins []string
+	// MaxSessions caps concurrently registered sessions; zero means no cap.
+	MaxSessions int
+	// TicketSecret signs join tickets. Empty keeps the manager's random per-process secret.
+	TicketSecret string
+}
+
+// admission is the outcome of authenticating an upgrade request.
+type admission struct {
+	identity string
+	// name is the provider name the credential is bound to, if any.
+	name string
+}
+
+// AuditEvent records a session joining, resuming or leaving, or a rejected attempt.
+type AuditEvent struct {
+	Seq        int64     `json:"seq"`
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	SessionID  string    `json:"session_id,omitempty"`
+	Provider   string    `json:"provider,omitempty"`
+	Identity   string    `json:"identity,omitempty"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	Origin     string    `json:"origin,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+type ticketClaims struct {
+	Name    string `json:"n,omitempty"`
+	Expires int64  `json:"e"`
+	Nonce   string `json:"r"`
+}
+
+// ticketBook signs join tickets and remembers the ones used until they expire.
+type ticketBook struct {
+	mu       sync.Mutex
+	random   []byte
+	secret   []byte
+	consumed map[string]time.Time
+}
+
+func newTicketBook() *ticketBook {
+	random := make([]byte, 32)
+	_, _ = rand.Read(random)
+	return &ticketBook{random: random, secret: random, consumed: make(map[string]time.Time)}
+}
+
+func (b *ticketBook) setSecret(secret string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if secret == "" {
+		b.secret = b.random
+		return
+	}
+	b.secret = []byte(secret)
+}
+
+func (b *ticketBook) sign(data string) string {
+	b.mu.Lock()
+	mac := hmac.New(sha256.New, b.secret)
+	b.mu.Unlock()
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (b *ticketBook) issue(name string, ttl time.Duration, now time.Time) (string, time.Time, error) {
+	expires := now.Add(ttl)
+	raw, err := json.Marshal(ticketClaims{Name: name, Expires: expires.Unix(), Nonce: randomToken()[:16]})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	data := base64.RawURLEncoding.EncodeToString(raw)
+	return data + "." + b.sign(data), expires, nil
+}
+
+// redeem checks a ticket and marks it used.
+func (b *ticketBook) redeem(ticket string, now time.Time) (ticketClaims, error) {
+	var claims ticketClaims
+	data, sig, ok := strings.Cut(ticket, ".")
+	if !ok || subtle.ConstantTimeCompare([]byte(sig), []byte(b.sign(data))) != 1 {
+		return claims, errTicketInvalid
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil || json.Unmarshal(raw, &claims) != nil || claims.Nonce == "" {
+		return claims, errTicketInvalid
+	}
+	if now.Unix() >= claims.Expires {
+		return claims, errTicketExpired
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for nonce, expires := range b.consumed {
+		if now.After(expires) {
+			delete(b.consumed, nonce)
+		}
+	}
+	if _, used := b.consumed[claims.Nonce]; used {
+		return claims, errTicketConsumed
+	}
+	b.consumed[claims.Nonce] = time.Unix(claims.Expires, 0)
+	return claims, nil
+}
+
+// SetAuth replaces the relay's admission rules. Sessions already open are kept.
+func (m *Manager) SetAuth(cfg AuthConfig) {
+	if m == nil {
+		return
+	}
+	cfg.Keys = append([]JoinKey(nil), cfg.Keys...)
+	cfg.AllowedOrigins = append([]string(nil), cfg.AllowedOrigins...)
+	m.tickets.setSecret(cfg.TicketSecret)
+	m.authMu.Lock()
+	m.auth = cfg
+	m.authMu.Unlock()
+}
+
+func (m *Manager) authConfig() AuthConfig {
+	m.authMu.RLock()
+	defer m.authMu.RUnlock()
+	return m.auth
+}
+
+// IssueTicket signs a single-use join ticket valid for ttl. A non-empty name binds the session
+// opened with the ticket to that provider name.
+func (m *Manager) IssueTicket(name string, ttl time.Duration) (string, time.Time, error) {
+	if m == nil {
+		return "", time.Time{}, errors.New("wsrelay: manager is nil")
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if strings.ContainsAny(name, "/ ") {
+		return "", time.Time{}, fmt.Errorf("wsrelay: invalid ticket name %q", name)
+	}
+	if ttl <= 0 {
+		return "", time.Time{}, errors.New("wsrelay: ticket ttl must be positive")
+	}
+	return m.tickets.issue(name, ttl, time.Now())
+}
+
+// checkOrigin accepts requests without an Origin header and, when an allowlist is set,
+// browser origins on it.
+func (m *Manager) checkOrigin(r *http.Request) bool {
+	origin := strings.TrimSpace(r.Header.Get("Origin"))
+	if origin == "" {
+		return true
+	}
+	allowed := m.authConfig().AllowedOrigins
+	if len(allowed) == 0 {
+		return true
+	}
+	origin = strings.TrimSuffix(origin, "/")
+	for _, candidate := range allowed {
+		if candidate == "*" || strings.EqualFold(strings.TrimSuffix(candidate, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate resolves the credential presented with an upgrade request.
+func (m *Manager) authenticate(r *http.Request) (admission, error) {
+	if ticket := credential(r, TicketHeader, "ticket"); ticket != "" {
+		claims, err := m.tickets.redeem(ticket, time.Now())
+		if err != nil {
+			return admission{}, err
+		}
+		identity := "ticket"
+		if claims.Name != "" {
+			identity = "ticket:" + claims.Name
+		}
+		return admission{identity: identity, name: claims.Name}, nil
+	}
+	cfg := m.authConfig()
+	token := credential(r, TokenHeader, "token")
+	if token != "" {
+		for _, key := range cfg.Keys {
+			if key.Key != "" && subtle.ConstantTimeCompare([]byte(token), []byte(key.Key)) == 1 {
+				return admission{identity: "key:" + key.Name, name: key.Name}, nil
+			}
+		}
+		if cfg.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Token)) == 1 {
+			return admission{identity: "token"}, nil
+		}
+	}
+	if cfg.Token != "" || len(cfg.Keys) > 0 {
+		return admission{}, errUnauthorized
+	}
+	return admission{identity: "anonymous"}, nil
+}
+
+func credential(r *http.Request, header, query string) string {
+	if value := strings.TrimSpace(r.Header.Get(header)); value != "" {
+		return value
+	}
+	if r.URL != nil {
+		return strings.TrimSpace(r.URL.Query().Get(query))
+	}
+	return ""
+}
+
+// audit appends an event to the audit log and logs it.
+func (m *Manager) audit(event AuditEvent) {
+	event.Time = time.Now().UTC()
+	m.auditMu.Lock()
+	m.auditSeq++
+	event.Seq = m.auditSeq
+	m.auditLog = append(m.auditLog, event)
+	if over := len(m.auditLog) - auditLogSize; over > 0 {
+		m.auditLog = append(m.auditLog[:0], m.auditLog[over:]...)
+	}
+	m.auditMu.Unlock()
+
+	if event.Event == "reject" {
+		m.logWarnf("wsrelay: %s rejected from %s (origin=%q): %s", m.path, event.RemoteAddr, event.Origin, event.Reason)
+		return
+	}
+	m.logInfof("wsrelay: session %s %s (provider=%s identity=%s remote=%s)", event.SessionID, event.Event, event.Provider, event.Identity, event.RemoteAddr)
+}
+
+// AuditLog returns the most recent session join, resume, leave and reject events, oldest first.
+func (m *Manager) AuditLog() []AuditEvent {
+	if m == nil {
+		return nil
+	}
+	m.auditMu.Lock()
+	defer m.auditMu.Unlock()
+	return append([]AuditEvent(nil), m.auditLog...)
+}
+
+func (m *Manager) reject(w http.ResponseWriter, r *http.Request, status int, reason string) {
+	m.audit(AuditEvent{Event: "reject", RemoteAddr: r.RemoteAddr, Origin: r.Header.Get("Origin"), Reason: reason})
+	http.Error(w, reason, status)
+}
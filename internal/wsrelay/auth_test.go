@@ -0,0 +1,192 @@
+package wsrelay
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func dial(t *testing.T, server *httptest.Server, query string, header http.Header) (*websocket.Conn, int) {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/ws" + query
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		if resp == nil {
+			t.Fatalf("dial: %v", err)
+		}
+		return nil, resp.StatusCode
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn, http.StatusSwitchingProtocols
+}
+
+func TestAuthRejectsWithoutCredential(t *testing.T) {
+	connected := make(chan string, 4)
+	mgr := NewManager(Options{
+		OnConnected: func(provider string) { connected <- provider },
+		Auth: AuthConfig{
+			Token:          "secret",
+			Keys:           []JoinKey{{Key: "laptop-key", Name: "laptop"}},
+			AllowedOrigins: []string{"https://aistudio.google.com"},
+			MaxSessions:    2,
+		},
+	})
+	server := httptest.NewServer(mgr.Handler())
+	t.Cleanup(server.Close)
+
+	if _, status := dial(t, server, "", nil); status != http.StatusUnauthorized {
+		t.Fatalf("no credential: status = %d, want 401", status)
+	}
+	if _, status := dial(t, server, "?token=wrong", nil); status != http.StatusUnauthorized {
+		t.Fatalf("wrong token: status = %d, want 401", status)
+	}
+	evil := http.Header{"Origin": []string{"https://evil.example"}, TokenHeader: []string{"secret"}}
+	if _, status := dial(t, server, "", evil); status != http.StatusForbidden {
+		t.Fatalf("foreign origin: status = %d, want 403", status)
+	}
+
+	if _, status := dial(t, server, "?token=laptop-key", http.Header{"Origin": []string{"https://aistudio.google.com/"}}); status != http.StatusSwitchingProtocols {
+		t.Fatalf("join key: status = %d", status)
+	}
+	if provider := <-connected; provider != "aistudio-laptop" {
+		t.Fatalf("provider = %q, want aistudio-laptop", provider)
+	}
+	if _, status := dial(t, server, "", http.Header{TokenHeader: []string{"secret"}}); status != http.StatusSwitchingProtocols {
+		t.Fatalf("token: status = %d", status)
+	}
+	<-connected
+	if _, status := dial(t, server, "", http.Header{TokenHeader: []string{"secret"}}); status != http.StatusServiceUnavailable {
+		t.Fatalf("over cap: status = %d, want 503", status)
+	}
+
+	var joins, rejects int
+	for _, event := range mgr.AuditLog() {
+		switch event.Event {
+		case "join":
+			joins++
+		case "reject":
+			rejects++
+		}
+	}
+	if joins != 2 || rejects != 4 {
+		t.Fatalf("audit joins=%d rejects=%d, want 2 and 4: %+v", joins, rejects, mgr.AuditLog())
+	}
+}
+
+func TestJoinTicketIsSingleUse(t *testing.T) {
+	connected := make(chan string, 2)
+	mgr := NewManager(Options{
+		OnConnected: func(provider string) { connected <- provider },
+		Auth:        AuthConfig{Token: "secret"},
+	})
+	server := httptest.NewServer(mgr.Handler())
+	t.Cleanup(server.Close)
+
+	ticket, _, err := mgr.IssueTicket("Kiosk", time.Minute)
+	if err != nil {
+		t.Fatalf("IssueTicket: %v", err)
+	}
+	conn, status := dial(t, server, "?ticket="+ticket, nil)
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("ticket: status = %d", status)
+	}
+	if provider := <-connected; provider != "aistudio-kiosk" {
+		t.Fatalf("provider = %q, want aistudio-kiosk", provider)
+	}
+	if _, status = dial(t, server, "?ticket="+ticket, nil); status != http.StatusUnauthorized {
+		t.Fatalf("reused ticket: status = %d, want 401", status)
+	}
+
+	expired, _, _ := mgr.tickets.issue("", -time.Second, time.Now())
+	if _, status = dial(t, server, "", http.Header{TicketHeader: []string{expired}}); status != http.StatusUnauthorized {
+		t.Fatalf("expired ticket: status = %d, want 401", status)
+	}
+	if _, status = dial(t, server, "?ticket="+ticket[:len(ticket)-2]+"xx", nil); status != http.StatusUnauthorized {
+		t.Fatalf("tampered ticket: status = %d, want 401", status)
+	}
+
+	_ = conn.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		log := mgr.AuditLog()
+		if last := log[len(log)-1]; last.Event == "leave" && last.Identity == "ticket:kiosk" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("leave not audited: %+v", log)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExclusiveProvidersRejectsDuplicateName(t *testing.T) {
+	disconnected := make(chan string, 4)
+	mgr := NewManager(Options{
+		ProviderFactory:    func(r *http.Request, _ string) (string, error) { return r.Header.Get("X-Name"), nil },
+		ExclusiveProviders: true,
+		OnDisconnected:     func(provider string, _ error) { disconnected <- provider },
+	})
+	server := httptest.NewServer(mgr.Handler())
+	t.Cleanup(server.Close)
+
+	first, status := dial(t, server, "", http.Header{"X-Name": []string{"edge"}})
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("first: status = %d", status)
+	}
+	if _, status = dial(t, server, "", http.Header{"X-Name": []string{"edge"}}); status != http.StatusConflict {
+		t.Fatalf("duplicate name: status = %d, want 409", status)
+	}
+	if _, status = dial(t, server, "", http.Header{"X-Name": []string{"core"}}); status != http.StatusSwitchingProtocols {
+		t.Fatalf("other name: status = %d", status)
+	}
+
+	_ = first.Close()
+	select {
+	case <-disconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("first connection was not released")
+	}
+	if _, status = dial(t, server, "", http.Header{"X-Name": []string{"edge"}}); status != http.StatusSwitchingProtocols {
+		t.Fatalf("name reuse after leave: status = %d", status)
+	}
+}
+
+func TestProviderFactoryReceivesBoundName(t *testing.T) {
+	connected := make(chan string, 2)
+	mgr := NewManager(Options{
+		ProviderFactory: func(_ *http.Request, name string) (string, error) { return "worker-" + name, nil },
+		OnConnected:     func(provider string) { connected <- provider },
+		Auth:            AuthConfig{Keys: []JoinKey{{Key: "edge-key", Name: "edge"}}},
+	})
+	server := httptest.NewServer(mgr.Handler())
+	t.Cleanup(server.Close)
+
+	if _, status := dial(t, server, "?token=edge-key", nil); status != http.StatusSwitchingProtocols {
+		t.Fatalf("join key: status = %d", status)
+	}
+	if provider := <-connected; provider != "worker-edge" {
+		t.Fatalf("provider = %q, want worker-edge", provider)
+	}
+}
+
+func TestMaxSessionsCountsConnectionsBeingUpgraded(t *testing.T) {
+	mgr := NewManager(Options{Auth: AuthConfig{Token: "secret", MaxSessions: 2}})
+	if err := mgr.reserve("a"); err != nil {
+		t.Fatalf("first reserve: %v", err)
+	}
+	if err := mgr.reserve("b"); err != nil {
+		t.Fatalf("second reserve: %v", err)
+	}
+	if err := mgr.reserve("c"); !errors.Is(err, errSessionLimit) {
+		t.Fatalf("third reserve: err = %v, want session limit", err)
+	}
+	mgr.release("a")
+	if err := mgr.reserve("c"); err != nil {
+		t.Fatalf("reserve after release: %v", err)
+	}
+}
@@ -0,0 +1,378 @@
+package wsrelay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	clientMinBackoff = time.Second
+	clientMaxBackoff = 30 * time.Second
+)
+
+// RequestHandler serves a request received by a Client. reply sends a message answering
+// the request; ctx is cancelled when the Manager cancels the request or the session is lost.
+type RequestHandler func(ctx context.Context, msg Message, reply func(Message) error)
+
+// ClientOptions configures a Client.
+type ClientOptions struct {
+	// URL is the ws:// or wss:// endpoint of the Manager.
+	URL    string
+	Header http.Header
+	// Handler serves execute and http_request messages.
+	Handler RequestHandler
+	// OnConnected runs after every successful dial, before requests are served.
+	OnConnected func(send func(Message) error) error
+	LogDebugf   func(string, ...any)
+	LogInfof    func(string, ...any)
+	LogWarnf    func(string, ...any)
+}
+
+// Client is the dialing side of the relay. It keeps a connection to a Manager open,
+// reconnecting with backoff, and serves the requests the Manager sends over it. When the
+// Manager grants a resume window, requests survive a reconnect: replies are numbered and
+// buffered, and the ones the Manager missed are replayed once the session is resumed.
+type Client struct {
+	opts ClientOptions
+
+	mu       sync.Mutex
+	conn     *clientConn
+	token    string
+	grace    time.Duration
+	sessions int // incremented per session message, to tell resumes apart from grace expiry
+	requests map[string]*clientRequest
+}
+
+type clientConn struct {
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+}
+
+func (c *clientConn) write(msg Message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.ws.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return fmt.Errorf("set write deadline: %w", err)
+	}
+	if err := c.ws.WriteJSON(msg); err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+	return nil
+}
+
+// clientRequest tracks one request being served and the replies sent for it.
+type clientRequest struct {
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	seq     int64
+	replies []Message
+	flushed int // replies[:flushed] were written successfully
+	// held buffers replies after a connection loss until the resumed session says which
+	// ones the Manager already has.
+	held bool
+	done bool
+}
+
+// NewClient builds a relay client with the supplied options.
+func NewClient(opts ClientOptions) *Client {
+	if opts.LogDebugf == nil {
+		opts.LogDebugf = func(string, ...any) {}
+	}
+	if opts.LogInfof == nil {
+		opts.LogInfof = func(string, ...any) {}
+	}
+	if opts.LogWarnf == nil {
+		opts.LogWarnf = func(s string, args ...any) { fmt.Printf(s+"\n", args...) }
+	}
+	return &Client{opts: opts, requests: make(map[string]*clientRequest)}
+}
+
+// Run keeps the client connected until ctx is done.
+func (c *Client) Run(ctx context.Context) {
+	defer c.cancelRequests()
+	backoff := clientMinBackoff
+	for {
+		connected, err := c.connectAndServe(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = clientMinBackoff
+		}
+		c.connectionLost()
+		c.opts.LogWarnf("wsrelay: connection to %s lost: %v (retrying in %s)", c.opts.URL, err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > clientMaxBackoff {
+			backoff = clientMaxBackoff
+		}
+	}
+}
+
+// Send writes msg on the current connection.
+func (c *Client) Send(msg Message) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return errors.New("wsrelay: client not connected")
+	}
+	return conn.write(msg)
+}
+
+func (c *Client) connectAndServe(ctx context.Context) (bool, error) {
+	header := c.opts.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	c.mu.Lock()
+	if c.token != "" {
+		header.Set(ResumeTokenHeader, c.token)
+	}
+	c.mu.Unlock()
+	ws, resp, err := websocket.DefaultDialer.DialContext(ctx, strings.TrimSpace(c.opts.URL), header)
+	if err != nil {
+		if resp != nil {
+			return false, fmt.Errorf("dial: %w (status=%d)", err, resp.StatusCode)
+		}
+		return false, fmt.Errorf("dial: %w", err)
+	}
+	conn := &clientConn{ws: ws}
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-connCtx.Done()
+		_ = ws.Close()
+	}()
+
+	ws.SetReadLimit(maxInboundMessageLen)
+	_ = ws.SetReadDeadline(time.Now().Add(2 * readTimeout))
+	ws.SetPingHandler(func(data string) error {
+		_ = ws.SetReadDeadline(time.Now().Add(2 * readTimeout))
+		conn.writeMu.Lock()
+		defer conn.writeMu.Unlock()
+		return ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(writeTimeout))
+	})
+
+	if c.opts.OnConnected != nil {
+		if err = c.opts.OnConnected(conn.write); err != nil {
+			return false, err
+		}
+	}
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		if c.conn == conn {
+			c.conn = nil
+		}
+		c.mu.Unlock()
+	}()
+	c.opts.LogInfof("wsrelay: connected to %s", c.opts.URL)
+
+	for {
+		var msg Message
+		if err = ws.ReadJSON(&msg); err != nil {
+			return true, err
+		}
+		switch msg.Type {
+		case MessageTypePing:
+			_ = conn.write(Message{ID: msg.ID, Type: MessageTypePong})
+		case MessageTypeSession:
+			c.handleSession(conn, msg)
+		case MessageTypeCancel:
+			c.mu.Lock()
+			req := c.requests[msg.ID]
+			delete(c.requests, msg.ID)
+			c.mu.Unlock()
+			if req != nil {
+				req.cancel()
+			}
+		case MessageTypeExecute, MessageTypeHTTPReq:
+			if c.opts.Handler == nil {
+				_ = conn.write(Message{ID: msg.ID, Type: MessageTypeError, Payload: map[string]any{"error": "no handler", "status": http.StatusNotImplemented}})
+				continue
+			}
+			c.serve(ctx, msg)
+		default:
+			c.opts.LogDebugf("wsrelay: ignoring %s message %s", msg.Type, msg.ID)
+		}
+	}
+}
+
+// serve runs the handler for msg. The request context outlives the connection so the
+// request can finish after a resume.
+func (c *Client) serve(ctx context.Context, msg Message) {
+	reqCtx, reqCancel := context.WithCancel(ctx)
+	req := &clientRequest{cancel: reqCancel}
+	c.mu.Lock()
+	c.requests[msg.ID] = req
+	c.mu.Unlock()
+	go func() {
+		defer reqCancel()
+		c.opts.Handler(reqCtx, msg, func(reply Message) error {
+			return c.reply(msg.ID, req, reply)
+		})
+		req.mu.Lock()
+		req.done = true
+		flushed := req.flushed == len(req.replies)
+		req.mu.Unlock()
+		if flushed {
+			c.forget(msg.ID, req)
+		}
+	}()
+}
+
+// reply numbers and buffers msg, then writes it if connected. While a resumable session is
+// detached the reply stays buffered and is replayed on resume.
+func (c *Client) reply(id string, req *clientRequest, msg Message) error {
+	c.mu.Lock()
+	conn, resumable := c.conn, c.token != ""
+	c.mu.Unlock()
+
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.seq++
+	msg.ID = id
+	msg.Seq = req.seq
+	req.replies = append(req.replies, msg)
+	if conn == nil {
+		if resumable {
+			return nil
+		}
+		return errors.New("wsrelay: client not connected")
+	}
+	if req.held || req.flushed != len(req.replies)-1 {
+		// Earlier replies are still waiting for a resume; keep the order.
+		return nil
+	}
+	if err := conn.write(msg); err != nil {
+		if resumable {
+			return nil
+		}
+		return err
+	}
+	req.flushed = len(req.replies)
+	return nil
+}
+
+// handleSession records the session token and reconciles the requests held since the last
+// connection loss. After a resume it replays the replies the Manager has not seen and cancels
+// requests the Manager no longer waits for; a fresh session means those requests are lost.
+func (c *Client) handleSession(conn *clientConn, msg Message) {
+	token, _ := msg.Payload["token"].(string)
+	resumed, _ := msg.Payload["resumed"].(bool)
+	graceMs, _ := msg.Payload["resume_grace_ms"].(float64)
+	lastSeqs, _ := msg.Payload["requests"].(map[string]any)
+
+	c.mu.Lock()
+	c.token = token
+	c.grace = time.Duration(graceMs) * time.Millisecond
+	c.sessions++
+	requests := make(map[string]*clientRequest, len(c.requests))
+	for id, req := range c.requests {
+		requests[id] = req
+	}
+	c.mu.Unlock()
+
+	for id, req := range requests {
+		req.mu.Lock()
+		if !req.held {
+			// Received on this connection; nothing to reconcile.
+			req.mu.Unlock()
+			continue
+		}
+		last, pending := lastSeqs[id].(float64)
+		if !resumed || !pending {
+			req.mu.Unlock()
+			req.cancel()
+			c.forget(id, req)
+			continue
+		}
+		kept := req.replies[:0]
+		for _, reply := range req.replies {
+			if float64(reply.Seq) > last {
+				kept = append(kept, reply)
+			}
+		}
+		req.replies = kept
+		req.flushed = 0
+		req.held = false
+		for _, reply := range req.replies {
+			if err := conn.write(reply); err != nil {
+				req.held = true
+				break
+			}
+			req.flushed++
+		}
+		done := req.done && req.flushed == len(req.replies)
+		req.mu.Unlock()
+		if done {
+			c.forget(id, req)
+		}
+	}
+	if resumed {
+		c.opts.LogInfof("wsrelay: session resumed with %d request(s) in flight", len(lastSeqs))
+	}
+}
+
+// connectionLost cancels in-flight requests unless the Manager may still resume the session,
+// in which case they are cancelled only if the resume window passes without a new session.
+func (c *Client) connectionLost() {
+	c.mu.Lock()
+	token, grace, generation := c.token, c.grace, c.sessions
+	c.mu.Unlock()
+	if token == "" || grace <= 0 {
+		c.cancelRequests()
+		return
+	}
+	c.mu.Lock()
+	for _, req := range c.requests {
+		req.mu.Lock()
+		req.held = true
+		req.mu.Unlock()
+	}
+	c.mu.Unlock()
+	time.AfterFunc(grace, func() {
+		c.mu.Lock()
+		expired := c.sessions == generation
+		if expired {
+			c.token = ""
+		}
+		c.mu.Unlock()
+		if expired {
+			c.cancelRequests()
+		}
+	})
+}
+
+func (c *Client) cancelRequests() {
+	c.mu.Lock()
+	requests := c.requests
+	c.requests = make(map[string]*clientRequest)
+	c.mu.Unlock()
+	for _, req := range requests {
+		req.cancel()
+	}
+}
+
+func (c *Client) forget(id string, req *clientRequest) {
+	c.mu.Lock()
+	if c.requests[id] == req {
+		delete(c.requests, id)
+	}
+	c.mu.Unlock()
+}
@@ -41,6 +41,11 @@ func (m *Manager) NonStream(ctx context.Context, provider string, req *HTTPReque
 		return nil, fmt.Errorf("wsrelay: request is nil")
 	}
 	msg := Message{ID: uuid.NewString(), Type: MessageTypeHTTPReq, Payload: encodeRequest(req)}
+	return m.collect(ctx, provider, msg)
+}
+
+// collect sends msg and gathers the reply, buffering streamed chunks into a single body.
+func (m *Manager) collect(ctx context.Context, provider string, msg Message) (*HTTPResponse, error) {
 	respCh, err := m.Send(ctx, provider, msg)
 	if err != nil {
 		return nil, err
@@ -117,6 +122,11 @@ func (m *Manager) Stream(ctx context.Context, provider string, req *HTTPRequest)
 		return nil, fmt.Errorf("wsrelay: request is nil")
 	}
 	msg := Message{ID: uuid.NewString(), Type: MessageTypeHTTPReq, Payload: encodeRequest(req)}
+	return m.stream(ctx, provider, msg)
+}
+
+// stream sends msg and converts the reply messages into stream events.
+func (m *Manager) stream(ctx context.Context, provider string, msg Message) (<-chan StreamEvent, error) {
 	respCh, err := m.Send(ctx, provider, msg)
 	if err != nil {
 		return nil, err
@@ -232,6 +242,18 @@ func decodeChunk(payload map[string]any) []byte {
 	return nil
 }
 
+// RemoteError is an error reported by the client for a relayed request.
+type RemoteError struct {
+	Message string
+	Status  int
+	// RetryAfter is the cooldown the client reported, if any.
+	RetryAfter time.Duration
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("%s (status=%d)", e.Message, e.Status)
+}
+
 func decodeError(payload map[string]any) error {
 	if payload == nil {
 		return errors.New("wsrelay: unknown error")
@@ -244,5 +266,30 @@ func decodeError(payload map[string]any) error {
 	if message == "" {
 		message = "wsrelay: upstream error"
 	}
-	return fmt.Errorf("%s (status=%d)", message, status)
+	err := &RemoteError{Message: message, Status: status}
+	if v, ok := payload["retry_after_ms"].(float64); ok && v > 0 {
+		err.RetryAfter = time.Duration(v) * time.Millisecond
+	}
+	return err
+}
+
+// ErrorPayload encodes err as the payload of an error message. The status code and retry
+// hint are taken from the error when it carries them.
+func ErrorPayload(err error) map[string]any {
+	payload := map[string]any{"error": "wsrelay: unknown error"}
+	if err == nil {
+		return payload
+	}
+	payload["error"] = err.Error()
+	var coder interface{ StatusCode() int }
+	if errors.As(err, &coder) && coder.StatusCode() > 0 {
+		payload["status"] = coder.StatusCode()
+	}
+	var hinted interface{ RetryAfter() *time.Duration }
+	if errors.As(err, &hinted) {
+		if retryAfter := hinted.RetryAfter(); retryAfter != nil && *retryAfter > 0 {
+			payload["retry_after_ms"] = retryAfter.Milliseconds()
+		}
+	}
+	return payload
 }
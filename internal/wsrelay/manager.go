@@ -3,11 +3,14 @@ package wsrelay
 import (
 	"context"
 	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -16,29 +19,63 @@ import (
 // Manager exposes a websocket endpoint that proxies Gemini requests to
 // connected clients.
 type Manager struct {
-	path      string
-	upgrader  websocket.Upgrader
-	sessions  map[string]*session
+	path     string
+	upgrader websocket.Upgrader
+	sessions map[string][]*session
+	byToken  map[string]*session
+	// pending counts connections per provider that passed admission but are not yet in
+	// sessions, so admission checks and the insert see the same pool.
+	pending   map[string]int
 	sessMutex sync.RWMutex
 
-	providerFactory func(*http.Request) (string, error)
+	exclusiveProviders bool
+
+	resumeGrace atomic.Int64
+
+	authMu  sync.RWMutex
+	auth    AuthConfig
+	tickets *ticketBook
+
+	auditMu  sync.Mutex
+	auditSeq int64
+	auditLog []AuditEvent
+
+	providerFactory func(*http.Request, string) (string, error)
 	onConnected     func(string)
 	onDisconnected  func(string, error)
+	onMessage       func(string, Message)
 
 	logDebugf func(string, ...any)
 	logInfof  func(string, ...any)
 	logWarnf  func(string, ...any)
 }
 
+// ResumeTokenHeader carries the session token of a client re-attaching after a dropped
+// connection. The resume_token query parameter works too, for browser clients.
+const ResumeTokenHeader = "X-Relay-Resume-Token"
+
 // Options configures a Manager instance.
 type Options struct {
-	Path            string
-	ProviderFactory func(*http.Request) (string, error)
+	Path string
+	// ProviderFactory names the provider a new connection serves. name is the provider name
+	// bound to the join key or ticket the client authenticated with, empty if unbound. An
+	// error rejects the connection. Without a factory, bound sessions join as
+	// "aistudio-<name>" and unbound ones under a random name.
+	ProviderFactory func(r *http.Request, name string) (string, error)
 	OnConnected     func(string)
 	OnDisconnected  func(string, error)
-	LogDebugf       func(string, ...any)
-	LogInfof        func(string, ...any)
-	LogWarnf        func(string, ...any)
+	OnMessage       func(string, Message)
+	// ResumeGrace is how long a dropped session waits for its client to re-attach with the
+	// session token before its pending requests fail. Zero fails them immediately.
+	ResumeGrace time.Duration
+	// Auth sets the initial admission rules; see SetAuth.
+	Auth AuthConfig
+	// ExclusiveProviders admits one connection per provider name. A second connection under
+	// a name that is already in use is rejected until the first one leaves.
+	ExclusiveProviders bool
+	LogDebugf          func(string, ...any)
+	LogInfof           func(string, ...any)
+	LogWarnf           func(string, ...any)
 }
 
 // NewManager builds a websocket relay manager with the supplied options.
@@ -52,21 +89,26 @@ func NewManager(opts Options) *Manager {
 	}
 	mgr := &Manager{
 		path:     path,
-		sessions: make(map[string]*session),
+		sessions: make(map[string][]*session),
+		byToken:  make(map[string]*session),
+		pending:  make(map[string]int),
+		tickets:  newTicketBook(),
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
-			CheckOrigin: func(r *http.Request) bool {
-				return true
-			},
 		},
-		providerFactory: opts.ProviderFactory,
-		onConnected:     opts.OnConnected,
-		onDisconnected:  opts.OnDisconnected,
-		logDebugf:       opts.LogDebugf,
-		logInfof:        opts.LogInfof,
-		logWarnf:        opts.LogWarnf,
+		providerFactory:    opts.ProviderFactory,
+		exclusiveProviders: opts.ExclusiveProviders,
+		onConnected:        opts.OnConnected,
+		onDisconnected:     opts.OnDisconnected,
+		onMessage:          opts.OnMessage,
+		logDebugf:          opts.LogDebugf,
+		logInfof:           opts.LogInfof,
+		logWarnf:           opts.LogWarnf,
 	}
+	mgr.upgrader.CheckOrigin = mgr.checkOrigin
+	mgr.resumeGrace.Store(int64(opts.ResumeGrace))
+	mgr.SetAuth(opts.Auth)
 	if mgr.logDebugf == nil {
 		mgr.logDebugf = func(string, ...any) {}
 	}
@@ -95,11 +137,10 @@ func (m *Manager) Handler() http.Handler {
 // Stop gracefully closes all active websocket sessions.
 func (m *Manager) Stop(_ context.Context) error {
 	m.sessMutex.Lock()
-	sessions := make([]*session, 0, len(m.sessions))
-	for _, sess := range m.sessions {
-		sessions = append(sessions, sess)
+	sessions := make([]*session, 0, len(m.byToken))
+	for _, pool := range m.sessions {
+		sessions = append(sessions, pool...)
 	}
-	m.sessions = make(map[string]*session)
 	m.sessMutex.Unlock()
 
 	for _, sess := range sessions {
@@ -110,7 +151,8 @@ func (m *Manager) Stop(_ context.Context) error {
 	return nil
 }
 
-// handleWebsocket upgrades the connection and wires the session into the pool.
+// handleWebsocket upgrades the connection and wires the session into the pool. A client that
+// presents the token of a detached session is re-attached to it instead.
 func (m *Manager) handleWebsocket(w http.ResponseWriter, r *http.Request) {
 	expectedPath := m.Path()
 	if expectedPath != "" && r.URL != nil && r.URL.Path != expectedPath {
@@ -122,45 +164,159 @@ func (m *Manager) handleWebsocket(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	conn, err := m.upgrader.Upgrade(w, r, nil)
+	if !m.checkOrigin(r) {
+		m.reject(w, r, http.StatusForbidden, "origin not allowed")
+		return
+	}
+
+	if existing := m.resumable(r); existing != nil {
+		conn, err := m.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			m.logWarnf("wsrelay: upgrade failed: %v", err)
+			return
+		}
+		existing.attach(conn)
+		m.audit(AuditEvent{Event: "resume", SessionID: existing.id, Provider: existing.provider, Identity: existing.identity, RemoteAddr: r.RemoteAddr, Origin: r.Header.Get("Origin")})
+		_ = existing.send(context.Background(), Message{ID: existing.id, Type: MessageTypeSession, Payload: map[string]any{
+			"token":           existing.token,
+			"resumed":         true,
+			"resume_grace_ms": m.ResumeGrace().Milliseconds(),
+			"requests":        existing.resumeState(),
+		}})
+		go existing.run(context.Background())
+		return
+	}
+
+	adm, err := m.authenticate(r)
 	if err != nil {
-		m.logWarnf("wsrelay: upgrade failed: %v", err)
+		m.reject(w, r, http.StatusUnauthorized, err.Error())
 		return
 	}
-	s := newSession(conn, m, randomProviderName())
+	id := randomProviderName()
+	provider := ""
 	if m.providerFactory != nil {
-		name, err := m.providerFactory(r)
-		if err != nil {
-			s.cleanup(err)
+		name, errFactory := m.providerFactory(r, adm.name)
+		if errFactory != nil {
+			m.reject(w, r, http.StatusForbidden, errFactory.Error())
 			return
 		}
-		if strings.TrimSpace(name) != "" {
-			s.provider = strings.ToLower(name)
+		provider = strings.ToLower(strings.TrimSpace(name))
+	} else if adm.name != "" {
+		provider = "aistudio-" + adm.name
+	}
+	if provider == "" {
+		provider = strings.ToLower(id)
+	}
+	if errReserve := m.reserve(provider); errReserve != nil {
+		status := http.StatusConflict
+		if errors.Is(errReserve, errSessionLimit) {
+			status = http.StatusServiceUnavailable
 		}
+		m.reject(w, r, status, errReserve.Error())
+		return
 	}
-	if s.provider == "" {
-		s.provider = strings.ToLower(s.id)
+	conn, err := m.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		m.release(provider)
+		m.logWarnf("wsrelay: upgrade failed: %v", err)
+		return
 	}
+
+	s := newSession(conn, m, id)
+	s.provider = provider
+	s.identity = adm.identity
+	s.remoteAddr = r.RemoteAddr
 	m.sessMutex.Lock()
-	var replaced *session
-	if existing, ok := m.sessions[s.provider]; ok {
-		replaced = existing
-	}
-	m.sessions[s.provider] = s
+	m.releaseLocked(provider)
+	first := len(m.sessions[s.provider]) == 0
+	m.sessions[s.provider] = append(m.sessions[s.provider], s)
+	m.byToken[s.token] = s
 	m.sessMutex.Unlock()
+	m.audit(AuditEvent{Event: "join", SessionID: s.id, Provider: s.provider, Identity: s.identity, RemoteAddr: s.remoteAddr, Origin: r.Header.Get("Origin")})
 
-	if replaced != nil {
-		replaced.cleanup(errors.New("replaced by new connection"))
-	}
-	if m.onConnected != nil {
+	if first && m.onConnected != nil {
 		m.onConnected(s.provider)
 	}
+	if grace := m.ResumeGrace(); grace > 0 {
+		_ = s.send(context.Background(), Message{ID: s.id, Type: MessageTypeSession, Payload: map[string]any{
+			"token":           s.token,
+			"resumed":         false,
+			"resume_grace_ms": grace.Milliseconds(),
+		}})
+	}
 
 	go s.run(context.Background())
 }
 
-// Send forwards the message to the specific provider connection and returns a channel
-// yielding response messages.
+// resumable returns the session whose resume token the request presents. The token proves
+// the client already passed admission, so no other credential is needed.
+func (m *Manager) resumable(r *http.Request) *session {
+	token := resumeToken(r)
+	if token == "" || m.ResumeGrace() <= 0 {
+		return nil
+	}
+	m.sessMutex.RLock()
+	defer m.sessMutex.RUnlock()
+	return m.byToken[token]
+}
+
+var (
+	errSessionLimit     = errors.New("session limit reached")
+	errProviderConflict = errors.New("provider already connected")
+)
+
+// reserve claims a slot for a new connection of provider before the websocket upgrade.
+// Reserved slots count towards AuthConfig.MaxSessions like joined sessions do, so concurrent
+// connects cannot exceed the limit. It also fails when the manager admits one connection per
+// provider and the name is already taken. Every successful reserve must be matched by
+// release or releaseLocked.
+func (m *Manager) reserve(provider string) error {
+	limit := m.authConfig().MaxSessions
+	m.sessMutex.Lock()
+	defer m.sessMutex.Unlock()
+	if limit > 0 {
+		count := len(m.byToken)
+		for _, n := range m.pending {
+			count += n
+		}
+		if count >= limit {
+			return errSessionLimit
+		}
+	}
+	if m.exclusiveProviders && (len(m.sessions[provider]) > 0 || m.pending[provider] > 0) {
+		return errProviderConflict
+	}
+	m.pending[provider]++
+	return nil
+}
+
+func (m *Manager) release(provider string) {
+	m.sessMutex.Lock()
+	defer m.sessMutex.Unlock()
+	m.releaseLocked(provider)
+}
+
+func (m *Manager) releaseLocked(provider string) {
+	if m.pending[provider] <= 1 {
+		delete(m.pending, provider)
+		return
+	}
+	m.pending[provider]--
+}
+
+// resumeToken returns the session token a reconnecting client presents.
+func resumeToken(r *http.Request) string {
+	if token := strings.TrimSpace(r.Header.Get(ResumeTokenHeader)); token != "" {
+		return token
+	}
+	if r.URL != nil {
+		return strings.TrimSpace(r.URL.Query().Get("resume_token"))
+	}
+	return ""
+}
+
+// Send forwards the message to the least busy connection of the provider and returns a
+// channel yielding response messages.
 func (m *Manager) Send(ctx context.Context, provider string, msg Message) (<-chan Message, error) {
 	s := m.session(provider)
 	if s == nil {
@@ -169,29 +325,110 @@ func (m *Manager) Send(ctx context.Context, provider string, msg Message) (<-cha
 	return s.request(ctx, msg)
 }
 
+// session picks the attached connection with the fewest requests in flight.
 func (m *Manager) session(provider string) *session {
 	key := strings.ToLower(strings.TrimSpace(provider))
 	m.sessMutex.RLock()
-	s := m.sessions[key]
+	defer m.sessMutex.RUnlock()
+	var best *session
+	for _, candidate := range m.sessions[key] {
+		if !candidate.isAttached() {
+			continue
+		}
+		if best == nil || candidate.inFlight.Load() < best.inFlight.Load() {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// Stats returns health and latency figures for every session, including detached ones that
+// are still inside the resume window.
+func (m *Manager) Stats() []SessionStats {
+	if m == nil {
+		return nil
+	}
+	m.sessMutex.RLock()
+	out := make([]SessionStats, 0, len(m.byToken))
+	for _, sessions := range m.sessions {
+		for _, s := range sessions {
+			out = append(out, s.stats())
+		}
+	}
 	m.sessMutex.RUnlock()
-	return s
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// SetResumeGrace changes how long a dropped session waits for its client to re-attach.
+// Zero or less fails pending requests as soon as the connection drops.
+func (m *Manager) SetResumeGrace(grace time.Duration) {
+	if m == nil {
+		return
+	}
+	m.resumeGrace.Store(int64(grace))
+}
+
+// ResumeGrace returns the current resume window.
+func (m *Manager) ResumeGrace() time.Duration {
+	if m == nil {
+		return 0
+	}
+	return time.Duration(m.resumeGrace.Load())
 }
 
+// handleSessionClosed removes s from the pool. OnDisconnected fires once the provider has no
+// sessions left.
 func (m *Manager) handleSessionClosed(s *session, cause error) {
 	if s == nil {
 		return
 	}
 	key := strings.ToLower(strings.TrimSpace(s.provider))
 	m.sessMutex.Lock()
-	if cur, ok := m.sessions[key]; ok && cur == s {
+	removed := false
+	sessions := m.sessions[key]
+	for i, cur := range sessions {
+		if cur == s {
+			sessions = append(sessions[:i:i], sessions[i+1:]...)
+			removed = true
+			break
+		}
+	}
+	if len(sessions) == 0 {
 		delete(m.sessions, key)
+	} else {
+		m.sessions[key] = sessions
 	}
+	if m.byToken[s.token] == s {
+		delete(m.byToken, s.token)
+	}
+	last := removed && len(sessions) == 0
 	m.sessMutex.Unlock()
-	if m.onDisconnected != nil {
+	if removed {
+		reason := ""
+		if cause != nil {
+			reason = cause.Error()
+		}
+		m.audit(AuditEvent{Event: "leave", SessionID: s.id, Provider: s.provider, Identity: s.identity, RemoteAddr: s.remoteAddr, Reason: reason})
+	}
+	if last && m.onDisconnected != nil {
 		m.onDisconnected(s.provider, cause)
 	}
 }
 
+func randomToken() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
 func randomProviderName() string {
 	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
 	buf := make([]byte, 16)
@@ -5,6 +5,9 @@ type Message struct {
 	ID      string         `json:"id"`
 	Type    string         `json:"type"`
 	Payload map[string]any `json:"payload,omitempty"`
+	// Seq numbers the replies to one request, starting at 1. After a resume the client replays
+	// replies the relay has not seen, and the relay drops the ones it already delivered.
+	Seq int64 `json:"seq,omitempty"`
 }
 
 const (
@@ -24,4 +27,13 @@ const (
 	MessageTypePing = "ping"
 	// MessageTypePong represents pong responses back to clients.
 	MessageTypePong = "pong"
+	// MessageTypeCancel tells the client to abandon the in-flight request with the same ID.
+	MessageTypeCancel = "cancel"
+	// MessageTypeExecute carries an executor request for a remote worker.
+	MessageTypeExecute = "execute"
+	// MessageTypeRegister announces the auths a remote worker can serve.
+	MessageTypeRegister = "register"
+	// MessageTypeSession tells the client its session token and, after a resume, the last
+	// sequence number received for each request still in flight.
+	MessageTypeSession = "session"
 )
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -17,11 +18,17 @@ const (
 	heartbeatInterval    = 30 * time.Second
 )
 
-var errClosed = errors.New("websocket session closed")
+var (
+	errClosed   = errors.New("websocket session closed")
+	errDetached = errors.New("websocket session detached, waiting for client to resume")
+)
 
 type pendingRequest struct {
 	ch        chan Message
 	closeOnce sync.Once
+	sentAt    time.Time
+	lastSeq   atomic.Int64
+	answered  atomic.Bool
 }
 
 func (pr *pendingRequest) close() {
@@ -34,36 +41,112 @@ func (pr *pendingRequest) close() {
 }
 
 type session struct {
-	conn       *websocket.Conn
-	manager    *Manager
-	provider   string
-	id         string
+	manager  *Manager
+	provider string
+	id       string
+	// token lets the client re-attach to this session after its connection drops.
+	token string
+	// identity and remoteAddr describe who opened the session, for the audit log.
+	identity   string
+	remoteAddr string
 	closed     chan struct{}
 	closeOnce  sync.Once
-	writeMutex sync.Mutex
 	pending    sync.Map // map[string]*pendingRequest
+
+	// mu guards the current connection and the attach state.
+	mu          sync.Mutex
+	conn        *websocket.Conn
+	connDone    chan struct{}
+	attached    bool
+	detachTimer *time.Timer
+	reconnects  int
+	writeMutex  sync.Mutex
+
+	connectedAt    time.Time
+	lastSeen       atomic.Int64
+	inFlight       atomic.Int64
+	requestsTotal  atomic.Int64
+	errorsTotal    atomic.Int64
+	latencyTotal   atomic.Int64
+	latencyCount   atomic.Int64
+	lastLatency    atomic.Int64
+	pingSentAt     atomic.Int64
+	pingRTT        atomic.Int64
+	resumedStreams atomic.Int64
+}
+
+// SessionStats is a point-in-time view of one relay session.
+type SessionStats struct {
+	ID                  string    `json:"id"`
+	Provider            string    `json:"provider"`
+	Attached            bool      `json:"attached"`
+	ConnectedAt         time.Time `json:"connected_at"`
+	LastSeen            time.Time `json:"last_seen"`
+	Reconnects          int       `json:"reconnects"`
+	InFlight            int64     `json:"in_flight"`
+	RequestsTotal       int64     `json:"requests_total"`
+	ErrorsTotal         int64     `json:"errors_total"`
+	ResumedRequests     int64     `json:"resumed_requests"`
+	AvgFirstResponseMs  float64   `json:"avg_first_response_ms"`
+	LastFirstResponseMs float64   `json:"last_first_response_ms"`
+	PingRTTMs           float64   `json:"ping_rtt_ms"`
 }
 
 func newSession(conn *websocket.Conn, mgr *Manager, id string) *session {
+	now := time.Now()
 	s := &session{
-		conn:     conn,
-		manager:  mgr,
-		provider: "",
-		id:       id,
-		closed:   make(chan struct{}),
+		manager:     mgr,
+		provider:    "",
+		id:          id,
+		token:       randomToken(),
+		closed:      make(chan struct{}),
+		connectedAt: now,
 	}
+	s.lastSeen.Store(now.UnixNano())
+	s.attach(conn)
+	return s
+}
+
+// attach makes conn the session's live connection, dropping any previous one.
+func (s *session) attach(conn *websocket.Conn) {
 	conn.SetReadLimit(maxInboundMessageLen)
 	conn.SetReadDeadline(time.Now().Add(readTimeout))
 	conn.SetPongHandler(func(string) error {
-		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		now := time.Now()
+		conn.SetReadDeadline(now.Add(readTimeout))
+		s.lastSeen.Store(now.UnixNano())
+		if sent := s.pingSentAt.Load(); sent > 0 {
+			s.pingRTT.Store(now.UnixNano() - sent)
+		}
 		return nil
 	})
-	s.startHeartbeat()
-	return s
+
+	s.mu.Lock()
+	previous := s.conn
+	if s.connDone != nil && s.attached {
+		close(s.connDone)
+	}
+	if s.detachTimer != nil {
+		s.detachTimer.Stop()
+		s.detachTimer = nil
+	}
+	if previous != nil {
+		s.reconnects++
+	}
+	s.conn = conn
+	s.connDone = make(chan struct{})
+	s.attached = true
+	done := s.connDone
+	s.mu.Unlock()
+
+	if previous != nil && previous != conn {
+		_ = previous.Close()
+	}
+	s.startHeartbeat(conn, done)
 }
 
-func (s *session) startHeartbeat() {
-	if s == nil || s.conn == nil {
+func (s *session) startHeartbeat(conn *websocket.Conn, done <-chan struct{}) {
+	if s == nil || conn == nil {
 		return
 	}
 	ticker := time.NewTicker(heartbeatInterval)
@@ -73,12 +156,15 @@ func (s *session) startHeartbeat() {
 			select {
 			case <-s.closed:
 				return
+			case <-done:
+				return
 			case <-ticker.C:
 				s.writeMutex.Lock()
-				err := s.conn.WriteControl(websocket.PingMessage, []byte("ping"), time.Now().Add(writeTimeout))
+				s.pingSentAt.Store(time.Now().UnixNano())
+				err := conn.WriteControl(websocket.PingMessage, []byte("ping"), time.Now().Add(writeTimeout))
 				s.writeMutex.Unlock()
 				if err != nil {
-					s.cleanup(err)
+					s.connLost(conn, err)
 					return
 				}
 			}
@@ -87,17 +173,57 @@ func (s *session) startHeartbeat() {
 }
 
 func (s *session) run(ctx context.Context) {
-	defer s.cleanup(errClosed)
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
 	for {
 		var msg Message
-		if err := s.conn.ReadJSON(&msg); err != nil {
-			s.cleanup(err)
+		if err := conn.ReadJSON(&msg); err != nil {
+			s.connLost(conn, err)
 			return
 		}
+		s.lastSeen.Store(time.Now().UnixNano())
 		s.dispatch(msg)
 	}
 }
 
+// connLost handles the end of conn. Within the resume grace window the session stays
+// registered, detached, and its pending requests wait for the client to re-attach.
+func (s *session) connLost(conn *websocket.Conn, cause error) {
+	s.mu.Lock()
+	if s.conn != conn || !s.attached {
+		s.mu.Unlock()
+		return
+	}
+	s.attached = false
+	close(s.connDone)
+	grace := s.manager.ResumeGrace()
+	select {
+	case <-s.closed:
+		grace = 0
+	default:
+	}
+	if grace > 0 {
+		s.detachTimer = time.AfterFunc(grace, func() {
+			s.cleanup(fmt.Errorf("not resumed within %s: %w", grace, cause))
+		})
+	}
+	s.mu.Unlock()
+	_ = conn.Close()
+
+	if grace <= 0 {
+		s.cleanup(cause)
+		return
+	}
+	s.manager.logInfof("wsrelay: session %s (provider=%s) detached, waiting %s for resume: %v", s.id, s.provider, grace, cause)
+}
+
+func (s *session) isAttached() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attached
+}
+
 func (s *session) dispatch(msg Message) {
 	if msg.Type == MessageTypePing {
 		_ = s.send(context.Background(), Message{ID: msg.ID, Type: MessageTypePong})
@@ -105,34 +231,72 @@ func (s *session) dispatch(msg Message) {
 	}
 	if value, ok := s.pending.Load(msg.ID); ok {
 		req := value.(*pendingRequest)
+		if msg.Seq > 0 {
+			// Replayed after a resume; the original already got through.
+			if msg.Seq <= req.lastSeq.Load() {
+				return
+			}
+			req.lastSeq.Store(msg.Seq)
+		}
+		if req.answered.CompareAndSwap(false, true) {
+			latency := time.Since(req.sentAt).Nanoseconds()
+			s.latencyTotal.Add(latency)
+			s.latencyCount.Add(1)
+			s.lastLatency.Store(latency)
+		}
+		if msg.Type == MessageTypeError {
+			s.errorsTotal.Add(1)
+		}
 		select {
 		case req.ch <- msg:
 		case <-s.closed:
 		}
 		if msg.Type == MessageTypeHTTPResp || msg.Type == MessageTypeError || msg.Type == MessageTypeStreamEnd {
-			if actual, loaded := s.pending.LoadAndDelete(msg.ID); loaded {
-				actual.(*pendingRequest).close()
-			}
+			s.finish(msg.ID)
 		}
 		return
 	}
 	if msg.Type == MessageTypeHTTPResp || msg.Type == MessageTypeError || msg.Type == MessageTypeStreamEnd {
 		s.manager.logDebugf("wsrelay: received terminal message for unknown id %s (provider=%s)", msg.ID, s.provider)
+		return
+	}
+	if msg.Type == MessageTypeStreamChunk || msg.Type == MessageTypeStreamStart {
+		return
+	}
+	if s.manager.onMessage != nil {
+		s.manager.onMessage(s.provider, msg)
 	}
 }
 
+// finish drops the pending request with the given ID and reports whether it was pending.
+func (s *session) finish(id string) bool {
+	actual, loaded := s.pending.LoadAndDelete(id)
+	if !loaded {
+		return false
+	}
+	actual.(*pendingRequest).close()
+	s.inFlight.Add(-1)
+	return true
+}
+
 func (s *session) send(ctx context.Context, msg Message) error {
 	select {
 	case <-s.closed:
 		return errClosed
 	default:
 	}
+	s.mu.Lock()
+	conn, attached := s.conn, s.attached
+	s.mu.Unlock()
+	if !attached {
+		return errDetached
+	}
 	s.writeMutex.Lock()
 	defer s.writeMutex.Unlock()
-	if err := s.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+	if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
 		return fmt.Errorf("set write deadline: %w", err)
 	}
-	if err := s.conn.WriteJSON(msg); err != nil {
+	if err := conn.WriteJSON(msg); err != nil {
 		return fmt.Errorf("write json: %w", err)
 	}
 	return nil
@@ -142,23 +306,23 @@ func (s *session) request(ctx context.Context, msg Message) (<-chan Message, err
 	if msg.ID == "" {
 		return nil, fmt.Errorf("wsrelay: message id is required")
 	}
-	if _, loaded := s.pending.LoadOrStore(msg.ID, &pendingRequest{ch: make(chan Message, 8)}); loaded {
+	req := &pendingRequest{ch: make(chan Message, 8), sentAt: time.Now()}
+	if _, loaded := s.pending.LoadOrStore(msg.ID, req); loaded {
 		return nil, fmt.Errorf("wsrelay: duplicate message id %s", msg.ID)
 	}
-	value, _ := s.pending.Load(msg.ID)
-	req := value.(*pendingRequest)
+	s.inFlight.Add(1)
+	s.requestsTotal.Add(1)
 	if err := s.send(ctx, msg); err != nil {
-		if actual, loaded := s.pending.LoadAndDelete(msg.ID); loaded {
-			req := actual.(*pendingRequest)
-			req.close()
-		}
+		s.finish(msg.ID)
+		s.errorsTotal.Add(1)
 		return nil, err
 	}
 	go func() {
 		select {
 		case <-ctx.Done():
-			if actual, loaded := s.pending.LoadAndDelete(msg.ID); loaded {
-				actual.(*pendingRequest).close()
+			if s.finish(msg.ID) {
+				// Let the client stop work nobody is waiting for any more.
+				_ = s.send(context.Background(), Message{ID: msg.ID, Type: MessageTypeCancel})
 			}
 		case <-s.closed:
 		}
@@ -166,6 +330,42 @@ func (s *session) request(ctx context.Context, msg Message) (<-chan Message, err
 	return req.ch, nil
 }
 
+// resumeState lists the pending requests and the last sequence number received for each,
+// so a re-attached client can replay what was lost.
+func (s *session) resumeState() map[string]any {
+	requests := make(map[string]any)
+	s.pending.Range(func(key, value any) bool {
+		requests[key.(string)] = value.(*pendingRequest).lastSeq.Load()
+		return true
+	})
+	s.resumedStreams.Add(int64(len(requests)))
+	return requests
+}
+
+func (s *session) stats() SessionStats {
+	s.mu.Lock()
+	attached, reconnects := s.attached, s.reconnects
+	s.mu.Unlock()
+	stats := SessionStats{
+		ID:              s.id,
+		Provider:        s.provider,
+		Attached:        attached,
+		ConnectedAt:     s.connectedAt,
+		LastSeen:        time.Unix(0, s.lastSeen.Load()),
+		Reconnects:      reconnects,
+		InFlight:        s.inFlight.Load(),
+		RequestsTotal:   s.requestsTotal.Load(),
+		ErrorsTotal:     s.errorsTotal.Load(),
+		ResumedRequests: s.resumedStreams.Load(),
+		PingRTTMs:       float64(s.pingRTT.Load()) / float64(time.Millisecond),
+	}
+	if count := s.latencyCount.Load(); count > 0 {
+		stats.AvgFirstResponseMs = float64(s.latencyTotal.Load()) / float64(count) / float64(time.Millisecond)
+		stats.LastFirstResponseMs = float64(s.lastLatency.Load()) / float64(time.Millisecond)
+	}
+	return stats
+}
+
 func (s *session) cleanup(cause error) {
 	s.closeOnce.Do(func() {
 		close(s.closed)
@@ -176,11 +376,25 @@ func (s *session) cleanup(cause error) {
 			case req.ch <- msg:
 			case <-time.After(200 * time.Millisecond):
 			}
-			req.close()
+			if s.finish(key.(string)) {
+				s.errorsTotal.Add(1)
+			}
 			return true
 		})
-		s.pending = sync.Map{}
-		_ = s.conn.Close()
+		s.mu.Lock()
+		if s.detachTimer != nil {
+			s.detachTimer.Stop()
+			s.detachTimer = nil
+		}
+		if s.attached {
+			s.attached = false
+			close(s.connDone)
+		}
+		conn := s.conn
+		s.mu.Unlock()
+		if conn != nil {
+			_ = conn.Close()
+		}
 		if s.manager != nil {
 			s.manager.handleSessionClosed(s, cause)
 		}
@@ -0,0 +1,135 @@
+package wsrelay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startPool serves mgr and connects n clients under the same worker name.
+func startPool(t *testing.T, mgr *Manager, n int, handler RequestHandler) {
+	t.Helper()
+	server := httptest.NewServer(mgr.Handler())
+	t.Cleanup(server.Close)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	for i := 0; i < n; i++ {
+		client := NewClient(ClientOptions{
+			URL:     "ws" + strings.TrimPrefix(server.URL, "http") + mgr.Path(),
+			Header:  http.Header{"X-Worker-Name": []string{"edge"}},
+			Handler: handler,
+		})
+		go client.Run(ctx)
+	}
+	waitFor(t, func() bool { return len(mgr.Stats()) == n })
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 5s")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func workerManager(grace time.Duration) *Manager {
+	return NewManager(Options{
+		Path:            "/v1/ws/worker",
+		ProviderFactory: func(r *http.Request, _ string) (string, error) { return r.Header.Get("X-Worker-Name"), nil },
+		ResumeGrace:     grace,
+	})
+}
+
+func TestSessionPoolPrefersLeastBusy(t *testing.T) {
+	mgr := workerManager(0)
+	release := make(chan struct{})
+	startPool(t, mgr, 2, func(_ context.Context, _ Message, reply func(Message) error) {
+		<-release
+		_ = reply(Message{Type: MessageTypeHTTPResp, Payload: ResponsePayload(http.StatusOK, nil, nil)})
+	})
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := mgr.Execute(context.Background(), "edge", &ExecuteRequest{Provider: "claude", AuthID: "a"})
+			done <- err
+		}()
+		waitFor(t, func() bool {
+			var total int64
+			for _, s := range mgr.Stats() {
+				total += s.InFlight
+			}
+			return total == int64(i+1)
+		})
+	}
+	for _, s := range mgr.Stats() {
+		if s.InFlight != 1 {
+			t.Fatalf("session %s in flight = %d, want 1 on each session", s.ID, s.InFlight)
+		}
+	}
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+	for _, s := range mgr.Stats() {
+		if s.RequestsTotal != 1 || s.InFlight != 0 || s.AvgFirstResponseMs <= 0 {
+			t.Fatalf("stats = %+v", s)
+		}
+	}
+}
+
+func TestSessionResumesStreamWithoutDuplicates(t *testing.T) {
+	mgr := workerManager(10 * time.Second)
+	resume := make(chan struct{})
+	startPool(t, mgr, 1, func(_ context.Context, _ Message, reply func(Message) error) {
+		_ = reply(Message{Type: MessageTypeStreamStart, Payload: ResponsePayload(http.StatusOK, nil, nil)})
+		_ = reply(Message{Type: MessageTypeStreamChunk, Payload: ChunkPayload([]byte("one"))})
+		<-resume
+		// Sent while detached; buffered and replayed after the resume.
+		_ = reply(Message{Type: MessageTypeStreamChunk, Payload: ChunkPayload([]byte("two"))})
+		_ = reply(Message{Type: MessageTypeStreamEnd})
+	})
+
+	events, err := mgr.ExecuteStream(context.Background(), "edge", &ExecuteRequest{Provider: "claude", AuthID: "a", Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	var got []string
+	for len(got) < 2 {
+		ev := <-events
+		got = append(got, ev.Type+":"+string(ev.Payload))
+	}
+
+	mgr.sessMutex.RLock()
+	sess := mgr.sessions["edge"][0]
+	mgr.sessMutex.RUnlock()
+	sess.mu.Lock()
+	conn := sess.conn
+	sess.mu.Unlock()
+	_ = conn.Close()
+	waitFor(t, func() bool { return !sess.isAttached() })
+	close(resume)
+
+	for ev := range events {
+		if ev.Err != nil {
+			t.Fatalf("stream error: %v", ev.Err)
+		}
+		got = append(got, ev.Type+":"+string(ev.Payload))
+	}
+	want := []string{"stream_start:", "stream_chunk:one", "stream_chunk:two", "stream_end:"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	stats := mgr.Stats()
+	if len(stats) != 1 || stats[0].Reconnects != 1 || stats[0].ResumedRequests != 1 || !stats[0].Attached {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
@@ -0,0 +1,220 @@
+package wsrelay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// WorkerAuth describes a credential a remote worker executes requests with.
+type WorkerAuth struct {
+	ID       string                `json:"id"`
+	Provider string                `json:"provider"`
+	Label    string                `json:"label,omitempty"`
+	Models   []*registry.ModelInfo `json:"models,omitempty"`
+}
+
+// WorkerRegistration is the payload of a register message. Each registration replaces the
+// previous one from the same worker.
+type WorkerRegistration struct {
+	Auths []WorkerAuth `json:"auths"`
+}
+
+// ExecuteRequest is an executor call forwarded to a remote worker. The worker runs it with
+// the auth identified by AuthID.
+type ExecuteRequest struct {
+	Provider        string         `json:"provider"`
+	AuthID          string         `json:"auth_id"`
+	Model           string         `json:"model"`
+	Format          string         `json:"format,omitempty"`
+	Payload         []byte         `json:"payload,omitempty"`
+	RequestMetadata map[string]any `json:"request_metadata,omitempty"`
+	Stream          bool           `json:"stream,omitempty"`
+	CountTokens     bool           `json:"count_tokens,omitempty"`
+	Alt             string         `json:"alt,omitempty"`
+	Headers         http.Header    `json:"headers,omitempty"`
+	Query           url.Values     `json:"query,omitempty"`
+	OriginalRequest []byte         `json:"original_request,omitempty"`
+	SourceFormat    string         `json:"source_format,omitempty"`
+	Metadata        map[string]any `json:"metadata,omitempty"`
+}
+
+// NewExecuteRequest captures req and opts for execution with a remote auth.
+func NewExecuteRequest(provider, authID string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) *ExecuteRequest {
+	return &ExecuteRequest{
+		Provider:        provider,
+		AuthID:          authID,
+		Model:           req.Model,
+		Format:          req.Format.String(),
+		Payload:         req.Payload,
+		RequestMetadata: portableMetadata(req.Metadata),
+		Stream:          opts.Stream,
+		Alt:             opts.Alt,
+		Headers:         portableHeaders(opts.Headers),
+		Query:           portableQuery(opts.Query),
+		OriginalRequest: opts.OriginalRequest,
+		SourceFormat:    opts.SourceFormat.String(),
+		Metadata:        portableMetadata(opts.Metadata),
+	}
+}
+
+// Executor rebuilds the executor request and options on the worker side.
+func (r *ExecuteRequest) Executor() (cliproxyexecutor.Request, cliproxyexecutor.Options) {
+	req := cliproxyexecutor.Request{
+		Model:    r.Model,
+		Payload:  r.Payload,
+		Format:   sdktranslator.FromString(r.Format),
+		Metadata: r.RequestMetadata,
+	}
+	opts := cliproxyexecutor.Options{
+		Stream:          r.Stream,
+		Alt:             r.Alt,
+		Headers:         r.Headers,
+		Query:           r.Query,
+		OriginalRequest: r.OriginalRequest,
+		SourceFormat:    sdktranslator.FromString(r.SourceFormat),
+		Metadata:        r.Metadata,
+	}
+	return req, opts
+}
+
+// credentialHeaders carry the client's proxy credentials and never leave the conductor.
+var credentialHeaders = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"x-api-key":           {},
+	"api-key":             {},
+	"x-goog-api-key":      {},
+	"x-management-key":    {},
+	"cookie":              {},
+}
+
+// portableHeaders copies the client headers without its credentials.
+func portableHeaders(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	out := make(http.Header, len(header))
+	for key, values := range header {
+		if _, credential := credentialHeaders[strings.ToLower(key)]; credential {
+			continue
+		}
+		out[key] = append([]string(nil), values...)
+	}
+	return out
+}
+
+// portableQuery copies the client query without the Gemini-style "key" credential.
+func portableQuery(query url.Values) url.Values {
+	if len(query) == 0 {
+		return nil
+	}
+	out := make(url.Values, len(query))
+	for key, values := range query {
+		if strings.EqualFold(key, "key") || strings.EqualFold(key, "auth_token") {
+			continue
+		}
+		out[key] = append([]string(nil), values...)
+	}
+	return out
+}
+
+// portableMetadata keeps the scalar metadata values that survive JSON. Auth selection keys
+// are dropped because the worker pins its own auth.
+func portableMetadata(meta map[string]any) map[string]any {
+	if len(meta) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(meta))
+	for key, value := range meta {
+		switch key {
+		case cliproxyexecutor.PinnedAuthMetadataKey, cliproxyexecutor.SelectedAuthMetadataKey, cliproxyexecutor.SelectedAuthCallbackMetadataKey:
+			continue
+		}
+		switch value.(type) {
+		case string, bool, int, int32, int64, float32, float64:
+			out[key] = value
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// EncodePayload converts v into a message payload.
+func EncodePayload(v any) (map[string]any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var payload map[string]any
+	if err = json.Unmarshal(raw, &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// DecodePayload fills v from a message payload.
+func DecodePayload(payload map[string]any, v any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// Execute runs req on the named worker and waits for the full response.
+func (m *Manager) Execute(ctx context.Context, worker string, req *ExecuteRequest) (*HTTPResponse, error) {
+	msg, err := executeMessage(req)
+	if err != nil {
+		return nil, err
+	}
+	return m.collect(ctx, worker, msg)
+}
+
+// ExecuteStream runs req on the named worker and returns its stream events.
+func (m *Manager) ExecuteStream(ctx context.Context, worker string, req *ExecuteRequest) (<-chan StreamEvent, error) {
+	msg, err := executeMessage(req)
+	if err != nil {
+		return nil, err
+	}
+	return m.stream(ctx, worker, msg)
+}
+
+func executeMessage(req *ExecuteRequest) (Message, error) {
+	if req == nil {
+		return Message{}, fmt.Errorf("wsrelay: execute request is nil")
+	}
+	payload, err := EncodePayload(req)
+	if err != nil {
+		return Message{}, fmt.Errorf("wsrelay: encode execute request: %w", err)
+	}
+	return Message{ID: uuid.NewString(), Type: MessageTypeExecute, Payload: payload}, nil
+}
+
+// ResponsePayload encodes a complete response or the start of a stream.
+func ResponsePayload(status int, headers http.Header, body []byte) map[string]any {
+	encoded := make(map[string]any, len(headers))
+	for key, values := range headers {
+		encoded[key] = append([]string(nil), values...)
+	}
+	payload := map[string]any{"status": status, "headers": encoded}
+	if body != nil {
+		payload["body"] = string(body)
+	}
+	return payload
+}
+
+// ChunkPayload encodes one stream chunk.
+func ChunkPayload(data []byte) map[string]any {
+	return map[string]any{"data": string(data)}
+}
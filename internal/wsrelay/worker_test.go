@@ -0,0 +1,153 @@
+package wsrelay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type statusError struct {
+	status     int
+	retryAfter time.Duration
+}
+
+func (e statusError) Error() string              { return "upstream rejected" }
+func (e statusError) StatusCode() int            { return e.status }
+func (e statusError) RetryAfter() *time.Duration { return &e.retryAfter }
+
+// startWorker connects a Client serving handler to a fresh Manager and waits for the session.
+func startWorker(t *testing.T, handler RequestHandler) (*Manager, chan Message) {
+	t.Helper()
+	connected := make(chan string, 1)
+	received := make(chan Message, 4)
+	mgr := NewManager(Options{
+		Path:            "/v1/ws/worker",
+		ProviderFactory: func(r *http.Request, _ string) (string, error) { return r.Header.Get("X-Worker-Name"), nil },
+		OnConnected:     func(name string) { connected <- name },
+		OnMessage:       func(_ string, msg Message) { received <- msg },
+	})
+	server := httptest.NewServer(mgr.Handler())
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	client := NewClient(ClientOptions{
+		URL:     "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/ws/worker",
+		Header:  http.Header{"X-Worker-Name": []string{"edge"}},
+		Handler: handler,
+		OnConnected: func(send func(Message) error) error {
+			return send(Message{ID: "reg", Type: MessageTypeRegister, Payload: map[string]any{"auths": []any{}}})
+		},
+	})
+	go client.Run(ctx)
+	select {
+	case name := <-connected:
+		if name != "edge" {
+			t.Fatalf("connected worker = %q", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not connect")
+	}
+	return mgr, received
+}
+
+func TestWorkerExecuteRoundTrip(t *testing.T) {
+	mgr, received := startWorker(t, func(_ context.Context, msg Message, reply func(Message) error) {
+		var req ExecuteRequest
+		if err := DecodePayload(msg.Payload, &req); err != nil {
+			_ = reply(Message{Type: MessageTypeError, Payload: ErrorPayload(err)})
+			return
+		}
+		if req.AuthID == "rate-limited" {
+			_ = reply(Message{Type: MessageTypeError, Payload: ErrorPayload(statusError{status: 429, retryAfter: 2 * time.Second})})
+			return
+		}
+		body := req.Model + ":" + string(req.Payload) + ":" + req.Metadata["requested_model"].(string)
+		_ = reply(Message{Type: MessageTypeHTTPResp, Payload: ResponsePayload(http.StatusOK, http.Header{"X-Worker": []string{"edge"}}, []byte(body))})
+	})
+	select {
+	case msg := <-received:
+		if msg.Type != MessageTypeRegister {
+			t.Fatalf("unsolicited message type = %s, want register", msg.Type)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("registration was not delivered")
+	}
+
+	req := NewExecuteRequest("claude", "auth-1", cliproxyexecutor.Request{Model: "m", Payload: []byte(`{"a":1}`)}, cliproxyexecutor.Options{
+		Metadata: map[string]any{
+			"requested_model": "alias",
+			cliproxyexecutor.SelectedAuthCallbackMetadataKey: func(string) {},
+		},
+	})
+	resp, err := mgr.Execute(context.Background(), "edge", req)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if string(resp.Body) != `m:{"a":1}:alias` || resp.Headers.Get("X-Worker") != "edge" {
+		t.Fatalf("response = %d %v %q", resp.Status, resp.Headers, resp.Body)
+	}
+
+	req.AuthID = "rate-limited"
+	_, err = mgr.Execute(context.Background(), "edge", req)
+	var remote *RemoteError
+	if !errors.As(err, &remote) || remote.Status != 429 || remote.RetryAfter != 2*time.Second {
+		t.Fatalf("err = %#v, want RemoteError with status 429 and 2s retry", err)
+	}
+}
+
+func TestWorkerStreamAndCancel(t *testing.T) {
+	cancelled := make(chan struct{})
+	mgr, _ := startWorker(t, func(ctx context.Context, _ Message, reply func(Message) error) {
+		_ = reply(Message{Type: MessageTypeStreamStart, Payload: ResponsePayload(http.StatusOK, nil, nil)})
+		_ = reply(Message{Type: MessageTypeStreamChunk, Payload: ChunkPayload([]byte("data: one"))})
+		<-ctx.Done()
+		close(cancelled)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := mgr.ExecuteStream(ctx, "edge", &ExecuteRequest{Provider: "claude", AuthID: "auth-1", Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	if ev := <-events; ev.Type != MessageTypeStreamStart {
+		t.Fatalf("first event = %+v", ev)
+	}
+	if ev := <-events; ev.Type != MessageTypeStreamChunk || string(ev.Payload) != "data: one" {
+		t.Fatalf("second event = %+v", ev)
+	}
+	cancel()
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancel was not propagated to the worker")
+	}
+}
+
+func TestNewExecuteRequestDropsClientCredentials(t *testing.T) {
+	opts := cliproxyexecutor.Options{
+		Headers: http.Header{
+			"Authorization":  []string{"Bearer client-key"},
+			"X-Api-Key":      []string{"client-key"},
+			"X-Goog-Api-Key": []string{"client-key"},
+			"Anthropic-Beta": []string{"tools-2024"},
+		},
+		Query: map[string][]string{"key": {"client-key"}, "alt": {"sse"}},
+	}
+	req := NewExecuteRequest("claude", "a.json", cliproxyexecutor.Request{Model: "m"}, opts)
+	if len(req.Headers) != 1 || req.Headers.Get("Anthropic-Beta") != "tools-2024" {
+		t.Fatalf("headers = %v, want only Anthropic-Beta", req.Headers)
+	}
+	if len(req.Query) != 1 || req.Query.Get("alt") != "sse" {
+		t.Fatalf("query = %v, want only alt", req.Query)
+	}
+	if opts.Headers.Get("Authorization") == "" {
+		t.Fatal("caller headers were modified")
+	}
+}
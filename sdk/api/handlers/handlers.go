@@ -6,8 +6,10 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +18,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/promptqueue"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -185,6 +188,22 @@ func PassthroughHeadersEnabled(cfg *config.SDKConfig) bool {
 	return cfg != nil && cfg.PassthroughHeaders
 }
 
+// clientAPIKey returns the client API key the request authenticated with, or "".
+func clientAPIKey(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	value, exists := ginCtx.Get("apiKey")
+	if !exists {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(value))
+}
+
 func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// It is forwarded as execution metadata; when absent we generate a UUID.
@@ -273,6 +292,8 @@ type BaseAPIHandler struct {
 // Returns:
 //   - *BaseAPIHandler: A new API handlers instance
 func NewBaseAPIHandlers(cfg *config.SDKConfig, authManager *coreauth.Manager) *BaseAPIHandler {
+	schedulerInstance().SetLimits(schedulerLimits(cfg))
+	configureResponseCache(cfg)
 	return &BaseAPIHandler{
 		Cfg:         cfg,
 		AuthManager: authManager,
@@ -285,7 +306,11 @@ func NewBaseAPIHandlers(cfg *config.SDKConfig, authManager *coreauth.Manager) *B
 // Parameters:
 //   - clients: The new slice of AI service clients
 //   - cfg: The new application configuration
-func (h *BaseAPIHandler) UpdateClients(cfg *config.SDKConfig) { h.Cfg = cfg }
+func (h *BaseAPIHandler) UpdateClients(cfg *config.SDKConfig) {
+	h.Cfg = cfg
+	schedulerInstance().SetLimits(schedulerLimits(cfg))
+	configureResponseCache(cfg)
+}
 
 // GetAlt extracts the 'alt' parameter from the request query string.
 // It checks both 'alt' and '$alt' parameters and returns the appropriate value.
@@ -466,12 +491,42 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 }
 
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
-// This path is the only supported execution route.
+// This path is the only supported execution route. With the prompt queue enabled, requests
+// of the same session run one at a time. With the response cache enabled, deterministic
+// requests seen before are answered from the cache.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	cacheKey := h.responseCacheKey(ctx, handlerType, modelName, rawJSON, alt, false)
+	if entry, hit := h.lookupCachedResponse(ctx, cacheKey, modelName); hit {
+		return bytes.Clone(entry.Payload), entry.Headers.Clone(), nil
+	}
+	var (
+		resp    []byte
+		headers http.Header
+		errMsg  *interfaces.ErrorMessage
+	)
+	if sessionKey := h.promptQueueSessionKey(ctx, rawJSON); sessionKey != "" {
+		resp, headers, errMsg = h.executeQueued(ctx, sessionKey, handlerType, modelName, func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+			return h.executeWithAuthManager(ctx, handlerType, modelName, rawJSON, alt)
+		})
+	} else {
+		resp, headers, errMsg = h.executeWithAuthManager(ctx, handlerType, modelName, rawJSON, alt)
+	}
+	if errMsg == nil {
+		storeCachedResponse(cacheKey, resp, headers)
+	}
+	return resp, headers, errMsg
+}
+
+func (h *BaseAPIHandler) executeWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	ctx, providers, release, errMsg := h.acquireExecutionSlot(ctx, providers)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	defer release()
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
@@ -560,7 +615,32 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 // The returned http.Header carries upstream response headers captured before streaming begins.
+// With the prompt queue enabled, a session's stream holds its turn until it is drained.
+// Cached streams are replayed chunk by chunk in the client's format.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	cacheKey := h.responseCacheKey(ctx, handlerType, modelName, rawJSON, alt, true)
+	if entry, hit := h.lookupCachedResponse(ctx, cacheKey, modelName); hit {
+		return replayCachedStream(entry)
+	}
+	var (
+		data    <-chan []byte
+		headers http.Header
+		errs    <-chan *interfaces.ErrorMessage
+	)
+	if sessionKey := h.promptQueueSessionKey(ctx, rawJSON); sessionKey != "" {
+		data, headers, errs = h.executeStreamQueued(ctx, sessionKey, handlerType, modelName, func() (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+			return h.executeStreamWithAuthManager(ctx, handlerType, modelName, rawJSON, alt)
+		})
+	} else {
+		data, headers, errs = h.executeStreamWithAuthManager(ctx, handlerType, modelName, rawJSON, alt)
+	}
+	if cacheKey == "" {
+		return data, headers, errs
+	}
+	return recordStream(ctx, cacheKey, data, headers, errs)
+}
+
+func (h *BaseAPIHandler) executeStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
@@ -568,6 +648,10 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		close(errChan)
 		return nil, nil, errChan
 	}
+	ctx, providers, release, errMsg := h.acquireExecutionSlot(ctx, providers)
+	if errMsg != nil {
+		return nil, nil, errorMessageChan(errMsg)
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
@@ -587,6 +671,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	opts.Metadata = reqMeta
 	streamResult, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
 	if err != nil {
+		release()
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -618,6 +703,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
 	go func() {
+		defer release()
 		defer close(dataChan)
 		defer close(errChan)
 		sentPayload := false
@@ -830,6 +916,11 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 	}
 
 	body := BuildErrorResponseBody(status, errText)
+	var overloaded *promptqueue.OverloadedError
+	if msg != nil && errors.As(msg.Error, &overloaded) {
+		body = promptQueueOverloadBody(overloaded)
+		c.Writer.Header().Set("X-Queue-Position", strconv.Itoa(overloaded.Position))
+	}
 	// Append first to preserve upstream response logs, then drop duplicate payloads if already recorded.
 	var previous []byte
 	if existing, exists := c.Get("API_RESPONSE"); exists {
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/promptqueue"
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/context"
+)
+
+const (
+	defaultPromptQueueSessionHeader = "X-Session-ID"
+	defaultPromptQueueMaxDepth      = 16
+)
+
+// promptQueueManager returns the queue that session turns are submitted to.
+var promptQueueManager = promptqueue.GetDefaultManager
+
+// promptQueueSessionKey returns the session whose turns the request must not overlap with,
+// or "" when the prompt queue is disabled or the request carries no session key. The
+// configured header wins over metadata.user_id, which wins over the Codex execution session.
+// The key is prefixed with a digest of the client API key, so clients that happen to send the
+// same session ID never share a queue.
+func (h *BaseAPIHandler) promptQueueSessionKey(ctx context.Context, rawJSON []byte) string {
+	if h == nil || h.Cfg == nil || !h.Cfg.PromptQueue.Enabled || ctx == nil {
+		return ""
+	}
+	key := ""
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		header := strings.TrimSpace(h.Cfg.PromptQueue.SessionHeader)
+		if header == "" {
+			header = defaultPromptQueueSessionHeader
+		}
+		key = strings.TrimSpace(ginCtx.GetHeader(header))
+	}
+	if key == "" {
+		key = strings.TrimSpace(gjson.GetBytes(rawJSON, "metadata.user_id").String())
+	}
+	if key == "" {
+		key = executionSessionIDFromContext(ctx)
+	}
+	if key == "" {
+		return ""
+	}
+	if client := logging.HashClientKey(clientAPIKey(ctx)); client != "" {
+		return client + ":" + key
+	}
+	return key
+}
+
+func (h *BaseAPIHandler) promptQueueRequest(ctx context.Context, sessionKey, handlerType, modelName string) promptqueue.SubmitRequest {
+	depth := h.Cfg.PromptQueue.MaxQueueDepth
+	if depth <= 0 {
+		depth = defaultPromptQueueMaxDepth
+	}
+	return promptqueue.SubmitRequest{
+		SessionKey:    sessionKey,
+		Handler:       handlerType,
+		Model:         modelName,
+		RequestID:     logging.GetRequestID(ctx),
+		MaxQueueDepth: depth,
+	}
+}
+
+// executeQueued runs a non-streaming execution as one turn of the session.
+func (h *BaseAPIHandler) executeQueued(ctx context.Context, sessionKey, handlerType, modelName string, execute func() ([]byte, http.Header, *interfaces.ErrorMessage)) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	var (
+		resp    []byte
+		headers http.Header
+		errMsg  *interfaces.ErrorMessage
+		ran     bool
+	)
+	_, err := promptQueueManager().SubmitAndWait(h.promptQueueRequest(ctx, sessionKey, handlerType, modelName), func(string) error {
+		if errCtx := ctx.Err(); errCtx != nil {
+			return errCtx
+		}
+		ran = true
+		resp, headers, errMsg = execute()
+		if errMsg != nil {
+			return errorMessageError(errMsg)
+		}
+		return nil
+	})
+	if !ran && err != nil {
+		return nil, nil, errorMessageFromError(err)
+	}
+	return resp, headers, errMsg
+}
+
+type queuedStream struct {
+	data    <-chan []byte
+	headers http.Header
+	errs    <-chan *interfaces.ErrorMessage
+}
+
+// executeStreamQueued runs a streaming execution as one turn of the session. It returns once
+// the turn has started; the turn ends when the upstream stream is drained or ctx is done.
+func (h *BaseAPIHandler) executeStreamQueued(ctx context.Context, sessionKey, handlerType, modelName string, execute func() (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage)) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	started := make(chan queuedStream, 1)
+	go func() {
+		ran := false
+		_, err := promptQueueManager().SubmitAndWait(h.promptQueueRequest(ctx, sessionKey, handlerType, modelName), func(string) error {
+			if errCtx := ctx.Err(); errCtx != nil {
+				return errCtx
+			}
+			ran = true
+			data, headers, errs := execute()
+			dataOut := make(chan []byte)
+			errOut := make(chan *interfaces.ErrorMessage, 1)
+			started <- queuedStream{data: dataOut, headers: headers, errs: errOut}
+			return forwardQueuedStream(ctx, data, errs, dataOut, errOut, nil)
+		})
+		if !ran && err != nil {
+			started <- queuedStream{errs: errorMessageChan(errorMessageFromError(err))}
+		}
+	}()
+
+	select {
+	case stream := <-started:
+		return stream.data, stream.headers, stream.errs
+	case <-ctx.Done():
+		return nil, nil, errorMessageChan(errorMessageFromError(ctx.Err()))
+	}
+}
+
+// forwardQueuedStream relays an execution stream to the caller and reports how it ended.
+// onChunk, when set, sees every chunk before it is relayed.
+func forwardQueuedStream(ctx context.Context, data <-chan []byte, errs <-chan *interfaces.ErrorMessage, dataOut chan<- []byte, errOut chan<- *interfaces.ErrorMessage, onChunk func([]byte)) error {
+	defer close(dataOut)
+	defer close(errOut)
+	var streamErr error
+	for data != nil || errs != nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case chunk, ok := <-data:
+			if !ok {
+				data = nil
+				continue
+			}
+			if onChunk != nil {
+				onChunk(chunk)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case dataOut <- chunk:
+			}
+		case msg, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			streamErr = errorMessageError(msg)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case errOut <- msg:
+			}
+		}
+	}
+	return streamErr
+}
+
+func errorMessageError(msg *interfaces.ErrorMessage) error {
+	if msg == nil {
+		return nil
+	}
+	if msg.Error != nil {
+		return msg.Error
+	}
+	return errors.New(http.StatusText(msg.StatusCode))
+}
+
+func errorMessageFromError(err error) *interfaces.ErrorMessage {
+	status := statusFromError(err)
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	return &interfaces.ErrorMessage{StatusCode: status, Error: err}
+}
+
+func errorMessageChan(msg *interfaces.ErrorMessage) <-chan *interfaces.ErrorMessage {
+	errChan := make(chan *interfaces.ErrorMessage, 1)
+	errChan <- msg
+	close(errChan)
+	return errChan
+}
+
+// promptQueueOverloadBody renders a 429 body that tells the client its queue position.
+func promptQueueOverloadBody(overloaded *promptqueue.OverloadedError) []byte {
+	payload, _ := json.Marshal(map[string]any{
+		"error": map[string]any{
+			"message":        overloaded.Error(),
+			"type":           "rate_limit_error",
+			"code":           "session_queue_full",
+			"queue_position": overloaded.Position,
+			"queue_limit":    overloaded.Limit,
+		},
+	})
+	return payload
+}
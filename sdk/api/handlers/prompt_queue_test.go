@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/promptqueue"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// gatedStreamExecutor holds its first stream open until release is closed.
+type gatedStreamExecutor struct {
+	mu      sync.Mutex
+	calls   int
+	release chan struct{}
+}
+
+func (e *gatedStreamExecutor) Identifier() string { return "codex" }
+
+func (e *gatedStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	return coreexecutor.Response{Payload: []byte("ok")}, nil
+}
+
+func (e *gatedStreamExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	e.mu.Lock()
+	e.calls++
+	call := e.calls
+	e.mu.Unlock()
+
+	ch := make(chan coreexecutor.StreamChunk)
+	go func() {
+		defer close(ch)
+		if call == 1 {
+			<-e.release
+		}
+		ch <- coreexecutor.StreamChunk{Payload: []byte("ok")}
+	}()
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *gatedStreamExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *gatedStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *gatedStreamExecutor) HttpRequest(ctx context.Context, auth *coreauth.Auth, req *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func (e *gatedStreamExecutor) Calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+func TestPromptQueueSessionKey_Precedence(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	ctx := WithExecutionSessionID(context.WithValue(context.Background(), "gin", c), "exec-1")
+	body := []byte(`{"metadata":{"user_id":"user-1"}}`)
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	if got := handler.promptQueueSessionKey(ctx, body); got != "" {
+		t.Fatalf("disabled queue returned session key %q", got)
+	}
+
+	handler.Cfg.PromptQueue.Enabled = true
+	if got := handler.promptQueueSessionKey(ctx, []byte(`{}`)); got != "exec-1" {
+		t.Fatalf("session key = %q, want execution session", got)
+	}
+	if got := handler.promptQueueSessionKey(ctx, body); got != "user-1" {
+		t.Fatalf("session key = %q, want metadata.user_id", got)
+	}
+	c.Request.Header.Set("X-Session-ID", "header-1")
+	if got := handler.promptQueueSessionKey(ctx, body); got != "header-1" {
+		t.Fatalf("session key = %q, want header", got)
+	}
+
+	c.Set("apiKey", "client-a")
+	keyA := handler.promptQueueSessionKey(ctx, body)
+	c.Set("apiKey", "client-b")
+	keyB := handler.promptQueueSessionKey(ctx, body)
+	if keyA == keyB || !strings.HasSuffix(keyA, ":header-1") || strings.Contains(keyA, "client-a") {
+		t.Fatalf("session keys = %q and %q, want distinct hashed client prefixes", keyA, keyB)
+	}
+}
+
+func TestExecuteWithAuthManager_PromptQueueSerializesSession(t *testing.T) {
+	queue := promptqueue.NewManager(promptqueue.Config{})
+	previous := promptQueueManager
+	promptQueueManager = func() *promptqueue.Manager { return queue }
+	t.Cleanup(func() { promptQueueManager = previous })
+
+	executor := &gatedStreamExecutor{release: make(chan struct{})}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "queue-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "queue-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		PromptQueue: sdkconfig.PromptQueueConfig{Enabled: true, MaxQueueDepth: 2},
+	}, manager)
+	body := []byte(`{"model":"queue-model","metadata":{"user_id":"session-1"}}`)
+
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "queue-model", body, "")
+
+	secondDone := make(chan struct{})
+	go func() {
+		defer close(secondDone)
+		if _, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "queue-model", body, ""); errMsg != nil {
+			t.Errorf("queued request failed: %v", errMsg.Error)
+		}
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for queue.MetricsSnapshot().Submitted < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The session is full: one stream running and one request waiting.
+	_, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "queue-model", body, "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for a full session, got %+v", errMsg)
+	}
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	handler.WriteErrorResponse(c, errMsg)
+	if got := recorder.Header().Get("X-Queue-Position"); got != "3" {
+		t.Fatalf("X-Queue-Position = %q, want 3", got)
+	}
+	if got := gjson.Get(recorder.Body.String(), "error.queue_position").Int(); got != 3 {
+		t.Fatalf("queue_position = %d, body %s", got, recorder.Body.String())
+	}
+
+	if calls := executor.Calls(); calls != 1 {
+		t.Fatalf("upstream calls while the first turn runs = %d, want 1", calls)
+	}
+	close(executor.release)
+
+	var got strings.Builder
+	for chunk := range dataChan {
+		got.Write(chunk)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected stream error: %+v", msg)
+		}
+	}
+	if got.String() != "ok" {
+		t.Fatalf("stream payload = %q, want ok", got.String())
+	}
+	<-secondDone
+	if calls := executor.Calls(); calls != 2 {
+		t.Fatalf("upstream calls = %d, want 2", calls)
+	}
+}
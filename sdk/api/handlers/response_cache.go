@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/respcache"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/context"
+)
+
+const (
+	defaultResponseCacheHeader = "Cache"
+	responseCacheStatusHeader  = "X-Cache"
+	// responseCacheProvider is the provider recorded in usage for requests answered from the cache.
+	responseCacheProvider = "response-cache"
+)
+
+// responseCacheTemperaturePaths are where the client formats carry the sampling temperature.
+var responseCacheTemperaturePaths = []string{
+	"temperature",
+	"generationConfig.temperature",
+	"generation_config.temperature",
+	"request.generationConfig.temperature",
+}
+
+// responseCacheProviderFormats maps providers to the format their executors send upstream.
+// Other providers, such as OpenAI-compatible ones, receive OpenAI chat completions.
+var responseCacheProviderFormats = map[string]sdktranslator.Format{
+	"gemini":      sdktranslator.FormatGemini,
+	"vertex":      sdktranslator.FormatGemini,
+	"aistudio":    sdktranslator.FormatGemini,
+	"gemini-cli":  sdktranslator.FormatGeminiCLI,
+	"antigravity": sdktranslator.FormatAntigravity,
+	"claude":      sdktranslator.FormatClaude,
+	"codex":       sdktranslator.FormatCodex,
+}
+
+// responseCacheInstance returns the cache that deterministic responses are stored in.
+var responseCacheInstance = respcache.Default
+
+func configureResponseCache(cfg *config.SDKConfig) {
+	var opts respcache.Options
+	if cfg != nil && cfg.ResponseCache.Enabled {
+		rc := cfg.ResponseCache
+		opts = respcache.Options{
+			Enabled:    true,
+			Store:      rc.Store,
+			Dir:        rc.Dir,
+			TTL:        time.Duration(rc.TTLSeconds) * time.Second,
+			MaxEntries: rc.MaxEntries,
+			MaxBytes:   int64(rc.MaxSizeMB) << 20,
+		}
+	}
+	if err := responseCacheInstance().Configure(opts); err != nil {
+		log.Errorf("response cache disabled: %v", err)
+	}
+}
+
+// responseCacheKey returns the cache key of the request, or "" when the cache is off or the
+// request is not deterministic. The opt-in header decides when present; otherwise a
+// temperature of 0 makes a request cacheable. Entries are scoped to the client key and keyed
+// on the payload translated for the first provider serving the model.
+func (h *BaseAPIHandler) responseCacheKey(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string, stream bool) string {
+	if h == nil || h.Cfg == nil || !h.Cfg.ResponseCache.Enabled || !responseCacheInstance().Enabled() {
+		return ""
+	}
+	cacheable := false
+	decided := false
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		header := strings.TrimSpace(h.Cfg.ResponseCache.Header)
+		if header == "" {
+			header = defaultResponseCacheHeader
+		}
+		if value := strings.TrimSpace(ginCtx.GetHeader(header)); value != "" {
+			cacheable, decided = parseResponseCacheHeader(value)
+		}
+	}
+	if !decided {
+		for _, path := range responseCacheTemperaturePaths {
+			if temperature := gjson.GetBytes(rawJSON, path); temperature.Exists() {
+				cacheable = temperature.Type == gjson.Number && temperature.Float() == 0
+				break
+			}
+		}
+	}
+	if !cacheable {
+		return ""
+	}
+	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	if errMsg != nil {
+		return ""
+	}
+	to, ok := responseCacheProviderFormats[strings.ToLower(providers[0])]
+	if !ok {
+		to = sdktranslator.FormatOpenAI
+	}
+	upstream := sdktranslator.TranslateRequest(sdktranslator.FromString(handlerType), to, normalizedModel, rawJSON, stream)
+	return respcache.Key(logging.HashClientKey(clientAPIKey(ctx)), handlerType, normalizedModel, alt, stream, upstream)
+}
+
+func parseResponseCacheHeader(value string) (cacheable, ok bool) {
+	cacheable, err := strconv.ParseBool(value)
+	return cacheable, err == nil
+}
+
+// lookupCachedResponse returns the entry stored under key and marks the response as a hit or
+// miss. Hits are recorded in usage with no tokens.
+func (h *BaseAPIHandler) lookupCachedResponse(ctx context.Context, key, modelName string) (*respcache.Entry, bool) {
+	if key == "" {
+		return nil, false
+	}
+	entry, ok := responseCacheInstance().Get(key)
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	if !ok {
+		if ginCtx != nil {
+			ginCtx.Header(responseCacheStatusHeader, "MISS")
+		}
+		return nil, false
+	}
+	record := coreusage.Record{
+		Provider:    responseCacheProvider,
+		Model:       modelName,
+		Source:      responseCacheProvider,
+		RequestedAt: time.Now(),
+		CacheHit:    true,
+	}
+	if ginCtx != nil {
+		ginCtx.Header(responseCacheStatusHeader, "HIT")
+		ginCtx.Header("Age", strconv.Itoa(int(time.Since(entry.StoredAt).Seconds())))
+		record.APIKey = clientAPIKey(ctx)
+	}
+	coreusage.PublishRecord(ctx, record)
+	return entry, true
+}
+
+func storeCachedResponse(key string, payload []byte, headers http.Header) {
+	if key == "" {
+		return
+	}
+	responseCacheInstance().Set(key, &respcache.Entry{Payload: bytes.Clone(payload), Headers: headers.Clone()})
+}
+
+// replayCachedStream feeds the recorded chunks of entry to the caller as a fresh stream.
+func replayCachedStream(entry *respcache.Entry) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	data := make(chan []byte, len(entry.Chunks))
+	for _, chunk := range entry.Chunks {
+		data <- bytes.Clone(chunk)
+	}
+	close(data)
+	errs := make(chan *interfaces.ErrorMessage)
+	close(errs)
+	return data, entry.Headers.Clone(), errs
+}
+
+// recordStream relays an execution stream to the caller and stores it under key once it has
+// ended without an error.
+func recordStream(ctx context.Context, key string, data <-chan []byte, headers http.Header, errs <-chan *interfaces.ErrorMessage) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	dataOut := make(chan []byte)
+	errOut := make(chan *interfaces.ErrorMessage, 1)
+	go func() {
+		chunks := [][]byte{}
+		err := forwardQueuedStream(ctx, data, errs, dataOut, errOut, func(chunk []byte) {
+			chunks = append(chunks, bytes.Clone(chunk))
+		})
+		if err == nil && len(chunks) > 0 {
+			responseCacheInstance().Set(key, &respcache.Entry{Chunks: chunks, Headers: headers.Clone()})
+		}
+	}()
+	return dataOut, headers, errOut
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/respcache"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func responseCacheTestHandler(t *testing.T, model string) (*BaseAPIHandler, *gatedStreamExecutor) {
+	t.Helper()
+	cache := respcache.New()
+	previous := responseCacheInstance
+	responseCacheInstance = func() *respcache.Cache { return cache }
+	t.Cleanup(func() { responseCacheInstance = previous })
+
+	executor := &gatedStreamExecutor{release: make(chan struct{})}
+	close(executor.release)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: model + "-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: model}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		ResponseCache: sdkconfig.ResponseCacheConfig{Enabled: true},
+	}, manager)
+	return handler, executor
+}
+
+func responseCacheTestContext(header string) (context.Context, *httptest.ResponseRecorder) {
+	return responseCacheClientContext(header, "client-a")
+}
+
+func responseCacheClientContext(header, apiKey string) (context.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	c.Set("apiKey", apiKey)
+	if header != "" {
+		c.Request.Header.Set("Cache", header)
+	}
+	return context.WithValue(context.Background(), "gin", c), recorder
+}
+
+func TestResponseCacheKey_Deterministic(t *testing.T) {
+	handler, _ := responseCacheTestHandler(t, "cache-key-model")
+	cases := []struct {
+		header string
+		body   string
+		want   bool
+	}{
+		{body: `{"temperature":0}`, want: true},
+		{body: `{"generationConfig":{"temperature":0.0}}`, want: true},
+		{body: `{"temperature":0.7}`},
+		{body: `{}`},
+		{header: "true", body: `{"temperature":1}`, want: true},
+		{header: "false", body: `{"temperature":0}`},
+	}
+	for _, tc := range cases {
+		ctx, _ := responseCacheTestContext(tc.header)
+		got := handler.responseCacheKey(ctx, "openai", "cache-key-model", []byte(tc.body), "", false) != ""
+		if got != tc.want {
+			t.Errorf("header %q body %s: cacheable = %t, want %t", tc.header, tc.body, got, tc.want)
+		}
+	}
+}
+
+func TestExecuteWithAuthManager_ResponseCacheReplays(t *testing.T) {
+	handler, executor := responseCacheTestHandler(t, "cache-model")
+	body := []byte(`{"model":"cache-model","temperature":0}`)
+
+	ctx, recorder := responseCacheTestContext("")
+	if _, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "cache-model", body, ""); errMsg != nil {
+		t.Fatalf("first call: %v", errMsg.Error)
+	}
+	if got := recorder.Header().Get("X-Cache"); got != "MISS" {
+		t.Fatalf("first X-Cache = %q, want MISS", got)
+	}
+	ctx, recorder = responseCacheTestContext("")
+	resp, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "cache-model", body, "")
+	if errMsg != nil || string(resp) != "ok" {
+		t.Fatalf("second call = %q, %v", resp, errMsg)
+	}
+	if got := recorder.Header().Get("X-Cache"); got != "HIT" {
+		t.Fatalf("second X-Cache = %q, want HIT", got)
+	}
+	if executor.Calls() != 1 {
+		t.Fatalf("executor calls = %d, want 1", executor.Calls())
+	}
+
+	streamBody := []byte(`{"model":"cache-model","temperature":0,"stream":true}`)
+	for i := 0; i < 2; i++ {
+		ctx, _ = responseCacheTestContext("")
+		data, _, errs := handler.ExecuteStreamWithAuthManager(ctx, "openai", "cache-model", streamBody, "")
+		var chunks []string
+		for chunk := range data {
+			chunks = append(chunks, string(chunk))
+		}
+		if msg := <-errs; msg != nil {
+			t.Fatalf("stream %d: %v", i, msg.Error)
+		}
+		if strings.Join(chunks, ",") != "ok" {
+			t.Fatalf("stream %d chunks = %v", i, chunks)
+		}
+		// The recorded stream is stored after the relay goroutine finishes.
+		deadline := time.Now().Add(time.Second)
+		for i == 0 && time.Now().Before(deadline) {
+			if _, ok := responseCacheInstance().Get(handler.responseCacheKey(ctx, "openai", "cache-model", streamBody, "", true)); ok {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	if executor.Calls() != 2 {
+		t.Fatalf("executor calls = %d, want 2", executor.Calls())
+	}
+}
+
+func TestResponseCacheKey_ScopedToClientAndUpstreamPayload(t *testing.T) {
+	handler, executor := responseCacheTestHandler(t, "cache-scope-model")
+	body := []byte(`{"model":"cache-scope-model","temperature":0,"messages":[{"role":"user","content":"hi"}]}`)
+
+	ctxA, _ := responseCacheClientContext("", "client-a")
+	ctxB, recorder := responseCacheClientContext("", "client-b")
+	if handler.responseCacheKey(ctxA, "openai", "cache-scope-model", body, "", false) == handler.responseCacheKey(ctxB, "openai", "cache-scope-model", body, "", false) {
+		t.Fatal("different clients share a cache key")
+	}
+	// Formatting differences that translate to the same upstream request share an entry.
+	reordered := []byte(`{"messages":[{"content":"hi","role":"user"}],"temperature":0,"model":"cache-scope-model"}`)
+	if handler.responseCacheKey(ctxA, "openai", "cache-scope-model", body, "", false) != handler.responseCacheKey(ctxA, "openai", "cache-scope-model", reordered, "", false) {
+		t.Fatal("equivalent requests have different cache keys")
+	}
+
+	if _, _, errMsg := handler.ExecuteWithAuthManager(ctxA, "openai", "cache-scope-model", body, ""); errMsg != nil {
+		t.Fatalf("client a: %v", errMsg.Error)
+	}
+	if _, _, errMsg := handler.ExecuteWithAuthManager(ctxB, "openai", "cache-scope-model", body, ""); errMsg != nil {
+		t.Fatalf("client b: %v", errMsg.Error)
+	}
+	if got := recorder.Header().Get("X-Cache"); got != "MISS" {
+		t.Fatalf("client b X-Cache = %q, want MISS", got)
+	}
+	if executor.Calls() != 2 {
+		t.Fatalf("executor calls = %d, want 2", executor.Calls())
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/fairshare"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"golang.org/x/net/context"
+)
+
+const defaultSchedulerPriorityHeader = "X-Request-Priority"
+
+// schedulerInstance returns the scheduler that admits upstream executions.
+var schedulerInstance = fairshare.Default
+
+func schedulerLimits(cfg *config.SDKConfig) fairshare.Limits {
+	if cfg == nil || !cfg.Scheduler.Enabled {
+		return fairshare.Limits{}
+	}
+	limits := fairshare.Limits{
+		Enabled:           true,
+		MaxConcurrent:     cfg.Scheduler.MaxConcurrency,
+		BatchPromoteAfter: time.Duration(cfg.Scheduler.BatchPromoteAfterSeconds) * time.Second,
+	}
+	if len(cfg.Scheduler.ProviderConcurrency) > 0 {
+		limits.ProviderConcurrency = make(map[string]int, len(cfg.Scheduler.ProviderConcurrency))
+		for provider, limit := range cfg.Scheduler.ProviderConcurrency {
+			limits.ProviderConcurrency[strings.ToLower(strings.TrimSpace(provider))] = limit
+		}
+	}
+	return limits
+}
+
+// schedulerTicket identifies the client of the request and its share and priority class. A
+// priority configured for the client key wins over the priority header.
+func (h *BaseAPIHandler) schedulerTicket(ctx context.Context, providers []string) fairshare.Ticket {
+	ticket := fairshare.Ticket{Weight: 1, Providers: providers}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	if ginCtx != nil {
+		if value, exists := ginCtx.Get("apiKey"); exists {
+			ticket.ClientKey = strings.TrimSpace(fmt.Sprint(value))
+		}
+	}
+
+	configured := false
+	for _, client := range h.Cfg.Scheduler.Clients {
+		if strings.TrimSpace(client.APIKey) != ticket.ClientKey {
+			continue
+		}
+		if client.Weight > 0 {
+			ticket.Weight = float64(client.Weight)
+		}
+		ticket.Priority, configured = fairshare.ParsePriority(client.Priority)
+		break
+	}
+	if !configured && ginCtx != nil && ginCtx.Request != nil {
+		header := strings.TrimSpace(h.Cfg.Scheduler.PriorityHeader)
+		if header == "" {
+			header = defaultSchedulerPriorityHeader
+		}
+		ticket.Priority, _ = fairshare.ParsePriority(ginCtx.GetHeader(header))
+	}
+	return ticket
+}
+
+// acquireExecutionSlot waits until the scheduler admits the request. The returned context
+// carries the queue wait for usage records and the returned providers are the ones the
+// request may be sent to: the provider charged for the slot when a provider cap applies.
+// release must be called when execution ends.
+func (h *BaseAPIHandler) acquireExecutionSlot(ctx context.Context, providers []string) (context.Context, []string, func(), *interfaces.ErrorMessage) {
+	if h == nil || h.Cfg == nil || !h.Cfg.Scheduler.Enabled {
+		return ctx, providers, func() {}, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	slot, err := schedulerInstance().Acquire(ctx, h.schedulerTicket(ctx, providers))
+	if err != nil {
+		return ctx, providers, func() {}, errorMessageFromError(err)
+	}
+	if slot.Provider != "" {
+		providers = []string{slot.Provider}
+	}
+	return coreusage.WithQueueWait(ctx, slot.Wait), providers, slot.Release, nil
+}
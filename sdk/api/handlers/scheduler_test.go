@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/fairshare"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func schedulerTestContext(apiKey, priority string) context.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	if priority != "" {
+		c.Request.Header.Set("X-Request-Priority", priority)
+	}
+	c.Set("apiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func TestSchedulerTicket_KeyConfigWinsOverHeader(t *testing.T) {
+	handler := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{Scheduler: sdkconfig.SchedulerConfig{
+		Enabled: true,
+		Clients: []sdkconfig.SchedulerClient{
+			{APIKey: "ide", Weight: 3, Priority: "interactive"},
+			{APIKey: "weighted", Weight: 2},
+		},
+	}}}
+
+	ticket := handler.schedulerTicket(schedulerTestContext("ide", "batch"), []string{"claude"})
+	if ticket.ClientKey != "ide" || ticket.Weight != 3 || ticket.Priority != fairshare.PriorityInteractive || len(ticket.Providers) != 1 || ticket.Providers[0] != "claude" {
+		t.Fatalf("ide ticket = %+v", ticket)
+	}
+	ticket = handler.schedulerTicket(schedulerTestContext("weighted", "batch"), nil)
+	if ticket.Weight != 2 || ticket.Priority != fairshare.PriorityBatch {
+		t.Fatalf("weighted ticket = %+v, want weight 2 and header priority batch", ticket)
+	}
+	ticket = handler.schedulerTicket(schedulerTestContext("unknown", ""), nil)
+	if ticket.Weight != 1 || ticket.Priority != fairshare.PriorityInteractive {
+		t.Fatalf("unknown ticket = %+v", ticket)
+	}
+}
+
+func TestAcquireExecutionSlot_RecordsQueueWait(t *testing.T) {
+	scheduler := fairshare.New(fairshare.Limits{})
+	previous := schedulerInstance
+	schedulerInstance = func() *fairshare.Scheduler { return scheduler }
+	t.Cleanup(func() { schedulerInstance = previous })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{Scheduler: sdkconfig.SchedulerConfig{Enabled: true, MaxConcurrency: 1}}, nil)
+	_, _, hold, errMsg := handler.acquireExecutionSlot(schedulerTestContext("a", ""), []string{"codex"})
+	if errMsg != nil {
+		t.Fatalf("first acquire: %v", errMsg.Error)
+	}
+
+	done := make(chan context.Context, 1)
+	go func() {
+		ctx, _, release, errMsg := handler.acquireExecutionSlot(schedulerTestContext("b", ""), []string{"codex"})
+		if errMsg != nil {
+			t.Errorf("second acquire: %v", errMsg.Error)
+			done <- nil
+			return
+		}
+		release()
+		done <- ctx
+	}()
+	for scheduler.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	hold()
+
+	ctx := <-done
+	if wait := coreusage.QueueWaitFromContext(ctx); wait < 20*time.Millisecond {
+		t.Fatalf("queue wait = %v, want at least 20ms", wait)
+	}
+
+	handler.UpdateClients(&sdkconfig.SDKConfig{})
+	if stats := scheduler.Stats(); stats.Enabled || stats.InFlight != 0 {
+		t.Fatalf("stats after disabling = %+v", stats)
+	}
+}
+
+func TestAcquireExecutionSlot_RoutesToChargedProvider(t *testing.T) {
+	scheduler := fairshare.New(fairshare.Limits{})
+	previous := schedulerInstance
+	schedulerInstance = func() *fairshare.Scheduler { return scheduler }
+	t.Cleanup(func() { schedulerInstance = previous })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{Scheduler: sdkconfig.SchedulerConfig{
+		Enabled:             true,
+		ProviderConcurrency: map[string]int{"claude": 1},
+	}}, nil)
+	_, providers, hold, errMsg := handler.acquireExecutionSlot(schedulerTestContext("a", ""), []string{"claude", "codex"})
+	if errMsg != nil || len(providers) != 1 || providers[0] != "claude" {
+		t.Fatalf("first slot providers = %v, err=%v, want [claude]", providers, errMsg)
+	}
+	defer hold()
+	_, providers, release, errMsg := handler.acquireExecutionSlot(schedulerTestContext("b", ""), []string{"claude", "codex"})
+	if errMsg != nil || len(providers) != 1 || providers[0] != "codex" {
+		t.Fatalf("second slot providers = %v, err=%v, want [codex]", providers, errMsg)
+	}
+	release()
+}
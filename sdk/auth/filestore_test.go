@@ -1,6 +1,15 @@
 package auth
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
 
 func TestExtractAccessToken(t *testing.T) {
 	t.Parallel()
@@ -78,3 +87,52 @@ func TestExtractAccessToken(t *testing.T) {
 		})
 	}
 }
+
+func TestFileTokenStoreEncryptsMetadataAtRest(t *testing.T) {
+	keyring, err := authcrypt.NewKeyring(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	authcrypt.Configure(keyring)
+	t.Cleanup(func() { authcrypt.Configure(nil) })
+
+	dir := t.TempDir()
+	store := NewFileTokenStore()
+	store.SetBaseDir(dir)
+	legacy := filepath.Join(dir, "legacy.json")
+	if err = os.WriteFile(legacy, []byte(`{"type":"claude","email":"legacy@example.com"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := store.Save(context.Background(), &cliproxyauth.Auth{
+		ID:       "codex.json",
+		FileName: "codex.json",
+		Provider: "codex",
+		Metadata: map[string]any{"type": "codex", "refresh_token": "rt-secret"},
+	})
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !authcrypt.IsEncrypted(raw) || bytes.Contains(raw, []byte("rt-secret")) {
+		t.Fatalf("auth file not encrypted: %s", raw)
+	}
+
+	auths, err := store.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	found := map[string]*cliproxyauth.Auth{}
+	for _, a := range auths {
+		found[a.ID] = a
+	}
+	if found["codex.json"] == nil || found["codex.json"].Metadata["refresh_token"] != "rt-secret" {
+		t.Fatalf("encrypted auth not listed with metadata: %+v", found["codex.json"])
+	}
+	if found["legacy.json"] == nil || found["legacy.json"].Provider != "claude" {
+		t.Fatalf("legacy plaintext auth not listed: %+v", found["legacy.json"])
+	}
+}
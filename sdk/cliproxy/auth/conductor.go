@@ -17,6 +17,7 @@ import (
 	"github.com/google/uuid"
 	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
@@ -154,6 +155,10 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// middlewares wrap executor calls; guarded by middlewareMu.
+	middlewareMu sync.RWMutex
+	middlewares  []ExecutorMiddleware
+
 	// Account egress determinism state.
 	egressMu                  sync.Mutex
 	egressEnabled             bool
@@ -168,6 +173,17 @@ type Manager struct {
 
 	// Auto refresh state
 	refreshCancel context.CancelFunc
+	// refreshFailures counts consecutive refresh failures per auth ID; guarded by mu.
+	refreshFailures map[string]int
+
+	// Cluster-mode shared runtime state; guarded by sharedMu.
+	sharedMu     sync.Mutex
+	sharedState  SharedState
+	sharedOpts   SharedStateOptions
+	sharedSince  time.Time
+	sharedCancel context.CancelFunc
+	// refreshLeader reports whether this replica holds the refresh lease.
+	refreshLeader atomic.Bool
 }
 
 // NewManager constructs a manager with optional custom selector and hook.
@@ -186,6 +202,7 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 		auths:           make(map[string]*Auth),
 		providerOffsets: make(map[string]int),
 		egressMappings:  make(map[string]egressMappingEntry),
+		refreshFailures: make(map[string]int),
 	}
 	// atomic.Value requires non-nil initial value.
 	manager.runtimeConfig.Store(&internalconfig.Config{})
@@ -327,6 +344,10 @@ func (m *Manager) rebuildAPIKeyModelAliasLocked(cfg *internalconfig.Config) {
 				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
 			}
 		default:
+			if entry := resolveHTTPProviderConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+				break
+			}
 			// OpenAI-compat uses config selection from auth.Attributes.
 			providerKey := ""
 			compatName := ""
@@ -469,7 +490,9 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 		return nil, nil
 	}
 	m.mu.Lock()
-	if existing, ok := m.auths[auth.ID]; ok && existing != nil && !auth.indexAssigned && auth.Index == "" {
+	existing, hadExisting := m.auths[auth.ID]
+	newlyDisabled := hadExisting && existing != nil && !existing.Disabled && auth.Disabled
+	if hadExisting && existing != nil && !auth.indexAssigned && auth.Index == "" {
 		auth.Index = existing.Index
 		auth.indexAssigned = existing.indexAssigned
 	}
@@ -478,6 +501,9 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 	m.mu.Unlock()
 	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
 	_ = m.persist(ctx, auth)
+	if newlyDisabled {
+		notifyAuthDisabled(auth)
+	}
 	m.hook.OnAuthUpdated(ctx, auth.Clone())
 	return auth.Clone(), nil
 }
@@ -633,7 +659,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+		resp, errExec := m.callExecute(execCtx, executor, ExecutorCall{Provider: provider, Auth: auth, Request: execReq, Options: opts})
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
@@ -689,7 +715,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+		resp, errExec := m.callCountTokens(execCtx, executor, ExecutorCall{Provider: provider, Auth: auth, Request: execReq, Options: opts})
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
@@ -745,7 +771,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		streamResult, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
+		streamResult, errStream := m.callExecuteStream(execCtx, executor, ExecutorCall{Provider: provider, Auth: auth, Request: execReq, Options: opts})
 		if errStream != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
 				return nil, errCtx
@@ -926,6 +952,10 @@ func (m *Manager) applyAPIKeyModelAlias(auth *Auth, requestedModel string) strin
 	case "vertex":
 		upstreamModel = resolveUpstreamModelForVertexAPIKey(cfg, auth, requestedModel)
 	default:
+		if entry := resolveHTTPProviderConfig(cfg, auth); entry != nil {
+			upstreamModel = resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+			break
+		}
 		upstreamModel = resolveUpstreamModelForOpenAICompatAPIKey(cfg, auth, requestedModel)
 	}
 
@@ -1085,6 +1115,23 @@ func resolveOpenAICompatConfig(cfg *internalconfig.Config, providerKey, compatNa
 	return nil
 }
 
+// resolveHTTPProviderConfig returns the http-providers entry an auth was synthesized from.
+func resolveHTTPProviderConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.HTTPProvider {
+	if cfg == nil || auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	name := strings.TrimSpace(auth.Attributes["http_provider"])
+	if name == "" {
+		return nil
+	}
+	for i := range cfg.HTTPProviders {
+		if strings.EqualFold(cfg.HTTPProviders[i].Name, name) {
+			return &cfg.HTTPProviders[i]
+		}
+	}
+	return nil
+}
+
 func asModelAliasEntries[T interface {
 	GetName() string
 	GetAlias() string
@@ -1228,13 +1275,20 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	suspendReason := ""
 	clearModelQuota := false
 	setModelQuota := false
+	var notifications []notify.Event
+	var shared *RuntimeState
 
 	m.mu.Lock()
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
 		now := time.Now()
+		// Successes are only replicated when they clear a failure state.
+		publish := !result.Success || auth.Unavailable || auth.Quota.Exceeded || auth.LastError != nil
 
 		if result.Success {
 			if result.Model != "" {
+				if state := auth.ModelStates[result.Model]; state != nil && (state.Unavailable || state.Quota.Exceeded || state.LastError != nil) {
+					publish = true
+				}
 				state := ensureModelState(auth, result.Model)
 				resetModelState(state, now)
 				updateAggregatedAvailability(auth, now)
@@ -1252,6 +1306,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 		} else {
 			if result.Model != "" {
 				state := ensureModelState(auth, result.Model)
+				wasUnavailable, wasQuota := state.Unavailable, state.Quota.Exceeded
 				state.Unavailable = true
 				state.Status = StatusError
 				state.UpdatedAt = now
@@ -1315,15 +1370,25 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 				auth.Status = StatusError
 				auth.UpdatedAt = now
 				updateAggregatedAvailability(auth, now)
+				notifications = failureNotifications(auth, result, wasUnavailable, wasQuota)
 			} else {
+				wasUnavailable, wasQuota := auth.Unavailable, auth.Quota.Exceeded
 				applyAuthFailureState(auth, result.Error, result.RetryAfter, now)
+				notifications = failureNotifications(auth, result, wasUnavailable, wasQuota)
 			}
 		}
 
 		_ = m.persist(ctx, auth)
+		if publish {
+			snapshot := runtimeStateFromAuth(auth)
+			shared = &snapshot
+		}
 	}
 	m.mu.Unlock()
 
+	if shared != nil {
+		m.publishRuntimeState(*shared)
+	}
 	if clearModelQuota && result.Model != "" {
 		registry.GetGlobalRegistry().ClearModelQuotaExceeded(result.AuthID, result.Model)
 	}
@@ -1335,6 +1400,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	} else if shouldSuspendModel {
 		registry.GetGlobalRegistry().SuspendClientModel(result.AuthID, result.Model, suspendReason)
 	}
+	publishNotifications(notifications)
 
 	m.hook.OnResult(ctx, result)
 }
@@ -1878,6 +1944,11 @@ func (m *Manager) StopAutoRefresh() {
 
 func (m *Manager) checkRefreshes(ctx context.Context) {
 	// log.Debugf("checking refreshes")
+	// In cluster mode with a shared token store only the lease holder refreshes;
+	// the others pick up the refreshed tokens from the store.
+	if !m.refreshesTokens() {
+		return
+	}
 	now := time.Now()
 	snapshot := m.snapshotAuths()
 	for _, a := range snapshot {
@@ -2152,15 +2223,27 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	log.Debugf("refreshed %s, %s, %v", auth.Provider, auth.ID, err)
 	now := time.Now()
 	if err != nil {
+		failures := 0
 		m.mu.Lock()
 		if current := m.auths[id]; current != nil {
 			current.NextRefreshAfter = now.Add(refreshFailureBackoff)
 			current.LastError = &Error{Message: err.Error()}
 			m.auths[id] = current
+			if m.refreshFailures == nil {
+				m.refreshFailures = make(map[string]int)
+			}
+			m.refreshFailures[id]++
+			failures = m.refreshFailures[id]
 		}
 		m.mu.Unlock()
+		if threshold := notify.RefreshFailureThreshold(); threshold > 0 && failures == threshold {
+			notifyRefreshFailed(auth, failures, err)
+		}
 		return
 	}
+	m.mu.Lock()
+	delete(m.refreshFailures, id)
+	m.mu.Unlock()
 	if updated == nil {
 		updated = cloned
 	}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -200,6 +202,22 @@ func (m *Manager) observeEgress(auth *Auth, provider, model string) {
 			"drift_alerted":         driftCount >= driftThreshold,
 			"state_file":            strings.TrimSpace(stateFile),
 		}).Warn("egress drift detected")
+		if driftCount == driftThreshold {
+			notify.Publish(notify.Event{
+				Type:     notify.EventEgressDrift,
+				Severity: notify.SeverityWarning,
+				Provider: provider,
+				Model:    model,
+				AuthID:   authID,
+				Message:  fmt.Sprintf("egress drift reached alert threshold (%d)", driftThreshold),
+				Fields: map[string]any{
+					"from_proxy_identity":   prevIdentity,
+					"to_proxy_identity":     proxyIdentity,
+					"drift_count":           driftCount,
+					"drift_alert_threshold": driftThreshold,
+				},
+			})
+		}
 	}
 	if persistNeeded {
 		if err := persistEgressMappingState(stateFile, snapshot, now); err != nil {
@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// ExecutorCall describes one executor invocation as seen by executor middlewares.
+type ExecutorCall struct {
+	// Provider is the executor identifier handling the call.
+	Provider string
+	// Auth is the credential selected for the call.
+	Auth *Auth
+	// Request carries the client payload and the upstream model name after alias resolution.
+	Request cliproxyexecutor.Request
+	Options cliproxyexecutor.Options
+}
+
+// Translate returns the request payload translated from the client format into to, the way
+// executors translate it before applying provider-specific changes. UpstreamRequest sees the
+// body as it is finally sent.
+func (c ExecutorCall) Translate(to sdktranslator.Format) []byte {
+	return sdktranslator.TranslateRequest(c.Options.SourceFormat, to, c.Request.Model, c.Request.Payload, c.Options.Stream)
+}
+
+// ExecuteHandler runs a non-streaming executor call.
+type ExecuteHandler func(ctx context.Context, call ExecutorCall) (cliproxyexecutor.Response, error)
+
+// ExecuteStreamHandler runs a streaming executor call.
+type ExecuteStreamHandler func(ctx context.Context, call ExecutorCall) (*cliproxyexecutor.StreamResult, error)
+
+// ExecutorMiddleware wraps executor calls. Each hook receives the call and the next handler
+// in the chain; it may change the call, return without calling next, or change the result.
+// Nil hooks pass calls through.
+type ExecutorMiddleware struct {
+	// Name identifies the middleware in logs.
+	Name string
+	// Providers limits the middleware to these executor identifiers. Empty matches all.
+	Providers []string
+
+	Execute       func(ctx context.Context, call ExecutorCall, next ExecuteHandler) (cliproxyexecutor.Response, error)
+	ExecuteStream func(ctx context.Context, call ExecutorCall, next ExecuteStreamHandler) (*cliproxyexecutor.StreamResult, error)
+	CountTokens   func(ctx context.Context, call ExecutorCall, next ExecuteHandler) (cliproxyexecutor.Response, error)
+	// UpstreamRequest sees every HTTP request the executor sends while serving the call, with
+	// the body exactly as it goes on the wire: translated, with thinking, payload rules and
+	// every provider-specific change applied. It returns the body to send instead. Auxiliary
+	// requests such as token refreshes pass through it too; req.URL tells them apart.
+	// Executors that do not talk HTTP (AI Studio, remote workers) do not call it.
+	UpstreamRequest func(ctx context.Context, call ExecutorCall, req *http.Request, body []byte) ([]byte, error)
+}
+
+func (mw ExecutorMiddleware) appliesTo(provider string) bool {
+	if len(mw.Providers) == 0 {
+		return true
+	}
+	for _, candidate := range mw.Providers {
+		if strings.EqualFold(strings.TrimSpace(candidate), provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// UseExecutorMiddleware appends executor middlewares. Middlewares run in registration order,
+// the first registered being the outermost.
+func (m *Manager) UseExecutorMiddleware(middlewares ...ExecutorMiddleware) {
+	if m == nil || len(middlewares) == 0 {
+		return
+	}
+	m.middlewareMu.Lock()
+	m.middlewares = append(m.middlewares, middlewares...)
+	m.middlewareMu.Unlock()
+}
+
+func (m *Manager) middlewaresFor(provider string) []ExecutorMiddleware {
+	m.middlewareMu.RLock()
+	defer m.middlewareMu.RUnlock()
+	if len(m.middlewares) == 0 {
+		return nil
+	}
+	out := make([]ExecutorMiddleware, 0, len(m.middlewares))
+	for _, mw := range m.middlewares {
+		if mw.appliesTo(provider) {
+			out = append(out, mw)
+		}
+	}
+	return out
+}
+
+// upstreamRequestHookKey carries the UpstreamRequest hooks of a call to the executor.
+type upstreamRequestHookKey struct{}
+
+type upstreamRequestHook func(req *http.Request, body []byte) ([]byte, error)
+
+// withUpstreamRequestHooks installs the UpstreamRequest hooks that apply to call on ctx, bound
+// to the call as the innermost middleware passed it to the executor.
+func (m *Manager) withUpstreamRequestHooks(ctx context.Context, call ExecutorCall) context.Context {
+	var hooks []func(context.Context, ExecutorCall, *http.Request, []byte) ([]byte, error)
+	for _, mw := range m.middlewaresFor(call.Provider) {
+		if mw.UpstreamRequest != nil {
+			hooks = append(hooks, mw.UpstreamRequest)
+		}
+	}
+	if len(hooks) == 0 {
+		return ctx
+	}
+	hook := upstreamRequestHook(func(req *http.Request, body []byte) ([]byte, error) {
+		var err error
+		for _, fn := range hooks {
+			if body, err = fn(req.Context(), call, req, body); err != nil {
+				return nil, err
+			}
+		}
+		return body, nil
+	})
+	return context.WithValue(ctx, upstreamRequestHookKey{}, hook)
+}
+
+// UpstreamRequestRewriter returns a function that runs the UpstreamRequest middleware hooks
+// installed on ctx and returns a copy of the request carrying the body they produced, or nil
+// when ctx carries no hooks. Executors call it from their HTTP transport.
+func UpstreamRequestRewriter(ctx context.Context) func(*http.Request) (*http.Request, error) {
+	if ctx == nil {
+		return nil
+	}
+	hook, ok := ctx.Value(upstreamRequestHookKey{}).(upstreamRequestHook)
+	if !ok {
+		return nil
+	}
+	return func(req *http.Request) (*http.Request, error) {
+		var body []byte
+		if req.Body != nil && req.Body != http.NoBody {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			_ = req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		out := req.Clone(req.Context())
+		rewritten, err := hook(out, body)
+		if err != nil {
+			return nil, err
+		}
+		out.Body = io.NopCloser(bytes.NewReader(rewritten))
+		out.ContentLength = int64(len(rewritten))
+		out.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(rewritten)), nil }
+		return out, nil
+	}
+}
+
+func (m *Manager) callExecute(ctx context.Context, executor ProviderExecutor, call ExecutorCall) (cliproxyexecutor.Response, error) {
+	handler := func(ctx context.Context, c ExecutorCall) (cliproxyexecutor.Response, error) {
+		return executor.Execute(m.withUpstreamRequestHooks(ctx, c), c.Auth, c.Request, c.Options)
+	}
+	middlewares := m.middlewaresFor(call.Provider)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if hook := middlewares[i].Execute; hook != nil {
+			next := handler
+			handler = func(ctx context.Context, c ExecutorCall) (cliproxyexecutor.Response, error) {
+				return hook(ctx, c, next)
+			}
+		}
+	}
+	return handler(ctx, call)
+}
+
+func (m *Manager) callCountTokens(ctx context.Context, executor ProviderExecutor, call ExecutorCall) (cliproxyexecutor.Response, error) {
+	handler := func(ctx context.Context, c ExecutorCall) (cliproxyexecutor.Response, error) {
+		return executor.CountTokens(m.withUpstreamRequestHooks(ctx, c), c.Auth, c.Request, c.Options)
+	}
+	middlewares := m.middlewaresFor(call.Provider)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if hook := middlewares[i].CountTokens; hook != nil {
+			next := handler
+			handler = func(ctx context.Context, c ExecutorCall) (cliproxyexecutor.Response, error) {
+				return hook(ctx, c, next)
+			}
+		}
+	}
+	return handler(ctx, call)
+}
+
+func (m *Manager) callExecuteStream(ctx context.Context, executor ProviderExecutor, call ExecutorCall) (*cliproxyexecutor.StreamResult, error) {
+	handler := func(ctx context.Context, c ExecutorCall) (*cliproxyexecutor.StreamResult, error) {
+		return executor.ExecuteStream(m.withUpstreamRequestHooks(ctx, c), c.Auth, c.Request, c.Options)
+	}
+	middlewares := m.middlewaresFor(call.Provider)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if hook := middlewares[i].ExecuteStream; hook != nil {
+			next := handler
+			handler = func(ctx context.Context, c ExecutorCall) (*cliproxyexecutor.StreamResult, error) {
+				return hook(ctx, c, next)
+			}
+		}
+	}
+	return handler(ctx, call)
+}
+
+// MapStreamChunks returns a stream result whose chunks are fn applied to the chunks of result.
+// fn may drop a chunk by returning false. Stream middlewares use it to inspect or rewrite
+// chunks as they pass.
+func MapStreamChunks(ctx context.Context, result *cliproxyexecutor.StreamResult, fn func(cliproxyexecutor.StreamChunk) (cliproxyexecutor.StreamChunk, bool)) *cliproxyexecutor.StreamResult {
+	if result == nil || result.Chunks == nil || fn == nil {
+		return result
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func(in <-chan cliproxyexecutor.StreamChunk) {
+		defer close(out)
+		for chunk := range in {
+			mapped, keep := fn(chunk)
+			if !keep {
+				continue
+			}
+			select {
+			case out <- mapped:
+			case <-ctx.Done():
+				// Drain so the producer can finish.
+				for range in {
+				}
+				return
+			}
+		}
+	}(result.Chunks)
+	return &cliproxyexecutor.StreamResult{Headers: result.Headers, Chunks: out}
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// echoExecutor answers with the payload it was given and streams it in two chunks.
+type echoExecutor struct{ noopProviderExecutor }
+
+func (echoExecutor) Execute(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: req.Payload}, nil
+}
+
+func (echoExecutor) ExecuteStream(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	ch := make(chan cliproxyexecutor.StreamChunk, 2)
+	ch <- cliproxyexecutor.StreamChunk{Payload: req.Payload}
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte("end")}
+	close(ch)
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func TestExecutorMiddlewareOrderAndScope(t *testing.T) {
+	mgr := NewManager(nil, deterministicSelector{}, nil)
+	mgr.RegisterExecutor(echoExecutor{noopProviderExecutor{identifier: "gemini"}})
+	if _, err := mgr.Register(context.Background(), &Auth{ID: "g1", Provider: "gemini"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	var trace []string
+	tag := func(name string) ExecutorMiddleware {
+		return ExecutorMiddleware{
+			Name: name,
+			Execute: func(ctx context.Context, call ExecutorCall, next ExecuteHandler) (cliproxyexecutor.Response, error) {
+				trace = append(trace, name+":"+call.Provider+":"+call.Auth.ID)
+				call.Request.Payload = append(call.Request.Payload, []byte("+"+name)...)
+				return next(ctx, call)
+			},
+		}
+	}
+	claudeOnly := tag("claude-only")
+	claudeOnly.Providers = []string{"claude"}
+	mgr.UseExecutorMiddleware(tag("outer"), claudeOnly, tag("inner"))
+	mgr.UseExecutorMiddleware(ExecutorMiddleware{
+		Providers: []string{"Gemini"},
+		ExecuteStream: func(ctx context.Context, call ExecutorCall, next ExecuteStreamHandler) (*cliproxyexecutor.StreamResult, error) {
+			result, err := next(ctx, call)
+			if err != nil {
+				return nil, err
+			}
+			return MapStreamChunks(ctx, result, func(chunk cliproxyexecutor.StreamChunk) (cliproxyexecutor.StreamChunk, bool) {
+				if string(chunk.Payload) == "end" {
+					return chunk, false
+				}
+				chunk.Payload = []byte(strings.ToUpper(string(chunk.Payload)))
+				return chunk, true
+			}), nil
+		},
+	})
+
+	resp, err := mgr.Execute(context.Background(), []string{"gemini"}, cliproxyexecutor.Request{Payload: []byte("body")}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if string(resp.Payload) != "body+outer+inner" {
+		t.Fatalf("payload = %q, want body+outer+inner", resp.Payload)
+	}
+	if strings.Join(trace, ",") != "outer:gemini:g1,inner:gemini:g1" {
+		t.Fatalf("trace = %v", trace)
+	}
+
+	stream, err := mgr.ExecuteStream(context.Background(), []string{"gemini"}, cliproxyexecutor.Request{Payload: []byte("chunk")}, cliproxyexecutor.Options{Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	var chunks []string
+	for chunk := range stream.Chunks {
+		chunks = append(chunks, string(chunk.Payload))
+	}
+	if strings.Join(chunks, ",") != "CHUNK" {
+		t.Fatalf("chunks = %v, want [CHUNK]", chunks)
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+// failureNotifications returns the operational events implied by a failed result.
+// Only transitions are reported: a credential already marked unavailable or already
+// over quota does not notify again until it recovers.
+func failureNotifications(auth *Auth, result Result, wasUnavailable, wasQuota bool) []notify.Event {
+	if auth == nil || result.Error == nil {
+		return nil
+	}
+	var events []notify.Event
+	if statusCodeFromResult(result.Error) == 429 && !wasQuota {
+		events = append(events, notify.Event{
+			Type:     notify.EventQuotaExceeded,
+			Severity: notify.SeverityWarning,
+			Provider: auth.Provider,
+			Model:    result.Model,
+			AuthID:   auth.ID,
+			Message:  "quota exceeded",
+			Fields:   authNotificationFields(auth),
+		})
+	}
+	if kind, reason, _, fatal := classifyResultError(result.Error, result.RetryAfter); fatal && !wasUnavailable {
+		fields := authNotificationFields(auth)
+		fields["error_kind"] = string(kind)
+		if reason != "" {
+			fields["reason"] = reason
+		}
+		events = append(events, notify.Event{
+			Type:     notify.EventAuthFatal,
+			Severity: notify.SeverityCritical,
+			Provider: auth.Provider,
+			Model:    result.Model,
+			AuthID:   auth.ID,
+			Message:  fmt.Sprintf("credential failed with fatal error: %s", kind),
+			Fields:   fields,
+		})
+	}
+	return events
+}
+
+// publishNotifications forwards events to the notifier and mirrors quota events
+// onto the usage event stream.
+func publishNotifications(events []notify.Event) {
+	for _, event := range events {
+		if event.Type == notify.EventQuotaExceeded {
+			authFile, _ := event.Fields["auth_file"].(string)
+			usage.PublishQuotaExceeded(event.Provider, event.Model, authFile)
+		}
+		notify.Publish(event)
+	}
+}
+
+func notifyAuthDisabled(auth *Auth) {
+	message := "credential disabled"
+	if status := strings.TrimSpace(auth.StatusMessage); status != "" {
+		message += ": " + status
+	}
+	notify.Publish(notify.Event{
+		Type:     notify.EventAuthDisabled,
+		Severity: notify.SeverityWarning,
+		Provider: auth.Provider,
+		AuthID:   auth.ID,
+		Message:  message,
+		Fields:   authNotificationFields(auth),
+	})
+}
+
+func notifyRefreshFailed(auth *Auth, failures int, err error) {
+	fields := authNotificationFields(auth)
+	fields["consecutive_failures"] = failures
+	notify.Publish(notify.Event{
+		Type:     notify.EventRefreshFailed,
+		Severity: notify.SeverityWarning,
+		Provider: auth.Provider,
+		AuthID:   auth.ID,
+		Message:  fmt.Sprintf("token refresh failed %d times in a row: %v", failures, err),
+		Fields:   fields,
+	})
+}
+
+func authNotificationFields(auth *Auth) map[string]any {
+	fields := map[string]any{}
+	if auth == nil {
+		return fields
+	}
+	if auth.Index != "" {
+		fields["auth_index"] = auth.Index
+	}
+	if auth.Label != "" {
+		fields["label"] = auth.Label
+	}
+	if auth.FileName != "" {
+		fields["auth_file"] = filepath.Base(auth.FileName)
+	}
+	return fields
+}
@@ -0,0 +1,326 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSharedStateSyncInterval = 2 * time.Second
+	defaultRefreshLeaseTTL         = 30 * time.Second
+	sharedStatePublishTimeout      = 5 * time.Second
+	// sharedStateLookback re-reads a short window on every poll so states written by
+	// replicas with slightly skewed clocks are not skipped. Applying a state twice is a no-op.
+	sharedStateLookback = 10 * time.Second
+
+	// RefreshLeaseName is the lease that elects the replica allowed to refresh OAuth tokens.
+	RefreshLeaseName = "auth-refresh"
+)
+
+// SharedState replicates credential runtime state between proxy replicas so that
+// cooldowns and quota backoff observed by one replica apply to all of them.
+type SharedState interface {
+	// PublishRuntimeState stores the latest runtime state of one auth.
+	PublishRuntimeState(ctx context.Context, state RuntimeState) error
+	// FetchRuntimeStates returns states published after since, in any order.
+	FetchRuntimeStates(ctx context.Context, since time.Time) ([]RuntimeState, error)
+	// AcquireLease takes or renews the named lease for holder and reports whether holder owns it.
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+}
+
+// SharedStore is implemented by token stores whose writes reach every replica, such as the
+// PostgreSQL store. Only with such a store can replicas leave token refresh to the leader.
+type SharedStore interface {
+	SharedAcrossReplicas() bool
+}
+
+// SharedStateOptions tunes cluster-mode synchronisation.
+type SharedStateOptions struct {
+	// NodeID identifies this replica. Defaults to hostname-pid.
+	NodeID string
+	// SyncInterval is how often remote states are pulled and the refresh lease renewed.
+	SyncInterval time.Duration
+	// LeaseTTL is how long the refresh leader keeps leadership without renewing.
+	LeaseTTL time.Duration
+}
+
+// RuntimeState is the replicated portion of an auth's runtime state.
+type RuntimeState struct {
+	AuthID         string                 `json:"auth_id"`
+	NodeID         string                 `json:"node_id"`
+	Status         Status                 `json:"status"`
+	StatusMessage  string                 `json:"status_message,omitempty"`
+	Unavailable    bool                   `json:"unavailable"`
+	NextRetryAfter time.Time              `json:"next_retry_after"`
+	Quota          QuotaState             `json:"quota"`
+	LastError      *Error                 `json:"last_error,omitempty"`
+	ModelStates    map[string]*ModelState `json:"model_states,omitempty"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+// DefaultNodeID returns a replica identifier derived from the hostname and process ID.
+func DefaultNodeID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "node"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// SetSharedState enables cluster mode. Runtime state transitions from MarkResult are
+// published to state and remote transitions are applied once StartSharedStateSync runs.
+// Passing nil disables cluster mode and makes this replica refresh tokens on its own again.
+func (m *Manager) SetSharedState(state SharedState, opts SharedStateOptions) {
+	if m == nil {
+		return
+	}
+	if opts.NodeID == "" {
+		opts.NodeID = DefaultNodeID()
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = defaultSharedStateSyncInterval
+	}
+	if opts.LeaseTTL <= 0 {
+		opts.LeaseTTL = defaultRefreshLeaseTTL
+	}
+	m.sharedMu.Lock()
+	m.sharedState = state
+	m.sharedOpts = opts
+	m.sharedSince = time.Time{}
+	m.sharedMu.Unlock()
+	m.refreshLeader.Store(false)
+}
+
+func (m *Manager) sharedStateSnapshot() (SharedState, SharedStateOptions) {
+	m.sharedMu.Lock()
+	defer m.sharedMu.Unlock()
+	return m.sharedState, m.sharedOpts
+}
+
+// IsRefreshLeader reports whether this replica refreshes OAuth tokens. Without shared
+// state every replica is its own leader.
+func (m *Manager) IsRefreshLeader() bool {
+	if m == nil {
+		return false
+	}
+	if state, _ := m.sharedStateSnapshot(); state == nil {
+		return true
+	}
+	return m.refreshLeader.Load()
+}
+
+// refreshesTokens reports whether this replica runs the refresh loop. Followers skip it only
+// when the leader's refreshed tokens reach them through a shared token store; with per-node
+// stores every replica refreshes its own copies.
+func (m *Manager) refreshesTokens() bool {
+	return m.IsRefreshLeader() || !m.TokenStoreShared()
+}
+
+// TokenStoreShared reports whether the token store reaches every replica.
+func (m *Manager) TokenStoreShared() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	shared, ok := m.store.(SharedStore)
+	return ok && shared.SharedAcrossReplicas()
+}
+
+// StartSharedStateSync launches the loop that pulls remote runtime states and renews
+// the refresh lease. Starting a new loop cancels the previous one.
+func (m *Manager) StartSharedStateSync(parent context.Context) {
+	state, opts := m.sharedStateSnapshot()
+	if state == nil {
+		return
+	}
+	m.StopSharedStateSync()
+	ctx, cancel := context.WithCancel(parent)
+	m.sharedMu.Lock()
+	m.sharedCancel = cancel
+	m.sharedMu.Unlock()
+	go func() {
+		ticker := time.NewTicker(opts.SyncInterval)
+		defer ticker.Stop()
+		m.syncSharedState(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.syncSharedState(ctx)
+			}
+		}
+	}()
+}
+
+// StopSharedStateSync cancels the shared state loop, if running, and releases the
+// refresh lease when the backend supports it so another replica takes over at once.
+func (m *Manager) StopSharedStateSync() {
+	m.sharedMu.Lock()
+	cancel := m.sharedCancel
+	m.sharedCancel = nil
+	state, opts := m.sharedState, m.sharedOpts
+	m.sharedMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if !m.refreshLeader.Swap(false) {
+		return
+	}
+	if releaser, ok := state.(interface {
+		ReleaseLease(ctx context.Context, name, holder string) error
+	}); ok {
+		ctx, cancelRelease := context.WithTimeout(context.Background(), sharedStatePublishTimeout)
+		defer cancelRelease()
+		if err := releaser.ReleaseLease(ctx, RefreshLeaseName, opts.NodeID); err != nil {
+			log.Warnf("cluster: release refresh lease: %v", err)
+		}
+	}
+}
+
+func (m *Manager) syncSharedState(ctx context.Context) {
+	state, opts := m.sharedStateSnapshot()
+	if state == nil {
+		return
+	}
+	leader, err := state.AcquireLease(ctx, RefreshLeaseName, opts.NodeID, opts.LeaseTTL)
+	if err != nil {
+		log.Warnf("cluster: renew refresh lease: %v", err)
+		leader = false
+	}
+	if previous := m.refreshLeader.Swap(leader); previous != leader {
+		log.Infof("cluster: node %s refresh leadership: %v", opts.NodeID, leader)
+	}
+
+	m.sharedMu.Lock()
+	since := m.sharedSince
+	m.sharedMu.Unlock()
+	polledAt := time.Now()
+	states, err := state.FetchRuntimeStates(ctx, since)
+	if err != nil {
+		log.Warnf("cluster: fetch runtime states: %v", err)
+		return
+	}
+	m.sharedMu.Lock()
+	m.sharedSince = polledAt.Add(-sharedStateLookback)
+	m.sharedMu.Unlock()
+	for i := range states {
+		if states[i].NodeID == opts.NodeID {
+			continue
+		}
+		m.ApplyRuntimeState(states[i])
+	}
+}
+
+// publishRuntimeState sends the auth's runtime state to the shared backend in the background.
+func (m *Manager) publishRuntimeState(snapshot RuntimeState) {
+	state, opts := m.sharedStateSnapshot()
+	if state == nil {
+		return
+	}
+	snapshot.NodeID = opts.NodeID
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), sharedStatePublishTimeout)
+		defer cancel()
+		if err := state.PublishRuntimeState(ctx, snapshot); err != nil {
+			log.Warnf("cluster: publish runtime state for %s: %v", snapshot.AuthID, err)
+		}
+	}()
+}
+
+func runtimeStateFromAuth(auth *Auth) RuntimeState {
+	return RuntimeState{
+		AuthID:         auth.ID,
+		Status:         auth.Status,
+		StatusMessage:  auth.StatusMessage,
+		Unavailable:    auth.Unavailable,
+		NextRetryAfter: auth.NextRetryAfter,
+		Quota:          auth.Quota,
+		LastError:      cloneError(auth.LastError),
+		ModelStates:    cloneModelStates(auth.ModelStates),
+		UpdatedAt:      auth.UpdatedAt,
+	}
+}
+
+// ApplyRuntimeState merges a runtime state published by another replica. Newer model
+// states replace local ones, and auth-level fields are taken when the remote state is
+// newer than the local auth. Applied states are not persisted again.
+func (m *Manager) ApplyRuntimeState(remote RuntimeState) bool {
+	if m == nil || remote.AuthID == "" {
+		return false
+	}
+	type modelChange struct {
+		model   string
+		blocked bool
+		quota   bool
+		reason  string
+	}
+	var changes []modelChange
+	applied := false
+
+	m.mu.Lock()
+	auth, ok := m.auths[remote.AuthID]
+	if !ok || auth == nil || auth.Disabled {
+		m.mu.Unlock()
+		return false
+	}
+	now := time.Now()
+	for model, state := range remote.ModelStates {
+		if state == nil {
+			continue
+		}
+		local := auth.ModelStates[model]
+		if local != nil && !state.UpdatedAt.After(local.UpdatedAt) {
+			continue
+		}
+		if auth.ModelStates == nil {
+			auth.ModelStates = make(map[string]*ModelState)
+		}
+		auth.ModelStates[model] = state.Clone()
+		applied = true
+		blocked := state.Status == StatusDisabled || (state.Unavailable && state.NextRetryAfter.After(now))
+		reason := ""
+		if blocked {
+			reason = state.StatusMessage
+			if state.Quota.Exceeded {
+				reason = "quota"
+			}
+		}
+		changes = append(changes, modelChange{model: model, blocked: blocked, quota: state.Quota.Exceeded, reason: reason})
+	}
+	if remote.UpdatedAt.After(auth.UpdatedAt) {
+		auth.Status = remote.Status
+		auth.StatusMessage = remote.StatusMessage
+		auth.Unavailable = remote.Unavailable
+		auth.NextRetryAfter = remote.NextRetryAfter
+		auth.Quota = remote.Quota
+		auth.LastError = cloneError(remote.LastError)
+		auth.UpdatedAt = remote.UpdatedAt
+		applied = true
+	}
+	if applied {
+		updateAggregatedAvailability(auth, now)
+	}
+	m.mu.Unlock()
+
+	reg := registry.GetGlobalRegistry()
+	for _, change := range changes {
+		if change.quota {
+			reg.SetModelQuotaExceeded(remote.AuthID, change.model)
+		} else {
+			reg.ClearModelQuotaExceeded(remote.AuthID, change.model)
+		}
+		if change.blocked {
+			reg.SuspendClientModel(remote.AuthID, change.model, change.reason)
+		} else {
+			reg.ResumeClientModel(remote.AuthID, change.model)
+		}
+	}
+	return applied
+}
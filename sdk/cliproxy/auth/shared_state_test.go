@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// followerState never grants the refresh lease.
+type followerState struct{}
+
+func (followerState) PublishRuntimeState(context.Context, RuntimeState) error { return nil }
+
+func (followerState) FetchRuntimeStates(context.Context, time.Time) ([]RuntimeState, error) {
+	return nil, nil
+}
+
+func (followerState) AcquireLease(context.Context, string, string, time.Duration) (bool, error) {
+	return false, nil
+}
+
+type sharedCountingStore struct {
+	countingStore
+}
+
+func (*sharedCountingStore) SharedAcrossReplicas() bool { return true }
+
+func TestFollowersRefreshUnlessTokenStoreIsShared(t *testing.T) {
+	local := NewManager(&countingStore{}, nil, nil)
+	local.SetSharedState(followerState{}, SharedStateOptions{NodeID: "follower"})
+	local.syncSharedState(context.Background())
+	if local.IsRefreshLeader() {
+		t.Fatal("follower reports refresh leadership")
+	}
+	if !local.refreshesTokens() {
+		t.Fatal("follower with a per-node token store does not refresh")
+	}
+
+	shared := NewManager(&sharedCountingStore{}, nil, nil)
+	shared.SetSharedState(followerState{}, SharedStateOptions{NodeID: "follower"})
+	shared.syncSharedState(context.Background())
+	if shared.refreshesTokens() {
+		t.Fatal("follower with a shared token store refreshes")
+	}
+	shared.SetSharedState(nil, SharedStateOptions{})
+	if !shared.refreshesTokens() {
+		t.Fatal("standalone replica does not refresh")
+	}
+}
@@ -48,6 +48,9 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// executorMiddlewares wrap provider executor calls.
+	executorMiddlewares []coreauth.ExecutorMiddleware
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -144,6 +147,13 @@ func (b *Builder) WithServerOptions(opts ...api.ServerOption) *Builder {
 	return b
 }
 
+// WithExecutorMiddleware registers middlewares wrapping provider executor calls. They run in
+// registration order and can be scoped to providers through ExecutorMiddleware.Providers.
+func (b *Builder) WithExecutorMiddleware(mw ...coreauth.ExecutorMiddleware) *Builder {
+	b.executorMiddlewares = append(b.executorMiddlewares, mw...)
+	return b
+}
+
 // WithLocalManagementPassword configures a password that is only accepted from localhost management requests.
 func (b *Builder) WithLocalManagementPassword(password string) *Builder {
 	if password == "" {
@@ -215,6 +225,7 @@ func (b *Builder) Build() (*Service, error) {
 	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
 	coreManager.SetConfig(b.cfg)
 	coreManager.SetOAuthModelAlias(b.cfg.OAuthModelAlias)
+	coreManager.UseExecutorMiddleware(b.executorMiddlewares...)
 
 	service := &Service{
 		cfg:            b.cfg,
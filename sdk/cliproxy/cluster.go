@@ -0,0 +1,53 @@
+package cliproxy
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cluster"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// startCluster connects the core auth manager to the shared runtime state backend
+// when cluster mode is enabled. Failures are logged and the replica runs standalone.
+func (s *Service) startCluster(ctx context.Context) {
+	if s == nil || s.coreManager == nil || s.cfg == nil || !s.cfg.Cluster.Enabled {
+		return
+	}
+	clusterCfg := s.cfg.Cluster
+	openCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	state, err := cluster.Open(openCtx, clusterCfg, filepath.Dir(s.configPath))
+	cancel()
+	if err != nil {
+		log.Errorf("cluster mode disabled: %v", err)
+		return
+	}
+	s.clusterState = state
+	s.coreManager.SetSharedState(state, coreauth.SharedStateOptions{
+		NodeID:       clusterCfg.NodeID,
+		SyncInterval: time.Duration(clusterCfg.SyncIntervalSeconds) * time.Second,
+		LeaseTTL:     time.Duration(clusterCfg.LeaseSeconds) * time.Second,
+	})
+	s.coreManager.StartSharedStateSync(context.Background())
+	log.Infof("cluster mode enabled (driver=%s)", clusterCfg.Driver)
+	if !s.coreManager.TokenStoreShared() {
+		log.Warn("cluster mode: the token store is not shared between replicas, so every replica refreshes its own OAuth tokens; use PGSTORE_DSN to refresh on the leader only")
+	}
+}
+
+// stopCluster stops state sync, hands refresh leadership back and closes the backend.
+func (s *Service) stopCluster() {
+	if s == nil || s.clusterState == nil {
+		return
+	}
+	if s.coreManager != nil {
+		s.coreManager.StopSharedStateSync()
+		s.coreManager.SetSharedState(nil, coreauth.SharedStateOptions{})
+	}
+	if err := s.clusterState.Close(); err != nil {
+		log.Errorf("failed to close cluster state: %v", err)
+	}
+	s.clusterState = nil
+}
@@ -0,0 +1,395 @@
+package cliproxy
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	remoteWorkerPath        = "/v1/ws/worker"
+	remoteWorkerNameHeader  = "X-Worker-Name"
+	remoteWorkerTokenHeader = "X-Worker-Token"
+	// remoteWorkerSyncInterval is how often a worker re-checks its auths and re-registers
+	// when they changed.
+	remoteWorkerSyncInterval = 30 * time.Second
+)
+
+// remoteWorkerSet tracks the auths registered by each connected worker on the conductor.
+type remoteWorkerSet struct {
+	mu sync.Mutex
+	// auths maps worker name -> conductor auth ID -> models served by that auth.
+	auths map[string]map[string][]*ModelInfo
+}
+
+func (r *remoteWorkerSet) replace(worker string, auths map[string][]*ModelInfo) (removed []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.auths == nil {
+		r.auths = make(map[string]map[string][]*ModelInfo)
+	}
+	for id := range r.auths[worker] {
+		if _, ok := auths[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	if len(auths) == 0 {
+		delete(r.auths, worker)
+	} else {
+		r.auths[worker] = auths
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+func (r *remoteWorkerSet) models(authID string) []*ModelInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, auths := range r.auths {
+		if models, ok := auths[authID]; ok {
+			return models
+		}
+	}
+	return nil
+}
+
+// remoteWorkerOffer records the auths a worker last registered with its conductor, so the
+// worker only executes requests for credentials it actually offered.
+type remoteWorkerOffer struct {
+	mu sync.Mutex
+	// auths maps local auth ID -> provider.
+	auths map[string]string
+}
+
+func (o *remoteWorkerOffer) set(registration wsrelay.WorkerRegistration) {
+	auths := make(map[string]string, len(registration.Auths))
+	for _, auth := range registration.Auths {
+		auths[auth.ID] = auth.Provider
+	}
+	o.mu.Lock()
+	o.auths = auths
+	o.mu.Unlock()
+}
+
+func (o *remoteWorkerOffer) allows(provider, authID string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	offered, ok := o.auths[authID]
+	return ok && offered == strings.ToLower(strings.TrimSpace(provider))
+}
+
+func remoteAuthID(worker, authID string) string {
+	return "remote/" + worker + "/" + authID
+}
+
+// startRemoteWorkerGateway serves the worker endpoint when this process accepts workers.
+func (s *Service) startRemoteWorkerGateway() {
+	if s == nil || s.cfg == nil || !s.cfg.RemoteWorkers.Accept || s.workerGateway != nil {
+		return
+	}
+	if s.cfg.RemoteWorkers.Token == "" {
+		log.Error("remote workers disabled: remote-workers.token is required")
+		return
+	}
+	token := s.cfg.RemoteWorkers.Token
+	s.workerGateway = wsrelay.NewManager(wsrelay.Options{
+		Path: remoteWorkerPath,
+		ProviderFactory: func(r *http.Request, bound string) (string, error) {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get(remoteWorkerTokenHeader)), []byte(token)) != 1 {
+				return "", errors.New("remote worker: invalid token")
+			}
+			name := strings.ToLower(strings.TrimSpace(r.Header.Get(remoteWorkerNameHeader)))
+			// A join key or ticket bound to a name decides the worker name.
+			if bound != "" {
+				if name != "" && name != bound {
+					return "", errors.New("remote worker: worker name does not match the join credential")
+				}
+				name = bound
+			}
+			if name == "" || strings.Contains(name, "/") {
+				return "", errors.New("remote worker: missing or invalid worker name")
+			}
+			return name, nil
+		},
+		// Registrations are keyed by worker name, so two workers must never share one.
+		ExclusiveProviders: true,
+		OnConnected:        func(worker string) { log.Infof("remote worker connected: %s", worker) },
+		OnDisconnected:     s.remoteWorkerDisconnected,
+		OnMessage:          s.remoteWorkerMessage,
+		ResumeGrace:        relayResumeGrace(s.cfg),
+		LogDebugf:          log.Debugf,
+		LogInfof:           log.Infof,
+		LogWarnf:           log.Warnf,
+	})
+	if s.server != nil {
+		s.server.AttachWebsocketRoute(s.workerGateway.Path(), s.workerGateway.Handler())
+	}
+	log.Infof("remote workers accepted on %s", remoteWorkerPath)
+}
+
+func (s *Service) remoteWorkerMessage(worker string, msg wsrelay.Message) {
+	if msg.Type != wsrelay.MessageTypeRegister {
+		log.Debugf("remote worker %s: ignoring %s message", worker, msg.Type)
+		return
+	}
+	var registration wsrelay.WorkerRegistration
+	if err := wsrelay.DecodePayload(msg.Payload, &registration); err != nil {
+		log.Warnf("remote worker %s: invalid registration: %v", worker, err)
+		return
+	}
+	s.applyRemoteWorkerRegistration(worker, registration)
+}
+
+// applyRemoteWorkerRegistration mirrors the worker's auths as runtime-only auths of the
+// remote-worker provider and drops the ones the worker no longer offers.
+func (s *Service) applyRemoteWorkerRegistration(worker string, registration wsrelay.WorkerRegistration) {
+	now := time.Now().UTC()
+	models := make(map[string][]*ModelInfo, len(registration.Auths))
+	auths := make([]*coreauth.Auth, 0, len(registration.Auths))
+	for _, remote := range registration.Auths {
+		remoteID := strings.TrimSpace(remote.ID)
+		provider := strings.ToLower(strings.TrimSpace(remote.Provider))
+		if remoteID == "" || provider == "" || len(remote.Models) == 0 {
+			continue
+		}
+		id := remoteAuthID(worker, remoteID)
+		label := strings.TrimSpace(remote.Label)
+		if label == "" {
+			label = remoteID
+		}
+		models[id] = remote.Models
+		auths = append(auths, &coreauth.Auth{
+			ID:        id,
+			Provider:  executor.RemoteWorkerProvider,
+			Label:     label + "@" + worker,
+			Status:    coreauth.StatusActive,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Attributes: map[string]string{
+				"runtime_only":                   "true",
+				executor.RemoteWorkerAttribute:   worker,
+				executor.RemoteProviderAttribute: provider,
+				executor.RemoteAuthIDAttribute:   remoteID,
+			},
+			Metadata: map[string]any{"email": label + "@" + worker},
+		})
+	}
+	removed := s.remoteWorkers.replace(worker, models)
+	ctx := context.Background()
+	for _, auth := range auths {
+		s.emitAuthUpdate(ctx, watcher.AuthUpdate{Action: watcher.AuthUpdateActionAdd, ID: auth.ID, Auth: auth})
+	}
+	for _, id := range removed {
+		s.emitAuthUpdate(ctx, watcher.AuthUpdate{Action: watcher.AuthUpdateActionDelete, ID: id})
+	}
+	log.Infof("remote worker %s registered %d auth(s)", worker, len(auths))
+}
+
+func (s *Service) remoteWorkerDisconnected(worker string, reason error) {
+	if worker == "" {
+		return
+	}
+	log.Warnf("remote worker disconnected: %s (%v)", worker, reason)
+	ctx := context.Background()
+	for _, id := range s.remoteWorkers.replace(worker, nil) {
+		s.emitAuthUpdate(ctx, watcher.AuthUpdate{Action: watcher.AuthUpdateActionDelete, ID: id})
+	}
+}
+
+// startRemoteWorkerClient connects this process to a conductor when it runs as a worker.
+func (s *Service) startRemoteWorkerClient(ctx context.Context) {
+	if s == nil || s.cfg == nil || s.cfg.RemoteWorkers.ConductorURL == "" || s.coreManager == nil {
+		return
+	}
+	rw := s.cfg.RemoteWorkers
+	name := rw.Name
+	if name == "" {
+		host, _ := os.Hostname()
+		name = strings.ToLower(strings.TrimSpace(host))
+	}
+	header := http.Header{}
+	header.Set(remoteWorkerNameHeader, name)
+	header.Set(remoteWorkerTokenHeader, rw.Token)
+	if rw.APIKey != "" {
+		header.Set("Authorization", "Bearer "+rw.APIKey)
+	}
+
+	var (
+		lastMu   sync.Mutex
+		lastSent []byte
+	)
+	register := func(send func(wsrelay.Message) error, force bool) error {
+		registration := s.remoteWorkerRegistration(rw.Providers)
+		payload, err := wsrelay.EncodePayload(registration)
+		if err != nil {
+			return err
+		}
+		raw, _ := json.Marshal(registration)
+		lastMu.Lock()
+		defer lastMu.Unlock()
+		if !force && string(raw) == string(lastSent) {
+			return nil
+		}
+		if err = send(wsrelay.Message{ID: fmt.Sprintf("register-%d", time.Now().UnixNano()), Type: wsrelay.MessageTypeRegister, Payload: payload}); err != nil {
+			return err
+		}
+		lastSent = raw
+		s.workerOffer.set(registration)
+		log.Infof("remote worker %s offered %d auth(s) to the conductor", name, len(registration.Auths))
+		return nil
+	}
+
+	client := wsrelay.NewClient(wsrelay.ClientOptions{
+		URL:         rw.ConductorURL,
+		Header:      header,
+		Handler:     s.serveRemoteExecute,
+		OnConnected: func(send func(wsrelay.Message) error) error { return register(send, true) },
+		LogDebugf:   log.Debugf,
+		LogInfof:    log.Infof,
+		LogWarnf:    log.Warnf,
+	})
+	clientCtx, cancel := context.WithCancel(ctx)
+	s.workerClientCancel = cancel
+	go client.Run(clientCtx)
+	go func() {
+		ticker := time.NewTicker(remoteWorkerSyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-clientCtx.Done():
+				return
+			case <-ticker.C:
+				if err := register(client.Send, false); err != nil {
+					log.Debugf("remote worker registration skipped: %v", err)
+				}
+			}
+		}
+	}()
+	log.Infof("remote worker %s connecting to %s", name, rw.ConductorURL)
+}
+
+// remoteWorkerRegistration lists the local auths and models offered to the conductor.
+func (s *Service) remoteWorkerRegistration(providers []string) wsrelay.WorkerRegistration {
+	allowed := make(map[string]struct{}, len(providers))
+	for _, provider := range providers {
+		allowed[provider] = struct{}{}
+	}
+	registration := wsrelay.WorkerRegistration{Auths: []wsrelay.WorkerAuth{}}
+	for _, auth := range s.coreManager.List() {
+		if auth == nil || auth.Disabled {
+			continue
+		}
+		provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+		if provider == "" || provider == executor.RemoteWorkerProvider {
+			continue
+		}
+		if _, ok := allowed[provider]; len(allowed) > 0 && !ok {
+			continue
+		}
+		models := registry.GetGlobalRegistry().GetModelsForClient(auth.ID)
+		if len(models) == 0 {
+			continue
+		}
+		registration.Auths = append(registration.Auths, wsrelay.WorkerAuth{
+			ID:       auth.ID,
+			Provider: provider,
+			Label:    auth.Label,
+			Models:   models,
+		})
+	}
+	sort.Slice(registration.Auths, func(i, j int) bool { return registration.Auths[i].ID < registration.Auths[j].ID })
+	return registration
+}
+
+// serveRemoteExecute runs a request from the conductor with the requested local auth.
+func (s *Service) serveRemoteExecute(ctx context.Context, msg wsrelay.Message, reply func(wsrelay.Message) error) {
+	fail := func(err error) {
+		_ = reply(wsrelay.Message{Type: wsrelay.MessageTypeError, Payload: wsrelay.ErrorPayload(err)})
+	}
+	if msg.Type != wsrelay.MessageTypeExecute {
+		fail(&coreauth.Error{Code: "unsupported", Message: "remote worker only serves execute requests", HTTPStatus: http.StatusNotImplemented})
+		return
+	}
+	var remoteReq wsrelay.ExecuteRequest
+	if err := wsrelay.DecodePayload(msg.Payload, &remoteReq); err != nil {
+		fail(&coreauth.Error{Code: "invalid_request", Message: err.Error(), HTTPStatus: http.StatusBadRequest})
+		return
+	}
+	if !s.workerOffer.allows(remoteReq.Provider, remoteReq.AuthID) {
+		fail(&coreauth.Error{Code: "forbidden", Message: "remote worker did not offer the requested auth", HTTPStatus: http.StatusForbidden})
+		return
+	}
+	req, opts := remoteReq.Executor()
+	if opts.Metadata == nil {
+		opts.Metadata = make(map[string]any)
+	}
+	opts.Metadata[cliproxyexecutor.PinnedAuthMetadataKey] = remoteReq.AuthID
+	providers := []string{remoteReq.Provider}
+
+	switch {
+	case remoteReq.CountTokens:
+		resp, err := s.coreManager.ExecuteCount(ctx, providers, req, opts)
+		if err != nil {
+			fail(err)
+			return
+		}
+		_ = reply(wsrelay.Message{Type: wsrelay.MessageTypeHTTPResp, Payload: wsrelay.ResponsePayload(http.StatusOK, resp.Headers, resp.Payload)})
+	case remoteReq.Stream:
+		result, err := s.coreManager.ExecuteStream(ctx, providers, req, opts)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err = reply(wsrelay.Message{Type: wsrelay.MessageTypeStreamStart, Payload: wsrelay.ResponsePayload(http.StatusOK, result.Headers, nil)}); err != nil {
+			return
+		}
+		for chunk := range result.Chunks {
+			if chunk.Err != nil {
+				fail(chunk.Err)
+				return
+			}
+			if err = reply(wsrelay.Message{Type: wsrelay.MessageTypeStreamChunk, Payload: wsrelay.ChunkPayload(chunk.Payload)}); err != nil {
+				return
+			}
+		}
+		_ = reply(wsrelay.Message{Type: wsrelay.MessageTypeStreamEnd})
+	default:
+		resp, err := s.coreManager.Execute(ctx, providers, req, opts)
+		if err != nil {
+			fail(err)
+			return
+		}
+		_ = reply(wsrelay.Message{Type: wsrelay.MessageTypeHTTPResp, Payload: wsrelay.ResponsePayload(http.StatusOK, resp.Headers, resp.Payload)})
+	}
+}
+
+// stopRemoteWorkers disconnects from the conductor and closes worker sessions.
+func (s *Service) stopRemoteWorkers(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	if s.workerClientCancel != nil {
+		s.workerClientCancel()
+		s.workerClientCancel = nil
+	}
+	if s.workerGateway != nil {
+		return s.workerGateway.Stop(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cliproxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestRemoteWorkerRegistrationMirrorsAuths(t *testing.T) {
+	service := &Service{
+		cfg:           &config.Config{},
+		coreManager:   coreauth.NewManager(nil, nil, nil),
+		workerGateway: wsrelay.NewManager(wsrelay.Options{Path: remoteWorkerPath}),
+	}
+	models := []*ModelInfo{{ID: "remote-test-model", Object: "model", OwnedBy: "anthropic", Type: "claude"}}
+	service.applyRemoteWorkerRegistration("edge", wsrelay.WorkerRegistration{Auths: []wsrelay.WorkerAuth{
+		{ID: "claude-a.json", Provider: "Claude", Label: "a@example.com", Models: models},
+		{ID: "claude-b.json", Provider: "claude", Models: models},
+		{ID: "no-models.json", Provider: "claude"},
+	}})
+	t.Cleanup(func() { service.remoteWorkerDisconnected("edge", errors.New("test done")) })
+
+	auth, ok := service.coreManager.GetByID("remote/edge/claude-a.json")
+	if !ok {
+		t.Fatal("remote auth was not registered")
+	}
+	if auth.Provider != executor.RemoteWorkerProvider || auth.Attributes[executor.RemoteProviderAttribute] != "claude" || auth.Attributes[executor.RemoteAuthIDAttribute] != "claude-a.json" {
+		t.Fatalf("remote auth = %+v", auth)
+	}
+	if _, ok = service.coreManager.Executor(executor.RemoteWorkerProvider); !ok {
+		t.Fatal("remote worker executor was not bound")
+	}
+	if !registry.GetGlobalRegistry().ClientSupportsModel(auth.ID, "remote-test-model") {
+		t.Fatal("remote auth models were not registered")
+	}
+	if _, ok = service.coreManager.GetByID("remote/edge/no-models.json"); ok {
+		t.Fatal("auth without models should not be mirrored")
+	}
+
+	service.applyRemoteWorkerRegistration("edge", wsrelay.WorkerRegistration{Auths: []wsrelay.WorkerAuth{
+		{ID: "claude-a.json", Provider: "claude", Models: models},
+	}})
+	if current, ok := service.coreManager.GetByID("remote/edge/claude-b.json"); ok && !current.Disabled {
+		t.Fatal("auth dropped by the worker should be removed")
+	}
+
+	service.remoteWorkerDisconnected("edge", errors.New("connection lost"))
+	if current, ok := service.coreManager.GetByID("remote/edge/claude-a.json"); ok && !current.Disabled {
+		t.Fatal("auths should be removed when the worker disconnects")
+	}
+}
+
+func TestServeRemoteExecuteRejectsAuthsNotOffered(t *testing.T) {
+	service := &Service{cfg: &config.Config{}, coreManager: coreauth.NewManager(nil, nil, nil)}
+	service.workerOffer.set(wsrelay.WorkerRegistration{Auths: []wsrelay.WorkerAuth{{ID: "claude-a.json", Provider: "claude"}}})
+
+	for _, tc := range []struct{ provider, authID string }{
+		{"claude", "claude-b.json"},
+		{"codex", "claude-a.json"},
+	} {
+		payload, err := wsrelay.EncodePayload(wsrelay.ExecuteRequest{Provider: tc.provider, AuthID: tc.authID, Model: "m"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var replies []wsrelay.Message
+		service.serveRemoteExecute(context.Background(), wsrelay.Message{Type: wsrelay.MessageTypeExecute, Payload: payload}, func(msg wsrelay.Message) error {
+			replies = append(replies, msg)
+			return nil
+		})
+		if len(replies) != 1 || replies[0].Type != wsrelay.MessageTypeError || replies[0].Payload["status"] != http.StatusForbidden {
+			t.Fatalf("%s/%s replies = %+v, want a single 403 error", tc.provider, tc.authID, replies)
+		}
+	}
+}
@@ -8,14 +8,18 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	managementHandlers "github.com/router-for-me/CLIProxyAPI/v6/internal/api/handlers/management"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cluster"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
-	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -89,6 +93,24 @@ type Service struct {
 
 	// wsGateway manages websocket Gemini providers.
 	wsGateway *wsrelay.Manager
+
+	// clusterState shares credential runtime state with other replicas in cluster mode.
+	clusterState *cluster.SQLState
+
+	// signatureSnapshotStop stops the file backend of the signature cache.
+	signatureSnapshotStop func()
+
+	// workerGateway accepts remote worker connections when this process is a conductor.
+	workerGateway *wsrelay.Manager
+
+	// remoteWorkers tracks the auths registered by connected remote workers.
+	remoteWorkers remoteWorkerSet
+
+	// workerClientCancel stops the conductor connection when this process is a remote worker.
+	workerClientCancel context.CancelFunc
+
+	// workerOffer holds the auths this process last offered to its conductor as a remote worker.
+	workerOffer remoteWorkerOffer
 }
 
 // RegisterUsagePlugin registers a usage plugin on the global usage manager.
@@ -212,6 +234,8 @@ func (s *Service) ensureWebsocketGateway() {
 		Path:           "/v1/ws",
 		OnConnected:    s.wsOnConnected,
 		OnDisconnected: s.wsOnDisconnected,
+		ResumeGrace:    relayResumeGrace(s.cfg),
+		Auth:           relayAuth(s.cfg),
 		LogDebugf:      log.Debugf,
 		LogInfof:       log.Infof,
 		LogWarnf:       log.Warnf,
@@ -219,6 +243,48 @@ func (s *Service) ensureWebsocketGateway() {
 	s.wsGateway = wsrelay.NewManager(opts)
 }
 
+// relayResumeGrace is the resume window configured for websocket relay sessions.
+func relayResumeGrace(cfg *config.Config) time.Duration {
+	if cfg == nil || cfg.WebsocketRelay.ResumeGraceSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.WebsocketRelay.ResumeGraceSeconds) * time.Second
+}
+
+// relayAuth builds the admission rules of the /v1/ws relay.
+func relayAuth(cfg *config.Config) wsrelay.AuthConfig {
+	if cfg == nil {
+		return wsrelay.AuthConfig{}
+	}
+	relay := cfg.WebsocketRelay
+	keys := make([]wsrelay.JoinKey, 0, len(relay.Keys))
+	for _, key := range relay.Keys {
+		keys = append(keys, wsrelay.JoinKey{Key: key.Key, Name: key.Name})
+	}
+	return wsrelay.AuthConfig{
+		Token:          relay.Token,
+		Keys:           keys,
+		AllowedOrigins: relay.AllowedOrigins,
+		MaxSessions:    relay.MaxSessions,
+		TicketSecret:   relay.TicketSecret,
+	}
+}
+
+// websocketRelay exposes the sessions and audit logs of every relay this service runs.
+func (s *Service) websocketRelay() managementHandlers.WebsocketRelay {
+	return managementHandlers.WebsocketRelay{
+		Stats: func() []wsrelay.SessionStats {
+			return append(s.wsGateway.Stats(), s.workerGateway.Stats()...)
+		},
+		Audit: func() []wsrelay.AuditEvent {
+			events := append(s.wsGateway.AuditLog(), s.workerGateway.AuditLog()...)
+			sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+			return events
+		},
+		IssueTicket: s.wsGateway.IssueTicket,
+	}
+}
+
 func (s *Service) wsOnConnected(channelID string) {
 	if s == nil || channelID == "" {
 		return
@@ -257,10 +323,6 @@ func (s *Service) wsOnDisconnected(channelID string, reason error) {
 		return
 	}
 	if reason != nil {
-		if strings.Contains(reason.Error(), "replaced by new connection") {
-			log.Infof("websocket provider replaced: %s", channelID)
-			return
-		}
 		log.Warnf("websocket provider disconnected: %s (%v)", channelID, reason)
 	} else {
 		log.Infof("websocket provider disconnected: %s", channelID)
@@ -360,6 +422,27 @@ func openAICompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName
 	return "", "", false
 }
 
+// httpProviderFromAuth returns the http-providers entry name an auth was synthesized from.
+func httpProviderFromAuth(a *coreauth.Auth) string {
+	if a == nil || a.Attributes == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(a.Attributes["http_provider"]))
+}
+
+func (s *Service) resolveHTTPProvider(a *coreauth.Auth) *config.HTTPProvider {
+	name := httpProviderFromAuth(a)
+	if name == "" || s.cfg == nil {
+		return nil
+	}
+	for i := range s.cfg.HTTPProviders {
+		if s.cfg.HTTPProviders[i].Name == name {
+			return &s.cfg.HTTPProviders[i]
+		}
+	}
+	return nil
+}
+
 func (s *Service) ensureExecutorsForAuth(a *coreauth.Auth) {
 	s.ensureExecutorsForAuthWithMode(a, false)
 }
@@ -387,6 +470,10 @@ func (s *Service) ensureExecutorsForAuthWithMode(a *coreauth.Auth, forceReplace
 	if a.Disabled {
 		return
 	}
+	if name := httpProviderFromAuth(a); name != "" {
+		s.coreManager.RegisterExecutor(executor.NewHTTPProviderExecutor(name, s.cfg))
+		return
+	}
 	if compatProviderKey, _, isCompat := openAICompatInfoFromAuth(a); isCompat {
 		if compatProviderKey == "" {
 			compatProviderKey = strings.ToLower(strings.TrimSpace(a.Provider))
@@ -409,6 +496,11 @@ func (s *Service) ensureExecutorsForAuthWithMode(a *coreauth.Auth, forceReplace
 			s.coreManager.RegisterExecutor(executor.NewAIStudioExecutor(s.cfg, a.ID, s.wsGateway))
 		}
 		return
+	case executor.RemoteWorkerProvider:
+		if s.workerGateway != nil {
+			s.coreManager.RegisterExecutor(executor.NewRemoteWorkerExecutor(s.cfg, s.workerGateway))
+		}
+		return
 	case "antigravity":
 		s.coreManager.RegisterExecutor(executor.NewAntigravityExecutor(s.cfg))
 	case "claude":
@@ -478,6 +570,7 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	s.applyRetryConfig(s.cfg)
+	s.applyUsageStorage(nil, s.cfg)
 
 	if s.coreManager != nil {
 		if errLoad := s.coreManager.Load(ctx); errLoad != nil {
@@ -530,6 +623,11 @@ func (s *Service) Run(ctx context.Context) error {
 			log.Debugf("ws-auth disabled; existing websocket sessions remain connected")
 		})
 	}
+	if s.server != nil {
+		s.server.SetWebsocketRelay(s.websocketRelay())
+	}
+
+	s.startRemoteWorkerGateway()
 
 	if s.hooks.OnBeforeStart != nil {
 		s.hooks.OnBeforeStart(s.cfg)
@@ -595,12 +693,18 @@ func (s *Service) Run(ctx context.Context) error {
 
 		s.applyRetryConfig(newCfg)
 		s.applyPprofConfig(newCfg)
+		cache.SetMaxSignaturesPerGroup(newCfg.SignatureCache.MaxEntriesPerGroup)
+		s.wsGateway.SetResumeGrace(relayResumeGrace(newCfg))
+		s.wsGateway.SetAuth(relayAuth(newCfg))
+		s.workerGateway.SetResumeGrace(relayResumeGrace(newCfg))
 		if s.server != nil {
 			s.server.UpdateClients(newCfg)
 		}
 		s.cfgMu.Lock()
+		previousCfg := s.cfg
 		s.cfg = newCfg
 		s.cfgMu.Unlock()
+		s.applyUsageStorage(previousCfg, newCfg)
 		if s.coreManager != nil {
 			s.coreManager.SetConfig(newCfg)
 			s.coreManager.SetOAuthModelAlias(newCfg.OAuthModelAlias)
@@ -626,6 +730,10 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 	log.Info("file watcher started for config and auth directory changes")
 
+	s.startCluster(ctx)
+	s.startSignatureCache()
+	s.startRemoteWorkerClient(ctx)
+
 	// Prefer core auth manager auto refresh if available.
 	if s.coreManager != nil {
 		interval := 15 * time.Minute
@@ -669,6 +777,14 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
 		}
+		s.stopSignatureCache()
+		s.stopCluster()
+		if err := s.stopRemoteWorkers(ctx); err != nil {
+			log.Errorf("failed to stop remote workers: %v", err)
+			if shutdownErr == nil {
+				shutdownErr = err
+			}
+		}
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
 				log.Errorf("failed to stop file watcher: %v", err)
@@ -709,6 +825,7 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 
 		usage.StopDefault()
+		internalusage.CloseSQLStore()
 	})
 	return shutdownErr
 }
@@ -801,6 +918,9 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 	case "aistudio":
 		models = registry.GetAIStudioModels()
 		models = applyExcludedModels(models, excluded)
+	case executor.RemoteWorkerProvider:
+		// The worker already applied its own exclusions, aliases and prefixes.
+		models = s.remoteWorkers.models(a.ID)
 	case "antigravity":
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		dynamicModels := executor.FetchAntigravityModels(ctx, a, s.cfg)
@@ -856,6 +976,10 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 		models = registry.GetKimiModels()
 		models = applyExcludedModels(models, excluded)
 	default:
+		if entry := s.resolveHTTPProvider(a); entry != nil {
+			models = buildConfigModels(entry.Models, entry.Name, "http-provider")
+			break
+		}
 		// Handle OpenAI-compatibility providers by name using config
 		if s.cfg != nil {
 			providerKey := provider
@@ -0,0 +1,55 @@
+package cliproxy
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultSignatureSnapshotFile = "signature-cache.json"
+
+// startSignatureCache applies the signature cache backend. It runs after startCluster so the
+// postgres backend can share the cluster connection.
+func (s *Service) startSignatureCache() {
+	if s == nil || s.cfg == nil {
+		return
+	}
+	sigCfg := s.cfg.SignatureCache
+	cache.SetMaxSignaturesPerGroup(sigCfg.MaxEntriesPerGroup)
+	switch strings.ToLower(strings.TrimSpace(sigCfg.Backend)) {
+	case "file":
+		path := strings.TrimSpace(sigCfg.Path)
+		if path == "" {
+			path = defaultSignatureSnapshotFile
+		}
+		if !filepath.IsAbs(path) && s.configPath != "" {
+			path = filepath.Join(filepath.Dir(s.configPath), path)
+		}
+		s.signatureSnapshotStop = cache.StartSignatureSnapshots(path, time.Duration(sigCfg.SnapshotIntervalSeconds)*time.Second)
+		log.Infof("signature cache snapshots enabled (path=%s)", path)
+	case "postgres":
+		if s.clusterState == nil {
+			log.Error("signature cache: the postgres backend requires cluster mode; keeping signatures in memory")
+			return
+		}
+		cache.SetSignatureStore(s.clusterState)
+		log.Info("signature cache shared through the cluster database")
+	}
+}
+
+// stopSignatureCache writes the final snapshot and detaches the shared store.
+func (s *Service) stopSignatureCache() {
+	if s == nil {
+		return
+	}
+	if s.signatureSnapshotStop != nil {
+		s.signatureSnapshotStop()
+		s.signatureSnapshotStop = nil
+	}
+	if s.clusterState != nil {
+		cache.SetSignatureStore(nil)
+	}
+}
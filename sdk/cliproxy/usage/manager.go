@@ -17,8 +17,31 @@ type Record struct {
 	AuthIndex   string
 	Source      string
 	RequestedAt time.Time
-	Failed      bool
-	Detail      Detail
+	// QueueWait is how long the request waited for an execution slot before it was sent upstream.
+	QueueWait time.Duration
+	// CacheHit marks a request answered from the response cache without an upstream call.
+	CacheHit bool
+	Failed   bool
+	Detail   Detail
+}
+
+type queueWaitContextKey struct{}
+
+// WithQueueWait returns a child context carrying the time a request spent queued for admission.
+func WithQueueWait(ctx context.Context, wait time.Duration) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, queueWaitContextKey{}, wait)
+}
+
+// QueueWaitFromContext returns the queue wait recorded by WithQueueWait, or 0.
+func QueueWaitFromContext(ctx context.Context) time.Duration {
+	if ctx == nil {
+		return 0
+	}
+	wait, _ := ctx.Value(queueWaitContextKey{}).(time.Duration)
+	return wait
 }
 
 // Detail holds the token usage breakdown.
@@ -26,8 +49,11 @@ type Detail struct {
 	InputTokens     int64
 	OutputTokens    int64
 	ReasoningTokens int64
-	CachedTokens    int64
-	TotalTokens     int64
+	// CachedTokens are input tokens read from a prompt cache.
+	CachedTokens int64
+	// CacheCreationTokens are input tokens written to a prompt cache.
+	CacheCreationTokens int64
+	TotalTokens         int64
 }
 
 // Plugin consumes usage records emitted by the proxy runtime.
@@ -0,0 +1,52 @@
+package cliproxy
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyUsageStorage opens the durable usage store for next, replacing the one opened for
+// previous. It does nothing when usage-storage is unchanged, so a reload keeps the open
+// connection. A nil previous opens the store on startup.
+func (s *Service) applyUsageStorage(previous, next *config.Config) {
+	if s == nil || next == nil {
+		return
+	}
+	if previous != nil && reflect.DeepEqual(previous.UsageStorage, next.UsageStorage) {
+		return
+	}
+	storage := next.UsageStorage
+	if storage.Driver == "" {
+		if previous != nil && previous.UsageStorage.Driver != "" {
+			internalusage.CloseSQLStore()
+			log.Info("usage storage disabled")
+		}
+		return
+	}
+	baseDir := ""
+	if s.configPath != "" {
+		baseDir = filepath.Dir(s.configPath)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	store, err := internalusage.InitSQLStore(ctx, internalusage.SQLStoreConfig{
+		Driver:    storage.Driver,
+		Path:      storage.Path,
+		BaseDir:   baseDir,
+		DSN:       storage.DSN,
+		Table:     storage.Table,
+		Retention: time.Duration(storage.RetentionDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		// InitSQLStore leaves the previous store installed when the new one cannot be opened.
+		log.Errorf("failed to initialize usage storage: %v", err)
+		return
+	}
+	log.Infof("usage storage enabled, driver: %s, location: %s", store.Driver(), store.Location())
+}
@@ -0,0 +1,44 @@
+package cliproxy
+
+import (
+	"path/filepath"
+	"testing"
+
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestApplyUsageStorageReopensOnlyWhenChanged(t *testing.T) {
+	dir := t.TempDir()
+	service := &Service{configPath: filepath.Join(dir, "config.yaml")}
+	t.Cleanup(internalusage.CloseSQLStore)
+
+	first := &config.Config{}
+	first.UsageStorage.Driver = "sqlite"
+	first.UsageStorage.Path = "first.db"
+	service.applyUsageStorage(nil, first)
+	opened := internalusage.GetSQLStore()
+	if opened == nil || opened.Location() != filepath.Join(dir, "first.db") {
+		t.Fatalf("store after start = %v", opened)
+	}
+
+	same := &config.Config{}
+	same.UsageStorage = first.UsageStorage
+	service.applyUsageStorage(first, same)
+	if internalusage.GetSQLStore() != opened {
+		t.Fatal("unchanged usage-storage reopened the store")
+	}
+
+	moved := &config.Config{}
+	moved.UsageStorage = first.UsageStorage
+	moved.UsageStorage.Path = "second.db"
+	service.applyUsageStorage(same, moved)
+	if store := internalusage.GetSQLStore(); store == nil || store.Location() != filepath.Join(dir, "second.db") {
+		t.Fatalf("store after path change = %v", store)
+	}
+
+	service.applyUsageStorage(moved, &config.Config{})
+	if store := internalusage.GetSQLStore(); store != nil {
+		t.Fatalf("store after disabling = %v", store)
+	}
+}
@@ -11,6 +11,10 @@ type SDKConfig = internalconfig.SDKConfig
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type PromptQueueConfig = internalconfig.PromptQueueConfig
+type SchedulerConfig = internalconfig.SchedulerConfig
+type ResponseCacheConfig = internalconfig.ResponseCacheConfig
+type SchedulerClient = internalconfig.SchedulerClient
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type AmpCode = internalconfig.AmpCode
@@ -28,6 +32,11 @@ type VertexCompatModel = internalconfig.VertexCompatModel
 type OpenAICompatibility = internalconfig.OpenAICompatibility
 type OpenAICompatibilityAPIKey = internalconfig.OpenAICompatibilityAPIKey
 type OpenAICompatibilityModel = internalconfig.OpenAICompatibilityModel
+type HTTPProvider = internalconfig.HTTPProvider
+type HTTPMapping = internalconfig.HTTPMapping
+type HTTPFieldMapping = internalconfig.HTTPFieldMapping
+type HTTPStreamMapping = internalconfig.HTTPStreamMapping
+type HTTPUsagePaths = internalconfig.HTTPUsagePaths
 
 type TLS = internalconfig.TLSConfig
 
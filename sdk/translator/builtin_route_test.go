@@ -0,0 +1,41 @@
+package translator_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/translator/builtin"
+	"github.com/tidwall/gjson"
+)
+
+func TestBuiltinRouteStreamsGeminiCLIFromAntigravity(t *testing.T) {
+	r := builtin.Registry()
+	from, to := sdktranslator.FormatGeminiCLI, sdktranslator.FormatAntigravity
+	want := []sdktranslator.Format{from, sdktranslator.FormatGemini, to}
+	if got := r.Route(from, to); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Route(%s, %s) = %v, want %v", from, to, got, want)
+	}
+
+	ctx := context.WithValue(context.Background(), "alt", "")
+	request := []byte(`{"model":"gemini-2.5-pro","request":{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}}`)
+	upstream := []string{
+		`{"response":{"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]}}]}}`,
+		`{"response":{"candidates":[{"content":{"role":"model","parts":[{"text":"lo"}]},"finishReason":"STOP"}]}}`,
+		"[DONE]",
+	}
+	var param any
+	var out []string
+	for _, line := range upstream {
+		out = append(out, r.TranslateStream(ctx, to, from, "gemini-2.5-pro", request, request, []byte(line), &param)...)
+	}
+	if len(out) != 2 {
+		t.Fatalf("stream output = %q, want two chunks", out)
+	}
+	for i, text := range []string{"Hel", "lo"} {
+		if got := gjson.Get(out[i], "response.candidates.0.content.parts.0.text").String(); got != text {
+			t.Fatalf("chunk %d = %s, want response text %q", i, out[i], text)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+// Package conformance checks translators against a shared corpus of golden fixtures.
+//
+// Each fixture is a request, a streaming response or a non-streaming response written in one
+// format, together with the semantics that must survive translation: text, system prompt, tool
+// declarations and calls, images, thinking, stop reason and usage. Run translates every fixture
+// through every registered pair and compares the semantics extracted from the output. Request
+// fixtures are also translated back to their own format when the reverse pair exists.
+//
+// SDK users that register custom formats add an Adapter with RegisterAdapter and their own
+// fixtures with Corpus.Load or Corpus.Add.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// Kind identifies what a fixture holds and which transform a check exercises.
+type Kind string
+
+const (
+	// KindRequest fixtures are client requests translated with the request transform.
+	KindRequest Kind = "request"
+	// KindStream fixtures are upstream stream lines translated with the stream transform.
+	KindStream Kind = "stream"
+	// KindNonStream fixtures are upstream bodies translated with the non-stream transform.
+	KindNonStream Kind = "non-stream"
+	// KindRoundTrip checks translate a request fixture to another format and back.
+	KindRoundTrip Kind = "round-trip"
+)
+
+// DefaultModel is the model name passed to translators when Options.Model is empty.
+const DefaultModel = "conformance-model"
+
+// Usage holds the token counts reported by a response.
+type Usage struct {
+	Input  int64 `json:"input"`
+	Output int64 `json:"output"`
+}
+
+// Semantics is the format-independent content extracted from a payload.
+type Semantics struct {
+	System bool
+	// Text is every text fragment of the payload, including system prompts and tool results.
+	Text        string
+	Tools       []string
+	ToolCalls   []string
+	ToolResults int
+	Images      int
+	Thinking    bool
+	// StopReason is normalized to stop, length or tool_calls.
+	StopReason string
+	Usage      Usage
+}
+
+// Expect lists the semantics a fixture must keep in every format. Zero fields are not checked.
+type Expect struct {
+	System bool `json:"system,omitempty"`
+	// Texts are fragments that must appear in the extracted text.
+	Texts       []string `json:"texts,omitempty"`
+	Tools       []string `json:"tools,omitempty"`
+	ToolCalls   []string `json:"tool_calls,omitempty"`
+	ToolResults int      `json:"tool_results,omitempty"`
+	Images      int      `json:"images,omitempty"`
+	Thinking    bool     `json:"thinking,omitempty"`
+	StopReason  string   `json:"stop_reason,omitempty"`
+	Usage       *Usage   `json:"usage,omitempty"`
+}
+
+// Mismatch is one expectation that the extracted semantics do not meet.
+type Mismatch struct {
+	Feature string
+	Message string
+}
+
+// Check compares extracted semantics with a fixture's expectations.
+func Check(expect Expect, got Semantics) []Mismatch {
+	var out []Mismatch
+	add := func(feature, format string, args ...any) {
+		out = append(out, Mismatch{Feature: feature, Message: fmt.Sprintf(format, args...)})
+	}
+	if expect.System && !got.System {
+		add("system", "system prompt missing")
+	}
+	for _, fragment := range expect.Texts {
+		if !strings.Contains(got.Text, fragment) {
+			add("text", "text %q missing from %q", fragment, got.Text)
+		}
+	}
+	for _, name := range expect.Tools {
+		if !containsName(got.Tools, name) {
+			add("tools", "tool %q missing from %v", name, got.Tools)
+		}
+	}
+	if len(expect.ToolCalls) > 0 && !sameNames(expect.ToolCalls, got.ToolCalls) {
+		add("tool_calls", "tool calls = %v, want %v", got.ToolCalls, expect.ToolCalls)
+	}
+	if expect.ToolResults > 0 && got.ToolResults != expect.ToolResults {
+		add("tool_results", "tool results = %d, want %d", got.ToolResults, expect.ToolResults)
+	}
+	if expect.Images > 0 && got.Images != expect.Images {
+		add("images", "images = %d, want %d", got.Images, expect.Images)
+	}
+	if expect.Thinking && !got.Thinking {
+		add("thinking", "thinking missing")
+	}
+	if expect.StopReason != "" && got.StopReason != expect.StopReason {
+		add("stop_reason", "stop reason = %q, want %q", got.StopReason, expect.StopReason)
+	}
+	if expect.Usage != nil && got.Usage != *expect.Usage {
+		add("usage", "usage = %+v, want %+v", got.Usage, *expect.Usage)
+	}
+	return out
+}
+
+func containsName(names []string, name string) bool {
+	for _, candidate := range names {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sameNames(want, got []string) bool {
+	if len(want) != len(got) {
+		return false
+	}
+	a := append([]string(nil), want...)
+	b := append([]string(nil), got...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Gap records a known translation loss so that Run tolerates it. From and To follow the data:
+// client to upstream for requests and round trips, upstream to client for responses. Empty
+// fields match anything. A gap that no check hits fails the run, so the list shrinks as
+// translators improve.
+type Gap struct {
+	From    translator.Format
+	To      translator.Format
+	Kind    Kind
+	Fixture string
+	Feature string
+	Reason  string
+}
+
+func (g Gap) matches(from, to translator.Format, kind Kind, fixture, feature string) bool {
+	return (g.From == "" || g.From == from) &&
+		(g.To == "" || g.To == to) &&
+		(g.Kind == "" || g.Kind == kind) &&
+		(g.Fixture == "" || g.Fixture == fixture) &&
+		(g.Feature == "" || g.Feature == feature)
+}
+
+func (g Gap) String() string {
+	field := func(v string) string {
+		if v == "" {
+			return "*"
+		}
+		return v
+	}
+	return fmt.Sprintf("%s->%s %s/%s/%s", field(string(g.From)), field(string(g.To)), field(string(g.Kind)), field(g.Fixture), field(g.Feature))
+}
+
+// Options configures Run.
+type Options struct {
+	// Registry holds the transforms under test; nil uses translator.Default().
+	Registry *translator.Registry
+	// Corpus holds the fixtures; nil uses Builtin().
+	Corpus *Corpus
+	// Model is passed to every transform; empty uses DefaultModel.
+	Model string
+	// Gaps lists the known losses to tolerate.
+	Gaps []Gap
+}
+
+// Run checks every registered pair whose formats have adapters, as one subtest per pair.
+func Run(t *testing.T, opts Options) {
+	t.Helper()
+	r := runner{opts: opts, used: make([]bool, len(opts.Gaps))}
+	if r.opts.Registry == nil {
+		r.opts.Registry = translator.Default()
+	}
+	if r.opts.Corpus == nil {
+		r.opts.Corpus = Builtin()
+	}
+	if r.opts.Model == "" {
+		r.opts.Model = DefaultModel
+	}
+
+	pairs := r.opts.Registry.Pairs()
+	requests := make(map[[2]translator.Format]bool, len(pairs))
+	for _, pair := range pairs {
+		if pair.Request {
+			requests[[2]translator.Format{pair.From, pair.To}] = true
+		}
+	}
+	for _, pair := range pairs {
+		pair := pair
+		t.Run(string(pair.From)+"->"+string(pair.To), func(t *testing.T) {
+			r.runPair(t, pair, requests[[2]translator.Format{pair.To, pair.From}])
+		})
+	}
+
+	for i, gap := range r.opts.Gaps {
+		if !r.used[i] {
+			t.Errorf("gap %s no longer reproduces: %s", gap, gap.Reason)
+		}
+	}
+}
+
+type runner struct {
+	opts Options
+	mu   sync.Mutex
+	used []bool
+}
+
+func (r *runner) runPair(t *testing.T, pair translator.Pair, reversible bool) {
+	from, okFrom := AdapterFor(pair.From)
+	to, okTo := AdapterFor(pair.To)
+	if !okFrom || !okTo {
+		t.Skipf("no adapter for %s or %s", pair.From, pair.To)
+	}
+	reg := r.opts.Registry
+	model := r.opts.Model
+	corpus := r.opts.Corpus
+
+	if pair.Request {
+		for _, fixture := range corpus.Fixtures(pair.From, KindRequest) {
+			out := reg.TranslateRequest(pair.From, pair.To, model, fixture.Payload(), fixture.Stream)
+			r.report(t, pair.From, pair.To, KindRequest, fixture, to.Request(out))
+			if reversible {
+				back := reg.TranslateRequest(pair.To, pair.From, model, out, fixture.Stream)
+				r.report(t, pair.From, pair.To, KindRoundTrip, fixture, from.Request(back))
+			}
+		}
+	}
+
+	// Executors pass the Gemini alt query parameter this way; "" selects SSE framing.
+	ctx := context.WithValue(context.Background(), "alt", "")
+	if pair.Stream {
+		for _, fixture := range corpus.Fixtures(pair.To, KindStream) {
+			original, translated := r.requests(pair, from, fixture, true)
+			var param any
+			var chunks []string
+			for _, chunk := range fixture.Chunks {
+				chunks = append(chunks, reg.TranslateStream(ctx, pair.To, pair.From, model, original, translated, []byte(chunk), &param)...)
+			}
+			r.report(t, pair.To, pair.From, KindStream, fixture, from.Stream(chunks))
+		}
+	}
+	if pair.NonStream {
+		for _, fixture := range corpus.Fixtures(pair.To, KindNonStream) {
+			original, translated := r.requests(pair, from, fixture, false)
+			var param any
+			out := reg.TranslateNonStream(ctx, pair.To, pair.From, model, original, translated, fixture.Payload(), &param)
+			r.report(t, pair.To, pair.From, KindNonStream, fixture, from.NonStream([]byte(out)))
+		}
+	}
+}
+
+// requests returns the client request a response fixture answers and its translated form.
+func (r *runner) requests(pair translator.Pair, client Adapter, fixture *Fixture, stream bool) (original, translated []byte) {
+	original = []byte(`{}`)
+	if fixture.Request != "" {
+		if req := r.opts.Corpus.Find(pair.From, KindRequest, fixture.Request); req != nil {
+			original = req.Payload()
+		}
+	}
+	if client.SetStream != nil {
+		original = client.SetStream(original, stream)
+	}
+	translated = r.opts.Registry.TranslateRequest(pair.From, pair.To, r.opts.Model, original, stream)
+	return original, translated
+}
+
+// report fails t for every mismatch that no gap covers. Responses are reported upstream format first.
+func (r *runner) report(t *testing.T, from, to translator.Format, kind Kind, fixture *Fixture, got Semantics) {
+	t.Helper()
+	for _, mismatch := range Check(fixture.Expect, got) {
+		if r.tolerated(from, to, kind, fixture.Name, mismatch.Feature) {
+			continue
+		}
+		t.Errorf("%s %s->%s %s: %s", kind, from, to, fixture.Name, mismatch.Message)
+	}
+}
+
+func (r *runner) tolerated(from, to translator.Format, kind Kind, fixture, feature string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, gap := range r.opts.Gaps {
+		if gap.matches(from, to, kind, fixture, feature) {
+			r.used[i] = true
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// wrappedFormat is an OpenAI chat payload nested under a "wrapped" key, standing in for a
+// custom SDK format.
+const wrappedFormat translator.Format = "wrapped-openai"
+
+func wrap(payload []byte) []byte {
+	out, _ := sjson.SetRawBytes([]byte(`{}`), "wrapped", payload)
+	return out
+}
+
+func unwrap(body []byte) []byte {
+	return []byte(gjson.GetBytes(body, "wrapped").Raw)
+}
+
+func TestRunCustomFormat(t *testing.T) {
+	reg := translator.NewRegistry()
+	reg.Register(wrappedFormat, translator.FormatOpenAI,
+		func(_ string, rawJSON []byte, _ bool) []byte { return unwrap(rawJSON) },
+		translator.ResponseTransform{
+			Stream: func(_ context.Context, _ string, _, _, rawJSON []byte, _ *any) []string {
+				payloads := StreamPayloads([]string{string(rawJSON)})
+				if len(payloads) == 0 {
+					return nil
+				}
+				return []string{string(wrap(payloads[0]))}
+			},
+			NonStream: func(_ context.Context, _ string, _, _, rawJSON []byte, _ *any) string {
+				return string(wrap(rawJSON))
+			},
+		})
+
+	openai, _ := AdapterFor(translator.FormatOpenAI)
+	RegisterAdapter(wrappedFormat, Adapter{
+		Request:   func(body []byte) Semantics { return openai.Request(unwrap(body)) },
+		NonStream: func(body []byte) Semantics { return openai.NonStream(unwrap(body)) },
+		Stream: func(chunks []string) Semantics {
+			inner := make([]string, 0, len(chunks))
+			for _, chunk := range chunks {
+				inner = append(inner, string(unwrap([]byte(chunk))))
+			}
+			return openai.Stream(inner)
+		},
+	})
+
+	corpus := Builtin()
+	for _, fixture := range corpus.Fixtures(translator.FormatOpenAI, KindRequest) {
+		custom := *fixture
+		custom.Format = wrappedFormat
+		custom.Body = json.RawMessage(wrap(fixture.Payload()))
+		corpus.Add(&custom)
+	}
+	if len(corpus.Fixtures(wrappedFormat, KindRequest)) == 0 {
+		t.Fatal("no custom request fixtures")
+	}
+
+	Run(t, Options{Registry: reg, Corpus: corpus})
+}
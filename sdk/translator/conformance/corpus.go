@@ -0,0 +1,190 @@
+package conformance
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+//go:embed corpus
+var builtinFS embed.FS
+
+// Fixture is one golden payload in a single format.
+type Fixture struct {
+	Name   string            `json:"name"`
+	Format translator.Format `json:"format"`
+	Kind   Kind              `json:"kind"`
+	// Stream is the stream flag passed with request fixtures.
+	Stream bool `json:"stream,omitempty"`
+	// Request names the request fixture a response answers. It is looked up in the client
+	// format and passed to the response transform as the original request.
+	Request string `json:"request,omitempty"`
+	// Body is the request or non-stream response payload. Payloads that are not JSON, such as an
+	// SSE body, are stored as a JSON string.
+	Body json.RawMessage `json:"body,omitempty"`
+	// Chunks are the stream lines in the order the executor passes them to the translator.
+	Chunks chunkList `json:"chunks,omitempty"`
+	Expect Expect    `json:"expect"`
+}
+
+// Payload returns the body as passed to a transform, unquoting string bodies.
+func (f *Fixture) Payload() []byte {
+	var s string
+	if err := json.Unmarshal(f.Body, &s); err == nil {
+		return []byte(s)
+	}
+	return f.Body
+}
+
+// chunkList accepts both strings and inline JSON objects, which are compacted into one line.
+type chunkList []string
+
+func (c *chunkList) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	out := make(chunkList, 0, len(raw))
+	for _, item := range raw {
+		var s string
+		if err := json.Unmarshal(item, &s); err == nil {
+			out = append(out, s)
+			continue
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, item); err != nil {
+			return err
+		}
+		out = append(out, compact.String())
+	}
+	*c = out
+	return nil
+}
+
+// Corpus is a set of fixtures indexed by format and kind.
+type Corpus struct {
+	mu       sync.RWMutex
+	fixtures map[translator.Format]map[Kind][]*Fixture
+}
+
+// NewCorpus returns an empty corpus.
+func NewCorpus() *Corpus {
+	return &Corpus{fixtures: make(map[translator.Format]map[Kind][]*Fixture)}
+}
+
+var (
+	builtinOnce   sync.Once
+	builtinCorpus *Corpus
+)
+
+// Builtin returns a new corpus holding the fixtures shipped with this package for the built-in
+// formats. Callers may add their own fixtures to it.
+func Builtin() *Corpus {
+	builtinOnce.Do(func() {
+		builtinCorpus = NewCorpus()
+		if err := builtinCorpus.Load(builtinFS); err != nil {
+			panic(fmt.Sprintf("conformance: load builtin corpus: %v", err))
+		}
+	})
+	c := NewCorpus()
+	for _, byKind := range builtinCorpus.fixtures {
+		for _, fixtures := range byKind {
+			for _, fixture := range fixtures {
+				copied := *fixture
+				c.Add(&copied)
+			}
+		}
+	}
+	return c
+}
+
+// Load adds every .json fixture found in fsys.
+func (c *Corpus) Load(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || path.Ext(name) != ".json" {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		var fixture Fixture
+		if err = json.Unmarshal(data, &fixture); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err = fixture.validate(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		c.Add(&fixture)
+		return nil
+	})
+}
+
+func (f *Fixture) validate() error {
+	if f.Name == "" || f.Format == "" {
+		return fmt.Errorf("fixture needs a name and a format")
+	}
+	switch f.Kind {
+	case KindRequest, KindNonStream:
+		if len(f.Body) == 0 {
+			return fmt.Errorf("%s fixture %q has no body", f.Kind, f.Name)
+		}
+	case KindStream:
+		if len(f.Chunks) == 0 {
+			return fmt.Errorf("stream fixture %q has no chunks", f.Name)
+		}
+	default:
+		return fmt.Errorf("fixture %q has unknown kind %q", f.Name, f.Kind)
+	}
+	return nil
+}
+
+// Add stores a fixture, replacing one with the same format, kind and name.
+func (c *Corpus) Add(fixture *Fixture) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	byKind, ok := c.fixtures[fixture.Format]
+	if !ok {
+		byKind = make(map[Kind][]*Fixture)
+		c.fixtures[fixture.Format] = byKind
+	}
+	list := byKind[fixture.Kind]
+	for i, existing := range list {
+		if existing.Name == fixture.Name {
+			list[i] = fixture
+			return
+		}
+	}
+	list = append(list, fixture)
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	byKind[fixture.Kind] = list
+}
+
+// Fixtures returns the fixtures of one format and kind, sorted by name.
+func (c *Corpus) Fixtures(format translator.Format, kind Kind) []*Fixture {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]*Fixture(nil), c.fixtures[format][kind]...)
+}
+
+// Find returns the named fixture, or nil.
+func (c *Corpus) Find(format translator.Format, kind Kind, name string) *Fixture {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, fixture := range c.fixtures[format][kind] {
+		if fixture.Name == name {
+			return fixture
+		}
+	}
+	return nil
+}
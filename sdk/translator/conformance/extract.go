@@ -0,0 +1,531 @@
+package conformance
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Adapter reads the semantics of payloads in one format.
+type Adapter struct {
+	// Request reads a request body.
+	Request func(body []byte) Semantics
+	// NonStream reads a complete response body.
+	NonStream func(body []byte) Semantics
+	// Stream reads the chunks a stream transform produced, in order.
+	Stream func(chunks []string) Semantics
+	// SetStream marks a request as streaming or not. It is nil for formats that choose streaming
+	// outside the body, such as Gemini.
+	SetStream func(body []byte, stream bool) []byte
+}
+
+var (
+	adaptersMu sync.RWMutex
+	adapters   = map[translator.Format]Adapter{
+		translator.FormatOpenAI:         {Request: openAIRequest, NonStream: openAINonStream, Stream: openAIStream, SetStream: setStreamField},
+		translator.FormatOpenAIResponse: {Request: responsesRequest, NonStream: responsesNonStream, Stream: responsesStream, SetStream: setStreamField},
+		translator.FormatCodex:          {Request: responsesRequest, NonStream: responsesNonStream, Stream: responsesStream, SetStream: setStreamField},
+		translator.FormatClaude:         {Request: claudeRequest, NonStream: claudeNonStream, Stream: claudeStream, SetStream: setStreamField},
+		translator.FormatGemini:         {Request: geminiRequest, NonStream: geminiNonStream, Stream: geminiStream},
+		translator.FormatGeminiCLI:      {Request: unwrapRequest(geminiRequest), NonStream: geminiNonStream, Stream: geminiStream},
+		translator.FormatAntigravity:    {Request: unwrapRequest(geminiRequest), NonStream: geminiNonStream, Stream: geminiStream},
+	}
+)
+
+// RegisterAdapter installs the adapter of a format, replacing any previous one.
+func RegisterAdapter(format translator.Format, adapter Adapter) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+	adapters[format] = adapter
+}
+
+// AdapterFor returns the adapter of a format. It reports false unless the adapter can read
+// requests and both kinds of responses.
+func AdapterFor(format translator.Format) (Adapter, bool) {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+	adapter, ok := adapters[format]
+	return adapter, ok && adapter.Request != nil && adapter.NonStream != nil && adapter.Stream != nil
+}
+
+func setStreamField(body []byte, stream bool) []byte {
+	out, err := sjson.SetBytes(body, "stream", stream)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// StreamPayloads returns the JSON payloads carried by stream chunks. It accepts SSE lines with or
+// without a data: prefix, skips event names, comments and [DONE], and splits multi-line chunks.
+func StreamPayloads(chunks []string) [][]byte {
+	var out [][]byte
+	for _, chunk := range chunks {
+		for _, line := range strings.Split(chunk, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "data:") {
+				line = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			}
+			if line == "" || line == "[DONE]" || !gjson.Valid(line) {
+				continue
+			}
+			out = append(out, []byte(line))
+		}
+	}
+	return out
+}
+
+// NormalizeStopReason maps the stop reasons of the built-in formats to stop, length or tool_calls.
+func NormalizeStopReason(reason string) string {
+	switch strings.ToLower(reason) {
+	case "":
+		return ""
+	case "stop", "end_turn", "stop_sequence", "completed":
+		return "stop"
+	case "length", "max_tokens", "max_output_tokens":
+		return "length"
+	case "tool_calls", "tool_use", "function_call":
+		return "tool_calls"
+	default:
+		return strings.ToLower(reason)
+	}
+}
+
+// finish normalizes the stop reason. Formats without a dedicated reason report a normal stop
+// for tool calls, which counts as tool_calls.
+func (s Semantics) finish() Semantics {
+	s.StopReason = NormalizeStopReason(s.StopReason)
+	if s.StopReason == "stop" && len(s.ToolCalls) > 0 {
+		s.StopReason = "tool_calls"
+	}
+	return s
+}
+
+func (s *Semantics) addText(text string) {
+	if text != "" {
+		s.Text += text + "\n"
+	}
+}
+
+func appendName(list *[]string, name string) {
+	if name != "" {
+		*list = append(*list, name)
+	}
+}
+
+func unwrapRequest(inner func([]byte) Semantics) func([]byte) Semantics {
+	return func(body []byte) Semantics {
+		if request := gjson.GetBytes(body, "request"); request.IsObject() {
+			return inner([]byte(request.Raw))
+		}
+		return inner(body)
+	}
+}
+
+// OpenAI chat completions.
+
+func openAIRequest(body []byte) Semantics {
+	var s Semantics
+	root := gjson.ParseBytes(body)
+	for _, msg := range root.Get("messages").Array() {
+		switch msg.Get("role").String() {
+		case "system", "developer":
+			s.System = true
+		case "tool":
+			s.ToolResults++
+		}
+		openAIContent(&s, msg.Get("content"))
+		if msg.Get("reasoning_content").String() != "" {
+			s.Thinking = true
+		}
+		for _, call := range msg.Get("tool_calls").Array() {
+			appendName(&s.ToolCalls, call.Get("function.name").String())
+		}
+	}
+	for _, tool := range root.Get("tools").Array() {
+		appendName(&s.Tools, tool.Get("function.name").String())
+	}
+	if effort := root.Get("reasoning_effort").String(); effort != "" && effort != "none" {
+		s.Thinking = true
+	}
+	return s
+}
+
+func openAIContent(s *Semantics, content gjson.Result) {
+	if content.Type == gjson.String {
+		s.addText(content.String())
+		return
+	}
+	for _, part := range content.Array() {
+		switch part.Get("type").String() {
+		case "text":
+			s.addText(part.Get("text").String())
+		case "image_url":
+			s.Images++
+		}
+	}
+}
+
+func openAINonStream(body []byte) Semantics {
+	var s Semantics
+	root := gjson.ParseBytes(body)
+	choice := root.Get("choices.0")
+	msg := choice.Get("message")
+	s.addText(msg.Get("content").String())
+	// Some translators use the reasoning field of OpenAI-compatible servers instead.
+	if msg.Get("reasoning_content").String() != "" || msg.Get("reasoning").String() != "" {
+		s.Thinking = true
+	}
+	for _, call := range msg.Get("tool_calls").Array() {
+		appendName(&s.ToolCalls, call.Get("function.name").String())
+	}
+	s.StopReason = choice.Get("finish_reason").String()
+	s.Usage = Usage{Input: root.Get("usage.prompt_tokens").Int(), Output: root.Get("usage.completion_tokens").Int()}
+	return s.finish()
+}
+
+func openAIStream(chunks []string) Semantics {
+	var s Semantics
+	var text strings.Builder
+	for _, payload := range StreamPayloads(chunks) {
+		root := gjson.ParseBytes(payload)
+		for _, choice := range root.Get("choices").Array() {
+			delta := choice.Get("delta")
+			text.WriteString(delta.Get("content").String())
+			if delta.Get("reasoning_content").String() != "" {
+				s.Thinking = true
+			}
+			for _, call := range delta.Get("tool_calls").Array() {
+				appendName(&s.ToolCalls, call.Get("function.name").String())
+			}
+			if reason := choice.Get("finish_reason").String(); reason != "" {
+				s.StopReason = reason
+			}
+		}
+		if usage := root.Get("usage"); usage.IsObject() {
+			s.Usage = Usage{Input: usage.Get("prompt_tokens").Int(), Output: usage.Get("completion_tokens").Int()}
+		}
+	}
+	s.Text = text.String()
+	return s.finish()
+}
+
+// OpenAI Responses, also spoken by Codex.
+
+func responsesRequest(body []byte) Semantics {
+	var s Semantics
+	root := gjson.ParseBytes(body)
+	if instructions := root.Get("instructions").String(); instructions != "" {
+		s.System = true
+		s.addText(instructions)
+	}
+	input := root.Get("input")
+	if input.Type == gjson.String {
+		s.addText(input.String())
+	}
+	for _, item := range input.Array() {
+		switch item.Get("type").String() {
+		case "function_call":
+			appendName(&s.ToolCalls, item.Get("name").String())
+		case "function_call_output":
+			s.ToolResults++
+			s.addText(item.Get("output").String())
+		case "reasoning":
+			s.Thinking = true
+		case "message", "":
+			if role := item.Get("role").String(); role == "system" || role == "developer" {
+				s.System = true
+			}
+			content := item.Get("content")
+			if content.Type == gjson.String {
+				s.addText(content.String())
+			}
+			for _, part := range content.Array() {
+				switch part.Get("type").String() {
+				case "input_text", "output_text", "text":
+					s.addText(part.Get("text").String())
+				case "input_image":
+					s.Images++
+				}
+			}
+		}
+	}
+	for _, tool := range root.Get("tools").Array() {
+		name := tool.Get("name").String()
+		if name == "" {
+			name = tool.Get("function.name").String()
+		}
+		appendName(&s.Tools, name)
+	}
+	if effort := root.Get("reasoning.effort").String(); effort != "" && effort != "none" {
+		s.Thinking = true
+	}
+	return s
+}
+
+func responsesNonStream(body []byte) Semantics {
+	root := gjson.ParseBytes(body)
+	if response := root.Get("response"); response.IsObject() {
+		root = response
+	}
+	var s Semantics
+	for _, item := range root.Get("output").Array() {
+		switch item.Get("type").String() {
+		case "message":
+			for _, part := range item.Get("content").Array() {
+				s.addText(part.Get("text").String())
+			}
+		case "function_call":
+			appendName(&s.ToolCalls, item.Get("name").String())
+		case "reasoning":
+			s.Thinking = true
+		}
+	}
+	responsesStatus(&s, root)
+	return s.finish()
+}
+
+func responsesStatus(s *Semantics, response gjson.Result) {
+	switch response.Get("status").String() {
+	case "completed":
+		s.StopReason = "stop"
+	case "incomplete":
+		s.StopReason = response.Get("incomplete_details.reason").String()
+	}
+	if usage := response.Get("usage"); usage.IsObject() {
+		s.Usage = Usage{Input: usage.Get("input_tokens").Int(), Output: usage.Get("output_tokens").Int()}
+	}
+}
+
+func responsesStream(chunks []string) Semantics {
+	var s Semantics
+	var text strings.Builder
+	for _, payload := range StreamPayloads(chunks) {
+		event := gjson.ParseBytes(payload)
+		switch event.Get("type").String() {
+		case "response.output_text.delta":
+			text.WriteString(event.Get("delta").String())
+		case "response.output_item.added":
+			if item := event.Get("item"); item.Get("type").String() == "function_call" {
+				appendName(&s.ToolCalls, item.Get("name").String())
+			}
+		case "response.reasoning_summary_text.delta", "response.reasoning_text.delta":
+			s.Thinking = true
+		case "response.completed", "response.incomplete":
+			responsesStatus(&s, event.Get("response"))
+		}
+	}
+	s.Text = text.String()
+	return s.finish()
+}
+
+// Claude messages.
+
+func claudeRequest(body []byte) Semantics {
+	var s Semantics
+	root := gjson.ParseBytes(body)
+	system := root.Get("system")
+	if system.Type == gjson.String && system.String() != "" {
+		s.System = true
+		s.addText(system.String())
+	}
+	for _, block := range system.Array() {
+		if block.IsObject() {
+			s.System = true
+			s.addText(block.Get("text").String())
+		}
+	}
+	for _, msg := range root.Get("messages").Array() {
+		content := msg.Get("content")
+		if content.Type == gjson.String {
+			s.addText(content.String())
+			continue
+		}
+		for _, block := range content.Array() {
+			claudeBlock(&s, block)
+		}
+	}
+	for _, tool := range root.Get("tools").Array() {
+		appendName(&s.Tools, tool.Get("name").String())
+	}
+	if kind := root.Get("thinking.type").String(); kind == "enabled" || kind == "adaptive" {
+		s.Thinking = true
+	}
+	return s
+}
+
+func claudeBlock(s *Semantics, block gjson.Result) {
+	switch block.Get("type").String() {
+	case "text":
+		s.addText(block.Get("text").String())
+	case "image":
+		s.Images++
+	case "tool_use":
+		appendName(&s.ToolCalls, block.Get("name").String())
+	case "tool_result":
+		s.ToolResults++
+		content := block.Get("content")
+		if content.Type == gjson.String {
+			s.addText(content.String())
+		}
+		for _, inner := range content.Array() {
+			claudeBlock(s, inner)
+		}
+	case "thinking", "redacted_thinking":
+		s.Thinking = true
+	}
+}
+
+func claudeNonStream(body []byte) Semantics {
+	var s Semantics
+	root := gjson.ParseBytes(body)
+	for _, block := range root.Get("content").Array() {
+		claudeBlock(&s, block)
+	}
+	s.StopReason = root.Get("stop_reason").String()
+	s.Usage = Usage{Input: root.Get("usage.input_tokens").Int(), Output: root.Get("usage.output_tokens").Int()}
+	return s.finish()
+}
+
+func claudeStream(chunks []string) Semantics {
+	var s Semantics
+	var text strings.Builder
+	for _, payload := range StreamPayloads(chunks) {
+		event := gjson.ParseBytes(payload)
+		switch event.Get("type").String() {
+		case "message_start":
+			s.Usage.Input = event.Get("message.usage.input_tokens").Int()
+		case "content_block_start":
+			block := event.Get("content_block")
+			switch block.Get("type").String() {
+			case "tool_use":
+				appendName(&s.ToolCalls, block.Get("name").String())
+			case "thinking", "redacted_thinking":
+				s.Thinking = true
+			case "text":
+				text.WriteString(block.Get("text").String())
+			}
+		case "content_block_delta":
+			delta := event.Get("delta")
+			switch delta.Get("type").String() {
+			case "text_delta":
+				text.WriteString(delta.Get("text").String())
+			case "thinking_delta":
+				s.Thinking = true
+			}
+		case "message_delta":
+			if reason := event.Get("delta.stop_reason").String(); reason != "" {
+				s.StopReason = reason
+			}
+			if input := event.Get("usage.input_tokens").Int(); input > 0 {
+				s.Usage.Input = input
+			}
+			if output := event.Get("usage.output_tokens"); output.Exists() {
+				s.Usage.Output = output.Int()
+			}
+		}
+	}
+	s.Text = text.String()
+	return s.finish()
+}
+
+// Gemini, also the payload inside Gemini CLI and Antigravity envelopes.
+
+func geminiRequest(body []byte) Semantics {
+	var s Semantics
+	root := gjson.ParseBytes(body)
+	system := root.Get("systemInstruction")
+	if !system.Exists() {
+		system = root.Get("system_instruction")
+	}
+	for _, part := range system.Get("parts").Array() {
+		if text := part.Get("text").String(); text != "" {
+			s.System = true
+			s.addText(text)
+		}
+	}
+	for _, content := range root.Get("contents").Array() {
+		for _, part := range content.Get("parts").Array() {
+			geminiPart(&s, part)
+		}
+	}
+	for _, tool := range root.Get("tools").Array() {
+		declarations := tool.Get("functionDeclarations")
+		if !declarations.Exists() {
+			declarations = tool.Get("function_declarations")
+		}
+		for _, declaration := range declarations.Array() {
+			appendName(&s.Tools, declaration.Get("name").String())
+		}
+	}
+	config := root.Get("generationConfig.thinkingConfig")
+	if !config.Exists() {
+		config = root.Get("generation_config.thinking_config")
+	}
+	if config.IsObject() && (config.Get("thinkingBudget").Int() != 0 || config.Get("thinking_budget").Int() != 0 ||
+		config.Get("includeThoughts").Bool() || config.Get("include_thoughts").Bool() ||
+		config.Get("thinkingLevel").String() != "" || config.Get("thinking_level").String() != "") {
+		s.Thinking = true
+	}
+	return s
+}
+
+func geminiPart(s *Semantics, part gjson.Result) {
+	switch {
+	case part.Get("functionCall").Exists():
+		appendName(&s.ToolCalls, part.Get("functionCall.name").String())
+	case part.Get("functionResponse").Exists():
+		s.ToolResults++
+		s.addText(part.Get("functionResponse.response").Raw)
+	case part.Get("inlineData").Exists() || part.Get("inline_data").Exists() || part.Get("fileData").Exists():
+		s.Images++
+	case part.Get("thought").Bool():
+		s.Thinking = true
+	case part.Get("text").Exists():
+		s.addText(part.Get("text").String())
+	}
+}
+
+func geminiResponse(s *Semantics, text *strings.Builder, root gjson.Result) {
+	if response := root.Get("response"); response.IsObject() {
+		root = response
+	}
+	candidate := root.Get("candidates.0")
+	for _, part := range candidate.Get("content.parts").Array() {
+		switch {
+		case part.Get("functionCall").Exists():
+			appendName(&s.ToolCalls, part.Get("functionCall.name").String())
+		case part.Get("thought").Bool():
+			s.Thinking = true
+		default:
+			text.WriteString(part.Get("text").String())
+		}
+	}
+	if reason := candidate.Get("finishReason").String(); reason != "" {
+		s.StopReason = reason
+	}
+	if usage := root.Get("usageMetadata"); usage.IsObject() {
+		s.Usage = Usage{Input: usage.Get("promptTokenCount").Int(), Output: usage.Get("candidatesTokenCount").Int()}
+	}
+}
+
+func geminiNonStream(body []byte) Semantics {
+	var s Semantics
+	var text strings.Builder
+	geminiResponse(&s, &text, gjson.ParseBytes(body))
+	s.Text = text.String()
+	return s.finish()
+}
+
+func geminiStream(chunks []string) Semantics {
+	var s Semantics
+	var text strings.Builder
+	for _, payload := range StreamPayloads(chunks) {
+		geminiResponse(&s, &text, gjson.ParseBytes(payload))
+	}
+	s.Text = text.String()
+	return s.finish()
+}
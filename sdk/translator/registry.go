@@ -2,21 +2,37 @@ package translator
 
 import (
 	"context"
+	"sort"
 	"sync"
 )
 
-// Registry manages translation functions across schemas.
+// Registry manages translation functions across schemas. Pairs without a registered translator
+// are translated through the shortest chain of registered request transforms, if one exists.
 type Registry struct {
 	mu        sync.RWMutex
 	requests  map[Format]map[Format]RequestTransform
 	responses map[Format]map[Format]ResponseTransform
+	framing   map[Format]StreamFraming
+
+	preferred map[[2]Format][]Format
+
+	// routeMu guards routes, which is filled while only mu's read lock is held.
+	routeMu sync.Mutex
+	routes  map[[2]Format][]Format
 }
 
 // NewRegistry constructs an empty translator registry.
 func NewRegistry() *Registry {
+	framing := make(map[Format]StreamFraming, len(builtinStreamFraming))
+	for format, f := range builtinStreamFraming {
+		framing[format] = f
+	}
 	return &Registry{
 		requests:  make(map[Format]map[Format]RequestTransform),
 		responses: make(map[Format]map[Format]ResponseTransform),
+		framing:   framing,
+		preferred: make(map[[2]Format][]Format),
+		routes:    make(map[[2]Format][]Format),
 	}
 }
 
@@ -36,10 +52,66 @@ func (r *Registry) Register(from, to Format, request RequestTransform, response
 		r.responses[from] = make(map[Format]ResponseTransform)
 	}
 	r.responses[from][to] = response
+
+	r.routeMu.Lock()
+	r.routes = make(map[[2]Format][]Format)
+	r.routeMu.Unlock()
+}
+
+// Pair describes the transforms registered between two formats. Request reports a request
+// transform from From to To; Stream and NonStream report response transforms from To back to From.
+type Pair struct {
+	From      Format
+	To        Format
+	Request   bool
+	Stream    bool
+	NonStream bool
+}
+
+// Pairs lists every registered format pair, sorted by source and target format.
+func (r *Registry) Pairs() []Pair {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[[2]Format]*Pair)
+	get := func(from, to Format) *Pair {
+		key := [2]Format{from, to}
+		if pair, ok := seen[key]; ok {
+			return pair
+		}
+		pair := &Pair{From: from, To: to}
+		seen[key] = pair
+		return pair
+	}
+	for from, byTarget := range r.requests {
+		for to, fn := range byTarget {
+			if fn != nil {
+				get(from, to).Request = true
+			}
+		}
+	}
+	for from, byTarget := range r.responses {
+		for to, fn := range byTarget {
+			pair := get(from, to)
+			pair.Stream = fn.Stream != nil
+			pair.NonStream = fn.NonStream != nil
+		}
+	}
+	pairs := make([]Pair, 0, len(seen))
+	for _, pair := range seen {
+		pairs = append(pairs, *pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].From != pairs[j].From {
+			return pairs[i].From < pairs[j].From
+		}
+		return pairs[i].To < pairs[j].To
+	})
+	return pairs
 }
 
 // TranslateRequest converts a payload between schemas, returning the original payload
-// if no translator is registered.
+// if no translator or route is registered.
 func (r *Registry) TranslateRequest(from, to Format, model string, rawJSON []byte, stream bool) []byte {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -49,10 +121,15 @@ func (r *Registry) TranslateRequest(from, to Format, model string, rawJSON []byt
 			return fn(model, rawJSON, stream)
 		}
 	}
+	if !r.hasDirect(from, to) {
+		if route := r.routeLocked(from, to); len(route) > 2 {
+			return r.translateRequestRoute(route, model, rawJSON, stream)
+		}
+	}
 	return rawJSON
 }
 
-// HasResponseTransformer indicates whether a response translator exists.
+// HasResponseTransformer indicates whether a response translator or route exists.
 func (r *Registry) HasResponseTransformer(from, to Format) bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -62,10 +139,11 @@ func (r *Registry) HasResponseTransformer(from, to Format) bool {
 			return true
 		}
 	}
-	return false
+	return len(r.routeLocked(from, to)) > 2
 }
 
-// TranslateStream applies the registered streaming response translator.
+// TranslateStream applies the registered streaming response translator. Routed pairs keep
+// the state of every hop in param.
 func (r *Registry) TranslateStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -75,6 +153,11 @@ func (r *Registry) TranslateStream(ctx context.Context, from, to Format, model s
 			return fn.Stream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 		}
 	}
+	if !r.hasDirect(to, from) {
+		if route := r.routeLocked(to, from); len(route) > 2 {
+			return r.translateStreamRoute(ctx, route, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+		}
+	}
 	return []string{string(rawJSON)}
 }
 
@@ -88,10 +171,16 @@ func (r *Registry) TranslateNonStream(ctx context.Context, from, to Format, mode
 			return fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 		}
 	}
+	if !r.hasDirect(to, from) {
+		if route := r.routeLocked(to, from); len(route) > 2 {
+			return r.translateNonStreamRoute(ctx, route, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+		}
+	}
 	return string(rawJSON)
 }
 
-// TranslateNonStream applies the registered non-stream response translator.
+// TranslateTokenCount applies the registered token count translator. Routed pairs use
+// the hop that produces the client format.
 func (r *Registry) TranslateTokenCount(ctx context.Context, from, to Format, count int64, rawJSON []byte) string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -101,6 +190,13 @@ func (r *Registry) TranslateTokenCount(ctx context.Context, from, to Format, cou
 			return fn.TokenCount(ctx, count)
 		}
 	}
+	if !r.hasDirect(to, from) {
+		if route := r.routeLocked(to, from); len(route) > 2 {
+			if fn := r.responses[route[0]][route[1]].TokenCount; fn != nil {
+				return fn(ctx, count)
+			}
+		}
+	}
 	return string(rawJSON)
 }
 
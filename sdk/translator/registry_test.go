@@ -0,0 +1,110 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// hop builds a request transform that appends name and a response transform that tags every
+// payload with name and a per-stream counter kept in param.
+func hop(name string) (RequestTransform, ResponseTransform) {
+	request := func(_ string, rawJSON []byte, _ bool) []byte {
+		return append(append([]byte(nil), rawJSON...), "|"+name...)
+	}
+	stream := func(_ context.Context, _ string, _, requestRawJSON, rawJSON []byte, param *any) []string {
+		if *param == nil {
+			*param = new(int)
+		}
+		count := (*param).(*int)
+		payload := strings.TrimSpace(strings.TrimPrefix(string(rawJSON), "data:"))
+		if payload == "[DONE]" {
+			return []string{fmt.Sprintf("data: end@%s#%d", name, *count)}
+		}
+		*count++
+		return []string{fmt.Sprintf("data: %s>%s#%d(%s)", payload, name, *count, requestRawJSON)}
+	}
+	nonStream := func(_ context.Context, _ string, _, requestRawJSON, rawJSON []byte, _ *any) string {
+		return fmt.Sprintf("%s>%s(%s)", rawJSON, name, requestRawJSON)
+	}
+	return request, ResponseTransform{Stream: stream, NonStream: nonStream}
+}
+
+func chainRegistry() *Registry {
+	r := NewRegistry()
+	req, resp := hop("b")
+	r.Register("a", "b", req, resp)
+	req, resp = hop("c")
+	r.Register("b", "c", req, resp)
+	return r
+}
+
+func TestRegistryRoutesUnregisteredPair(t *testing.T) {
+	r := chainRegistry()
+
+	if got := r.Route("a", "c"); !reflect.DeepEqual(got, []Format{"a", "b", "c"}) {
+		t.Fatalf("Route(a, c) = %v", got)
+	}
+	if got := r.Route("c", "a"); got != nil {
+		t.Fatalf("Route(c, a) = %v, want nil", got)
+	}
+	if !r.HasResponseTransformer("a", "c") {
+		t.Fatal("HasResponseTransformer(a, c) = false")
+	}
+	if got := string(r.TranslateRequest("a", "c", "m", []byte("req"), true)); got != "req|b|c" {
+		t.Fatalf("TranslateRequest = %q", got)
+	}
+	if got := string(r.TranslateRequest("c", "a", "m", []byte("req"), true)); got != "req" {
+		t.Fatalf("TranslateRequest without route = %q", got)
+	}
+
+	got := r.TranslateNonStream(context.Background(), "c", "a", "m", []byte("req"), []byte("sent"), []byte("body"), nil)
+	if want := "body>c(sent)>b(req|b)"; got != want {
+		t.Fatalf("TranslateNonStream = %q, want %q", got, want)
+	}
+}
+
+func TestRegistryStreamsThroughRouteWithPerHopState(t *testing.T) {
+	r := chainRegistry()
+
+	var param any
+	var out []string
+	for _, line := range []string{"data: x", "data: y", "data: [DONE]"} {
+		out = append(out, r.TranslateStream(context.Background(), "c", "a", "m", []byte("req"), []byte("sent"), []byte(line), &param)...)
+	}
+	want := []string{
+		"data: x>c#1(sent)>b#1(req|b)",
+		"data: y>c#2(sent)>b#2(req|b)",
+		"data: end@c#2>b#3(req|b)",
+		"data: end@b#3",
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Fatalf("stream output:\n got %q\nwant %q", out, want)
+	}
+}
+
+func TestRegistryRouteCacheFollowsRegistrations(t *testing.T) {
+	r := chainRegistry()
+	req, resp := hop("x")
+	r.Register("a", "x", req, resp)
+	req, resp = hop("c")
+	r.Register("x", "c", req, resp)
+
+	if got := r.Route("a", "c"); !reflect.DeepEqual(got, []Format{"a", "b", "c"}) {
+		t.Fatalf("shortest route = %v", got)
+	}
+	r.PreferRoute("a", "x", "c")
+	if got := r.Route("a", "c"); !reflect.DeepEqual(got, []Format{"a", "x", "c"}) {
+		t.Fatalf("preferred route = %v", got)
+	}
+	req, resp = hop("direct")
+	r.Register("a", "c", req, resp)
+	if got := r.Route("a", "c"); !reflect.DeepEqual(got, []Format{"a", "c"}) {
+		t.Fatalf("route after direct registration = %v", got)
+	}
+	if got := string(r.TranslateRequest("a", "c", "m", []byte("req"), false)); got != "req|direct" {
+		t.Fatalf("TranslateRequest = %q", got)
+	}
+}
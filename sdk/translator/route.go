@@ -0,0 +1,336 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// StreamFraming describes how an executor passes the stream lines of a format to its response
+// translators. Multi-hop translation uses it to re-frame the output of one hop as the input of
+// the next one.
+type StreamFraming struct {
+	// Bare passes JSON payloads without the SSE "data: " prefix.
+	Bare bool
+	// Done is the line that ends a stream, such as "data: [DONE]". Empty when the format has none.
+	Done string
+	// FinalEvents lists the payload "type" values that end a stream in formats without Done.
+	FinalEvents []string
+}
+
+var responsesFinalEvents = []string{"response.completed", "response.incomplete", "response.failed"}
+
+// builtinStreamFraming mirrors how the built-in executors feed each upstream format. Gemini is the
+// exception: its executor passes bare payloads, but the Gemini to Gemini CLI translator only reads
+// "data:" lines, and the other Gemini translators accept both.
+var builtinStreamFraming = map[Format]StreamFraming{
+	FormatOpenAI:         {Done: "data: [DONE]"},
+	FormatOpenAIResponse: {FinalEvents: responsesFinalEvents},
+	FormatClaude:         {FinalEvents: []string{"message_stop"}},
+	FormatCodex:          {FinalEvents: responsesFinalEvents},
+	FormatGemini:         {Done: "data: [DONE]"},
+	FormatGeminiCLI:      {Done: "[DONE]"},
+	FormatAntigravity:    {Bare: true, Done: "[DONE]"},
+}
+
+// defaultStreamFraming applies to formats without registered framing: OpenAI-style SSE.
+var defaultStreamFraming = StreamFraming{Done: "data: [DONE]"}
+
+// streamPayload strips SSE framing from a line. It returns false for blank, event and comment lines.
+func streamPayload(line []byte) ([]byte, bool) {
+	line = bytes.TrimSpace(line)
+	switch {
+	case len(line) == 0, bytes.HasPrefix(line, []byte("event:")), line[0] == ':':
+		return nil, false
+	case bytes.HasPrefix(line, []byte("data:")):
+		return bytes.TrimSpace(line[len("data:"):]), true
+	}
+	return line, true
+}
+
+// ends reports whether line is the last one of a stream in this framing.
+func (f StreamFraming) ends(line []byte) bool {
+	payload, ok := streamPayload(line)
+	if !ok {
+		return false
+	}
+	if bytes.Equal(payload, []byte("[DONE]")) {
+		return true
+	}
+	if len(f.FinalEvents) == 0 {
+		return false
+	}
+	eventType := gjson.GetBytes(payload, "type").String()
+	for _, final := range f.FinalEvents {
+		if eventType == final {
+			return true
+		}
+	}
+	return false
+}
+
+// frame splits translator output into input lines for this framing. Done markers in the output are
+// dropped and reported instead, so the caller can add the format's own Done line once.
+func (f StreamFraming) frame(chunks []string) (lines [][]byte, done bool) {
+	for _, chunk := range chunks {
+		trimmed := strings.TrimSpace(chunk)
+		parts := []string{trimmed}
+		if !strings.HasPrefix(trimmed, "{") {
+			parts = strings.Split(trimmed, "\n")
+		}
+		for _, part := range parts {
+			payload, ok := streamPayload([]byte(part))
+			if !ok {
+				continue
+			}
+			if bytes.Equal(payload, []byte("[DONE]")) {
+				done = true
+				continue
+			}
+			if f.Bare {
+				lines = append(lines, payload)
+			} else {
+				lines = append(lines, append([]byte("data: "), payload...))
+			}
+		}
+	}
+	return lines, done
+}
+
+// SetStreamFraming sets how stream lines of a format are framed when it sits in the middle of a
+// multi-hop route. Built-in formats are preset; other formats default to OpenAI-style SSE.
+func (r *Registry) SetStreamFraming(format Format, framing StreamFraming) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.framing[format] = framing
+}
+
+func (r *Registry) framingFor(format Format) StreamFraming {
+	if framing, ok := r.framing[format]; ok {
+		return framing
+	}
+	return defaultStreamFraming
+}
+
+// hasDirect reports whether anything is registered for the pair. Such pairs never use a route,
+// so registering a direct translator always overrides the computed path.
+func (r *Registry) hasDirect(from, to Format) bool {
+	if fn, ok := r.requests[from][to]; ok && fn != nil {
+		return true
+	}
+	_, ok := r.responses[from][to]
+	return ok
+}
+
+// Route returns the formats a request passes through from one format to another, including
+// both ends. A registered pair yields a single hop, from == to without a translator yields just
+// from, and nil means the formats cannot be connected.
+func (r *Registry) Route(from, to Format) []Format {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.hasDirect(from, to) {
+		return []Format{from, to}
+	}
+	return append([]Format(nil), r.routeLocked(from, to)...)
+}
+
+// PreferRoute makes route the path between its first and last format when they have no
+// registered translator, overriding the shortest path. It is ignored while any hop lacks a
+// request transform.
+func (r *Registry) PreferRoute(route ...Format) {
+	if len(route) < 3 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.preferred[[2]Format{route[0], route[len(route)-1]}] = append([]Format(nil), route...)
+	r.routeMu.Lock()
+	r.routes = make(map[[2]Format][]Format)
+	r.routeMu.Unlock()
+}
+
+// routeLocked returns the cached preferred or shortest path over request transforms. The caller
+// holds r.mu.
+func (r *Registry) routeLocked(from, to Format) []Format {
+	key := [2]Format{from, to}
+	r.routeMu.Lock()
+	defer r.routeMu.Unlock()
+	if route, ok := r.routes[key]; ok {
+		return route
+	}
+	route := r.preferred[key]
+	for i := 0; i+1 < len(route); i++ {
+		if r.requests[route[i]][route[i+1]] == nil {
+			route = nil
+			break
+		}
+	}
+	if route == nil {
+		route = r.shortestPath(from, to)
+	}
+	r.routes[key] = route
+	return route
+}
+
+// shortestPath runs a breadth-first search, visiting targets in sorted order so that ties
+// resolve the same way on every run.
+func (r *Registry) shortestPath(from, to Format) []Format {
+	if from == to {
+		return []Format{from}
+	}
+	prev := map[Format]Format{from: from}
+	queue := []Format{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		targets := make([]Format, 0, len(r.requests[current]))
+		for target, fn := range r.requests[current] {
+			if fn != nil {
+				targets = append(targets, target)
+			}
+		}
+		sort.Slice(targets, func(i, j int) bool { return targets[i] < targets[j] })
+		for _, target := range targets {
+			if _, seen := prev[target]; seen {
+				continue
+			}
+			prev[target] = current
+			if target == to {
+				var route []Format
+				for f := to; f != from; f = prev[f] {
+					route = append(route, f)
+				}
+				route = append(route, from)
+				for i, j := 0, len(route)-1; i < j; i, j = i+1, j-1 {
+					route[i], route[j] = route[j], route[i]
+				}
+				return route
+			}
+			queue = append(queue, target)
+		}
+	}
+	return nil
+}
+
+// routeState is the per-stream state of a multi-hop translation, kept in the caller's param.
+type routeState struct {
+	route []Format
+	// requests holds the request as seen by each format on the route.
+	requests [][]byte
+	params   []any
+	// done marks the hops whose output already received a Done line.
+	done []bool
+}
+
+// newRouteState rebuilds the intermediate requests from the client request. The last one is the
+// request actually sent upstream, which executors may have changed after translation.
+func (r *Registry) newRouteState(route []Format, model string, originalRequestRawJSON, requestRawJSON []byte, stream bool) *routeState {
+	hops := len(route) - 1
+	state := &routeState{
+		route:    route,
+		requests: make([][]byte, hops+1),
+		params:   make([]any, hops),
+		done:     make([]bool, hops),
+	}
+	state.requests[0] = originalRequestRawJSON
+	for i := 0; i < hops-1; i++ {
+		state.requests[i+1] = r.requests[route[i]][route[i+1]](model, state.requests[i], stream)
+	}
+	state.requests[hops] = requestRawJSON
+	return state
+}
+
+func (r *Registry) routeStateFor(param *any, route []Format, model string, originalRequestRawJSON, requestRawJSON []byte, stream bool) *routeState {
+	if param != nil {
+		if state, ok := (*param).(*routeState); ok && sameRoute(state.route, route) {
+			return state
+		}
+	}
+	state := r.newRouteState(route, model, originalRequestRawJSON, requestRawJSON, stream)
+	if param != nil {
+		*param = state
+	}
+	return state
+}
+
+func sameRoute(a, b []Format) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Registry) translateRequestRoute(route []Format, model string, rawJSON []byte, stream bool) []byte {
+	for i := 0; i+1 < len(route); i++ {
+		rawJSON = r.requests[route[i]][route[i+1]](model, rawJSON, stream)
+	}
+	return rawJSON
+}
+
+// translateStreamRoute passes one upstream line back along route, from the last hop to the first.
+// A hop sees the end of its input when the upstream format ends its stream; it then appends the
+// Done line of its output format, so translators that finish on Done flush their last events.
+func (r *Registry) translateStreamRoute(ctx context.Context, route []Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
+	state := r.routeStateFor(param, route, model, originalRequestRawJSON, requestRawJSON, true)
+	lines := [][]byte{rawJSON}
+	for i := len(route) - 2; i >= 0; i-- {
+		fn := r.responses[route[i]][route[i+1]]
+		input := r.framingFor(route[i+1])
+		var out []string
+		ended := false
+		for _, line := range lines {
+			if fn.Stream != nil {
+				out = append(out, fn.Stream(ctx, model, state.requests[i], state.requests[i+1], line, &state.params[i])...)
+			} else {
+				out = append(out, string(line))
+			}
+			ended = ended || input.ends(line)
+		}
+		if i == 0 {
+			return out
+		}
+		output := r.framingFor(route[i])
+		var done bool
+		lines, done = output.frame(out)
+		if (ended || done) && output.Done != "" && !state.done[i] {
+			state.done[i] = true
+			lines = append(lines, []byte(output.Done))
+		}
+	}
+	return []string{string(rawJSON)}
+}
+
+func (r *Registry) translateNonStreamRoute(ctx context.Context, route []Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) string {
+	state := r.routeStateFor(param, route, model, originalRequestRawJSON, requestRawJSON, false)
+	out := string(rawJSON)
+	for i := len(route) - 2; i >= 0; i-- {
+		if fn := r.responses[route[i]][route[i+1]]; fn.NonStream != nil {
+			out = fn.NonStream(ctx, model, state.requests[i], state.requests[i+1], []byte(out), &state.params[i])
+		}
+	}
+	return out
+}
+
+// Route is a helper on the default registry.
+func Route(from, to Format) []Format {
+	return defaultRegistry.Route(from, to)
+}
+
+// PreferRoute is a helper on the default registry.
+func PreferRoute(route ...Format) {
+	defaultRegistry.PreferRoute(route...)
+}
+
+// SetStreamFraming is a helper on the default registry.
+func SetStreamFraming(format Format, framing StreamFraming) {
+	defaultRegistry.SetStreamFraming(format, framing)
+}
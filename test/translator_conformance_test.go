@@ -0,0 +1,54 @@
+package test
+
+import (
+	"testing"
+
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/translator/conformance"
+)
+
+const (
+	fmtOpenAI         = sdktranslator.FormatOpenAI
+	fmtOpenAIResponse = sdktranslator.FormatOpenAIResponse
+	fmtClaude         = sdktranslator.FormatClaude
+	fmtGemini         = sdktranslator.FormatGemini
+	fmtGeminiCLI      = sdktranslator.FormatGeminiCLI
+	fmtCodex          = sdktranslator.FormatCodex
+)
+
+// builtinTranslatorGaps are the losses the built-in translators have today. Remove an entry once
+// the translator keeps the feature; the harness fails on entries that no longer reproduce.
+var builtinTranslatorGaps = []conformance.Gap{
+	// Requests and round trips.
+	{From: fmtOpenAI, To: fmtClaude, Feature: "system", Reason: "system messages are sent as the first user turn"},
+	{From: fmtOpenAIResponse, To: fmtClaude, Feature: "system", Reason: "instructions are sent as the first user turn"},
+	{From: fmtGeminiCLI, To: fmtClaude, Feature: "system", Reason: "the system instruction is sent as a user turn"},
+	{From: fmtGemini, To: fmtClaude, Fixture: "system", Reason: "systemInstruction is dropped"},
+	{From: fmtClaude, Kind: conformance.KindRoundTrip, Feature: "system", Reason: "the way back to Claude returns the system prompt as a user turn"},
+	{From: fmtClaude, To: fmtCodex, Fixture: "system", Reason: "the system prompt is not sent to Codex"},
+	{From: fmtGemini, To: fmtCodex, Fixture: "system", Reason: "systemInstruction is not sent to Codex"},
+	{From: fmtClaude, To: fmtGemini, Feature: "images", Reason: "base64 image blocks are dropped"},
+	{From: fmtClaude, To: fmtGeminiCLI, Kind: conformance.KindRoundTrip, Feature: "images", Reason: "inline images are dropped on the way back to Claude"},
+	{From: fmtGemini, To: fmtClaude, Feature: "images", Reason: "inlineData parts are dropped"},
+	{From: fmtGeminiCLI, To: fmtClaude, Feature: "images", Reason: "inlineData parts are dropped"},
+	{From: fmtGemini, To: fmtCodex, Feature: "images", Reason: "inlineData parts are dropped"},
+	{From: fmtGeminiCLI, To: fmtCodex, Feature: "images", Reason: "inlineData parts are dropped"},
+	{From: fmtGemini, To: fmtOpenAI, Kind: conformance.KindRoundTrip, Fixture: "tools", Feature: "text", Reason: "serialized function responses are not unwrapped on the way back"},
+	{From: fmtGeminiCLI, To: fmtOpenAI, Kind: conformance.KindRoundTrip, Fixture: "tools", Feature: "text", Reason: "serialized function responses are not unwrapped on the way back"},
+
+	// Responses.
+	{To: fmtOpenAIResponse, Kind: conformance.KindNonStream, Fixture: "length", Feature: "stop_reason", Reason: "non-stream Responses output always reports status completed"},
+	{From: fmtClaude, Kind: conformance.KindNonStream, Fixture: "length", Feature: "stop_reason", Reason: "max_tokens is reported as a normal stop"},
+	{From: fmtCodex, Kind: conformance.KindNonStream, Fixture: "length", Feature: "stop_reason", Reason: "incomplete responses are reported as a normal stop"},
+	{From: fmtCodex, Kind: conformance.KindNonStream, Fixture: "thinking", Feature: "thinking", Reason: "reasoning summaries are not copied into Gemini thought parts"},
+	{From: fmtCodex, Kind: conformance.KindStream, Feature: "stop_reason", Reason: "the last Gemini chunk has no finishReason unless a tool was called"},
+	{From: fmtGemini, To: fmtGeminiCLI, Kind: conformance.KindStream, Reason: "the Gemini executor passes bare JSON while the Gemini CLI transform only accepts data: lines"},
+	{From: fmtOpenAI, To: fmtOpenAIResponse, Kind: conformance.KindStream, Feature: "usage", Reason: "response.completed is sent on finish_reason, before the usage chunk"},
+	{From: fmtOpenAI, To: fmtOpenAIResponse, Kind: conformance.KindStream, Feature: "tool_calls", Reason: "tool calls are tracked per choice, so parallel calls merge"},
+}
+
+func TestTranslatorConformance(t *testing.T) {
+	conformance.Run(t, conformance.Options{Gaps: builtinTranslatorGaps})
+}